@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// accountingMagnitude matches the unsigned number of an accounting value,
+// with or without thousands separators, e.g. 1,234.56 or 1234.56
+var accountingMagnitude = regexp.MustCompile(`^(\d+|\d{1,3}(,\d{3})+)(\.\d+)?$|^\.\d+$`)
+
+// normalizeAccounting rewrites a number in accounting notation as a plain
+// signed number: a negative in parentheses, (1,234.56), or with a trailing
+// minus, 1234.56-, and thousands separators. It reports false for values
+// that need no rewriting, a leading plus being read as it is, and for
+// anything that is not a number, such as "(see note)".
+func normalizeAccounting(value string) (string, bool) {
+	sign := ""
+	magnitude := value
+	switch {
+	case strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")"):
+		sign, magnitude = "-", value[1:len(value)-1]
+	case strings.HasSuffix(value, "-"):
+		sign, magnitude = "-", value[:len(value)-1]
+	case strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-"):
+		// A leading sign is already read, so only separators need removing
+		sign, magnitude = value[:1], value[1:]
+		if sign == "+" {
+			sign = ""
+		}
+		if !strings.Contains(magnitude, ",") {
+			return value, false
+		}
+	default:
+		if !strings.Contains(value, ",") {
+			return value, false
+		}
+	}
+	if !accountingMagnitude.MatchString(magnitude) {
+		return value, false
+	}
+	return sign + strings.ReplaceAll(magnitude, ",", ""), true
+}
+
+// detectAccountingColumns marks the number columns whose values were read
+// in accounting notation with -accounting-numbers, since they need
+// rewriting when loaded
+func detectAccountingColumns(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) {
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		switch analyzer.GetTypes()[col.TypeIndex].Name {
+		case "tinyint", "smallint", "integer", "bigint", "numeric":
+		default:
+			continue
+		}
+		if col.AccountingCount == 0 {
+			continue
+		}
+		col.Accounting = true
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: field %s holds %s values in accounting notation\n", col.Name, groupDigits(col.AccountingCount))
+		}
+	}
+}
+
+// accountingExpression converts the text of a column of numbers in
+// accounting notation to its type, negating those in parentheses or with a
+// trailing minus and removing thousands separators
+func accountingExpression(value, typeName string, snowflake bool) string {
+	unwrapped := fmt.Sprintf("btrim(%s, '()')", value)
+	if snowflake {
+		unwrapped = fmt.Sprintf("TRIM(%s, '()')", value)
+	}
+	number := func(s string) string {
+		return fmt.Sprintf("CAST(replace(%s, ',', '') AS %s)", s, typeName)
+	}
+	return fmt.Sprintf("CASE WHEN %s LIKE '(%%)' THEN -%s WHEN %s LIKE '%%-' THEN -%s ELSE %s END",
+		value, number(unwrapped), value, number(fmt.Sprintf("rtrim(%s, '-')", value)), number(value))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestNormalizeAccounting(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"(1,234.56)", "-1234.56", true},
+		{"(42)", "-42", true},
+		{"1234.56-", "-1234.56", true},
+		{"1,234-", "-1234", true},
+		{"1,234,567", "1234567", true},
+		{"+1,000", "1000", true},
+		{"-1,000.5", "-1000.5", true},
+		{"(.5)", "-.5", true},
+		{"+42", "+42", false},
+		{"-42", "-42", false},
+		{"42", "42", false},
+		{"(see note)", "(see note)", false},
+		{"(1,23)", "(1,23)", false},
+		{"(-42)", "(-42)", false},
+		{"1,2,3", "1,2,3", false},
+		{"()", "()", false},
+		{"-", "-", false},
+	}
+	for _, tt := range tests {
+		got, ok := normalizeAccounting(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("normalizeAccounting(%q) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAccountingNumbersAnalysis(t *testing.T) {
+	input := "amount,count,memo\n\"(1,234.56)\",(42),(see note)\n1234.5-,7-,x\n+99.125,+3,(1)\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	tests := []struct {
+		name       string
+		accounting bool
+		want       []string
+		marked     []bool
+	}{
+		{name: "on", accounting: true, want: []string{"numeric", "smallint", "varchar(10)"}, marked: []bool{true, true, false}},
+		{name: "off", want: []string{"varchar(10)", "varchar(4)", "varchar(10)"}, marked: []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}, AccountingNumbers: tt.accounting}
+			result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			for i, col := range result.Columns {
+				if got := columnTypeName(col, analyzer); got != tt.want[i] || col.Accounting != tt.marked[i] {
+					t.Errorf("column %s = %s (accounting %v), want %s (%v)", col.Name, got, col.Accounting, tt.want[i], tt.marked[i])
+				}
+			}
+			if !tt.accounting {
+				return
+			}
+			// The magnitudes set the precision and scale
+			if amount := result.Columns[0]; amount.NumDigits != 4 || amount.NumScale != 3 {
+				t.Errorf("amount digits = %d, scale = %d, want 4, 3", amount.NumDigits, amount.NumScale)
+			}
+			if count := result.Columns[1]; count.IntMin != -42 || count.IntMax != 3 {
+				t.Errorf("count range = %d to %d, want -42 to 3", count.IntMin, count.IntMax)
+			}
+		})
+	}
+}
+
+func TestAccountingExpression(t *testing.T) {
+	want := "CASE WHEN x LIKE '(%)' THEN -CAST(replace(btrim(x, '()'), ',', '') AS numeric) " +
+		"WHEN x LIKE '%-' THEN -CAST(replace(rtrim(x, '-'), ',', '') AS numeric) ELSE CAST(replace(x, ',', '') AS numeric) END"
+	if got := accountingExpression("x", "numeric", false); got != want {
+		t.Errorf("accountingExpression() = %s, want %s", got, want)
+	}
+	if got := accountingExpression("x", "number", true); !strings.Contains(got, "TRIM(x, '()')") {
+		t.Errorf("accountingExpression() = %s, want TRIM for Snowflake", got)
+	}
+}
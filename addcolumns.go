@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// addedColumnFlags collects the -add-columns flags, which may be repeated
+type addedColumnFlags []string
+
+func (a *addedColumnFlags) String() string {
+	return strings.Join(*a, " ")
+}
+
+func (a *addedColumnFlags) Set(value string) error {
+	*a = append(*a, value)
+	return nil
+}
+
+// addedColumn is a column the DDL adds to the file's, such as a surrogate
+// key or the time a row was loaded
+type addedColumn struct {
+	Name    string
+	Type    string // SQL type, written as given
+	Default string // SQL default expression, "" for none
+	Kind    string // surrogate-key, loaded-at or source-file for the standard columns, spelled per flavor
+	First   bool   // goes ahead of the file's columns
+}
+
+// parseAddedColumn reads an -add-columns flag, name:type with an optional
+// :default; the default is the rest of the flag, so it may hold colons
+func parseAddedColumn(spec string) (addedColumn, error) {
+	parts := strings.SplitN(spec, ":", 3)
+	if len(parts) < 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+		return addedColumn{}, fmt.Errorf("-add-columns %q is not name:type or name:type:default", spec)
+	}
+	col := addedColumn{Name: strings.TrimSpace(parts[0]), Type: strings.TrimSpace(parts[1])}
+	if len(parts) == 3 {
+		col.Default = strings.TrimSpace(parts[2])
+	}
+	return col, nil
+}
+
+// addColumns sets the columns the DDL adds to the file's: the -add-columns
+// specs after them, the standard _id surrogate key ahead of them and the
+// _loaded_at and _source_file audit columns after them. An added column
+// with the name of one of the file's, or of another added column, is an
+// error, since the table could not hold both.
+func addColumns(result *fileAnalysis, specs []string, surrogateKey, audit bool) error {
+	var added []addedColumn
+	if surrogateKey {
+		added = append(added, addedColumn{Name: "_id", Kind: "surrogate-key", First: true})
+	}
+	if audit {
+		added = append(added, addedColumn{Name: "_loaded_at", Kind: "loaded-at"}, addedColumn{Name: "_source_file", Kind: "source-file"})
+	}
+	for _, spec := range specs {
+		col, err := parseAddedColumn(spec)
+		if err != nil {
+			return err
+		}
+		added = append(added, col)
+	}
+
+	taken := make(map[string]bool)
+	for _, col := range result.Columns {
+		taken[col.Name] = true
+	}
+	for _, col := range added {
+		if taken[col.Name] {
+			return fmt.Errorf("added column %s has the name of another column; rename the file's columns with -column-prefix, or add it under another name with -add-columns", col.Name)
+		}
+		taken[col.Name] = true
+	}
+	result.AddedColumns = added
+	return nil
+}
+
+// standardColumnSQL spells the standard added columns in a flavor's terms
+type standardColumnSQL struct {
+	surrogateKey    string // type and identity clause of the surrogate key
+	loadedAtType    string
+	loadedAtDefault string
+	sourceFileType  string
+}
+
+// standardColumns returns the flavor's spelling of the standard columns
+func standardColumns(analyzer dbtypes.TypeAnalyzer) standardColumnSQL {
+	switch analyzer.(type) {
+	case *dbtypes.SnowflakeAnalyzer:
+		return standardColumnSQL{"bigint IDENTITY", "timestamp_ltz", "CURRENT_TIMESTAMP()", "varchar"}
+	case *dbtypes.HanaAnalyzer:
+		return standardColumnSQL{"bigint GENERATED ALWAYS AS IDENTITY", "timestamp", "CURRENT_UTCTIMESTAMP", "nvarchar(5000)"}
+	case *dbtypes.VerticaAnalyzer:
+		return standardColumnSQL{"IDENTITY", "timestamptz", "now()", "varchar(65000)"}
+	case *dbtypes.FirebirdAnalyzer:
+		// Firebird 3 generates identities only by default
+		return standardColumnSQL{"bigint GENERATED BY DEFAULT AS IDENTITY", "timestamp", "CURRENT_TIMESTAMP", "blob sub_type text"}
+	case *dbtypes.ExasolAnalyzer:
+		return standardColumnSQL{"decimal(18,0) IDENTITY", "timestamp", "CURRENT_TIMESTAMP", "varchar(2000000)"}
+	case *dbtypes.MariaDBAnalyzer:
+		// MariaDB numbers only a column with a key on it
+		return standardColumnSQL{"bigint AUTO_INCREMENT UNIQUE", "datetime(6)", "CURRENT_TIMESTAMP(6)", "text"}
+	}
+	return standardColumnSQL{"bigint GENERATED ALWAYS AS IDENTITY", "timestamptz", "now()", "text"}
+}
+
+// columnSQL returns the definition of an added column in the flavor's terms
+func (c addedColumn) columnSQL(analyzer dbtypes.TypeAnalyzer) string {
+	standard := standardColumns(analyzer)
+	typeName, defaultSQL := c.Type, c.Default
+	switch c.Kind {
+	case "surrogate-key":
+		return flavorIdentifier(analyzer, c.Name) + " " + standard.surrogateKey
+	case "loaded-at":
+		typeName, defaultSQL = standard.loadedAtType, standard.loadedAtDefault
+	case "source-file":
+		typeName = standard.sourceFileType
+	}
+	column := flavorIdentifier(analyzer, c.Name) + " " + typeName
+	if defaultSQL != "" {
+		column += " DEFAULT " + defaultSQL
+	}
+	return column
+}
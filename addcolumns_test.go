@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestParseAddedColumn(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    addedColumn
+		wantErr bool
+	}{
+		{spec: "batch_id:integer", want: addedColumn{Name: "batch_id", Type: "integer"}},
+		{spec: "batch_id:integer:0", want: addedColumn{Name: "batch_id", Type: "integer", Default: "0"}},
+		{spec: "load_date:date:now()::date", want: addedColumn{Name: "load_date", Type: "date", Default: "now()::date"}},
+		{spec: "batch_id", wantErr: true},
+		{spec: ":integer", wantErr: true},
+		{spec: "batch_id:", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseAddedColumn(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAddedColumn(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAddedColumn(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddColumns(t *testing.T) {
+	input := "id,name\n1,Ann\n2,Bo\n"
+	tests := []struct {
+		name     string
+		analyzer dbtypes.TypeAnalyzer
+		specs    []string
+		want     string
+	}{
+		{
+			name:     "postgresql",
+			analyzer: &dbtypes.PostgreSQLAnalyzer{},
+			specs:    []string{"batch_id:integer:0"},
+			want: "CREATE TABLE people (\n    _id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,\n    id smallint NOT NULL,\n" +
+				"    name varchar(3) NOT NULL,\n    _loaded_at timestamptz DEFAULT now(),\n    _source_file text,\n" +
+				"    batch_id integer DEFAULT 0\n);\n",
+		},
+		{
+			name:     "snowflake",
+			analyzer: &dbtypes.SnowflakeAnalyzer{},
+			want: "CREATE TABLE people (\n    _id bigint IDENTITY PRIMARY KEY,\n    id smallint NOT NULL,\n" +
+				"    name varchar(3) NOT NULL,\n    _loaded_at timestamp_ltz DEFAULT CURRENT_TIMESTAMP(),\n    _source_file varchar\n);\n",
+		},
+		{
+			name:     "hana",
+			analyzer: &dbtypes.HanaAnalyzer{},
+			want: "CREATE COLUMN TABLE people (\n    _id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,\n    id tinyint NOT NULL,\n" +
+				"    name nvarchar(3) NOT NULL,\n    _loaded_at timestamp DEFAULT CURRENT_UTCTIMESTAMP,\n    _source_file nvarchar(5000)\n);\n",
+		},
+		{
+			name:     "vertica",
+			analyzer: &dbtypes.VerticaAnalyzer{},
+			want: "CREATE TABLE people (\n    _id IDENTITY PRIMARY KEY,\n    id int NOT NULL,\n" +
+				"    name varchar(3) NOT NULL,\n    _loaded_at timestamptz DEFAULT now(),\n    _source_file varchar(65000)\n);\n",
+		},
+		{
+			name:     "firebird",
+			analyzer: &dbtypes.FirebirdAnalyzer{},
+			want: "CREATE TABLE people (\n    _id bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,\n    id smallint NOT NULL,\n" +
+				"    name varchar(3) NOT NULL,\n    _loaded_at timestamp DEFAULT CURRENT_TIMESTAMP,\n    _source_file blob sub_type text\n);\n",
+		},
+		{
+			name:     "exasol",
+			analyzer: &dbtypes.ExasolAnalyzer{},
+			want: "CREATE TABLE PEOPLE (\n    _ID decimal(18,0) IDENTITY PRIMARY KEY,\n    ID decimal(18,0) NOT NULL,\n" +
+				"    NAME varchar(3) NOT NULL,\n    _LOADED_AT timestamp DEFAULT CURRENT_TIMESTAMP,\n    _SOURCE_FILE varchar(2000000)\n);\n",
+		},
+		{
+			name:     "mariadb",
+			analyzer: &dbtypes.MariaDBAnalyzer{},
+			want: "CREATE TABLE people (\n    _id bigint AUTO_INCREMENT UNIQUE PRIMARY KEY,\n    id tinyint NOT NULL,\n" +
+				"    name varchar(3) NOT NULL,\n    _loaded_at datetime(6) DEFAULT CURRENT_TIMESTAMP(6),\n    _source_file text\n);\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}}, tt.analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			if err := addColumns(result, tt.specs, true, true); err != nil {
+				t.Fatalf("addColumns() error = %v, want nil", err)
+			}
+			got, err := createTableSQL(result, tt.analyzer, "people", "_id")
+			if err != nil {
+				t.Fatalf("createTableSQL() error = %v, want nil", err)
+			}
+			if got != tt.want {
+				t.Errorf("createTableSQL() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddColumnsCollisions(t *testing.T) {
+	tests := []struct {
+		name         string
+		specs        []string
+		surrogateKey bool
+	}{
+		{name: "file column", specs: []string{"name:text"}},
+		{name: "standard column", specs: []string{"_id:integer"}, surrogateKey: true},
+		{name: "repeated spec", specs: []string{"batch:integer", "batch:text"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeFileTypes(strings.NewReader("id,name\n1,Ann\n"), analysisOptions{Options: analyze.Options{Delimiter: ","}}, &dbtypes.PostgreSQLAnalyzer{})
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			if err := addColumns(result, tt.specs, tt.surrogateKey, false); err == nil {
+				t.Errorf("addColumns(%q) error = nil, want a collision", tt.specs)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Package analyze infers the narrowest database type of text values, the
+// way file2ddl infers the type of each field of a file, so that services
+// receiving single values can ask the same question without a file. An
+// Analyzer does the same for the columns of whole files, and can be shared
+// by the goroutines of a service.
+package analyze
+
+import (
+	"fmt"
+	"regexp"
+
+	"file2ddl/dbtypes"
+)
+
+// Options adjust how values and files are read; the zero value reads them
+// as file2ddl does by default, except that files are comma-separated. The
+// yaml tags name the file2ddl flags, and profile keys, that set the fields.
+type Options struct {
+	TwoDigitYears bool `yaml:"two-digit-years"` // accept dates with two-digit years such as 03/20/24
+	YearPivot     int  `yaml:"year-pivot"`      // two-digit years below the pivot are 20xx, the rest 19xx
+
+	Delimiter         string         // field delimiter of files, a comma when empty
+	DelimiterRegex    *regexp.Regexp // splits unquoted records instead of Delimiter when set
+	RecordSeparator   string         // the single character ending records instead of a newline when set
+	TSVEscapes        bool           // decode the \t, \n, \r and \\ escapes of TSV fields
+	Quotes            string         `yaml:"quotes"`             // quote character of files: none, single or double, none when empty
+	MaxRecordBytes    int            `yaml:"max-record-bytes"`   // longest record read, in bytes; 0 means 64 KiB, or 64 MiB for an Analyzer
+	MaxFieldBytes     int            `yaml:"max-field-bytes"`    // longest field read, in bytes; 0 means no limit
+	StrictBlankLines  bool           `yaml:"strict-blank-lines"` // treat blank lines as errors instead of skipping them
+	SkipBadRows       bool           // leave out rows that cannot be read or have the wrong width instead of failing
+	NoHeader          bool           // the first line of files is data; columns are named column_1, column_2, ...
+	KeepHeaderRepeats bool           `yaml:"no-skip-repeated-headers"` // read rows identical to the header as data instead of skipping them
+	NullTokens        []string       // values read as nulls like empty fields, e.g. NULL
+}
+
+// InferValue returns the narrowest of the analyzer's types that holds the
+// value, e.g. smallint for "42" or date for "2024-03-20". An empty value is
+// a varchar, since it says nothing about the type of a null. It is an error
+// when none of the types holds the value, which only happens to values too
+// long for a varchar under an analyzer without text.
+func InferValue(value string, analyzer dbtypes.TypeAnalyzer) (dbtypes.DataType, error) {
+	return InferValueWith(value, analyzer, Options{})
+}
+
+// InferValueWith is InferValue with options
+func InferValueWith(value string, analyzer dbtypes.TypeAnalyzer, opts Options) (dbtypes.DataType, error) {
+	types := analyzer.GetTypes()
+	index := InferIndex(value, types, opts)
+	if index < 0 {
+		return dbtypes.DataType{}, fmt.Errorf("none of the types %v holds %q", typeNames(types), value)
+	}
+	return types[index], nil
+}
+
+// InferIndex returns the index in types of the narrowest type that holds the
+// value, or -1 if none does. Types are tried in order, so they must be in
+// the order of preference GetTypes returns them in; each then takes the
+// values the narrower types before it did not, so a flavor with a single
+// integer type takes every integer in it.
+func InferIndex(value string, types []dbtypes.DataType, opts Options) int {
+	for i, dbType := range types {
+		switch dbType.Name {
+		case "boolean":
+			if IsBoolean(value) {
+				return i
+			}
+		case "tinyint":
+			if dbType.Unsigned && IsUnsignedTinyInt(value) || !dbType.Unsigned && IsTinyInt(value) {
+				return i
+			}
+		case "smallint":
+			if IsSmallInt(value) {
+				return i
+			}
+		case "integer":
+			if IsInteger(value) {
+				return i
+			}
+		case "bigint":
+			if IsBigInt(value) {
+				return i
+			}
+		case "numeric":
+			if IsNumeric(value) {
+				return i
+			}
+		case "timestamp":
+			if IsTimestamp(value) {
+				return i
+			}
+		case "date":
+			if IsDate(value, opts) {
+				return i
+			}
+		case "geometry":
+			if IsWKT(value) {
+				return i
+			}
+		case "uuid":
+			if IsUUID(value) {
+				return i
+			}
+		case "varchar":
+			// Values longer than the flavor's limit fall to text
+			if dbType.InferLength == 0 || len(value) <= dbType.InferLength {
+				return i
+			}
+		case "text":
+			return i // text is always valid
+		}
+	}
+	return -1
+}
+
+// PromoteTypes returns the type a column holding values of both types needs:
+// the later of the two in the analyzer's order of preference, e.g. integer
+// for smallint and integer, or varchar for date and varchar. Types the
+// analyzer does not have are ordered by their priority.
+func PromoteTypes(a, b dbtypes.DataType, analyzer dbtypes.TypeAnalyzer) dbtypes.DataType {
+	order := make(map[string]int)
+	for i, t := range analyzer.GetTypes() {
+		order[t.Name] = i
+	}
+	ai, aKnown := order[a.Name]
+	bi, bKnown := order[b.Name]
+	if !aKnown || !bKnown {
+		ai, bi = a.Priority, b.Priority
+	}
+	if bi > ai {
+		return b
+	}
+	return a
+}
+
+func typeNames(types []dbtypes.DataType) []string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.Name
+	}
+	return names
+}
@@ -0,0 +1,140 @@
+package analyze
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/dbtypes"
+)
+
+func TestInferValue(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"true", "boolean"},
+		{"42", "smallint"},
+		{"40000", "integer"},
+		{"9223372036854775807", "bigint"},
+		{"123.45", "numeric"},
+		{"2024-03-20 10:30:00", "timestamp"},
+		{"2024-03-20", "date"},
+		{"POINT(1 2)", "varchar"},
+		{"", "varchar"},
+		{"Hello, World!", "varchar"},
+	}
+	for _, tt := range tests {
+		got, err := InferValue(tt.value, analyzer)
+		if err != nil {
+			t.Fatalf("InferValue(%q) error = %v, want nil", tt.value, err)
+		}
+		if got.Name != tt.expected {
+			t.Errorf("InferValue(%q) = %s, want %s", tt.value, got.Name, tt.expected)
+		}
+	}
+
+	got, err := InferValue("POINT(1 2)", &dbtypes.PostgreSQLAnalyzer{PostGIS: true})
+	if err != nil || got.Name != "geometry" {
+		t.Errorf("InferValue() with PostGIS = %s, %v, want geometry", got.Name, err)
+	}
+	got, err = InferValue("550E8400-e29b-41d4-a716-446655440000", &dbtypes.MariaDBAnalyzer{})
+	if err != nil || got.Name != "uuid" {
+		t.Errorf("InferValue() of a UUID under mariadb = %s, %v, want uuid", got.Name, err)
+	}
+	got, err = InferValue("550e8400e29b41d4a716446655440000", &dbtypes.MariaDBAnalyzer{})
+	if err != nil || got.Name != "varchar" {
+		t.Errorf("InferValue() of ungrouped hex under mariadb = %s, %v, want varchar", got.Name, err)
+	}
+	got, err = InferValueWith("03/20/24", analyzer, Options{TwoDigitYears: true, YearPivot: 69})
+	if err != nil || got.Name != "date" {
+		t.Errorf("InferValueWith() with two-digit years = %s, %v, want date", got.Name, err)
+	}
+}
+
+func TestInferIndexWithoutText(t *testing.T) {
+	types := []dbtypes.DataType{{Name: "integer"}, {Name: "varchar", InferLength: 64000}}
+	if got := InferIndex("abc", types, Options{}); got != 1 {
+		t.Errorf("InferIndex(\"abc\") = %d, want 1", got)
+	}
+	if got := InferIndex(string(make([]byte, 64001)), types, Options{}); got != -1 {
+		t.Errorf("InferIndex() of an overlong value = %d, want -1", got)
+	}
+}
+
+func TestInferIndexSingleInteger(t *testing.T) {
+	// A flavor with only bigint takes integers of every width in it
+	types := []dbtypes.DataType{{Name: "boolean"}, {Name: "bigint"}, {Name: "numeric"}, {Name: "text"}}
+	for value, want := range map[string]string{"42": "bigint", "70000": "bigint", "9223372036854775807": "bigint", "1.5": "numeric", "abc": "text"} {
+		if got := InferIndex(value, types, Options{}); got < 0 || types[got].Name != want {
+			t.Errorf("InferIndex(%q) = %d, want %s", value, got, want)
+		}
+	}
+}
+
+func TestInferValueTinyInt(t *testing.T) {
+	// PostgreSQL has no 8-bit integer; SAP HANA's is unsigned, and MariaDB's
+	// signed unless asked otherwise
+	tests := []struct {
+		name     string
+		analyzer dbtypes.TypeAnalyzer
+		value    string
+		expected string
+	}{
+		{"postgresql", &dbtypes.PostgreSQLAnalyzer{}, "200", "smallint"},
+		{"hana", &dbtypes.HanaAnalyzer{}, "200", "tinyint"},
+		{"hana negative", &dbtypes.HanaAnalyzer{}, "-5", "smallint"},
+		{"hana beyond 8 bits", &dbtypes.HanaAnalyzer{}, "256", "smallint"},
+		{"mariadb", &dbtypes.MariaDBAnalyzer{}, "-128", "tinyint"},
+		{"mariadb beyond signed", &dbtypes.MariaDBAnalyzer{}, "200", "smallint"},
+		{"mariadb unsigned", &dbtypes.MariaDBAnalyzer{UnsignedTinyInt: true}, "200", "tinyint"},
+		{"mariadb unsigned negative", &dbtypes.MariaDBAnalyzer{UnsignedTinyInt: true}, "-5", "smallint"},
+	}
+	for _, tt := range tests {
+		got, err := InferValue(tt.value, tt.analyzer)
+		if err != nil || got.Name != tt.expected {
+			t.Errorf("InferValue(%q) under %s = %s, %v, want %s", tt.value, tt.name, got.Name, err, tt.expected)
+		}
+	}
+}
+
+func TestInferIndexVarcharLimit(t *testing.T) {
+	long := strings.Repeat("x", 5000)
+	tests := []struct {
+		name  string
+		types []dbtypes.DataType
+		want  string
+	}{
+		{"postgresql", (&dbtypes.PostgreSQLAnalyzer{}).GetTypes(), "varchar"},
+		// A flavor whose varchar takes 4000 bytes falls to its large object type
+		{"4000-byte varchar", []dbtypes.DataType{{Name: "varchar", InferLength: 4000}, {Name: "text"}}, "text"},
+		{"unlimited varchar", []dbtypes.DataType{{Name: "varchar"}, {Name: "text"}}, "varchar"},
+	}
+	for _, tt := range tests {
+		if got := InferIndex(long, tt.types, Options{}); got < 0 || tt.types[got].Name != tt.want {
+			t.Errorf("%s: InferIndex() of a 5000-byte value = %d, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPromoteTypes(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	types := make(map[string]dbtypes.DataType)
+	for _, typ := range analyzer.GetTypes() {
+		types[typ.Name] = typ
+	}
+	tests := []struct {
+		a, b, expected string
+	}{
+		{"smallint", "integer", "integer"},
+		{"integer", "smallint", "integer"},
+		{"date", "varchar", "varchar"},
+		{"boolean", "boolean", "boolean"},
+		{"numeric", "text", "text"},
+	}
+	for _, tt := range tests {
+		if got := PromoteTypes(types[tt.a], types[tt.b], analyzer); got.Name != tt.expected {
+			t.Errorf("PromoteTypes(%s, %s) = %s, want %s", tt.a, tt.b, got.Name, tt.expected)
+		}
+	}
+}
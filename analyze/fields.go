@@ -0,0 +1,135 @@
+package analyze
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SplitFields splits a line into fields, handling quoted fields. A quote
+// begins a quoted field only at the start of a field, and ends it only when
+// followed by the delimiter or the end of the line; a doubled quote inside a
+// quoted field stands for one quote. Any other quote, such as the apostrophe
+// in an unquoted O'Brien, is literal. A line that ends inside a quoted field
+// is an error naming the column it started in, and an empty delimiter is
+// ErrEmptyDelimiter. Alongside the fields it returns which of them were
+// quoted, nil when quotes are not processed.
+func SplitFields(line, delim, quotes string) ([]string, []bool, error) {
+	return AppendFields(nil, nil, line, delim, quotes)
+}
+
+// AppendFields is SplitFields appending the fields and their quoting to the
+// given slices, so that a reader can reuse them from one record to the
+// next, like csv.Reader with ReuseRecord. The fields are substrings of the
+// line, so splitting allocates nothing but for quoted fields holding a
+// doubled quote; a caller keeping a field past the record it belongs to
+// should copy it with strings.Clone, or the whole line stays in memory.
+func AppendFields(fields []string, quoted []bool, line, delim, quotes string) ([]string, []bool, error) {
+	// An empty delimiter would match at every byte without advancing
+	if delim == "" {
+		return fields, quoted, ErrEmptyDelimiter
+	}
+	if quotes == "none" {
+		for {
+			i := strings.Index(line, delim)
+			if i < 0 {
+				return append(fields, line), nil, nil
+			}
+			fields = append(fields, line[:i])
+			line = line[i+len(delim):]
+		}
+	}
+
+	var quoteChar byte
+	if quotes == "double" {
+		quoteChar = '"'
+	} else {
+		quoteChar = '\''
+	}
+	column, quotedColumn := len(fields), len(quoted)
+
+	start := 0         // where the value of the current field starts
+	end := -1          // where the value of a closed quoted field ends
+	inQuote := false   // inside a quoted field
+	fieldStart := true // at the first byte of a field
+	fieldQuoted := false
+	escaped := false // the quoted field holds a doubled quote
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if fieldStart && c == quoteChar {
+			inQuote = true
+			fieldStart = false
+			fieldQuoted = true
+			start = i + 1
+			continue
+		}
+		fieldStart = false
+
+		if inQuote && c == quoteChar {
+			rest := line[i+1:]
+			if rest != "" && rest[0] == quoteChar {
+				// Escaped quote
+				escaped = true
+				i++
+				continue
+			}
+			// Blanks between the closing quote and the delimiter are
+			// dropped with the quote
+			after := strings.TrimLeft(rest, strings.Trim(" \t", delim))
+			if after == "" || strings.HasPrefix(after, delim) {
+				// End of quoted field
+				inQuote = false
+				end = i
+				i += len(rest) - len(after)
+				continue
+			}
+		}
+
+		if !inQuote && strings.HasPrefix(line[i:], delim) {
+			fields = append(fields, fieldValue(line, start, end, i, escaped, quoteChar))
+			quoted = append(quoted, fieldQuoted)
+			i += len(delim) - 1
+			start, end = i+1, -1
+			fieldStart = true
+			fieldQuoted = false
+			escaped = false
+			continue
+		}
+	}
+
+	if inQuote {
+		return fields[:column], quoted[:quotedColumn], &UnterminatedQuoteError{Column: len(fields) - column + 1}
+	}
+	// Add the last field
+	fields = append(fields, fieldValue(line, start, end, len(line), escaped, quoteChar))
+	quoted = append(quoted, fieldQuoted)
+	return fields, quoted, nil
+}
+
+// fieldValue returns the value of a field starting at start, ending at the
+// closing quote end of a quoted field or else at the delimiter at next,
+// with its doubled quotes undoubled when it holds any
+func fieldValue(line string, start, end, next int, escaped bool, quoteChar byte) string {
+	if end < 0 {
+		end = next
+	}
+	value := line[start:end]
+	if escaped {
+		quote := string(quoteChar)
+		value = strings.ReplaceAll(value, quote+quote, quote)
+	}
+	return value
+}
+
+// ErrEmptyDelimiter is the error of splitting a line on an empty delimiter
+var ErrEmptyDelimiter = errors.New("empty delimiter")
+
+// UnterminatedQuoteError is a line that ends inside a quoted field. Records
+// end at line ends, so a quoted field cannot span lines.
+type UnterminatedQuoteError struct {
+	Column int // 1-based position of the field the quote opened
+}
+
+func (e *UnterminatedQuoteError) Error() string {
+	return fmt.Sprintf("unterminated quoted field starting at column %d", e.Column)
+}
@@ -0,0 +1,163 @@
+package analyze
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"file2ddl/dbtypes"
+)
+
+// maxRecordBytes is the longest line an Analyzer reads when the options set
+// no limit
+const maxRecordBytes = 64 << 20
+
+// Column is the inferred type of a column of a file
+type Column struct {
+	Name      string
+	Type      dbtypes.DataType // narrowest type holding every value, the most general one when all are null
+	Nullable  bool             // some values were empty or null tokens
+	MaxLength int              // longest value, in characters or bytes as the flavor measures varchar
+}
+
+// Result is the analysis of a file
+type Result struct {
+	Columns       []Column
+	Rows          int // data rows, not counting the header or blank lines
+	HeaderRepeats int // rows skipped for repeating the header
+}
+
+// Analyzer infers the column types of delimited files under a flavor and
+// options fixed when it is made. It holds no state between calls, so one
+// Analyzer may analyze many files at once from different goroutines.
+type Analyzer struct {
+	opts       Options
+	types      []dbtypes.DataType
+	promotions [][]int // index of the common type of two types, by their indices
+	nulls      map[string]bool
+	chars      bool // lengths are counted in characters
+}
+
+// New returns an Analyzer reading files with the options and inferring the
+// types of the named flavor, e.g. postgresql
+func New(opts Options, flavor string) (*Analyzer, error) {
+	analyzer, err := dbtypes.ForFlavor(flavor)
+	if err != nil {
+		return nil, err
+	}
+	switch opts.Quotes {
+	case "":
+		opts.Quotes = "none"
+	case "none", "single", "double":
+	default:
+		return nil, fmt.Errorf("invalid quotes %q: use none, single or double", opts.Quotes)
+	}
+	if opts.Delimiter == "" {
+		opts.Delimiter = ","
+	}
+	if opts.MaxRecordBytes == 0 {
+		opts.MaxRecordBytes = maxRecordBytes
+	}
+	opts.NullTokens = slices.Clone(opts.NullTokens)
+
+	a := &Analyzer{opts: opts, types: analyzer.GetTypes(), nulls: make(map[string]bool)}
+	for _, token := range opts.NullTokens {
+		a.nulls[token] = true
+	}
+	if counter, ok := analyzer.(dbtypes.LengthCounter); ok {
+		a.chars = counter.LengthSemantics() == "chars"
+	}
+	a.promotions = make([][]int, len(a.types))
+	for i, t := range a.types {
+		a.promotions[i] = make([]int, len(a.types))
+		for j, u := range a.types {
+			common, err := dbtypes.CommonType(analyzer, t.Name, u.Name)
+			if err != nil {
+				return nil, err
+			}
+			a.promotions[i][j] = slices.IndexFunc(a.types, func(d dbtypes.DataType) bool { return d.Name == common })
+		}
+	}
+	return a, nil
+}
+
+// Analyze reads a file to its end and infers the type of each of its
+// columns. The file is read with Stream, as file2ddl reads delimited text:
+// a file without even a header is an error, every row must have as many
+// fields as the first unless SkipBadRows leaves it out, and rows repeating
+// the header are skipped unless KeepHeaderRepeats is set.
+func (a *Analyzer) Analyze(r io.Reader) (*Result, error) {
+	var columns []Column
+	var typeIndexes []int // by column, -1 until a value is seen
+	var header []string   // names of the columns, nil without a header
+	result := &Result{}
+	err := Stream(r, a.opts, func(line int, fields []string) error {
+		if columns == nil {
+			columns = make([]Column, len(fields))
+			typeIndexes = make([]int, len(fields))
+			for i := range columns {
+				columns[i].Name = fmt.Sprintf("column_%d", i+1)
+				if !a.opts.NoHeader {
+					columns[i].Name = strings.Clone(fields[i])
+					header = append(header, columns[i].Name)
+				}
+				typeIndexes[i] = -1
+			}
+			if !a.opts.NoHeader {
+				return nil
+			}
+		}
+		if len(fields) != len(columns) {
+			if a.opts.SkipBadRows {
+				return nil
+			}
+			return fmt.Errorf("%s %d has %d fields, expected %d", recordUnit(a.opts), line, len(fields), len(columns))
+		}
+		// Concatenated exports repeat the header where each part starts
+		if header != nil && !a.opts.KeepHeaderRepeats && slices.Equal(fields, header) {
+			result.HeaderRepeats++
+			return nil
+		}
+
+		result.Rows++
+		for i, field := range fields {
+			if field == "" || a.nulls[field] {
+				columns[i].Nullable = true
+				continue
+			}
+			length := len(field)
+			if a.chars {
+				length = utf8.RuneCountInString(field)
+			}
+			columns[i].MaxLength = max(columns[i].MaxLength, length)
+
+			index := InferIndex(field, a.types, a.opts)
+			if index < 0 {
+				index = len(a.types) - 1
+			}
+			if typeIndexes[i] >= 0 {
+				index = a.promotions[typeIndexes[i]][index]
+			}
+			typeIndexes[i] = index
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if columns == nil {
+		return nil, fmt.Errorf("file contains no data")
+	}
+
+	for i := range columns {
+		index := typeIndexes[i]
+		if index < 0 {
+			index = len(a.types) - 1
+		}
+		columns[i].Type = a.types[index]
+	}
+	result.Columns = columns
+	return result, nil
+}
@@ -0,0 +1,157 @@
+package analyze
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAnalyzerAnalyze(t *testing.T) {
+	a, err := New(Options{Quotes: "double", NullTokens: []string{"NULL"}}, "postgresql")
+	if err != nil {
+		t.Fatalf("New() error = %v, want nil", err)
+	}
+	got, err := a.Analyze(strings.NewReader("id,name,born,score\n1,\"Zoë, Ann\",2001-02-03,1.5\n\n70000,Bo,NULL,2\n"))
+	if err != nil {
+		t.Fatalf("Analyze() error = %v, want nil", err)
+	}
+	want := []struct {
+		name, typeName string
+		nullable       bool
+		maxLength      int
+	}{
+		{"id", "integer", false, 5},
+		{"name", "varchar", false, 8},
+		{"born", "date", true, 10},
+		{"score", "numeric", false, 3},
+	}
+	if got.Rows != 2 || len(got.Columns) != len(want) {
+		t.Fatalf("Analyze() = %d rows of %d columns, want 2 of %d", got.Rows, len(got.Columns), len(want))
+	}
+	for i, w := range want {
+		col := got.Columns[i]
+		if col.Name != w.name || col.Type.Name != w.typeName || col.Nullable != w.nullable || col.MaxLength != w.maxLength {
+			t.Errorf("column %d = %s %s nullable %v length %d, want %s %s nullable %v length %d",
+				i, col.Name, col.Type.Name, col.Nullable, col.MaxLength, w.name, w.typeName, w.nullable, w.maxLength)
+		}
+	}
+}
+
+func TestAnalyzerErrors(t *testing.T) {
+	if _, err := New(Options{}, "oracle"); err == nil {
+		t.Error("New() with an unknown flavor error = nil, want error")
+	}
+	if _, err := New(Options{Quotes: "backtick"}, "postgresql"); err == nil {
+		t.Error("New() with unknown quotes error = nil, want error")
+	}
+	a, err := New(Options{Quotes: "double"}, "postgresql")
+	if err != nil {
+		t.Fatalf("New() error = %v, want nil", err)
+	}
+	for _, input := range []string{"a,b\n1\n", "a,b\n\"1,2\n"} {
+		if _, err := a.Analyze(strings.NewReader(input)); err == nil || !strings.HasPrefix(err.Error(), "line 2 ") {
+			t.Errorf("Analyze(%q) error = %v, want an error on line 2", input, err)
+		}
+	}
+	for _, input := range []string{"", "\n\n"} {
+		if _, err := a.Analyze(strings.NewReader(input)); err == nil || err.Error() != "file contains no data" {
+			t.Errorf("Analyze(%q) error = %v, want file contains no data", input, err)
+		}
+	}
+}
+
+// TestAnalyzerReadsAsStream checks that an Analyzer reads files as the tool
+// does: within the size limits, leaving out bad rows when told to and
+// skipping rows that repeat the header
+func TestAnalyzerReadsAsStream(t *testing.T) {
+	input := "id,name\n1,Ann\nid,name\n2,\"" + strings.Repeat("x", 30) + "\"\n3\n4,Bo\n"
+	a, err := New(Options{Quotes: "double", MaxFieldBytes: 20}, "postgresql")
+	if err != nil {
+		t.Fatalf("New() error = %v, want nil", err)
+	}
+	want := "line 4 has a field 2 of 30 bytes, over the -max-field-bytes limit of 20"
+	if _, err := a.Analyze(strings.NewReader(input)); err == nil || err.Error() != want {
+		t.Errorf("Analyze() error = %v, want %q", err, want)
+	}
+
+	a, err = New(Options{Quotes: "double", MaxRecordBytes: 20, SkipBadRows: true}, "postgresql")
+	if err != nil {
+		t.Fatalf("New() error = %v, want nil", err)
+	}
+	got, err := a.Analyze(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Analyze() error = %v, want nil", err)
+	}
+	if got.Rows != 2 || got.HeaderRepeats != 1 || got.Columns[1].MaxLength != 3 {
+		t.Errorf("Analyze() = %d rows, %d header repeats, name length %d, want 2, 1 and 3", got.Rows, got.HeaderRepeats, got.Columns[1].MaxLength)
+	}
+}
+
+// TestAnalyzerConcurrent runs analyses of different files on one Analyzer
+// from many goroutines, under go test -race, and compares them with the
+// same analyses run one at a time
+func TestAnalyzerConcurrent(t *testing.T) {
+	fixtures := []string{
+		"id,name\n1,Ann\n2,Bo\n",
+		"when,amount\n2024-01-02 03:04:05,1.25\n2024-02-03 04:05:06,NULL\n",
+		"flag,count\ntrue,70000\nfalse,9223372036854775807\n",
+		"code,born\nA1,2001-02-03\n,1999-12-31\n",
+	}
+	var big strings.Builder
+	big.WriteString("n,label,ratio\n")
+	for i := range 5000 {
+		fmt.Fprintf(&big, "%d,row %d,%d.%d\n", i*17, i, i, i%10)
+	}
+	fixtures = append(fixtures, big.String())
+
+	for _, flavor := range []string{"postgresql", "snowflake", "hana", "firebird", "vertica", "greenplum", "mariadb", "exasol"} {
+		a, err := New(Options{NullTokens: []string{"NULL"}}, flavor)
+		if err != nil {
+			t.Fatalf("New() error = %v, want nil", err)
+		}
+		want := make([]*Result, len(fixtures))
+		for i, fixture := range fixtures {
+			if want[i], err = a.Analyze(strings.NewReader(fixture)); err != nil {
+				t.Fatalf("Analyze() error = %v, want nil", err)
+			}
+		}
+
+		const rounds = 8
+		got := make([]*Result, rounds*len(fixtures))
+		errs := make([]error, len(got))
+		var wg sync.WaitGroup
+		for i := range got {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got[i], errs[i] = a.Analyze(strings.NewReader(fixtures[i%len(fixtures)]))
+			}()
+		}
+		wg.Wait()
+
+		for i, result := range got {
+			if errs[i] != nil {
+				t.Fatalf("%s: concurrent Analyze() error = %v, want nil", flavor, errs[i])
+			}
+			if !sameResult(result, want[i%len(fixtures)]) {
+				t.Errorf("%s: concurrent Analyze() of fixture %d = %+v, want %+v", flavor, i%len(fixtures), result, want[i%len(fixtures)])
+			}
+		}
+	}
+}
+
+// sameResult compares results by their type names, since types carry
+// functions that cannot be compared
+func sameResult(a, b *Result) bool {
+	if a.Rows != b.Rows || len(a.Columns) != len(b.Columns) {
+		return false
+	}
+	for i, col := range a.Columns {
+		other := b.Columns[i]
+		if col.Name != other.Name || col.Type.Name != other.Type.Name || col.Nullable != other.Nullable || col.MaxLength != other.MaxLength {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,281 @@
+package analyze
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// RowError is a record that cannot be analyzed, such as one over the size
+// limits or with an unterminated quote; SkipBadRows, -on-bad-row in file2ddl,
+// decides whether it stops the analysis
+type RowError struct {
+	Line   int
+	Unit   string // what Line counts, "line" when empty
+	Reason string
+}
+
+func (e *RowError) Error() string {
+	unit := e.Unit
+	if unit == "" {
+		unit = "line"
+	}
+	return fmt.Sprintf("%s %d %s", unit, e.Line, e.Reason)
+}
+
+// Records reads the records of delimited text, split as the options
+// describe: at the record separator, within the size limits, on the
+// delimiter and with the quoting. It is what Stream and an Analyzer read
+// files with, and what file2ddl reads delimited text with, so that a loader
+// using it splits records exactly as the analysis did.
+type Records struct {
+	scanner        *bufio.Scanner
+	limiter        *recordLimiter // discards records over MaxRecordBytes, nil without a limit
+	delimiter      string
+	delimiterRegex *regexp.Regexp
+	quotes         string
+	tsvEscapes     bool
+	maxField       int    // longest field allowed in bytes, 0 without a limit
+	oversize       int    // length of the record scanned when it was discarded, 0 if not
+	quoted         []bool // which fields of the record last read were quoted
+	fieldBuf       []string
+	quotedBuf      []bool
+	line           int
+	offset         int64  // bytes of the input split into records
+	unit           string // what line counts, "line" or "record"
+}
+
+// NewRecords reads the records of delimited text from r
+func NewRecords(r io.Reader, opts Options) *Records {
+	scanner := bufio.NewScanner(r)
+	split := bufio.ScanLines
+	if opts.RecordSeparator != "" {
+		split = scanRecords(opts.RecordSeparator)
+	}
+	records := &Records{scanner: scanner, delimiter: cmp.Or(opts.Delimiter, ","), delimiterRegex: opts.DelimiterRegex, quotes: cmp.Or(opts.Quotes, "none"),
+		tsvEscapes: opts.TSVEscapes, maxField: opts.MaxFieldBytes, unit: recordUnit(opts)}
+	if opts.MaxRecordBytes > 0 {
+		records.limiter = &recordLimiter{split: split, max: opts.MaxRecordBytes}
+		split = records.limiter.Split
+		scanner.Buffer(nil, records.limiter.bufferSize())
+	}
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := split(data, atEOF)
+		records.offset += int64(advance)
+		return advance, token, err
+	})
+	return records
+}
+
+// recordUnit is what line numbers count in messages: lines, or records when
+// a separator other than the newline ends them
+func recordUnit(opts Options) string {
+	if opts.RecordSeparator != "" {
+		return "record"
+	}
+	return "line"
+}
+
+// StartAt numbers the records read from here on as following the given
+// line, for an input that starts part way into a file, such as one resumed
+// from a checkpoint; offset is counted on from in the same way
+func (t *Records) StartAt(line int, offset int64) {
+	t.line, t.offset = line, offset
+}
+
+// Scan advances to the next record without splitting it, returning false at
+// the end of the input or on an error, which Err returns. Skipping records
+// with Scan alone is cheaper than reading them.
+func (t *Records) Scan() bool {
+	if !t.scanner.Scan() {
+		return false
+	}
+	t.line++
+	t.oversize = 0
+	if t.limiter != nil {
+		t.oversize, t.limiter.skipped = t.limiter.skipped, 0
+	}
+	return true
+}
+
+// Err returns the error that ended Scan, nil at the end of the input
+func (t *Records) Err() error {
+	return t.scanner.Err()
+}
+
+// Empty reports whether the record scanned is empty: a blank line, or a
+// record discarded for being over MaxRecordBytes
+func (t *Records) Empty() bool {
+	return len(t.scanner.Bytes()) == 0
+}
+
+// Split splits the record scanned into its fields. A blank record has no
+// fields, nil, and a record over the size limits or with an unterminated
+// quote is a *RowError. The fields are reused for the next record, so a
+// caller copies what it keeps.
+func (t *Records) Split() ([]string, error) {
+	t.quoted = nil
+	if t.oversize > 0 {
+		return nil, &RowError{Line: t.line, Unit: t.unit, Reason: fmt.Sprintf("is %d bytes long, over the -max-record-bytes limit of %d", t.oversize, t.limiter.max)}
+	}
+	if t.Empty() {
+		return nil, nil
+	}
+	// The line is the one string allocated for the record: its fields are
+	// substrings of it, held in buffers reused from record to record
+	line := t.scanner.Text()
+	var fields []string
+	if t.delimiterRegex != nil {
+		fields = t.delimiterRegex.Split(line, -1)
+	} else {
+		var err error
+		fields, t.quoted, err = AppendFields(t.fieldBuf[:0], t.quotedBuf[:0], line, t.delimiter, t.quotes)
+		t.fieldBuf, t.quotedBuf = fields, t.quoted
+		if quoteErr, ok := err.(*UnterminatedQuoteError); ok {
+			return nil, &RowError{Line: t.line, Unit: t.unit, Reason: fmt.Sprintf("has an unterminated quoted field starting at column %d", quoteErr.Column)}
+		}
+	}
+	if t.maxField > 0 {
+		for i, field := range fields {
+			if len(field) > t.maxField {
+				return nil, &RowError{Line: t.line, Unit: t.unit, Reason: fmt.Sprintf("has a field %d of %d bytes, over the -max-field-bytes limit of %d", i+1, len(field), t.maxField)}
+			}
+		}
+	}
+	if t.tsvEscapes {
+		for i, field := range fields {
+			fields[i] = unescapeTSV(field)
+		}
+	}
+	return fields, nil
+}
+
+// Read scans and splits the next record, returning io.EOF at the end of the
+// input
+func (t *Records) Read() ([]string, error) {
+	if !t.Scan() {
+		if err := t.Err(); err != nil {
+			return nil, fmt.Errorf("error reading file: %v", err)
+		}
+		return nil, io.EOF
+	}
+	return t.Split()
+}
+
+// Line returns the line of the record last scanned, counting records
+// rather than lines when a record separator ends them
+func (t *Records) Line() int {
+	return t.line
+}
+
+// Offset returns the bytes of the input split into records so far, where
+// the record after the one last scanned starts
+func (t *Records) Offset() int64 {
+	return t.offset
+}
+
+// Quoted returns which fields of the record last split were quoted, nil
+// when quotes are not processed
+func (t *Records) Quoted() []bool {
+	return t.quoted
+}
+
+// scanRecords returns a split function for bufio.Scanner that ends records
+// at sep instead of a newline. Unlike bufio.ScanLines it strips no carriage
+// returns, which belong to the record when newlines do not end it.
+func scanRecords(sep string) bufio.SplitFunc {
+	sepBytes := []byte(sep)
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.Index(data, sepBytes); i >= 0 {
+			return i + len(sepBytes), data[:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// recordLimiter wraps a split function so that a record longer than max
+// bytes is discarded as it is read instead of buffered whole. An empty token
+// stands for each discarded record, with its length left in skipped.
+type recordLimiter struct {
+	split    bufio.SplitFunc
+	max      int
+	skipping int // bytes of the record being discarded so far
+	skipped  int // length of the record just discarded, 0 if none
+}
+
+// bufferSize is the scanner buffer the limiter needs: room for a record of
+// max bytes and a separator, and one byte more to notice a longer one
+func (l *recordLimiter) bufferSize() int {
+	return l.max + utf8.UTFMax + 1
+}
+
+func (l *recordLimiter) Split(data []byte, atEOF bool) (int, []byte, error) {
+	advance, token, err := l.split(data, atEOF)
+	if err != nil {
+		return advance, token, err
+	}
+	switch {
+	case l.skipping > 0 && token != nil:
+		// The rest of the discarded record
+		l.skipped, l.skipping = l.skipping+len(token), 0
+		return advance, []byte{}, nil
+	case l.skipping > 0 && atEOF:
+		l.skipped, l.skipping = l.skipping+len(data), 0
+		return len(data), []byte{}, nil
+	case l.skipping > 0:
+		l.skipping += len(data)
+		return len(data), nil, nil
+	case token != nil && len(token) > l.max:
+		l.skipped = len(token)
+		return advance, []byte{}, nil
+	case token == nil && !atEOF && len(data) > l.max+utf8.UTFMax:
+		// Too long already, though the separator is not in sight
+		l.skipping = len(data)
+		return len(data), nil, nil
+	}
+	return advance, token, err
+}
+
+// unescapeTSV decodes the escapes TSV writes for the characters a field
+// cannot hold as they are, \t, \n and \r, and for the backslash itself, \\.
+// Other backslashes are taken literally.
+func unescapeTSV(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var b strings.Builder
+	b.Grow(len(field))
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		if c != '\\' || i+1 == len(field) {
+			b.WriteByte(c)
+			continue
+		}
+		switch field[i+1] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case '\\':
+			b.WriteByte('\\')
+		default:
+			b.WriteByte(c)
+			continue
+		}
+		i++
+	}
+	return b.String()
+}
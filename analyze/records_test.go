@@ -0,0 +1,23 @@
+package analyze
+
+import "testing"
+
+func TestUnescapeTSV(t *testing.T) {
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"plain", "plain"},
+		{`a\tb`, "a\tb"},
+		{`line 1\nline 2\r\n`, "line 1\nline 2\r\n"},
+		{`C:\\temp`, `C:\temp`},
+		{`\\t`, `\t`},
+		{`\x41`, `\x41`},
+		{`trailing\`, `trailing\`},
+	}
+	for _, tt := range tests {
+		if got := unescapeTSV(tt.field); got != tt.want {
+			t.Errorf("unescapeTSV(%q) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package analyze
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Stream reads the records of delimited text from r, the header included,
+// and hands each to fn with its line number. The records are split as for
+// the analysis, so loaders can reuse its handling of delimiters, quotes and
+// size limits. Blank lines are skipped, or are errors with StrictBlankLines,
+// and rows that cannot be read are errors unless SkipBadRows is set. An
+// error returned by fn stops the stream and is returned. The fields are
+// reused for the next record, so fn copies what it keeps.
+func Stream(r io.Reader, opts Options, fn func(line int, fields []string) error) error {
+	records := NewRecords(r, opts)
+	for {
+		fields, err := records.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) && opts.SkipBadRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if fields == nil {
+			if opts.StrictBlankLines {
+				return fmt.Errorf("%s %d is blank", recordUnit(opts), records.Line())
+			}
+			continue
+		}
+		if err := fn(records.Line(), fields); err != nil {
+			return err
+		}
+	}
+}
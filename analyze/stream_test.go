@@ -0,0 +1,56 @@
+package analyze
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	input := "id,name\n1,\"Smith, John\"\n\n2,\"Doe\n3,Lee\n"
+	type record struct {
+		line   int
+		fields []string
+	}
+	collect := func(opts Options) ([]record, error) {
+		var records []record
+		err := Stream(strings.NewReader(input), opts, func(line int, fields []string) error {
+			records = append(records, record{line, slices.Clone(fields)})
+			return nil
+		})
+		return records, err
+	}
+
+	opts := Options{Quotes: "double"}
+	_, err := collect(opts)
+	if want := "line 4 has an unterminated quoted field starting at column 2"; err == nil || err.Error() != want {
+		t.Fatalf("Stream() error = %v, want %q", err, want)
+	}
+
+	opts.SkipBadRows = true
+	got, err := collect(opts)
+	if err != nil {
+		t.Fatalf("Stream() error = %v, want nil", err)
+	}
+	want := []record{
+		{1, []string{"id", "name"}},
+		{2, []string{"1", "Smith, John"}},
+		{5, []string{"3", "Lee"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Stream() records = %v, want %v", got, want)
+	}
+
+	// An error from the callback stops the stream
+	stop := errors.New("stop")
+	calls := 0
+	err = Stream(strings.NewReader(input), opts, func(line int, fields []string) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Stream() = %v after %d calls, want %v after 1", err, calls, stop)
+	}
+}
@@ -0,0 +1,224 @@
+package analyze
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// IsBoolean reports whether the value is true, false, t or f in any case
+func IsBoolean(value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	// Only consider explicit boolean values, not numeric 1/0
+	return value == "true" || value == "false" || value == "t" || value == "f"
+}
+
+// IsTinyInt reports whether the value is an integer that fits a signed 8
+// bits, -128 to 127
+func IsTinyInt(value string) bool {
+	_, err := strconv.ParseInt(value, 10, 8)
+	return err == nil
+}
+
+// IsUnsignedTinyInt reports whether the value is an integer from 0 to 255
+func IsUnsignedTinyInt(value string) bool {
+	_, err := strconv.ParseUint(value, 10, 8)
+	return err == nil
+}
+
+// IsSmallInt reports whether the value is an integer that fits 16 bits
+func IsSmallInt(value string) bool {
+	num, err := strconv.ParseInt(value, 10, 16)
+	return err == nil && num >= -32768 && num <= 32767
+}
+
+// IsInteger reports whether the value is an integer that fits 32 bits
+func IsInteger(value string) bool {
+	_, err := strconv.ParseInt(value, 10, 32)
+	return err == nil
+}
+
+// IsBigInt reports whether the value is an integer that fits 64 bits
+func IsBigInt(value string) bool {
+	_, err := strconv.ParseInt(value, 10, 64)
+	return err == nil
+}
+
+// IsNumeric reports whether the value is a decimal or floating-point number
+func IsNumeric(value string) bool {
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+// uuidPattern matches a UUID in its canonical 8-4-4-4-12 hex form
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsUUID reports whether the value is a UUID written as 32 hex digits in
+// groups of 8, 4, 4, 4 and 12
+func IsUUID(value string) bool {
+	return uuidPattern.MatchString(value)
+}
+
+// IsTimestamp reports whether the value is a timestamp in one of the layouts
+// ParseTimestamp reads
+func IsTimestamp(value string) bool {
+	_, ok := ParseTimestamp(value, time.UTC)
+	return ok
+}
+
+// ParseTimestamp returns the instant a timestamp value stands for, zoneless
+// values being read in loc
+func ParseTimestamp(value string, loc *time.Location) (time.Time, bool) {
+	t, _, ok := MatchTimestamp(value, loc)
+	return t, ok
+}
+
+// MatchTimestamp is ParseTimestamp that also returns the Go layout the value
+// matched, e.g. 2006-01-02 15:04:05. The 12-hour layouts match either case
+// of the meridiem.
+func MatchTimestamp(value string, loc *time.Location) (time.Time, string, bool) {
+	// Try common timestamp formats
+	formats := []string{
+		"2006-01-02 15:04:05",
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05.000",
+		"2006-01-02T15:04:05.000",
+		time.RFC3339,
+		time.RFC1123,
+		time.RFC1123Z,
+		time.RFC850,
+		time.RFC822,
+		time.RFC822Z,
+		// Excel and SQL Server Management Studio exports
+		"1/2/2006 15:04:05",
+		"1/2/2006 15:04",
+	}
+
+	for _, format := range formats {
+		if t, err := time.ParseInLocation(format, value, loc); err == nil {
+			return t, format, true
+		}
+	}
+
+	// time.Parse only accepts an upper-case meridiem for the PM layout, so
+	// normalize the case before trying the 12-hour layouts
+	upper := strings.ToUpper(value)
+	if !strings.HasSuffix(upper, "AM") && !strings.HasSuffix(upper, "PM") {
+		return time.Time{}, "", false
+	}
+	twelveHourFormats := []string{
+		"1/2/2006 3:04:05 PM",
+		"1/2/2006 3:04 PM",
+		"1-2-2006 3:04:05 PM",
+		"1-2-2006 3:04 PM",
+		"2006-01-02 3:04:05 PM",
+		"2006-01-02 3:04 PM",
+	}
+	for _, format := range twelveHourFormats {
+		if t, err := time.ParseInLocation(format, upper, loc); err == nil {
+			return t, format, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// IsDate reports whether the value is a date in one of the layouts ParseDate
+// reads
+func IsDate(value string, opts Options) bool {
+	_, ok := ParseDate(value, opts)
+	return ok
+}
+
+// ParseDate returns the day a date value stands for, as midnight UTC
+func ParseDate(value string, opts Options) (time.Time, bool) {
+	t, _, ok := MatchDate(value, opts)
+	return t, ok
+}
+
+// MatchDate is ParseDate that also returns the Go layout the value matched,
+// e.g. 01/02/2006. The layout is empty for values with ordinal day
+// suffixes, which no layout describes.
+func MatchDate(value string, opts Options) (time.Time, string, bool) {
+	// Try common date formats
+	formats := []string{
+		"2006-01-02",
+		"01/02/2006",
+		"02/01/2006",
+		// Month-name layouts; month and weekday names match case-insensitively
+		"Jan 2, 2006",
+		"January 2, 2006",
+		"Jan 2 2006",
+		"January 2 2006",
+		"2 Jan 2006",
+		"2 January 2006",
+		"2-Jan-2006",
+		"2-January-2006",
+		"Mon, Jan 2, 2006",
+		"Monday, January 2, 2006",
+		"Mon, 2 Jan 2006",
+		"Monday, 2 January 2006",
+	}
+
+	// Ordinal day suffixes ("March 20th, 2024") are not understood by
+	// time.Parse, so drop them before matching
+	suffixed := false
+	if strings.IndexFunc(value, unicode.IsLetter) >= 0 {
+		stripped := ordinalSuffix.ReplaceAllString(value, "$1")
+		suffixed = stripped != value
+		value = stripped
+	}
+
+	for _, format := range formats {
+		if t, err := time.Parse(format, value); err == nil {
+			if suffixed {
+				format = ""
+			}
+			return t, format, true
+		}
+	}
+
+	if opts.TwoDigitYears {
+		return parseTwoDigitYearDate(value, opts.YearPivot)
+	}
+	return time.Time{}, "", false
+}
+
+// parseTwoDigitYearDate returns the day a date with a two-digit year stands
+// for. Years below the pivot belong to the 2000s and the rest to the 1900s;
+// the century matters because it decides whether February 29 exists.
+func parseTwoDigitYearDate(value string, pivot int) (time.Time, string, bool) {
+	formats := []string{
+		"01/02/06",
+		"02/01/06",
+		"1/2/06",
+		"2-Jan-06",
+		"2 Jan 06",
+	}
+
+	for _, format := range formats {
+		t, err := time.Parse(format, value)
+		if err != nil {
+			continue
+		}
+		// time.Parse applies its own pivot, so re-check the day against the
+		// configured century
+		year := twoDigitYear(t.Year()%100, pivot)
+		if day := time.Date(year, t.Month(), t.Day(), 0, 0, 0, 0, time.UTC); day.Day() == t.Day() {
+			return day, format, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// twoDigitYear expands a two-digit year to four digits using the pivot
+func twoDigitYear(yy, pivot int) int {
+	if yy < pivot {
+		return 2000 + yy
+	}
+	return 1900 + yy
+}
+
+// ordinalSuffix matches an English ordinal suffix directly after a day number
+var ordinalSuffix = regexp.MustCompile(`(?i)\b(\d{1,2})(st|nd|rd|th)\b`)
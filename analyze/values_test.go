@@ -0,0 +1,69 @@
+package analyze
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTwoDigitYear(t *testing.T) {
+	tests := []struct {
+		yy, pivot, expected int
+	}{
+		{68, 69, 2068},
+		{69, 69, 1969},
+		{30, 30, 1930},
+	}
+	for _, tt := range tests {
+		if got := twoDigitYear(tt.yy, tt.pivot); got != tt.expected {
+			t.Errorf("twoDigitYear(%d, %d) = %d, want %d", tt.yy, tt.pivot, got, tt.expected)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value    string
+		opts     Options
+		expected string
+	}{
+		{"2024-03-20", Options{}, "2024-03-20"},
+		{"March 20th, 2024", Options{}, "2024-03-20"},
+		{"03/20/24", Options{}, ""},
+		{"03/20/24", Options{TwoDigitYears: true, YearPivot: 69}, "2024-03-20"},
+		{"03/20/70", Options{TwoDigitYears: true, YearPivot: 69}, "1970-03-20"},
+	}
+	for _, tt := range tests {
+		got, ok := ParseDate(tt.value, tt.opts)
+		if !ok {
+			if tt.expected != "" {
+				t.Errorf("ParseDate(%q) failed, want %s", tt.value, tt.expected)
+			}
+			continue
+		}
+		if got.Format("2006-01-02") != tt.expected {
+			t.Errorf("ParseDate(%q) = %s, want %q", tt.value, got.Format("2006-01-02"), tt.expected)
+		}
+	}
+}
+
+func TestMatchLayouts(t *testing.T) {
+	dates := []struct{ value, layout string }{
+		{"2024-03-20", "2006-01-02"},
+		{"20 March 2024", "2 January 2006"},
+		{"March 20th, 2024", ""},
+	}
+	for _, tt := range dates {
+		if _, layout, ok := MatchDate(tt.value, Options{}); !ok || layout != tt.layout {
+			t.Errorf("MatchDate(%q) = %q, %v, want %q", tt.value, layout, ok, tt.layout)
+		}
+	}
+	timestamps := []struct{ value, layout string }{
+		{"2024-03-20T10:30:00", "2006-01-02T15:04:05"},
+		{"3/20/2024 10:30 pm", "1/2/2006 3:04 PM"},
+	}
+	for _, tt := range timestamps {
+		if _, layout, ok := MatchTimestamp(tt.value, time.UTC); !ok || layout != tt.layout {
+			t.Errorf("MatchTimestamp(%q) = %q, %v, want %q", tt.value, layout, ok, tt.layout)
+		}
+	}
+}
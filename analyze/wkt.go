@@ -0,0 +1,162 @@
+package analyze
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// IsWKT reports whether the value is a well-known text geometry: one of the
+// common primitives or a collection of them, optionally prefixed with an
+// EWKT "SRID=n;" and carrying Z, M or ZM coordinates
+func IsWKT(value string) bool {
+	p := &wktParser{input: strings.TrimSpace(value)}
+	if strings.HasPrefix(strings.ToUpper(p.input), "SRID=") {
+		semi := strings.IndexByte(p.input, ';')
+		if semi < 0 {
+			return false
+		}
+		if _, err := strconv.Atoi(p.input[len("SRID="):semi]); err != nil {
+			return false
+		}
+		p.pos = semi + 1
+	}
+	if !p.geometry() {
+		return false
+	}
+	p.skipSpace()
+	return p.pos == len(p.input)
+}
+
+// wktParser is a small recursive-descent parser over a WKT string
+type wktParser struct {
+	input string
+	pos   int
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// word consumes and returns the next run of letters, upper-cased
+func (p *wktParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && unicode.IsLetter(rune(p.input[p.pos])) {
+		p.pos++
+	}
+	return strings.ToUpper(p.input[start:p.pos])
+}
+
+// peekWord returns the next word without consuming it
+func (p *wktParser) peekWord() string {
+	pos := p.pos
+	w := p.word()
+	p.pos = pos
+	return w
+}
+
+// consume skips the given punctuation character if it comes next
+func (p *wktParser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// geometry parses a tagged geometry such as "POINT Z (1 2 3)"
+func (p *wktParser) geometry() bool {
+	tag := p.word()
+	switch p.peekWord() {
+	case "Z", "M", "ZM":
+		p.word()
+	}
+	if p.peekWord() == "EMPTY" {
+		p.word()
+		return isWKTTag(tag)
+	}
+
+	switch tag {
+	case "POINT":
+		return p.list(1, p.coordinate)
+	case "LINESTRING":
+		return p.list(2, p.coordinate)
+	case "POLYGON":
+		return p.list(1, p.ring)
+	case "MULTIPOINT":
+		return p.list(1, p.multiPointMember)
+	case "MULTILINESTRING":
+		return p.list(1, func() bool { return p.list(2, p.coordinate) })
+	case "MULTIPOLYGON":
+		return p.list(1, func() bool { return p.list(1, p.ring) })
+	case "GEOMETRYCOLLECTION":
+		return p.list(1, p.geometry)
+	}
+	return false
+}
+
+// isWKTTag reports whether the tag names a supported geometry type
+func isWKTTag(tag string) bool {
+	switch tag {
+	case "POINT", "LINESTRING", "POLYGON", "MULTIPOINT", "MULTILINESTRING", "MULTIPOLYGON", "GEOMETRYCOLLECTION":
+		return true
+	}
+	return false
+}
+
+// list parses a parenthesized, comma-separated list of at least min elements
+func (p *wktParser) list(min int, element func() bool) bool {
+	if !p.consume('(') {
+		return false
+	}
+	count := 0
+	for {
+		if !element() {
+			return false
+		}
+		count++
+		if !p.consume(',') {
+			break
+		}
+	}
+	return p.consume(')') && count >= min
+}
+
+// ring parses a polygon ring, which needs at least four positions
+func (p *wktParser) ring() bool {
+	return p.list(4, p.coordinate)
+}
+
+// multiPointMember accepts both "MULTIPOINT (1 2, 3 4)" and
+// "MULTIPOINT ((1 2), (3 4))"
+func (p *wktParser) multiPointMember() bool {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == '(' {
+		return p.list(1, p.coordinate)
+	}
+	return p.coordinate()
+}
+
+// coordinate parses a position of two to four space-separated numbers
+func (p *wktParser) coordinate() bool {
+	count := 0
+	for count < 4 {
+		p.skipSpace()
+		start := p.pos
+		for p.pos < len(p.input) && strings.IndexByte("+-.0123456789eE", p.input[p.pos]) >= 0 {
+			p.pos++
+		}
+		if start == p.pos {
+			break
+		}
+		if _, err := strconv.ParseFloat(p.input[start:p.pos], 64); err != nil {
+			return false
+		}
+		count++
+	}
+	return count >= 2
+}
@@ -0,0 +1,40 @@
+package analyze
+
+import "testing"
+
+func TestIsWKT(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{"POINT(-71.06 42.36)", true},
+		{"point (-71.06 42.36)", true},
+		{"POINT Z (1 2 3)", true},
+		{"POINT EMPTY", true},
+		{"SRID=4326;POINT(-71.06 42.36)", true},
+		{"LINESTRING(0 0, 1 1, 2 1)", true},
+		{"POLYGON((0 0, 4 0, 4 4, 0 4, 0 0), (1 1, 2 1, 2 2, 1 1))", true},
+		{"MULTIPOINT(0 0, 1 1)", true},
+		{"MULTIPOINT((0 0), (1 1))", true},
+		{"MULTILINESTRING((0 0, 1 1), (2 2, 3 3))", true},
+		{"MULTIPOLYGON(((0 0, 1 0, 1 1, 0 0)), ((2 2, 3 2, 3 3, 2 2)))", true},
+		{"GEOMETRYCOLLECTION(POINT(1 2), LINESTRING(0 0, 1 1))", true},
+		{"POINT(-71.06)", false},
+		{"POINT(a b)", false},
+		{"POINT(1 2", false},
+		{"POINT(1 2) trailing", false},
+		{"LINESTRING(0 0)", false},
+		{"POLYGON((0 0, 1 1, 0 0))", false},
+		{"CIRCLE(0 0, 1)", false},
+		{"SRID=abc;POINT(1 2)", false},
+		{"Hello, World!", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := IsWKT(tt.value); got != tt.expected {
+				t.Errorf("IsWKT(%q) = %v, want %v", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
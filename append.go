@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// appendSchema is the part of a state or manifest file that -check-append
+// compares with: a state lists its columns at the top level, a manifest
+// under its schema
+type appendSchema struct {
+	Columns []stateColumn `json:"columns"`
+	Schema  *struct {
+		Columns []jsonColumn `json:"columns"`
+	} `json:"schema"`
+}
+
+// loadAppendSchema reads the columns of a previous run from its state or
+// manifest file. Manifest types are spelled as in the output, e.g.
+// varchar(20), and are read back as the flavor's type names.
+func loadAppendSchema(path string, analyzer dbtypes.TypeAnalyzer) ([]stateColumn, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	var schema appendSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if schema.Schema == nil {
+		if schema.Columns == nil {
+			return nil, fmt.Errorf("%s is neither a state nor a manifest file", path)
+		}
+		return schema.Columns, nil
+	}
+
+	spellings := make(map[string]string)
+	for _, t := range analyzer.GetTypes() {
+		spelling := t.Name
+		if namer, ok := analyzer.(dbtypes.TypeNamer); ok {
+			spelling = namer.TypeName(t.Name)
+		}
+		// Snowflake spells text as varchar; text is told apart by its
+		// missing length below
+		if _, ok := spellings[strings.ToLower(spelling)]; !ok {
+			spellings[strings.ToLower(spelling)] = t.Name
+		}
+	}
+	// A manifest written with -column-order name lists the columns by name,
+	// while the table has them in file order
+	slices.SortStableFunc(schema.Schema.Columns, func(a, b jsonColumn) int {
+		return a.Ordinal - b.Ordinal
+	})
+	var columns []stateColumn
+	for _, col := range schema.Schema.Columns {
+		spelling, _, sized := strings.Cut(strings.ToLower(col.Type), "(")
+		name, ok := spellings[spelling]
+		if !ok {
+			return nil, fmt.Errorf("%s: column %s has type %s, which the flavor does not support", path, col.Name, col.Type)
+		}
+		if name == "varchar" && !sized {
+			name = "text"
+		}
+		columns = append(columns, stateColumn{Name: col.Name, Type: name, MaxLength: col.MaxLength})
+	}
+	return columns, nil
+}
+
+// checkAppend lists why the analyzed file could not be loaded into a table
+// created for the previous columns: they must have the same names in the
+// same order, each column's type must widen to the previous one, and
+// varchar and char values may be at most pad characters longer than the
+// previous length. Columns without values fit any type.
+func checkAppend(result *fileAnalysis, previous []stateColumn, analyzer dbtypes.TypeAnalyzer, pad int) []string {
+	if err := compareHeaders(previous, result.Columns); err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	for i, col := range result.Columns {
+		prev := previous[i]
+		if col.EmptyCount == result.RowCount {
+			continue
+		}
+		current := analyzer.GetTypes()[col.TypeIndex].Name
+		if !dbtypes.IsCompatible(analyzer, current, prev.Type) {
+			problems = append(problems, fmt.Sprintf("column %s: %s values do not fit %s", col.Name, current, prev.Type))
+			continue
+		}
+
+		if index := typeIndex(analyzer, prev.Type); index < 0 || !analyzer.GetTypes()[index].HasLength {
+			continue
+		}
+		// The longest value, even when -varchar-percentile sized the column
+		// shorter
+		longest := col.MaxLength
+		if len(col.LengthCounts) > 0 {
+			longest = max(longest, lengthPercentile(col.LengthCounts, 100))
+		}
+		if limit := prev.MaxLength + pad; longest > limit {
+			problems = append(problems, fmt.Sprintf("column %s: values up to %d characters long exceed %s(%d) with a pad of %d",
+				col.Name, longest, prev.Type, prev.MaxLength, pad))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestCheckAppend(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}
+	previous := []stateColumn{
+		{Name: "id", Type: "integer"},
+		{Name: "name", Type: "varchar", MaxLength: 5},
+		{Name: "amount", Type: "smallint"},
+		{Name: "note", Type: "date"},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		pad   int
+		want  []string
+	}{
+		{
+			name:  "compatible",
+			input: "id,name,amount,note\n1,ada,3,\n2,grace,4,\n",
+		},
+		{
+			name:  "narrower types widen to the previous ones",
+			input: "id,name,amount,note\n1,ada,3,2024-01-02\n",
+		},
+		{
+			name:  "incompatible types and long values",
+			input: "id,name,amount,note\n1,adalovelace,3.5,soon\n",
+			want: []string{
+				"column name: values up to 11 characters long exceed varchar(5) with a pad of 0",
+				"column amount: numeric values do not fit smallint",
+				"column note: varchar values do not fit date",
+			},
+		},
+		{
+			name:  "pad",
+			input: "id,name,amount,note\n1,adalovelace,3,\n",
+			pad:   6,
+		},
+		{
+			name:  "reordered columns",
+			input: "name,id,amount,note\nada,1,3,\n",
+			want:  []string{"headers do not match the saved state: columns reordered"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeFileTypes(strings.NewReader(tt.input), opts, analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			got := checkAppend(result, previous, analyzer, tt.pad)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkAppend() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAppendSchema(t *testing.T) {
+	analyzer := &dbtypes.SnowflakeAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}
+	input := "id,name,amount,note\n1,ada,3.25,2024-01-02 10:00:00\n"
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	result.Columns[3].TypeIndex = typeIndex(analyzer, "text")
+
+	dir := t.TempDir()
+	manifest := newManifest(result, analyzer, manifestInput{}, newContentHash(), nil, "t", time.Now())
+	manifestPath := filepath.Join(dir, "run.manifest.json")
+	if err := writeManifest(manifestPath, manifest); err != nil {
+		t.Fatal(err)
+	}
+	statePath := filepath.Join(dir, "state.json")
+	if err := saveState(statePath, result, analyzer); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both files give the same columns, the manifest with Snowflake spellings
+	want := []stateColumn{
+		{Name: "id", Type: "smallint"},
+		{Name: "name", Type: "varchar", MaxLength: 3},
+		{Name: "amount", Type: "numeric"},
+		{Name: "note", Type: "text"},
+	}
+	for _, path := range []string{manifestPath, statePath} {
+		got, err := loadAppendSchema(path, analyzer)
+		if err != nil {
+			t.Fatalf("loadAppendSchema(%s) error = %v, want nil", filepath.Base(path), err)
+		}
+		for i := range got {
+			got[i] = stateColumn{Name: got[i].Name, Type: got[i].Type, MaxLength: got[i].MaxLength}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("loadAppendSchema(%s) = %+v, want %+v", filepath.Base(path), got, want)
+		}
+	}
+
+	other := filepath.Join(dir, "other.json")
+	if err := os.WriteFile(other, []byte(`{"tool": "x"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadAppendSchema(other, analyzer); err == nil {
+		t.Error("loadAppendSchema() of a file without columns: error = nil, want an error")
+	}
+}
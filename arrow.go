@@ -0,0 +1,604 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// arrowMagic starts and ends an Arrow IPC file, which Feather v2 files are
+const arrowMagic = "ARROW1"
+
+// maxEnumSymbols caps the dictionary of a dictionary-encoded string column
+// reported as enum symbols
+const maxEnumSymbols = 50
+
+// Arrow message header types
+const (
+	arrowSchema          = 1
+	arrowDictionaryBatch = 2
+	arrowRecordBatch     = 3
+)
+
+// arrowTypeNames names the members of the flatbuffer Type union, by index
+var arrowTypeNames = []string{
+	"", "null", "int", "floatingpoint", "binary", "utf8", "bool", "decimal",
+	"date", "time", "timestamp", "interval", "list", "struct", "union",
+	"fixedsizebinary", "fixedsizelist", "map", "duration", "largebinary",
+	"largeutf8", "largelist", "runendencoded", "binaryview", "utf8view",
+	"listview", "largelistview",
+}
+
+// arrowTimeUnits names the TimeUnit enum
+var arrowTimeUnits = []string{"SECOND", "MILLISECOND", "MICROSECOND", "NANOSECOND"}
+
+// fbTable is a table in a flatbuffer. Its accessors take the field's index
+// in the table's schema and do no bounds checking of their own; a corrupt
+// buffer makes them panic, which decodeArrowMessage recovers from.
+type fbTable struct {
+	buf []byte
+	pos int
+}
+
+// fbRoot returns the root table of a flatbuffer
+func fbRoot(buf []byte) fbTable {
+	return fbTable{buf, int(binary.LittleEndian.Uint32(buf))}
+}
+
+// field returns the position of the field's value, 0 when it is absent
+func (t fbTable) field(i int) int {
+	vtable := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	entry := 4 + 2*i
+	if entry+2 > int(binary.LittleEndian.Uint16(t.buf[vtable:])) {
+		return 0
+	}
+	if off := int(binary.LittleEndian.Uint16(t.buf[vtable+entry:])); off != 0 {
+		return t.pos + off
+	}
+	return 0
+}
+
+func (t fbTable) uint8(i int, def uint8) uint8 {
+	if p := t.field(i); p != 0 {
+		return t.buf[p]
+	}
+	return def
+}
+
+func (t fbTable) int16(i int, def int16) int16 {
+	if p := t.field(i); p != 0 {
+		return int16(binary.LittleEndian.Uint16(t.buf[p:]))
+	}
+	return def
+}
+
+func (t fbTable) int32(i int, def int32) int32 {
+	if p := t.field(i); p != 0 {
+		return int32(binary.LittleEndian.Uint32(t.buf[p:]))
+	}
+	return def
+}
+
+func (t fbTable) int64(i int, def int64) int64 {
+	if p := t.field(i); p != 0 {
+		return int64(binary.LittleEndian.Uint64(t.buf[p:]))
+	}
+	return def
+}
+
+// deref follows the offset stored at p to the object it points to
+func (t fbTable) deref(p int) int {
+	return p + int(binary.LittleEndian.Uint32(t.buf[p:]))
+}
+
+// table returns a field holding a table, false when it is absent
+func (t fbTable) table(i int) (fbTable, bool) {
+	if p := t.field(i); p != 0 {
+		return fbTable{t.buf, t.deref(p)}, true
+	}
+	return fbTable{}, false
+}
+
+func (t fbTable) string(i int) string {
+	p := t.field(i)
+	if p == 0 {
+		return ""
+	}
+	p = t.deref(p)
+	n := int(binary.LittleEndian.Uint32(t.buf[p:]))
+	return string(t.buf[p+4 : p+4+n])
+}
+
+// vector returns the position of a vector field's first element and its
+// length
+func (t fbTable) vector(i int) (int, int) {
+	p := t.field(i)
+	if p == 0 {
+		return 0, 0
+	}
+	p = t.deref(p)
+	return p + 4, int(binary.LittleEndian.Uint32(t.buf[p:]))
+}
+
+// tables returns the tables of a vector field
+func (t fbTable) tables(i int) []fbTable {
+	start, n := t.vector(i)
+	tables := make([]fbTable, n)
+	for j := range tables {
+		tables[j] = fbTable{t.buf, t.deref(start + 4*j)}
+	}
+	return tables
+}
+
+// int64Pairs returns the elements of a vector of structs of two longs, such
+// as FieldNode and Buffer
+func (t fbTable) int64Pairs(i int) [][2]int64 {
+	start, n := t.vector(i)
+	pairs := make([][2]int64, n)
+	for j := range pairs {
+		p := start + 16*j
+		pairs[j][0] = int64(binary.LittleEndian.Uint64(t.buf[p:]))
+		pairs[j][1] = int64(binary.LittleEndian.Uint64(t.buf[p+8:]))
+	}
+	return pairs
+}
+
+// arrowField is a field of an Arrow schema
+type arrowField struct {
+	Name       string
+	Nullable   bool
+	Type       dbtypes.ArrowType
+	Dictionary int64 // id of the field's dictionary, -1 when not dictionary-encoded
+	Buffers    int   // buffers of the field itself in a record batch, -1 when variable
+	Children   []arrowField
+}
+
+// nodes returns the field nodes a record batch holds for the field and its
+// descendants
+func (f arrowField) nodes() int {
+	n := 1
+	for _, child := range f.Children {
+		n += child.nodes()
+	}
+	return n
+}
+
+// buffers returns the buffers a record batch holds for the field and its
+// descendants, -1 when that varies from batch to batch
+func (f arrowField) buffers() int {
+	n := f.Buffers
+	for _, child := range f.Children {
+		if n < 0 {
+			break
+		}
+		if b := child.buffers(); b < 0 {
+			n = -1
+		} else {
+			n += b
+		}
+	}
+	return n
+}
+
+// parseArrowField parses a Field table
+func parseArrowField(t fbTable) arrowField {
+	f := arrowField{Name: t.string(0), Nullable: t.uint8(1, 0) != 0, Dictionary: -1}
+	typeIndex := int(t.uint8(2, 0))
+	if typeIndex < len(arrowTypeNames) {
+		f.Type.Type = arrowTypeNames[typeIndex]
+	}
+	typ, _ := t.table(3)
+	switch f.Type.Type {
+	case "null", "runendencoded":
+		f.Buffers = 0
+	case "binary", "utf8", "largebinary", "largeutf8", "listview", "largelistview":
+		f.Buffers = 3
+	case "struct", "fixedsizelist":
+		f.Buffers = 1
+	case "union":
+		// Sparse unions have a buffer of type ids, dense ones offsets too
+		f.Buffers = 1 + int(typ.int16(0, 0))
+	case "binaryview", "utf8view", "":
+		f.Buffers = -1
+	default:
+		f.Buffers = 2
+	}
+
+	switch f.Type.Type {
+	case "int":
+		f.Type.BitWidth = int(typ.int32(0, 0))
+		f.Type.Signed = typ.uint8(1, 0) != 0
+	case "decimal":
+		f.Type.Precision = int(typ.int32(0, 0))
+		f.Type.Scale = int(typ.int32(1, 0))
+	case "timestamp":
+		if unit := int(typ.int16(0, 0)); unit >= 0 && unit < len(arrowTimeUnits) {
+			f.Type.Unit = arrowTimeUnits[unit]
+		}
+		f.Type.Timezone = typ.string(1)
+	}
+	if dictionary, ok := t.table(4); ok {
+		f.Dictionary = dictionary.int64(0, 0)
+	}
+	for _, child := range t.tables(5) {
+		f.Children = append(f.Children, parseArrowField(child))
+	}
+	return f
+}
+
+// arrowBatch is the metadata of a record batch: its length, a length and
+// null count per field node, and an offset and length per buffer in the
+// message body
+type arrowBatch struct {
+	Length     int64
+	Nodes      [][2]int64
+	Buffers    [][2]int64
+	Compressed bool
+}
+
+func parseArrowBatch(t fbTable) arrowBatch {
+	_, compressed := t.table(3)
+	return arrowBatch{
+		Length:     t.int64(0, 0),
+		Nodes:      t.int64Pairs(1),
+		Buffers:    t.int64Pairs(2),
+		Compressed: compressed,
+	}
+}
+
+// buffer returns the i-th buffer of a batch from its message body
+func (b arrowBatch) buffer(body []byte, i int) ([]byte, error) {
+	if i < 0 || i >= len(b.Buffers) {
+		return nil, fmt.Errorf("missing buffer %d", i)
+	}
+	offset, length := b.Buffers[i][0], b.Buffers[i][1]
+	if offset < 0 || length < 0 || offset+length > int64(len(body)) {
+		return nil, fmt.Errorf("buffer %d lies outside the message body", i)
+	}
+	return body[offset : offset+length], nil
+}
+
+// arrowStrings returns the values of a utf8 or large utf8 node of length n
+// whose offsets and data are the given buffers
+func arrowStrings(offsets, data []byte, n int, large bool) ([]string, error) {
+	width := 4
+	if large {
+		width = 8
+	}
+	if len(offsets) < (n+1)*width {
+		return nil, fmt.Errorf("offsets buffer too short")
+	}
+	offset := func(i int) int64 {
+		if large {
+			return int64(binary.LittleEndian.Uint64(offsets[i*8:]))
+		}
+		return int64(int32(binary.LittleEndian.Uint32(offsets[i*4:])))
+	}
+	values := make([]string, n)
+	for i := range values {
+		start, end := offset(i), offset(i+1)
+		if start < 0 || end < start || end > int64(len(data)) {
+			return nil, fmt.Errorf("bad string offsets")
+		}
+		values[i] = string(data[start:end])
+	}
+	return values, nil
+}
+
+// arrowMessage is a decoded message: a schema, or the batch of a record
+// batch or dictionary batch
+type arrowMessage struct {
+	Type       int
+	BodyLength int64
+	Fields     []arrowField
+	Batch      arrowBatch
+	Dictionary int64 // id of a dictionary batch's dictionary
+	Delta      bool  // whether a dictionary batch adds to its dictionary
+}
+
+// decodeArrowMessage decodes the flatbuffer metadata of a message
+func decodeArrowMessage(meta []byte) (msg arrowMessage, err error) {
+	defer func() {
+		if recover() != nil {
+			err = fmt.Errorf("corrupt message metadata")
+		}
+	}()
+	root := fbRoot(meta)
+	msg.Type = int(root.uint8(1, 0))
+	msg.BodyLength = root.int64(3, 0)
+	header, ok := root.table(2)
+	if !ok {
+		return msg, fmt.Errorf("message has no header")
+	}
+	switch msg.Type {
+	case arrowSchema:
+		for _, field := range header.tables(1) {
+			msg.Fields = append(msg.Fields, parseArrowField(field))
+		}
+	case arrowDictionaryBatch:
+		msg.Dictionary = header.int64(0, 0)
+		msg.Delta = header.uint8(2, 0) != 0
+		if data, ok := header.table(1); ok {
+			msg.Batch = parseArrowBatch(data)
+		}
+	case arrowRecordBatch:
+		msg.Batch = parseArrowBatch(header)
+	}
+	return msg, nil
+}
+
+// arrowFile is what file2ddl needs from an Arrow IPC stream or file
+type arrowFile struct {
+	Fields       []arrowField
+	RowCount     int
+	Nulls        []int              // nulls in each field, from the record batches' field nodes
+	MaxLength    []int              // longest string in each string field, measured by a scan
+	Dictionaries map[int64][]string // values of string dictionaries, read by a scan
+	Scanned      bool
+}
+
+// readArrow reads the schema of an Arrow IPC stream, or of an IPC file
+// (Feather v2) by way of the stream it embeds, and counts rows and nulls
+// from the metadata of the record batches, skipping over their bodies. With
+// scan set the bodies of uncompressed batches are read as well, to measure
+// strings and collect the values of string dictionaries.
+func readArrow(r io.Reader, scan bool) (*arrowFile, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(8)
+	switch {
+	case bytes.HasPrefix(head, []byte(arrowMagic)):
+		// The file's magic is padded to 8 bytes, after which the stream
+		// runs up to the footer
+		br.Discard(8)
+	case bytes.HasPrefix(head, []byte("FEA1")):
+		return nil, fmt.Errorf("Feather v1 files are not supported; rewrite them as Feather v2 (Arrow IPC)")
+	case !bytes.HasPrefix(head, []byte{0xff, 0xff, 0xff, 0xff}):
+		return nil, fmt.Errorf("not an Arrow IPC stream or file")
+	}
+
+	var af *arrowFile
+	var nodeIndex, bufferIndex []int
+	for message := 1; ; message++ {
+		meta, err := readArrowMetadata(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt Arrow message %d: %v", message, err)
+		}
+		msg, err := decodeArrowMessage(meta)
+		if err != nil {
+			return nil, fmt.Errorf("corrupt Arrow message %d: %v", message, err)
+		}
+		if msg.BodyLength < 0 {
+			return nil, fmt.Errorf("corrupt Arrow message %d: bad body length %d", message, msg.BodyLength)
+		}
+		if af == nil && msg.Type != arrowSchema {
+			return nil, fmt.Errorf("Arrow stream does not start with a schema")
+		}
+
+		var body []byte
+		readBody := scan && msg.Type != arrowSchema && !msg.Batch.Compressed
+		if scan && msg.Batch.Compressed {
+			return nil, fmt.Errorf("-scan does not support compressed Arrow record batches")
+		}
+		if readBody {
+			body = make([]byte, msg.BodyLength)
+			_, err = io.ReadFull(br, body)
+		} else {
+			_, err = br.Discard(int(msg.BodyLength))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt Arrow message %d: %v", message, err)
+		}
+
+		switch msg.Type {
+		case arrowSchema:
+			if af != nil {
+				return nil, fmt.Errorf("corrupt Arrow message %d: a second schema", message)
+			}
+			af = &arrowFile{
+				Fields:       msg.Fields,
+				Nulls:        make([]int, len(msg.Fields)),
+				MaxLength:    make([]int, len(msg.Fields)),
+				Dictionaries: make(map[int64][]string),
+				Scanned:      scan,
+			}
+			// Nodes and buffers are laid out depth first, so a field's come
+			// after those of the fields before it and their descendants
+			node, buffer := 0, 0
+			for _, f := range msg.Fields {
+				nodeIndex = append(nodeIndex, node)
+				bufferIndex = append(bufferIndex, buffer)
+				node += f.nodes()
+				if n := f.buffers(); n < 0 || buffer < 0 {
+					buffer = -1
+				} else {
+					buffer += n
+				}
+			}
+		case arrowDictionaryBatch:
+			if readBody {
+				if err := af.scanDictionary(msg, body); err != nil {
+					return nil, fmt.Errorf("corrupt Arrow message %d: %v", message, err)
+				}
+			}
+		case arrowRecordBatch:
+			batch := msg.Batch
+			for i := range af.Fields {
+				if nodeIndex[i] >= len(batch.Nodes) {
+					return nil, fmt.Errorf("corrupt Arrow message %d: missing field node %d", message, nodeIndex[i])
+				}
+				af.Nulls[i] += int(batch.Nodes[nodeIndex[i]][1])
+			}
+			if readBody {
+				if err := af.scanBatch(batch, body, nodeIndex, bufferIndex); err != nil {
+					return nil, fmt.Errorf("corrupt Arrow message %d: %v", message, err)
+				}
+			}
+			af.RowCount += int(batch.Length)
+		}
+	}
+	if af == nil {
+		return nil, fmt.Errorf("not an Arrow IPC stream or file")
+	}
+	return af, nil
+}
+
+// readArrowMetadata reads the metadata of the next message, returning
+// io.EOF at the end-of-stream marker or the end of the input
+func readArrowMetadata(br *bufio.Reader) ([]byte, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(br, prefix[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	// Since format version 0.15 the length follows a continuation marker
+	length := int32(binary.LittleEndian.Uint32(prefix[:]))
+	if length == -1 {
+		if _, err := io.ReadFull(br, prefix[:]); err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		length = int32(binary.LittleEndian.Uint32(prefix[:]))
+	}
+	if length == 0 {
+		return nil, io.EOF
+	}
+	if length < 0 || length > 1<<28 {
+		return nil, fmt.Errorf("bad metadata length %d", length)
+	}
+	meta := make([]byte, length)
+	if _, err := io.ReadFull(br, meta); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return meta, nil
+}
+
+// scanDictionary collects the values of a string dictionary
+func (af *arrowFile) scanDictionary(msg arrowMessage, body []byte) error {
+	var valueType string
+	for _, f := range af.Fields {
+		if f.Dictionary == msg.Dictionary {
+			valueType = f.Type.Type
+		}
+	}
+	if valueType != "utf8" && valueType != "largeutf8" {
+		return nil
+	}
+	batch := msg.Batch
+	if len(batch.Nodes) == 0 {
+		return fmt.Errorf("dictionary %d has no field node", msg.Dictionary)
+	}
+	offsets, err := batch.buffer(body, 1)
+	if err != nil {
+		return err
+	}
+	data, err := batch.buffer(body, 2)
+	if err != nil {
+		return err
+	}
+	values, err := arrowStrings(offsets, data, int(batch.Nodes[0][0]), valueType == "largeutf8")
+	if err != nil {
+		return err
+	}
+	if msg.Delta {
+		values = append(af.Dictionaries[msg.Dictionary], values...)
+	}
+	af.Dictionaries[msg.Dictionary] = values
+	return nil
+}
+
+// scanBatch measures the strings of the string fields of a record batch
+func (af *arrowFile) scanBatch(batch arrowBatch, body []byte, nodeIndex, bufferIndex []int) error {
+	for i, f := range af.Fields {
+		if f.Dictionary >= 0 || bufferIndex[i] < 0 || (f.Type.Type != "utf8" && f.Type.Type != "largeutf8") {
+			continue
+		}
+		offsets, err := batch.buffer(body, bufferIndex[i]+1)
+		if err != nil {
+			return err
+		}
+		data, err := batch.buffer(body, bufferIndex[i]+2)
+		if err != nil {
+			return err
+		}
+		values, err := arrowStrings(offsets, data, int(batch.Nodes[nodeIndex[i]][0]), f.Type.Type == "largeutf8")
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			af.MaxLength[i] = max(af.MaxLength[i], len(v))
+		}
+	}
+	return nil
+}
+
+// analyzeArrow translates the fields of an Arrow schema into the flavor's
+// types. Lists, structs, maps and unions have no column equivalent and are
+// reported as text with a warning. A scan measures the strings of string
+// fields and reports the values of small string dictionaries as enum
+// symbols.
+func analyzeArrow(af *arrowFile, analyzer dbtypes.TypeAnalyzer) *fileAnalysis {
+	result := &fileAnalysis{RowCount: af.RowCount}
+	for i, field := range af.Fields {
+		col := columnAnalysis{Name: field.Name, Nullable: field.Nullable, EmptyCount: af.Nulls[i]}
+		t := field.Type
+		switch t.Type {
+		case "list", "largelist", "fixedsizelist", "listview", "largelistview", "struct", "map", "union", "runendencoded":
+			result.warnf("column %s is an Arrow %s, which has no column equivalent; reported as text", field.Name, t.Type)
+		case "timestamp":
+			if t.Timezone != "" {
+				result.warnf("column %s holds timestamps in time zone %s; reported as timestamp without time zone", field.Name, t.Timezone)
+			}
+		case "utf8", "largeutf8":
+			if !af.Scanned {
+				break
+			}
+			t.Length = af.MaxLength[i]
+			if field.Dictionary < 0 {
+				break
+			}
+			symbols := af.Dictionaries[field.Dictionary]
+			for _, symbol := range symbols {
+				t.Length = max(t.Length, len(symbol))
+			}
+			if len(symbols) <= maxEnumSymbols {
+				col.EnumSymbols = symbols
+			} else if verbose {
+				fmt.Fprintf(os.Stderr, "DEBUG: field %s has %d dictionary values, too many to report as an enum\n", field.Name, len(symbols))
+			}
+		}
+
+		col.TypeIndex = dbtypes.MapArrowType(analyzer, t)
+		switch analyzer.GetTypes()[col.TypeIndex].Name {
+		case "varchar":
+			col.MaxLength = t.Length
+		case "timestamp":
+			col.FracDigits = t.FracDigits()
+		case "numeric":
+			if t.Type == "decimal" {
+				col.Precision, col.NumScale = t.Precision, t.Scale
+				col.NumDigits = max(t.Precision-t.Scale, 1)
+			}
+		}
+		if verbose {
+			description := t.Type
+			if field.Dictionary >= 0 {
+				description = "dictionary-encoded " + description
+			}
+			fmt.Fprintf(os.Stderr, "DEBUG: field %s is Arrow %s, mapped to %s\n",
+				field.Name, strings.TrimSpace(description+" "+t.Unit), analyzer.GetTypes()[col.TypeIndex].Name)
+		}
+		result.Columns = append(result.Columns, col)
+	}
+	clampTimestampPrecision(result, analyzer)
+	return result
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/dbtypes"
+)
+
+// fbt is a flatbuffer table for the test builder, its fields in schema
+// order: nil for an absent field, a uint8, bool, int16, int32, int64,
+// string, nested table (fbt), vector of tables ([]fbt) or vector of
+// two-long structs ([][2]int64)
+type fbt []interface{}
+
+// buildFlatbuffer lays out a flatbuffer with the root table. Every object is
+// written before the objects it refers to, as offsets only point forward.
+func buildFlatbuffer(root fbt) []byte {
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, uint32(writeFBTable(&buf, root)))
+	return buf
+}
+
+func writeFBTable(buf *[]byte, t fbt) int {
+	vtable := len(*buf)
+	*buf = binary.LittleEndian.AppendUint16(*buf, uint16(4+2*len(t)))
+	*buf = binary.LittleEndian.AppendUint16(*buf, 0)
+	offset := 4
+	for _, v := range t {
+		if v == nil {
+			*buf = binary.LittleEndian.AppendUint16(*buf, 0)
+			continue
+		}
+		*buf = binary.LittleEndian.AppendUint16(*buf, uint16(offset))
+		switch v.(type) {
+		case uint8, bool:
+			offset++
+		case int16:
+			offset += 2
+		case int64:
+			offset += 8
+		default:
+			offset += 4
+		}
+	}
+
+	table := len(*buf)
+	*buf = binary.LittleEndian.AppendUint32(*buf, uint32(table-vtable))
+	refs := make(map[int]interface{})
+	for _, v := range t {
+		switch v := v.(type) {
+		case uint8:
+			*buf = append(*buf, v)
+		case bool:
+			if v {
+				*buf = append(*buf, 1)
+			} else {
+				*buf = append(*buf, 0)
+			}
+		case int16:
+			*buf = binary.LittleEndian.AppendUint16(*buf, uint16(v))
+		case int32:
+			*buf = binary.LittleEndian.AppendUint32(*buf, uint32(v))
+		case int64:
+			*buf = binary.LittleEndian.AppendUint64(*buf, uint64(v))
+		case nil:
+		default:
+			refs[len(*buf)] = v
+			*buf = append(*buf, 0, 0, 0, 0)
+		}
+	}
+	for at, v := range refs {
+		// Writing the object may move the buffer, so look it up after
+		pos := writeFBObject(buf, v)
+		binary.LittleEndian.PutUint32((*buf)[at:], uint32(pos-at))
+	}
+	return table
+}
+
+func writeFBObject(buf *[]byte, v interface{}) int {
+	pos := len(*buf)
+	switch v := v.(type) {
+	case string:
+		*buf = binary.LittleEndian.AppendUint32(*buf, uint32(len(v)))
+		*buf = append(append(*buf, v...), 0)
+	case fbt:
+		return writeFBTable(buf, v)
+	case []fbt:
+		*buf = binary.LittleEndian.AppendUint32(*buf, uint32(len(v)))
+		*buf = append(*buf, make([]byte, 4*len(v))...)
+		for i, table := range v {
+			at := pos + 4 + 4*i
+			tablePos := writeFBTable(buf, table)
+			binary.LittleEndian.PutUint32((*buf)[at:], uint32(tablePos-at))
+		}
+	case [][2]int64:
+		*buf = binary.LittleEndian.AppendUint32(*buf, uint32(len(v)))
+		for _, pair := range v {
+			*buf = binary.LittleEndian.AppendUint64(*buf, uint64(pair[0]))
+			*buf = binary.LittleEndian.AppendUint64(*buf, uint64(pair[1]))
+		}
+	}
+	return pos
+}
+
+// appendArrowMessage appends an encapsulated message with the header of the
+// header type and the body
+func appendArrowMessage(b []byte, headerType uint8, header fbt, body []byte) []byte {
+	meta := buildFlatbuffer(fbt{int16(4), headerType, header, int64(len(body))})
+	for len(meta)%8 != 0 {
+		meta = append(meta, 0)
+	}
+	b = binary.LittleEndian.AppendUint32(b, 0xffffffff)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(meta)))
+	return append(append(b, meta...), body...)
+}
+
+// Field tables: name, nullable, type type, type, dictionary and children
+var ordersArrowSchema = fbt{int16(0), []fbt{
+	{"id", false, uint8(2), fbt{int32(64), true}},
+	{"name", true, uint8(5), fbt{}},
+	{"amount", true, uint8(7), fbt{int32(10), int32(2), int32(128)}},
+	{"placed_at", false, uint8(10), fbt{int16(2), "UTC"}},
+	{"status", false, uint8(5), fbt{}, fbt{int64(0), fbt{int32(32), true}}},
+	{"tags", true, uint8(12), fbt{}, nil, []fbt{{"item", true, uint8(5), fbt{}}}},
+	{"day", false, uint8(8), fbt{int16(0)}},
+	{"paid", false, uint8(6), fbt{}},
+}}
+
+// stringBuffers returns the offsets and data buffers of utf8 values
+func stringBuffers(values ...string) ([]byte, []byte) {
+	offsets := binary.LittleEndian.AppendUint32(nil, 0)
+	var data []byte
+	for _, v := range values {
+		data = append(data, v...)
+		offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(data)))
+	}
+	return offsets, data
+}
+
+// testOrdersBatch returns a record batch of ordersArrowSchema whose only
+// non-empty buffers are the name field's, and its body. Its nodes, one per
+// field and one for the tags item, carry the null counts of names.
+func testOrdersBatch(nameNulls int64, names ...string) (fbt, []byte) {
+	offsets, data := stringBuffers(names...)
+	body := append(offsets, data...)
+	rows := int64(len(names))
+	nodes := [][2]int64{{rows, 0}, {rows, nameNulls}}
+	for i := 0; i < 7; i++ {
+		nodes = append(nodes, [2]int64{rows, 0})
+	}
+	buffers := make([][2]int64, 20)
+	buffers[3] = [2]int64{0, int64(len(offsets))}
+	buffers[4] = [2]int64{int64(len(offsets)), int64(len(data))}
+	return fbt{rows, nodes, buffers}, body
+}
+
+// testArrowStream builds a stream of ordersArrowSchema with a status
+// dictionary and two record batches
+func testArrowStream() []byte {
+	b := appendArrowMessage(nil, arrowSchema, ordersArrowSchema, nil)
+	offsets, data := stringBuffers("NEW", "SHIPPED", "RETURNED")
+	dictionary := fbt{int64(3), [][2]int64{{3, 0}}, [][2]int64{{0, 0}, {0, int64(len(offsets))}, {int64(len(offsets)), int64(len(data))}}}
+	b = appendArrowMessage(b, arrowDictionaryBatch, fbt{int64(0), dictionary}, append(offsets, data...))
+	batch, body := testOrdersBatch(1, "Alice", "", "Christopher")
+	b = appendArrowMessage(b, arrowRecordBatch, batch, body)
+	batch, body = testOrdersBatch(0, "Bob", "Eve")
+	b = appendArrowMessage(b, arrowRecordBatch, batch, body)
+	return binary.LittleEndian.AppendUint64(b, 0xffffffff)
+}
+
+func TestAnalyzeArrow(t *testing.T) {
+	stream := testArrowStream()
+	file := append([]byte("ARROW1\x00\x00"), stream...)
+	file = append(file, "footer\x06\x00\x00\x00ARROW1"...)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		scan   bool
+		want   map[string]string
+		status []string
+	}{
+		{"stream", stream, false, map[string]string{"name": "text", "status": "text"}, nil},
+		{"file", file, false, map[string]string{"name": "text", "status": "text"}, nil},
+		{"scanned", stream, true, map[string]string{"name": "varchar(11)", "status": "varchar(8)"}, []string{"NEW", "SHIPPED", "RETURNED"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af, err := readArrow(bytes.NewReader(tt.data), tt.scan)
+			if err != nil {
+				t.Fatalf("readArrow() error = %v, want nil", err)
+			}
+
+			analyzer := &dbtypes.PostgreSQLAnalyzer{}
+			result := analyzeArrow(af, analyzer)
+			if result.RowCount != 5 {
+				t.Errorf("RowCount = %d, want 5", result.RowCount)
+			}
+			want := map[string]string{
+				"id":        "bigint",
+				"amount":    "numeric(10,2)",
+				"placed_at": "timestamp(6)",
+				"tags":      "text",
+				"day":       "date",
+				"paid":      "boolean",
+			}
+			for name, typeName := range tt.want {
+				want[name] = typeName
+			}
+			got := make(map[string]string)
+			for _, col := range result.Columns {
+				got[col.Name] = columnTypeName(col, analyzer)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("types = %v, want %v", got, want)
+			}
+
+			id, name, amount, status := result.Columns[0], result.Columns[1], result.Columns[2], result.Columns[4]
+			if !id.notNull(result.RowCount) || name.notNull(result.RowCount) || amount.notNull(result.RowCount) {
+				t.Errorf("notNull of id, name, amount = %v, %v, %v, want true, false, false",
+					id.notNull(result.RowCount), name.notNull(result.RowCount), amount.notNull(result.RowCount))
+			}
+			if name.EmptyCount != 1 {
+				t.Errorf("name EmptyCount = %d, want 1", name.EmptyCount)
+			}
+			if !reflect.DeepEqual(status.EnumSymbols, tt.status) {
+				t.Errorf("status EnumSymbols = %q, want %q", status.EnumSymbols, tt.status)
+			}
+			wantWarnings := []string{
+				"column placed_at holds timestamps in time zone UTC; reported as timestamp without time zone",
+				"column tags is an Arrow list, which has no column equivalent; reported as text",
+			}
+			if !reflect.DeepEqual(result.Warnings, wantWarnings) {
+				t.Errorf("Warnings = %q, want %q", result.Warnings, wantWarnings)
+			}
+		})
+	}
+}
+
+func TestReadArrowErrors(t *testing.T) {
+	batch, body := testOrdersBatch(0, "Alice")
+	compressed := append(batch, fbt{uint8(0)})
+	withCompressed := appendArrowMessage(appendArrowMessage(nil, arrowSchema, ordersArrowSchema, nil), arrowRecordBatch, compressed, body)
+	stream := testArrowStream()
+
+	tests := []struct {
+		name    string
+		data    []byte
+		scan    bool
+		errText string
+	}{
+		{"csv", []byte("id,name\n1,Alice\n"), false, "not an Arrow IPC stream or file"},
+		{"feather v1", []byte("FEA1\x00\x00\x00\x00"), false, "Feather v1 files are not supported"},
+		{"batch first", appendArrowMessage(nil, arrowRecordBatch, batch, body), false, "does not start with a schema"},
+		{"compressed scan", withCompressed, true, "-scan does not support compressed Arrow record batches"},
+		{"truncated", stream[:len(stream)-20], false, "corrupt Arrow message 4"},
+		{"corrupt metadata", []byte("\xff\xff\xff\xff\x08\x00\x00\x00\xff\xff\xff\x7f\x00\x00\x00\x00"), false, "corrupt Arrow message 1: corrupt message metadata"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readArrow(bytes.NewReader(tt.data), tt.scan)
+			if err == nil || !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("readArrow() error = %v, want containing %q", err, tt.errText)
+			}
+		})
+	}
+
+	// A compressed batch still counts rows and nulls without a scan
+	af, err := readArrow(bytes.NewReader(withCompressed), false)
+	if err != nil || af.RowCount != 1 {
+		t.Errorf("readArrow() of a compressed batch = %v rows, %v, want 1 row", af, err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+
+	"file2ddl/dbtypes"
+)
+
+// avroSchema is an Avro record schema
+type avroSchema struct {
+	Type   string      `json:"type"`
+	Name   string      `json:"name"`
+	Fields []avroField `json:"fields"`
+}
+
+// avroField is a field of an Avro record. Type is a primitive type name, a
+// logical type object or a union with null.
+type avroField struct {
+	Name    string      `json:"name"`
+	Doc     string      `json:"doc,omitempty"`
+	Type    interface{} `json:"type"`
+	Default *avroNull   `json:"default,omitempty"`
+}
+
+// avroNull marshals as JSON null, for the default of nullable fields
+type avroNull struct{}
+
+func (avroNull) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// avroLogicalType is an Avro type annotated with a logical type
+type avroLogicalType struct {
+	Type        string `json:"type"`
+	LogicalType string `json:"logicalType"`
+	Precision   int    `json:"precision,omitempty"`
+	Scale       int    `json:"scale,omitempty"`
+}
+
+// avroInvalidChars matches characters not allowed in Avro names
+var avroInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// avroName converts a column header or table name to a valid Avro name
+func avroName(name string) string {
+	name = avroInvalidChars.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// avroType maps a column's inferred type to an Avro type
+func avroType(col columnAnalysis, analyzer dbtypes.TypeAnalyzer) interface{} {
+	switch analyzer.GetTypes()[col.TypeIndex].Name {
+	case "boolean":
+		return "boolean"
+	case "tinyint", "smallint", "integer":
+		return "int"
+	case "bigint":
+		return "long"
+	case "numeric":
+		return avroLogicalType{
+			Type:        "bytes",
+			LogicalType: "decimal",
+			Precision:   max(col.NumDigits+col.NumScale, 1),
+			Scale:       col.NumScale,
+		}
+	case "timestamp":
+		return avroLogicalType{Type: "long", LogicalType: "timestamp-millis"}
+	case "date":
+		return avroLogicalType{Type: "int", LogicalType: "date"}
+	}
+	return "string"
+}
+
+// printAvro writes the column report as an Avro record schema named after the
+// table. Columns with empty values become unions with null defaulting to null.
+func printAvro(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table string) error {
+	schema := avroSchema{Type: "record", Name: avroName(table), Fields: []avroField{}}
+	for _, col := range result.Columns {
+		field := avroField{Name: avroName(col.Name), Type: avroType(col, analyzer)}
+		// Keep the original header when it had to be renamed
+		if field.Name != col.Name {
+			field.Doc = col.Name
+		}
+		if col.Stats != nil {
+			if field.Doc != "" {
+				field.Doc += "; "
+			}
+			field.Doc += col.Stats.String()
+		}
+		if !col.notNull(result.RowCount) {
+			field.Type = []interface{}{"null", field.Type}
+			field.Default = &avroNull{}
+		}
+		schema.Fields = append(schema.Fields, field)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
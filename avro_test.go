@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestPrintAvro(t *testing.T) {
+	input := strings.Join([]string{
+		"id|total|big|placed|shipped|active|order note",
+		"1|9.50|3000000000|2024-03-20|2024-03-20 10:30:00|true|gift",
+		"2|120.125|3000000001|2024-03-21|2024-03-21 11:00:00|false|",
+	}, "\n")
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	if err := printAvro(&buf, result, analyzer, "daily-orders"); err != nil {
+		t.Fatalf("printAvro() error = %v, want nil", err)
+	}
+
+	var schema struct {
+		Type   string `json:"type"`
+		Name   string `json:"name"`
+		Fields []struct {
+			Name    string          `json:"name"`
+			Type    json.RawMessage `json:"type"`
+			Default json.RawMessage `json:"default"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &schema); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if schema.Type != "record" || schema.Name != "daily_orders" {
+		t.Errorf("schema = %s %s, want record daily_orders", schema.Type, schema.Name)
+	}
+
+	expected := []struct {
+		name    string
+		typ     string
+		nullish bool
+	}{
+		{"id", `"int"`, false},
+		{"total", `{"type":"bytes","logicalType":"decimal","precision":6,"scale":3}`, false},
+		{"big", `"long"`, false},
+		{"placed", `{"type":"int","logicalType":"date"}`, false},
+		{"shipped", `{"type":"long","logicalType":"timestamp-millis"}`, false},
+		{"active", `"boolean"`, false},
+		{"order_note", `["null","string"]`, true},
+	}
+	if len(schema.Fields) != len(expected) {
+		t.Fatalf("got %d fields, want %d", len(schema.Fields), len(expected))
+	}
+	for i, want := range expected {
+		field := schema.Fields[i]
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, field.Type); err != nil {
+			t.Fatalf("field %s: invalid type JSON: %v", field.Name, err)
+		}
+		if field.Name != want.name || compact.String() != want.typ {
+			t.Errorf("field %d = %s %s, want %s %s", i, field.Name, compact.String(), want.name, want.typ)
+		}
+		if hasDefault := string(field.Default) == "null"; hasDefault != want.nullish {
+			t.Errorf("field %s default = %s, want null default %v", field.Name, field.Default, want.nullish)
+		}
+	}
+}
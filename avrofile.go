@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// avroMagic starts every Avro object container file
+const avroMagic = "Obj\x01"
+
+// avroSpec is a parsed Avro schema, as declared in a container file
+type avroSpec struct {
+	Type      string // a primitive type name, or record, enum, array, map, fixed or union
+	Name      string // full name of a record, enum or fixed
+	Logical   string
+	Precision int
+	Scale     int
+	Size      int             // bytes of a fixed
+	Symbols   []string        // symbols of an enum
+	Fields    []avroSpecField // fields of a record
+	Items     *avroSpec       // items of an array, values of a map
+	Branches  []*avroSpec     // branches of a union
+}
+
+// avroSpecField is a field of a record schema
+type avroSpecField struct {
+	Name string
+	Spec *avroSpec
+}
+
+// avroPrimitives are the types named without a definition
+var avroPrimitives = map[string]bool{
+	"null": true, "boolean": true, "int": true, "long": true,
+	"float": true, "double": true, "bytes": true, "string": true,
+}
+
+// parseAvroSpec parses a schema in its JSON form, registering the named
+// types it defines so later references to them resolve
+func parseAvroSpec(v interface{}, named map[string]*avroSpec, namespace string) (*avroSpec, error) {
+	switch v := v.(type) {
+	case string:
+		if avroPrimitives[v] {
+			return &avroSpec{Type: v}, nil
+		}
+		if spec, ok := named[v]; ok {
+			return spec, nil
+		}
+		if spec, ok := named[namespace+"."+v]; ok {
+			return spec, nil
+		}
+		return nil, fmt.Errorf("unknown Avro type %s", v)
+	case []interface{}:
+		union := &avroSpec{Type: "union"}
+		for _, branch := range v {
+			spec, err := parseAvroSpec(branch, named, namespace)
+			if err != nil {
+				return nil, err
+			}
+			union.Branches = append(union.Branches, spec)
+		}
+		return union, nil
+	case map[string]interface{}:
+		typeName, ok := v["type"].(string)
+		if !ok {
+			return parseAvroSpec(v["type"], named, namespace)
+		}
+		spec := &avroSpec{Type: typeName}
+		spec.Logical, _ = v["logicalType"].(string)
+		precision, _ := v["precision"].(float64)
+		scale, _ := v["scale"].(float64)
+		size, _ := v["size"].(float64)
+		spec.Precision, spec.Scale, spec.Size = int(precision), int(scale), int(size)
+
+		switch typeName {
+		case "record", "error", "enum", "fixed":
+			name, _ := v["name"].(string)
+			if ns, ok := v["namespace"].(string); ok {
+				namespace = ns
+			}
+			if !strings.Contains(name, ".") && namespace != "" {
+				name = namespace + "." + name
+			}
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				namespace = name[:i]
+			}
+			spec.Name = name
+			named[name] = spec
+		case "array":
+			items, err := parseAvroSpec(v["items"], named, namespace)
+			if err != nil {
+				return nil, err
+			}
+			spec.Items = items
+		case "map":
+			values, err := parseAvroSpec(v["values"], named, namespace)
+			if err != nil {
+				return nil, err
+			}
+			spec.Items = values
+		default:
+			if !avroPrimitives[typeName] {
+				return parseAvroSpec(typeName, named, namespace)
+			}
+		}
+
+		if typeName == "enum" {
+			symbols, _ := v["symbols"].([]interface{})
+			for _, symbol := range symbols {
+				s, _ := symbol.(string)
+				spec.Symbols = append(spec.Symbols, s)
+			}
+		}
+		if typeName == "record" || typeName == "error" {
+			spec.Type = "record"
+			fields, _ := v["fields"].([]interface{})
+			for _, f := range fields {
+				field, _ := f.(map[string]interface{})
+				name, _ := field["name"].(string)
+				fieldSpec, err := parseAvroSpec(field["type"], named, namespace)
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %v", name, err)
+				}
+				spec.Fields = append(spec.Fields, avroSpecField{Name: name, Spec: fieldSpec})
+			}
+		}
+		return spec, nil
+	}
+	return nil, fmt.Errorf("malformed Avro schema")
+}
+
+// avroFile is what file2ddl needs from an Avro object container file
+type avroFile struct {
+	Schema    *avroSpec
+	Codec     string
+	RowCount  int
+	Nulls     []int // nulls in each field, counted by a scan
+	MaxLength []int // longest string or enum symbol in each field, measured by a scan
+	Scanned   bool
+}
+
+// readAvroFile reads the schema from the header of an Avro object container
+// file, whose records must be of a record type, and counts the records,
+// skipping over the blocks. With scan set the records are decoded as well,
+// to count nulls and measure strings, which only the null and deflate
+// codecs allow.
+func readAvroFile(r io.Reader, scan bool) (*avroFile, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(avroMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != avroMagic {
+		return nil, fmt.Errorf("not an Avro object container file")
+	}
+
+	meta := make(map[string][]byte)
+	if err := readAvroBlocks(br, func() error {
+		key, err := readAvroBytes(br)
+		if err != nil {
+			return err
+		}
+		value, err := readAvroBytes(br)
+		meta[string(key)] = value
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("corrupt Avro header: %v", err)
+	}
+	sync := make([]byte, 16)
+	if _, err := io.ReadFull(br, sync); err != nil {
+		return nil, fmt.Errorf("corrupt Avro header: %v", err)
+	}
+
+	var schemaJSON interface{}
+	if err := json.Unmarshal(meta["avro.schema"], &schemaJSON); err != nil {
+		return nil, fmt.Errorf("error reading Avro schema: %v", err)
+	}
+	schema, err := parseAvroSpec(schemaJSON, make(map[string]*avroSpec), "")
+	if err != nil {
+		return nil, fmt.Errorf("error reading Avro schema: %v", err)
+	}
+	if schema.Type != "record" {
+		return nil, fmt.Errorf("Avro records are of type %s, not record", schema.Type)
+	}
+	af := &avroFile{Schema: schema, Codec: "null", Scanned: scan}
+	if codec, ok := meta["avro.codec"]; ok && len(codec) > 0 {
+		af.Codec = string(codec)
+	}
+	if scan && af.Codec != "null" && af.Codec != "deflate" {
+		return nil, fmt.Errorf("-scan does not support the Avro codec %s, only null and deflate", af.Codec)
+	}
+	af.Nulls = make([]int, len(schema.Fields))
+	af.MaxLength = make([]int, len(schema.Fields))
+
+	// Each block holds a count of records, their size and the sync marker
+	blockSync := make([]byte, 16)
+	for block := 1; ; block++ {
+		count, err := readAvroLong(br)
+		if err == io.EOF {
+			return af, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt Avro block %d: %v", block, err)
+		}
+		size, err := readAvroLong(br)
+		if err != nil || count < 0 || size < 0 {
+			return nil, fmt.Errorf("corrupt Avro block %d", block)
+		}
+		if scan {
+			err = af.scanBlock(io.LimitReader(br, size), int(count))
+		} else {
+			_, err = br.Discard(int(size))
+		}
+		if err == nil {
+			_, err = io.ReadFull(br, blockSync)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt Avro block %d: %v", block, err)
+		}
+		if !bytes.Equal(blockSync, sync) {
+			return nil, fmt.Errorf("corrupt Avro block %d: sync marker mismatch", block)
+		}
+		af.RowCount += int(count)
+	}
+}
+
+// scanBlock decodes a block of records, counting nulls and measuring strings
+func (af *avroFile) scanBlock(block io.Reader, count int) error {
+	data, err := io.ReadAll(block)
+	if err != nil {
+		return err
+	}
+	if af.Codec == "deflate" {
+		if data, err = io.ReadAll(flate.NewReader(bytes.NewReader(data))); err != nil {
+			return err
+		}
+	}
+	r := bytes.NewReader(data)
+	for i := 0; i < count; i++ {
+		for f, field := range af.Schema.Fields {
+			value, err := readAvroValue(r, field.Spec)
+			if err != nil {
+				return err
+			}
+			switch v := value.(type) {
+			case nil:
+				af.Nulls[f]++
+			case string:
+				af.MaxLength[f] = max(af.MaxLength[f], len(v))
+			}
+		}
+	}
+	return nil
+}
+
+// readAvroValue decodes a value, returning nil for null, strings for
+// strings and enum symbols, and true for every other value
+func readAvroValue(r *bytes.Reader, spec *avroSpec) (interface{}, error) {
+	switch spec.Type {
+	case "null":
+		return nil, nil
+	case "boolean":
+		_, err := r.ReadByte()
+		return true, err
+	case "int", "long":
+		_, err := readAvroLong(r)
+		return true, err
+	case "float":
+		_, err := r.Seek(4, io.SeekCurrent)
+		return true, err
+	case "double":
+		_, err := r.Seek(8, io.SeekCurrent)
+		return true, err
+	case "fixed":
+		_, err := r.Seek(int64(spec.Size), io.SeekCurrent)
+		return true, err
+	case "bytes":
+		_, err := readAvroBytes(r)
+		return true, err
+	case "string":
+		s, err := readAvroBytes(r)
+		return string(s), err
+	case "enum":
+		i, err := readAvroLong(r)
+		if err != nil || i < 0 || int(i) >= len(spec.Symbols) {
+			return nil, fmt.Errorf("bad enum index %d", i)
+		}
+		return spec.Symbols[i], nil
+	case "union":
+		i, err := readAvroLong(r)
+		if err != nil || i < 0 || int(i) >= len(spec.Branches) {
+			return nil, fmt.Errorf("bad union index %d", i)
+		}
+		return readAvroValue(r, spec.Branches[i])
+	case "array", "map":
+		err := readAvroBlocks(r, func() error {
+			if spec.Type == "map" {
+				if _, err := readAvroBytes(r); err != nil {
+					return err
+				}
+			}
+			_, err := readAvroValue(r, spec.Items)
+			return err
+		})
+		return true, err
+	case "record":
+		for _, field := range spec.Fields {
+			if _, err := readAvroValue(r, field.Spec); err != nil {
+				return nil, err
+			}
+		}
+		return true, nil
+	}
+	return nil, fmt.Errorf("unknown Avro type %s", spec.Type)
+}
+
+// readAvroBlocks reads the blocks of an array or map, calling item for each
+// item. A negative block count is followed by the block's size in bytes.
+func readAvroBlocks(r io.ByteReader, item func() error) error {
+	for {
+		count, err := readAvroLong(r)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			count = -count
+			if _, err := readAvroLong(r); err != nil {
+				return err
+			}
+		}
+		for i := int64(0); i < count; i++ {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readAvroLong reads a zigzag-encoded variable-length integer
+func readAvroLong(r io.ByteReader) (int64, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, err
+	}
+	return int64(n>>1) ^ -int64(n&1), nil
+}
+
+// readAvroBytes reads a length-prefixed byte string
+func readAvroBytes(r io.ByteReader) ([]byte, error) {
+	n, err := readAvroLong(r)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > 1<<30 {
+		return nil, fmt.Errorf("bad length %d", n)
+	}
+	data := make([]byte, n)
+	for i := range data {
+		if data[i], err = r.ReadByte(); err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+	}
+	return data, nil
+}
+
+// analyzeAvroFile translates the fields of an Avro record schema into the
+// flavor's types. Fields that are a union with null are nullable. Nested
+// records, arrays, maps and unions of several types have no column
+// equivalent and are reported as text with a warning.
+func analyzeAvroFile(af *avroFile, analyzer dbtypes.TypeAnalyzer) *fileAnalysis {
+	result := &fileAnalysis{RowCount: af.RowCount}
+	for i, field := range af.Schema.Fields {
+		col := columnAnalysis{Name: field.Name}
+		spec := field.Spec
+		if spec.Type == "union" {
+			var branches []*avroSpec
+			for _, branch := range spec.Branches {
+				if branch.Type == "null" {
+					col.Nullable = true
+				} else {
+					branches = append(branches, branch)
+				}
+			}
+			if len(branches) == 1 {
+				spec = branches[0]
+			}
+		}
+
+		t := dbtypes.AvroType{Type: spec.Type, Logical: spec.Logical, Precision: spec.Precision, Scale: spec.Scale}
+		switch spec.Type {
+		case "record", "array", "map":
+			result.warnf("column %s is an Avro %s, which has no column equivalent; reported as text", field.Name, spec.Type)
+		case "union":
+			result.warnf("column %s is a union of several Avro types; reported as text", field.Name)
+		case "enum":
+			col.EnumSymbols = spec.Symbols
+			for _, symbol := range spec.Symbols {
+				t.Length = max(t.Length, len(symbol))
+			}
+		}
+		if af.Scanned {
+			col.EmptyCount = af.Nulls[i]
+			if spec.Type == "string" {
+				t.Length = af.MaxLength[i]
+			}
+		}
+
+		col.TypeIndex = dbtypes.MapAvroType(analyzer, t)
+		switch analyzer.GetTypes()[col.TypeIndex].Name {
+		case "varchar":
+			col.MaxLength = t.Length
+		case "timestamp":
+			col.FracDigits = t.FracDigits()
+		case "numeric":
+			if t.Logical == "decimal" {
+				col.Precision, col.NumScale = t.Precision, t.Scale
+				col.NumDigits = max(t.Precision-t.Scale, 1)
+			}
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: field %s is Avro %s, mapped to %s\n",
+				field.Name, strings.TrimSpace(spec.Type+" "+spec.Logical), analyzer.GetTypes()[col.TypeIndex].Name)
+		}
+		result.Columns = append(result.Columns, col)
+	}
+	clampTimestampPrecision(result, analyzer)
+	return result
+}
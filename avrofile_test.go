@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/dbtypes"
+)
+
+func appendAvroLong(b []byte, n int64) []byte {
+	return binary.AppendUvarint(b, uint64(n<<1^n>>63))
+}
+
+func appendAvroString(b []byte, s string) []byte {
+	return append(appendAvroLong(b, int64(len(s))), s...)
+}
+
+// testAvroFile builds an Avro object container file with the schema and
+// blocks of already encoded records
+func testAvroFile(schema, codec string, blocks ...[][]byte) []byte {
+	sync := []byte("0123456789abcdef")
+	b := []byte(avroMagic)
+	b = appendAvroLong(b, 2)
+	b = appendAvroString(b, "avro.schema")
+	b = appendAvroString(b, schema)
+	b = appendAvroString(b, "avro.codec")
+	b = appendAvroString(b, codec)
+	b = appendAvroLong(b, 0)
+	b = append(b, sync...)
+	for _, records := range blocks {
+		data := bytes.Join(records, nil)
+		if codec == "deflate" {
+			var buf bytes.Buffer
+			w, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+			w.Write(data)
+			w.Close()
+			data = buf.Bytes()
+		}
+		b = appendAvroLong(b, int64(len(records)))
+		b = appendAvroLong(b, int64(len(data)))
+		b = append(b, data...)
+		b = append(b, sync...)
+	}
+	return b
+}
+
+const ordersAvroSchema = `{
+	"type": "record", "name": "Order", "namespace": "com.example",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "customer", "type": ["null", "string"]},
+		{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+		{"name": "placed_at", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["NEW", "SHIPPED", "RETURNED"]}},
+		{"name": "previous_status", "type": ["null", "Status"]},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "paid", "type": "boolean"}
+	]
+}`
+
+// testOrder encodes an order record of ordersAvroSchema
+func testOrder(id int64, customer string, status int64, tags ...string) []byte {
+	b := appendAvroLong(nil, id)
+	if customer == "" {
+		b = appendAvroLong(b, 0)
+	} else {
+		b = appendAvroString(appendAvroLong(b, 1), customer)
+	}
+	b = appendAvroString(b, "\x04\xd2")
+	b = appendAvroLong(b, 1700000000000000)
+	b = appendAvroLong(b, status)
+	b = appendAvroLong(b, 0)
+	if len(tags) > 0 {
+		b = appendAvroLong(b, int64(len(tags)))
+		for _, tag := range tags {
+			b = appendAvroString(b, tag)
+		}
+	}
+	b = appendAvroLong(b, 0)
+	return append(b, 1)
+}
+
+func TestAnalyzeAvroFile(t *testing.T) {
+	blocks := [][][]byte{
+		{testOrder(1, "Alice", 0, "gift"), testOrder(2, "", 1)},
+		{testOrder(3, "Christopher", 2, "rush", "gift")},
+	}
+
+	tests := []struct {
+		name  string
+		codec string
+		scan  bool
+		want  map[string]string
+		empty int
+	}{
+		{"schema only", "null", false, map[string]string{"customer": "text"}, 0},
+		{"scanned", "null", true, map[string]string{"customer": "varchar(11)"}, 1},
+		{"scanned deflate", "deflate", true, map[string]string{"customer": "varchar(11)"}, 1},
+		{"snappy schema only", "snappy", false, map[string]string{"customer": "text"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data []byte
+			if tt.codec == "snappy" {
+				// Blocks are skipped whole, so their contents do not matter
+				data = testAvroFile(ordersAvroSchema, "snappy", [][]byte{{0xff, 0xff}}, [][]byte{{0}, {0}})
+			} else {
+				data = testAvroFile(ordersAvroSchema, tt.codec, blocks...)
+			}
+			af, err := readAvroFile(bytes.NewReader(data), tt.scan)
+			if err != nil {
+				t.Fatalf("readAvroFile() error = %v, want nil", err)
+			}
+
+			analyzer := &dbtypes.PostgreSQLAnalyzer{}
+			result := analyzeAvroFile(af, analyzer)
+			if result.RowCount != 3 {
+				t.Errorf("RowCount = %d, want 3", result.RowCount)
+			}
+			want := map[string]string{
+				"id":              "bigint",
+				"amount":          "numeric(10,2)",
+				"placed_at":       "timestamp(6)",
+				"status":          "varchar(8)",
+				"previous_status": "varchar(8)",
+				"tags":            "text",
+				"paid":            "boolean",
+			}
+			for name, typeName := range tt.want {
+				want[name] = typeName
+			}
+			got := make(map[string]string)
+			for _, col := range result.Columns {
+				got[col.Name] = columnTypeName(col, analyzer)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("types = %v, want %v", got, want)
+			}
+
+			customer, status, previous := result.Columns[1], result.Columns[4], result.Columns[5]
+			if !customer.Nullable || customer.EmptyCount != tt.empty {
+				t.Errorf("customer nullable = %v with %d empty, want nullable with %d", customer.Nullable, customer.EmptyCount, tt.empty)
+			}
+			if !status.notNull(result.RowCount) || previous.notNull(result.RowCount) {
+				t.Errorf("status notNull = %v, previous_status notNull = %v, want true and false",
+					status.notNull(result.RowCount), previous.notNull(result.RowCount))
+			}
+			if notes := columnNotes(status); !reflect.DeepEqual(notes, []string{"enum NEW, SHIPPED, RETURNED"}) {
+				t.Errorf("status notes = %q, want the enum symbols", notes)
+			}
+			wantWarnings := []string{"column tags is an Avro array, which has no column equivalent; reported as text"}
+			if !reflect.DeepEqual(result.Warnings, wantWarnings) {
+				t.Errorf("Warnings = %q, want %q", result.Warnings, wantWarnings)
+			}
+		})
+	}
+}
+
+func TestReadAvroFileErrors(t *testing.T) {
+	valid := testAvroFile(ordersAvroSchema, "null", [][]byte{testOrder(1, "Alice", 0)})
+	badSync := bytes.Clone(valid)
+	badSync[len(badSync)-1] = 'x'
+
+	tests := []struct {
+		name    string
+		data    []byte
+		scan    bool
+		errText string
+	}{
+		{"csv", []byte("id,name\n1,Alice\n"), false, "not an Avro object container file"},
+		{"not a record", testAvroFile(`"string"`, "null"), false, "Avro records are of type string, not record"},
+		{"unknown type", testAvroFile(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "Missing"}]}`, "null"), false, "field a: unknown Avro type Missing"},
+		{"snappy scan", testAvroFile(ordersAvroSchema, "snappy"), true, "-scan does not support the Avro codec snappy"},
+		{"sync mismatch", badSync, false, "corrupt Avro block 1: sync marker mismatch"},
+		{"truncated", valid[:len(valid)-20], true, "corrupt Avro block 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readAvroFile(bytes.NewReader(tt.data), tt.scan)
+			if err == nil || !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("readAvroFile() error = %v, want containing %q", err, tt.errText)
+			}
+		})
+	}
+}
+
+func TestParseAvroSpecNamedTypes(t *testing.T) {
+	schema := `{"type": "record", "name": "Outer", "namespace": "a.b", "fields": [
+		{"name": "inner", "type": {"type": "fixed", "name": "Hash", "size": 16}},
+		{"name": "again", "type": "Hash"},
+		{"name": "qualified", "type": "a.b.Hash"},
+		{"name": "id", "type": {"type": "string", "logicalType": "uuid"}}
+	]}`
+	af, err := readAvroFile(bytes.NewReader(testAvroFile(schema, "null")), false)
+	if err != nil {
+		t.Fatalf("readAvroFile() error = %v, want nil", err)
+	}
+	fields := af.Schema.Fields
+	if fields[1].Spec != fields[0].Spec || fields[2].Spec != fields[0].Spec || fields[0].Spec.Name != "a.b.Hash" {
+		t.Errorf("named type references did not resolve to a.b.Hash")
+	}
+	if fields[3].Spec.Logical != "uuid" {
+		t.Errorf("id logical type = %q, want uuid", fields[3].Spec.Logical)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// fieldCountTally is how many rows had a given number of fields, and where
+// the first and last of them were
+type fieldCountTally struct {
+	Rows      int
+	FirstLine int
+	LastLine  int
+}
+
+// fieldCounts tallies rows by their number of fields, to show the shape of
+// a file whose rows do not all match the header, such as two exports with
+// different columns run together
+type fieldCounts map[int]*fieldCountTally
+
+// observe counts a row of n fields read from line
+func (c fieldCounts) observe(n, line int) {
+	tally, ok := c[n]
+	if !ok {
+		tally = &fieldCountTally{FirstLine: line}
+		c[n] = tally
+	}
+	tally.Rows++
+	tally.LastLine = line
+}
+
+// summary describes the rows by field count in ascending order, with the
+// lines of the counts other than expected, e.g. "8 fields: 120,301 rows;
+// 9 fields: 4,512 rows, lines 120,302 to 124,813"
+func (c fieldCounts) summary(expected int) string {
+	var parts []string
+	for _, n := range slices.Sorted(maps.Keys(c)) {
+		tally := c[n]
+		part := fmt.Sprintf("%d fields: %s rows", n, groupDigits(tally.Rows))
+		if n == 1 {
+			part = fmt.Sprintf("1 field: %s rows", groupDigits(tally.Rows))
+		}
+		if tally.Rows == 1 {
+			part = strings.TrimSuffix(part, "s")
+		}
+		switch {
+		case n == expected:
+		case tally.Rows == 1:
+			part += ", line " + groupDigits(tally.FirstLine)
+		default:
+			part += fmt.Sprintf(", lines %s to %s", groupDigits(tally.FirstLine), groupDigits(tally.LastLine))
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// groupDigits writes a count with commas between groups of three digits
+func groupDigits(n int) string {
+	digits := strconv.Itoa(n)
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return digits
+}
+
+// headerRepeatWarning describes the rows skipped for repeating the header,
+// e.g. "3 repeated header rows skipped at lines 50001, 100001, 150001"
+func headerRepeatWarning(count int, lines []int) string {
+	lineList := make([]string, len(lines))
+	for i, line := range lines {
+		lineList[i] = strconv.Itoa(line)
+	}
+	if count > len(lines) {
+		lineList = append(lineList, "...")
+	}
+	if count == 1 {
+		return fmt.Sprintf("1 repeated header row skipped at line %s", lineList[0])
+	}
+	return fmt.Sprintf("%s repeated header rows skipped at lines %s", groupDigits(count), strings.Join(lineList, ", "))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestSkipBadRows(t *testing.T) {
+	input := "id,name\n1,a\n2,b\nid,name,region\n3,c,eu\n\n4,d,us\n5,e\n6\n"
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none", SkipBadRows: true}}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, &dbtypes.PostgreSQLAnalyzer{})
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if result.RowCount != 3 {
+		t.Errorf("RowCount = %d, want 3", result.RowCount)
+	}
+	want := []string{"skipped 4 rows without 2 fields; rows by field count: 1 field: 1 row, line 9; 2 fields: 3 rows; 3 fields: 3 rows, lines 4 to 7"}
+	if !reflect.DeepEqual(result.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", result.Warnings, want)
+	}
+
+	// Without bad rows there is nothing to report
+	result, err = analyzeFileTypes(strings.NewReader("id,name\n1,a\n"), opts, &dbtypes.PostgreSQLAnalyzer{})
+	if err != nil || len(result.Warnings) != 0 {
+		t.Errorf("analyzeFileTypes() = %q, %v, want no warnings", result.Warnings, err)
+	}
+}
+
+func TestSkipBadFirstRow(t *testing.T) {
+	// Without a header, -ncols fixes the width before the first row, which
+	// is skipped like any other of the wrong width
+	input := "1,2\n3,4,5\n6,7,8\n"
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none", SkipBadRows: true}, Header: "no", ExpectedCols: 3}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, &dbtypes.PostgreSQLAnalyzer{})
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if result.RowCount != 2 || len(result.Columns) != 3 {
+		t.Errorf("RowCount = %d with %d columns, want 2 with 3", result.RowCount, len(result.Columns))
+	}
+	want := []string{"skipped 1 rows without 3 fields; rows by field count: 2 fields: 1 row, line 1; 3 fields: 2 rows"}
+	if !reflect.DeepEqual(result.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", result.Warnings, want)
+	}
+}
+
+func TestGroupDigits(t *testing.T) {
+	tests := map[int]string{0: "0", 999: "999", 1000: "1,000", 120301: "120,301", 1234567: "1,234,567"}
+	for n, want := range tests {
+		if got := groupDigits(n); got != want {
+			t.Errorf("groupDigits(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestUnterminatedQuote(t *testing.T) {
+	input := "id,name,city\n1,\"Smith, John\",Boston\n2,\"Doe, Jane,Denver\n3,Lee,Austin\n"
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}}
+	_, err := analyzeFileTypes(strings.NewReader(input), opts, &dbtypes.PostgreSQLAnalyzer{})
+	want := "line 3 has an unterminated quoted field starting at column 2"
+	if err == nil || err.Error() != want {
+		t.Fatalf("analyzeFileTypes() error = %v, want %q", err, want)
+	}
+
+	opts.SkipBadRows = true
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, &dbtypes.PostgreSQLAnalyzer{})
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if result.RowCount != 2 {
+		t.Errorf("RowCount = %d, want 2", result.RowCount)
+	}
+	wantWarnings := []string{"skipped 1 rows that could not be read, the first because " + want}
+	if !reflect.DeepEqual(result.Warnings, wantWarnings) {
+		t.Errorf("Warnings = %q, want %q", result.Warnings, wantWarnings)
+	}
+}
+
+func TestRepeatedHeaders(t *testing.T) {
+	input := "id,name\n1,a\nid,name\n2,b\nid,name\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if result.RowCount != 2 || result.HeaderRepeats != 2 {
+		t.Errorf("RowCount = %d, HeaderRepeats = %d, want 2 and 2", result.RowCount, result.HeaderRepeats)
+	}
+	if got := analyzer.GetTypes()[result.Columns[0].TypeIndex].Name; got != "smallint" {
+		t.Errorf("id = %s, want smallint", got)
+	}
+	if want := []string{"2 repeated header rows skipped at lines 3, 5"}; !reflect.DeepEqual(result.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", result.Warnings, want)
+	}
+
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none", KeepHeaderRepeats: true}}
+	result, err = analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if result.RowCount != 4 || result.HeaderRepeats != 0 {
+		t.Errorf("with KeepHeaderRepeats: RowCount = %d, HeaderRepeats = %d, want 4 and 0", result.RowCount, result.HeaderRepeats)
+	}
+}
+
+func TestHeaderRepeatWarning(t *testing.T) {
+	tests := []struct {
+		count    int
+		lines    []int
+		expected string
+	}{
+		{1, []int{7}, "1 repeated header row skipped at line 7"},
+		{7, []int{2, 3, 4, 5, 6}, "7 repeated header rows skipped at lines 2, 3, 4, 5, 6, ..."},
+	}
+	for _, tt := range tests {
+		if got := headerRepeatWarning(tt.count, tt.lines); got != tt.expected {
+			t.Errorf("headerRepeatWarning(%d) = %q, want %q", tt.count, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+// The generator is run through the tests, e.g.
+//
+//	go test -run TestGenerateTestData -gen-test-data big.csv -gen-rows 1000000
+var (
+	genTestData = flag.String("gen-test-data", "", "Write a generated CSV to this file")
+	genRows     = flag.Int("gen-rows", 1000, "Data rows of the generated CSV")
+	genMix      = flag.String("gen-mix", "int,decimal,bool,date,timestamp,text,quoted", "Comma-separated kinds of the generated columns, one column each")
+	genSeed     = flag.Int64("gen-seed", 1, "Seed of the generated values")
+)
+
+// genKinds generate the values of a column of each kind, quoted as
+// -quotes double reads them. Every tenth value of a sparse column is empty.
+var genKinds = map[string]func(r *rand.Rand) string{
+	"int":     func(r *rand.Rand) string { return fmt.Sprint(r.Intn(100000)) },
+	"bigint":  func(r *rand.Rand) string { return fmt.Sprint(r.Int63()) },
+	"decimal": func(r *rand.Rand) string { return fmt.Sprintf("%.2f", r.Float64()*10000) },
+	"bool":    func(r *rand.Rand) string { return []string{"true", "false"}[r.Intn(2)] },
+	"date": func(r *rand.Rand) string {
+		return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, r.Intn(2000)).Format("2006-01-02")
+	},
+	"timestamp": func(r *rand.Rand) string {
+		return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(r.Int63n(int64(2000 * 24 * time.Hour)))).Format("2006-01-02 15:04:05")
+	},
+	"text": func(r *rand.Rand) string { return genWord(r) },
+	"quoted": func(r *rand.Rand) string {
+		return `"` + genWord(r) + ", " + genWord(r) + `"`
+	},
+	"sparse": func(r *rand.Rand) string {
+		if r.Intn(10) == 0 {
+			return ""
+		}
+		return fmt.Sprint(r.Intn(1000))
+	},
+}
+
+func genWord(r *rand.Rand) string {
+	letters := make([]byte, 3+r.Intn(10))
+	for i := range letters {
+		letters[i] = byte('a' + r.Intn(26))
+	}
+	return string(letters)
+}
+
+// generateCSV writes a comma-delimited file with a header and rows of
+// values of the given kinds, the same for the same seed
+func generateCSV(w io.Writer, rows int, kinds []string, seed int64) error {
+	r := rand.New(rand.NewSource(seed))
+	out := bufio.NewWriter(w)
+	header := make([]string, len(kinds))
+	for i, kind := range kinds {
+		if genKinds[kind] == nil {
+			return fmt.Errorf("unknown column kind %q", kind)
+		}
+		header[i] = fmt.Sprintf("%s_%d", kind, i+1)
+	}
+	fmt.Fprintln(out, strings.Join(header, ","))
+	fields := make([]string, len(kinds))
+	for row := 0; row < rows; row++ {
+		for i, kind := range kinds {
+			fields[i] = genKinds[kind](r)
+		}
+		fmt.Fprintln(out, strings.Join(fields, ","))
+	}
+	return out.Flush()
+}
+
+func TestGenerateTestData(t *testing.T) {
+	if *genTestData == "" {
+		t.Skip("-gen-test-data not given")
+	}
+	f, err := os.Create(*genTestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := generateCSV(f, *genRows, strings.Split(*genMix, ","), *genSeed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateCSV(t *testing.T) {
+	kinds := []string{"int", "bigint", "decimal", "bool", "date", "timestamp", "text", "quoted", "sparse"}
+	var first, second bytes.Buffer
+	if err := generateCSV(&first, 200, kinds, 7); err != nil {
+		t.Fatalf("generateCSV() error = %v, want nil", err)
+	}
+	generateCSV(&second, 200, kinds, 7)
+	if first.String() != second.String() {
+		t.Error("generateCSV() differs for the same seed")
+	}
+
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(&first, analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if result.RowCount != 200 {
+		t.Errorf("RowCount = %d, want 200", result.RowCount)
+	}
+	want := []string{"integer", "bigint", "numeric", "boolean", "date", "timestamp", "varchar", "varchar", "smallint"}
+	for i, col := range result.Columns {
+		if got := analyzer.GetTypes()[col.TypeIndex].Name; got != want[i] {
+			t.Errorf("column %s: got %s, want %s", col.Name, got, want[i])
+		}
+	}
+}
+
+func BenchmarkSplitFields(b *testing.B) {
+	lines := map[string]struct{ line, quotes string }{
+		"unquoted": {"42,Smith,123 Main St,555-1234,2024-03-20 10:30:00,19.99,true", "none"},
+		"quoted":   {`42,"Smith, John","123 Main St, Suite 100","555-1234","2024-03-20 10:30:00",19.99,"say ""hi"""`, "double"},
+	}
+	for name, tt := range lines {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(tt.line)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				analyze.SplitFields(tt.line, ",", tt.quotes)
+			}
+		})
+		b.Run(name+" reused", func(b *testing.B) {
+			b.SetBytes(int64(len(tt.line)))
+			b.ReportAllocs()
+			var fields []string
+			var quoted []bool
+			for i := 0; i < b.N; i++ {
+				fields, quoted, _ = analyze.AppendFields(fields[:0], quoted[:0], tt.line, ",", tt.quotes)
+			}
+		})
+	}
+}
+
+// BenchmarkTextRecords reads and splits the records of a generated file,
+// reporting the allocations per record, which stay the same however many
+// fields a record has
+func BenchmarkTextRecords(b *testing.B) {
+	const rows = 100000
+	var data bytes.Buffer
+	if err := generateCSV(&data, rows, strings.Split(*genMix, ","), 1); err != nil {
+		b.Fatal(err)
+	}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}}
+	b.SetBytes(int64(data.Len()))
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		records := newTextRecords(bytes.NewReader(data.Bytes()), opts)
+		for {
+			if _, err := records.Read(); err == io.EOF {
+				break
+			}
+		}
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.Mallocs-before.Mallocs)/float64(b.N*rows), "allocs/record")
+}
+
+func TestTextRecordsAllocations(t *testing.T) {
+	row := `42,"Smith, John",123 Main St,2024-03-20 10:30:00,19.99,true,"say ""hi"""` + "\n"
+	records := newTextRecords(strings.NewReader(strings.Repeat(row, 1000)), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}})
+	records.Read() // sizes the buffers
+	// The line, and the field holding a doubled quote
+	if allocs := testing.AllocsPerRun(100, func() { records.Read() }); allocs > 2 {
+		t.Errorf("Read() allocates %v times per record, want 2", allocs)
+	}
+}
+
+func BenchmarkInferType(b *testing.B) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := &analysisOptions{}
+	values := []string{"true", "42", "40000", "9223372036854775807", "19.99", "2024-03-20 10:30:00", "2024-03-20", "March 20th, 2024", "Smith, John", ""}
+	for _, value := range values {
+		name := value
+		if name == "" {
+			name = "empty"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				inferType(value, analyzer, opts)
+			}
+		})
+	}
+}
+
+func BenchmarkAnalyzeFileTypes(b *testing.B) {
+	var data bytes.Buffer
+	if err := generateCSV(&data, 1000000, strings.Split(*genMix, ","), 1); err != nil {
+		b.Fatal(err)
+	}
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}}
+	b.SetBytes(int64(data.Len()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := analyzeFileTypes(bytes.NewReader(data.Bytes()), opts, analyzer); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// BigQuery's NUMERIC holds 29 digits before the decimal point and 9 after
+// it, and BIGNUMERIC 38 and 38; wider numbers fall back to FLOAT64
+const (
+	bqNumericDigits    = 29
+	bqNumericScale     = 9
+	bqBigNumericDigits = 38
+	bqBigNumericScale  = 38
+)
+
+// bqField is a column of a BigQuery schema as bq load --schema reads it
+type bqField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Mode        string `json:"mode"`
+	Description string `json:"description,omitempty"`
+}
+
+// bqType maps a column's inferred type to a BigQuery type for a CSV load,
+// reporting false when dates and timestamps fell back to STRING because
+// they are not written as BigQuery reads them. Timestamps carry no zone, so
+// they are DATETIME; detected epochs and compact dates are loaded as the
+// integers they are written as, and hex or binary literals, percentages and
+// accounting numbers as strings.
+func bqType(col columnAnalysis, analyzer dbtypes.TypeAnalyzer) (string, bool) {
+	if col.EpochUnit != "" || col.CompactFormat != "" {
+		return "INT64", true
+	}
+	if col.LiteralBase != "" || col.Percentages || col.Accounting {
+		return "STRING", true
+	}
+	switch analyzer.GetTypes()[col.TypeIndex].Name {
+	case "boolean":
+		return "BOOL", true
+	case "tinyint", "smallint", "integer", "bigint":
+		return "INT64", true
+	case "numeric":
+		digits := col.NumDigits
+		if col.Precision > 0 {
+			digits = col.Precision - col.NumScale
+		}
+		switch {
+		case digits <= bqNumericDigits && col.NumScale <= bqNumericScale:
+			return "NUMERIC", true
+		case digits <= bqBigNumericDigits && col.NumScale <= bqBigNumericScale:
+			return "BIGNUMERIC", true
+		}
+		return "FLOAT64", true
+	case "timestamp":
+		if col.TimeLayout == "2006-01-02 15:04:05" || col.TimeLayout == "2006-01-02T15:04:05" {
+			return "DATETIME", true
+		}
+		return "STRING", false
+	case "date":
+		if col.TimeLayout == "2006-01-02" {
+			return "DATE", true
+		}
+		return "STRING", false
+	case "geometry":
+		return "GEOGRAPHY", true
+	case "bytea":
+		if col.BinaryEncoding == "base64" {
+			return "BYTES", true
+		}
+	}
+	return "STRING", true
+}
+
+// printBQSchema writes the column report as the JSON array of fields bq
+// load --schema reads. Columns without empty values are REQUIRED, and the
+// warnings name the dates and timestamps declared as strings.
+func printBQSchema(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) ([]string, error) {
+	fields := []bqField{}
+	var unreadable []string
+	for _, col := range result.Columns {
+		typeName, ok := bqType(col, analyzer)
+		if !ok {
+			unreadable = append(unreadable, col.Name)
+		}
+		field := bqField{Name: col.Name, Type: typeName, Mode: "NULLABLE"}
+		if col.notNull(result.RowCount) {
+			field.Mode = "REQUIRED"
+		}
+		if col.Stats != nil {
+			field.Description = col.Stats.String()
+		}
+		fields = append(fields, field)
+	}
+	var warnings []string
+	if len(unreadable) > 0 {
+		warnings = append(warnings, fmt.Sprintf("dates and timestamps of %s are not written as YYYY-MM-DD or YYYY-MM-DD HH:MM:SS, so they are declared as STRING",
+			strings.Join(unreadable, ", ")))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return warnings, enc.Encode(fields)
+}
+
+// bqLoadCommand returns a bq load command loading the source file into the
+// table with the schema file and the settings the file was analyzed with.
+// Like COPY, bq load reads a single null marker, so the most frequent of
+// the null tokens is used and the warnings name the rest.
+func bqLoadCommand(result *fileAnalysis, table, source, schema string, opts analysisOptions) (string, []string, error) {
+	if opts.DelimiterRegex != nil {
+		return "", nil, fmt.Errorf("-with-load needs -delim, since bq load does not split fields by a regular expression")
+	}
+	if opts.RecordSeparator != "" {
+		return "", nil, fmt.Errorf("-with-load cannot load a file with -record-sep into bigquery, which only ends rows at newlines")
+	}
+	if len(opts.Delimiter) != 1 {
+		return "", nil, fmt.Errorf("-with-load needs a single-byte delimiter for bigquery, which does not take %q", opts.Delimiter)
+	}
+	null, warnings := nullString(result, opts.NullTokens, "bq load")
+	quote := map[string]string{"single": "'", "double": `"`}[opts.Quotes]
+	delimiter := opts.Delimiter
+	if delimiter == "\t" {
+		delimiter = "tab"
+	}
+
+	args := []string{"bq", "load", "--source_format=CSV"}
+	if !result.NoHeader {
+		args = append(args, "--skip_leading_rows=1")
+	}
+	args = append(args, "--field_delimiter="+shellQuote(delimiter), "--quote="+shellQuote(quote))
+	if quote != "" {
+		args = append(args, "--allow_quoted_newlines")
+	}
+	args = append(args, "--null_marker="+shellQuote(null), "--schema="+shellQuote(schema), shellQuote(table), shellQuote(source))
+	return strings.Join(args, " ") + "\n", warnings, nil
+}
+
+// shellQuote single-quotes a word for a POSIX shell unless it is plain
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./:@%+=,") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
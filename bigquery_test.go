@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestBQType(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	numeric := typeIndex(analyzer, "numeric")
+	tests := []struct {
+		name   string
+		col    columnAnalysis
+		want   string
+		wantOK bool
+	}{
+		{"numeric", columnAnalysis{TypeIndex: numeric, NumDigits: 29, NumScale: 9}, "NUMERIC", true},
+		{"wide numeric", columnAnalysis{TypeIndex: numeric, NumDigits: 30, NumScale: 2}, "BIGNUMERIC", true},
+		{"fine numeric", columnAnalysis{TypeIndex: numeric, NumDigits: 1, NumScale: 12}, "BIGNUMERIC", true},
+		{"declared numeric", columnAnalysis{TypeIndex: numeric, Precision: 40, NumScale: 5}, "BIGNUMERIC", true},
+		{"huge numeric", columnAnalysis{TypeIndex: numeric, NumDigits: 39}, "FLOAT64", true},
+		{"iso timestamp", columnAnalysis{TypeIndex: typeIndex(analyzer, "timestamp"), TimeLayout: "2006-01-02 15:04:05"}, "DATETIME", true},
+		{"us date", columnAnalysis{TypeIndex: typeIndex(analyzer, "date"), TimeLayout: "01/02/2006"}, "STRING", false},
+		{"epoch", columnAnalysis{TypeIndex: typeIndex(analyzer, "timestamp"), EpochUnit: "seconds"}, "INT64", true},
+		{"text", columnAnalysis{TypeIndex: typeIndex(analyzer, "text")}, "STRING", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := bqType(tt.col, analyzer); got != tt.want || ok != tt.wantOK {
+				t.Errorf("bqType() = %s, %v, want %s, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPrintBQSchema(t *testing.T) {
+	input := "id|active|amount|ordered|note\n1|true|1.25|2024-03-20|\n2|false|3.5|2024-03-21|gift\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	var buf bytes.Buffer
+	warnings, err := printBQSchema(&buf, result, analyzer)
+	if err != nil || warnings != nil {
+		t.Fatalf("printBQSchema() = %q, %v, want no warnings", warnings, err)
+	}
+	var got []bqField
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("printBQSchema() wrote invalid JSON: %v", err)
+	}
+	want := []bqField{
+		{Name: "id", Type: "INT64", Mode: "REQUIRED"},
+		{Name: "active", Type: "BOOL", Mode: "REQUIRED"},
+		{Name: "amount", Type: "NUMERIC", Mode: "REQUIRED"},
+		{Name: "ordered", Type: "DATE", Mode: "REQUIRED"},
+		{Name: "note", Type: "STRING", Mode: "NULLABLE"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("printBQSchema() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBQLoadCommand(t *testing.T) {
+	result := &fileAnalysis{Columns: []columnAnalysis{{Name: "id"}}, NullCounts: map[string]int{"NULL": 3, "": 1}}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: "\t", Quotes: "single", NullTokens: []string{"NULL"}}}
+	got, warnings, err := bqLoadCommand(result, "sales.orders", "data/my orders.tsv", "orders.json", opts)
+	if err != nil {
+		t.Fatalf("bqLoadCommand() error = %v, want nil", err)
+	}
+	want := `bq load --source_format=CSV --skip_leading_rows=1 --field_delimiter=tab --quote=''\''' --allow_quoted_newlines --null_marker=NULL --schema=orders.json sales.orders 'data/my orders.tsv'` + "\n"
+	if got != want {
+		t.Errorf("bqLoadCommand() =\n%s\nwant\n%s", got, want)
+	}
+	wantWarnings := []string{`bq load reads a single null string, "NULL", so 1 empty values will not load as nulls`}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("bqLoadCommand() warnings = %q, want %q", warnings, wantWarnings)
+	}
+
+	opts = analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}
+	result.NoHeader = true
+	got, _, _ = bqLoadCommand(result, "orders", "orders.csv", "schema.json", opts)
+	want = "bq load --source_format=CSV --field_delimiter=, --quote='' --null_marker='' --schema=schema.json orders orders.csv\n"
+	if got != want {
+		t.Errorf("bqLoadCommand() without quotes =\n%s\nwant\n%s", got, want)
+	}
+
+	opts.Delimiter = "||"
+	if _, _, err := bqLoadCommand(result, "orders", "orders.csv", "schema.json", opts); err == nil {
+		t.Error("bqLoadCommand() with a multi-character delimiter error = nil, want an error")
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"file2ddl/analyze"
+)
+
+// checkpointVersion is written in every checkpoint, so that one saved by a
+// build that kept different state is not resumed from
+const checkpointVersion = 1
+
+// fingerprintBytes is how much of the start of a file is hashed to tell
+// whether it changed since a checkpoint was saved
+const fingerprintBytes = 1 << 20
+
+// inputFingerprint identifies the contents of a file well enough to resume
+// its analysis: its size and a hash of its first MiB
+type inputFingerprint struct {
+	Size int64  `json:"size"`
+	Head string `json:"head_sha256"`
+}
+
+// fingerprintFile returns the fingerprint of a local file
+func fingerprintFile(path string) (inputFingerprint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return inputFingerprint{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return inputFingerprint{}, err
+	}
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, fingerprintBytes); err != nil && err != io.EOF {
+		return inputFingerprint{}, err
+	}
+	return inputFingerprint{Size: info.Size(), Head: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// flagFingerprint hashes the flags set for a run, on the command line or by
+// a profile, by name, but for those that only say where and how often to
+// checkpoint or how to write diagnostics, so that a run resumes only with
+// the flags it started with
+func flagFingerprint(flags map[string]string) string {
+	var settings []string
+	for _, name := range slices.Sorted(maps.Keys(flags)) {
+		switch name {
+		case "checkpoint", "checkpoint-rows", "v", "plain":
+			continue
+		}
+		settings = append(settings, name+"="+flags[name])
+	}
+	sum := sha256.Sum256([]byte(strings.Join(settings, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkpoint is the state of an analysis after a number of rows: where to
+// read on from in the file, and everything the rows read so far decided
+type checkpoint struct {
+	Version int              `json:"version"`
+	Flags   string           `json:"flags"`
+	Input   inputFingerprint `json:"input"`
+	Offset  int64            `json:"offset"` // bytes of the file read
+	Line    int              `json:"line"`   // line last read
+
+	Headers      []string `json:"headers"`
+	HeaderQuoted []bool   `json:"header_quoted,omitempty"`
+	HeaderLine   int      `json:"header_line"`
+	NoHeader     bool     `json:"no_header,omitempty"`
+
+	RowCount          int               `json:"row_count"`
+	BlankLines        int               `json:"blank_lines,omitempty"`
+	HeaderRepeats     int               `json:"header_repeats,omitempty"`
+	HeaderRepeatLines []int             `json:"header_repeat_lines,omitempty"`
+	NullCounts        map[string]int    `json:"null_counts,omitempty"`
+	Warnings          []string          `json:"warnings,omitempty"`
+	Columns           []columnAnalysis  `json:"columns"`
+	Typed             []bool            `json:"typed"`
+	Formats           []*formatCheck    `json:"formats"`
+	Widths            fieldCounts       `json:"widths,omitempty"`
+	Skipped           int               `json:"skipped,omitempty"`
+	Unreadable        int               `json:"unreadable,omitempty"`
+	FirstUnreadable   *analyze.RowError `json:"first_unreadable,omitempty"`
+	HintValues        [][]string        `json:"hint_values,omitempty"`
+}
+
+// checkpointer saves the analysis of a file every so many rows with
+// -checkpoint, and holds the checkpoint a run resumes from, if any
+type checkpointer struct {
+	path   string
+	every  int // rows between checkpoints
+	next   int // row count at which the next checkpoint is due
+	flags  string
+	input  inputFingerprint
+	resume *checkpoint // the checkpoint resumed from, nil for a fresh start
+}
+
+// openCheckpoint prepares to checkpoint the analysis of the local file at
+// input to path every so many rows, loading the checkpoint already there.
+// A checkpoint saved for other contents of the file or other flags is an
+// error, so that a run does not mix the state of two analyses.
+func openCheckpoint(path string, every int, input, flags string) (*checkpointer, error) {
+	fingerprint, err := fingerprintFile(input)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s for -checkpoint: %v", input, err)
+	}
+	c := &checkpointer{path: path, every: every, next: every, flags: flags, input: fingerprint}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint: %v", err)
+	}
+	var saved checkpoint
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("error parsing checkpoint %s: %v", path, err)
+	}
+	switch {
+	case saved.Version != checkpointVersion:
+		return nil, fmt.Errorf("checkpoint %s was saved by another version of file2ddl; remove it to start over", path)
+	case saved.Input != fingerprint:
+		return nil, fmt.Errorf("checkpoint %s was saved for other contents of %s; remove it to start over", path, input)
+	case saved.Flags != flags:
+		return nil, fmt.Errorf("checkpoint %s was saved with other flags; rerun with the same flags, or remove it to start over", path)
+	}
+	c.resume = &saved
+	c.next = (saved.RowCount/every + 1) * every
+	return c, nil
+}
+
+// due reports whether a checkpoint is due after rows data rows
+func (c *checkpointer) due(rows int) bool {
+	return c != nil && rows >= c.next
+}
+
+// save writes the checkpoint to a temporary file that then replaces the
+// previous checkpoint, so that a run killed while saving leaves the previous
+// one whole
+func (c *checkpointer) save(cp *checkpoint) error {
+	cp.Version, cp.Flags, cp.Input = checkpointVersion, c.flags, c.input
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("error saving checkpoint: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error saving checkpoint: %v", err)
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error saving checkpoint: %v", err)
+	}
+	c.next = (cp.RowCount/c.every + 1) * c.every
+	if verbose {
+		fmt.Fprintf(os.Stderr, "DEBUG: checkpoint saved after %s rows, at line %d\n", groupDigits(cp.RowCount), cp.Line)
+	}
+	return nil
+}
+
+// finish removes the checkpoint of a completed analysis, so that the next
+// run starts afresh
+func (c *checkpointer) finish() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error removing checkpoint: %v", err)
+	}
+	return nil
+}
+
+// resumeRecords prepares the records of a resumed analysis, read on from
+// the checkpoint's offset, for analyzeRecords: the columns named before the
+// checkpoint are replayed as the header, even for a file without one, whose
+// decision is restored with the rest of the state
+func (c *checkpointer) resumeRecords(records recordReader, opts *analysisOptions) recordReader {
+	replay := &replayRecords{recordReader: records}
+	replay.push(c.resume.Headers, c.resume.HeaderQuoted, c.resume.HeaderLine)
+	opts.Header = "yes"
+	return replay
+}
+
+// resumed returns the checkpoint the analysis resumes from, nil without one
+func (c *checkpointer) resumed() *checkpoint {
+	if c == nil {
+		return nil
+	}
+	return c.resume
+}
+
+// textSource returns the delimited text records reads, under any records
+// replayed ahead of them, or nil for other inputs
+func textSource(records recordReader) *textRecords {
+	for {
+		switch r := records.(type) {
+		case *textRecords:
+			return r
+		case *replayRecords:
+			records = r.recordReader
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "orders.csv")
+	text := "id,amount,shipped,note\n" +
+		"1,10,2024-01-15,a\n" +
+		"2,11.5,2024-01-16,\n" +
+		"\n" +
+		"3,12,2024-01-02,bb\n" +
+		"4,x,2024-01-18,ccc\n" +
+		"5,14,,NULL\n" +
+		"6,15,2024-01-20,dddd\n" +
+		"7,16,2024-01-21,e\n"
+	if err := os.WriteFile(input, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "orders.checkpoint")
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", NullTokens: []string{"NULL"}, SkipBadRows: true}}
+
+	// The whole analysis leaves the checkpoint saved after four rows behind
+	analyze := func(opts analysisOptions) *fileAnalysis {
+		t.Helper()
+		f, err := os.Open(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if cp := opts.Checkpoint.resumed(); cp != nil {
+			if _, err := f.Seek(cp.Offset, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+		result, err := analyzeFileTypes(f, opts, &dbtypes.PostgreSQLAnalyzer{})
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		return result
+	}
+	var err error
+	opts.Checkpoint, err = openCheckpoint(path, 4, input, "flags")
+	if err != nil {
+		t.Fatalf("openCheckpoint() error = %v, want nil", err)
+	}
+	whole := analyze(opts)
+
+	opts.Checkpoint, err = openCheckpoint(path, 4, input, "flags")
+	if err != nil {
+		t.Fatalf("openCheckpoint() error = %v, want nil", err)
+	}
+	cp := opts.Checkpoint.resumed()
+	if cp == nil {
+		t.Fatal("openCheckpoint() found no checkpoint to resume")
+	}
+	if cp.RowCount != 4 || cp.Line != 6 || cp.Offset != int64(strings.Index(text, "5,14")) {
+		t.Errorf("checkpoint at %d rows, line %d, offset %d, want 4 rows, line 6, offset %d", cp.RowCount, cp.Line, cp.Offset, strings.Index(text, "5,14"))
+	}
+	resumed := analyze(opts)
+	if !reflect.DeepEqual(resumed, whole) {
+		t.Errorf("resumed analysis = %+v, want %+v", resumed, whole)
+	}
+
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(matches) > 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+	if err := opts.Checkpoint.finish(); err != nil {
+		t.Fatalf("finish() error = %v, want nil", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("checkpoint still present after finish(): %v", err)
+	}
+}
+
+func TestOpenCheckpointMismatch(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "data.csv")
+	if err := os.WriteFile(input, []byte("a,b\n1,2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "data.checkpoint")
+	c, err := openCheckpoint(path, 1, input, "flags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.save(&checkpoint{RowCount: 1, Line: 2, Offset: 8}); err != nil {
+		t.Fatalf("save() error = %v, want nil", err)
+	}
+
+	if _, err := openCheckpoint(path, 1, input, "other flags"); err == nil || !strings.Contains(err.Error(), "other flags") {
+		t.Errorf("openCheckpoint() with other flags error = %v, want one about the flags", err)
+	}
+	if err := os.WriteFile(input, []byte("a,b\n1,3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openCheckpoint(path, 1, input, "flags"); err == nil || !strings.Contains(err.Error(), "other contents") {
+		t.Errorf("openCheckpoint() of a changed file error = %v, want one about its contents", err)
+	}
+}
+
+func TestFlagFingerprint(t *testing.T) {
+	base := flagFingerprint(map[string]string{"delim": ",", "quotes": "double"})
+	if got := flagFingerprint(map[string]string{"quotes": "double", "delim": ",", "checkpoint": "run.checkpoint", "v": "true"}); got != base {
+		t.Errorf("-checkpoint and -v changed the fingerprint")
+	}
+	if got := flagFingerprint(map[string]string{"delim": "|", "quotes": "double"}); got == base {
+		t.Errorf("-delim | has the fingerprint of -delim ,")
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"file2ddl/dbtypes"
+)
+
+// maxCheckValues is the most distinct values of a string column listed in a
+// CHECK (... IN (...)) constraint
+const maxCheckValues = 10
+
+// maxCheckValueLength is the longest value listed in such a constraint, in
+// bytes, so that free text with a few repeated values is left out
+const maxCheckValueLength = 64
+
+// integerBounds are the smallest and largest values of the integer types,
+// with an unsigned tinyint's taken from 0 to 255 instead
+var integerBounds = map[string][2]int64{
+	"tinyint":  {math.MinInt8, math.MaxInt8},
+	"smallint": {math.MinInt16, math.MaxInt16},
+	"integer":  {math.MinInt32, math.MaxInt32},
+	"bigint":   {math.MinInt64, math.MaxInt64},
+}
+
+// valueEntryBytes estimates the memory a value kept in a map takes beyond
+// its bytes
+const valueEntryBytes = 32
+
+// valueTracker keeps the distinct values of each column until there are
+// more than maxCheckValues of them or one is longer than
+// maxCheckValueLength, so memory stays small whatever the file, unless the
+// file is wide enough to need a memory budget, past which columns are
+// dropped
+type valueTracker struct {
+	values  []map[string]bool // by column, nil once the column was ruled out
+	held    []int64           // bytes reserved for the values of each column
+	budget  *memoryBudget
+	dropped int // columns ruled out by the budget
+}
+
+func newValueTracker(columns int, budget *memoryBudget) *valueTracker {
+	v := &valueTracker{values: make([]map[string]bool, columns), held: make([]int64, columns), budget: budget}
+	for i := range v.values {
+		v.values[i] = make(map[string]bool)
+	}
+	return v
+}
+
+// observe records a non-null value of column i
+func (v *valueTracker) observe(i int, field string) {
+	values := v.values[i]
+	if values == nil || values[field] {
+		return
+	}
+	if len(values) == maxCheckValues || len(field) > maxCheckValueLength {
+		v.drop(i)
+		return
+	}
+	size := int64(len(field)) + valueEntryBytes
+	if !v.budget.reserve(size) {
+		v.drop(i)
+		v.dropped++
+		return
+	}
+	v.held[i] += size
+	values[strings.Clone(field)] = true
+}
+
+// drop rules column i out, giving back the memory of its values
+func (v *valueTracker) drop(i int) {
+	v.values[i] = nil
+	v.budget.release(v.held[i])
+	v.held[i] = 0
+}
+
+// finish sets the sorted distinct values of the columns that kept them
+func (v *valueTracker) finish(columns []columnAnalysis) {
+	for i := range columns {
+		if len(v.values[i]) > 0 {
+			columns[i].Values = slices.Sorted(maps.Keys(v.values[i]))
+		}
+	}
+}
+
+// budgetWarnings describes how the memory budget limited the values kept
+func (v *valueTracker) budgetWarnings() []string {
+	if v.dropped == 0 {
+		return nil
+	}
+	return []string{budgetWarning(v.budget, "-with-checks dropped the values of %s, which get no constraint listing them", countOf(v.dropped, "column"))}
+}
+
+// checkSQL returns ALTER TABLE statements adding CHECK constraints that hold
+// the values seen: integer and date columns are kept to their observed range
+// widened at each end by headroom times its span, not below zero for columns
+// without negative values, and string columns with few distinct values that
+// each repeat are kept to those values. A column of a single value gets
+// none. The statements are headed by a comment saying they come from the
+// file rather than business rules, and Snowflake and Exasol, which have no
+// CHECK constraints, get them commented out.
+func checkSQL(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table string, headroom float64) string {
+	var noChecks string
+	switch analyzer.(type) {
+	case *dbtypes.SnowflakeAnalyzer:
+		noChecks = "-- Snowflake does not support CHECK constraints\n"
+	case *dbtypes.ExasolAnalyzer:
+		noChecks = "-- Exasol does not support CHECK constraints\n"
+	}
+	literal := quoteLiteral
+	if _, ok := analyzer.(*dbtypes.MariaDBAnalyzer); ok {
+		literal = mariadbLiteral
+	}
+	limit := identifierLimit(analyzer)
+	taken := make(map[string]bool)
+	var b strings.Builder
+	for _, col := range result.Columns {
+		dataType := analyzer.GetTypes()[col.TypeIndex]
+		typeName := dataType.Name
+		column := flavorIdentifier(analyzer, col.Name)
+		var observed, check string
+		switch {
+		case integerBounds[typeName] != [2]int64{} && col.EpochUnit == "" && col.IntCount > 0 && col.IntMin < col.IntMax:
+			bounds := integerBounds[typeName]
+			if dataType.Unsigned {
+				bounds = [2]int64{0, math.MaxUint8}
+			}
+			pad := int64(math.MaxInt64)
+			if span := math.Ceil(headroom * (float64(col.IntMax) - float64(col.IntMin))); span < math.MaxInt64 {
+				pad = int64(span)
+			}
+			low := widen(col.IntMin, -pad, bounds[0])
+			if col.IntMin >= 0 {
+				low = max(low, 0)
+			}
+			observed = fmt.Sprintf("%d to %d", col.IntMin, col.IntMax)
+			check = fmt.Sprintf("%s BETWEEN %d AND %d", column, low, widen(col.IntMax, pad, bounds[1]))
+		case typeName == "date" && col.CompactFormat == "" && col.Earliest != nil && col.Earliest.At.Before(col.Latest.At):
+			const layout = "2006-01-02"
+			earliest, latest := col.Earliest.At.UTC(), col.Latest.At.UTC()
+			days := int(math.Ceil(headroom * latest.Sub(earliest).Hours() / 24))
+			low, high := earliest.AddDate(0, 0, -days), latest.AddDate(0, 0, days)
+			if low.Year() < 1 {
+				low = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+			}
+			if high.Year() > 9999 {
+				high = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+			}
+			observed = fmt.Sprintf("%s to %s", earliest.Format(layout), latest.Format(layout))
+			check = fmt.Sprintf("%s BETWEEN DATE '%s' AND DATE '%s'", column, low.Format(layout), high.Format(layout))
+		case isStringType(typeName) && len(col.Values) > 1 && result.RowCount-col.EmptyCount >= 2*len(col.Values):
+			literals := make([]string, len(col.Values))
+			for i, value := range col.Values {
+				literals[i] = literal(value)
+			}
+			observed = fmt.Sprintf("%d distinct values", len(col.Values))
+			check = fmt.Sprintf("%s IN (%s)", column, strings.Join(literals, ", "))
+		default:
+			continue
+		}
+
+		name := table + "_" + col.Name + "_check"
+		if limit > 0 && len(name) > limit {
+			name = shortName(name, limit, taken)
+		}
+		taken[name] = true
+		statement := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s);", flavorIdentifier(analyzer, table), flavorIdentifier(analyzer, name), check)
+		if noChecks != "" {
+			statement = "-- " + statement
+		}
+		fmt.Fprintf(&b, "-- %s: observed %s\n%s\n", col.Name, observed, statement)
+	}
+	if b.Len() == 0 {
+		return "-- no CHECK constraints derived\n"
+	}
+	header := "-- CHECK constraints derived from the values in the file, not from business rules\n"
+	return header + noChecks + b.String()
+}
+
+// widen moves v by pad towards bound, stopping at the bound rather than
+// overflowing
+func widen(v, pad, bound int64) int64 {
+	// The distance to the bound is computed unsigned, which cannot overflow
+	if pad < 0 {
+		if uint64(v)-uint64(bound) <= uint64(-pad) {
+			return bound
+		}
+	} else if uint64(bound)-uint64(v) <= uint64(pad) {
+		return bound
+	}
+	return v + pad
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestCheckSQL(t *testing.T) {
+	input := "age,status,signup,note,flag\n18,active,2024-03-01,a,1\n99,closed,2024-03-11,b,1\n45,active,2024-03-06,c,1\n30,closed,2024-03-02,d,1\n"
+	tests := []struct {
+		name     string
+		analyzer dbtypes.TypeAnalyzer
+		headroom float64
+		want     string
+	}{
+		{
+			name:     "postgresql",
+			analyzer: &dbtypes.PostgreSQLAnalyzer{},
+			headroom: 0.5,
+			want: "-- CHECK constraints derived from the values in the file, not from business rules\n" +
+				"-- age: observed 18 to 99\nALTER TABLE people ADD CONSTRAINT people_age_check CHECK (age BETWEEN 0 AND 140);\n" +
+				"-- status: observed 2 distinct values\nALTER TABLE people ADD CONSTRAINT people_status_check CHECK (status IN ('active', 'closed'));\n" +
+				"-- signup: observed 2024-03-01 to 2024-03-11\n" +
+				"ALTER TABLE people ADD CONSTRAINT people_signup_check CHECK (signup BETWEEN DATE '2024-02-25' AND DATE '2024-03-16');\n",
+		},
+		{
+			name:     "snowflake without headroom",
+			analyzer: &dbtypes.SnowflakeAnalyzer{},
+			want: "-- CHECK constraints derived from the values in the file, not from business rules\n" +
+				"-- Snowflake does not support CHECK constraints\n" +
+				"-- age: observed 18 to 99\n-- ALTER TABLE people ADD CONSTRAINT people_age_check CHECK (age BETWEEN 18 AND 99);\n" +
+				"-- status: observed 2 distinct values\n-- ALTER TABLE people ADD CONSTRAINT people_status_check CHECK (status IN ('active', 'closed'));\n" +
+				"-- signup: observed 2024-03-01 to 2024-03-11\n" +
+				"-- ALTER TABLE people ADD CONSTRAINT people_signup_check CHECK (signup BETWEEN DATE '2024-03-01' AND DATE '2024-03-11');\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}, TrackValues: true}, tt.analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			if got := checkSQL(result, tt.analyzer, "people", tt.headroom); got != tt.want {
+				t.Errorf("checkSQL() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSQLMariaDB(t *testing.T) {
+	// MariaDB reads backslashes in literals as escapes, so they are doubled
+	input := "path,kind\nC:\\tmp,a\nC:\\tmp,a\nD:\\,b\nD:\\,b\n"
+	analyzer := &dbtypes.MariaDBAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}, TrackValues: true}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	want := "ALTER TABLE `keys` ADD CONSTRAINT keys_path_check CHECK (path IN ('C:\\\\tmp', 'D:\\\\'));\n"
+	if got := checkSQL(result, analyzer, "keys", 0); !strings.Contains(got, want) {
+		t.Errorf("checkSQL() =\n%s\nwant it to contain\n%s", got, want)
+	}
+}
+
+func TestCheckSQLTinyInt(t *testing.T) {
+	// The headroom stops at the bounds of an 8-bit integer, unsigned for
+	// SAP HANA and signed for MariaDB
+	input := "age\n18\n99\n"
+	for _, tt := range []struct {
+		analyzer dbtypes.TypeAnalyzer
+		want     string
+	}{
+		{&dbtypes.HanaAnalyzer{}, "CHECK (age BETWEEN 0 AND 255)"},
+		{&dbtypes.MariaDBAnalyzer{}, "CHECK (age BETWEEN 0 AND 127)"},
+	} {
+		result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}, TrackValues: true}, tt.analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		if got := checkSQL(result, tt.analyzer, "people", 3); !strings.Contains(got, tt.want) {
+			t.Errorf("checkSQL() under %T =\n%s\nwant it to contain %s", tt.analyzer, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSQLLongName(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader("n\n1\n2\n"), analysisOptions{Options: analyze.Options{Delimiter: ","}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	table := strings.Repeat("t", 70)
+	first := checkSQL(result, analyzer, table, 0)
+	if first != checkSQL(result, analyzer, table, 0) {
+		t.Error("checkSQL() names the constraint differently on the same input")
+	}
+	name := strings.Fields(strings.Split(first, "ADD CONSTRAINT ")[1])[0]
+	if len(name) > 63 {
+		t.Errorf("constraint name %s is %d bytes, want at most 63", name, len(name))
+	}
+}
+
+func TestWiden(t *testing.T) {
+	tests := []struct {
+		v, pad, bound, want int64
+	}{
+		{10, 5, 100, 15},
+		{10, -5, -100, 5},
+		{90, 50, 100, 100},
+		{math.MaxInt64 - 1, math.MaxInt64, math.MaxInt64, math.MaxInt64},
+		{math.MinInt64 + 1, -math.MaxInt64, math.MinInt64, math.MinInt64},
+	}
+	for _, tt := range tests {
+		if got := widen(tt.v, tt.pad, tt.bound); got != tt.want {
+			t.Errorf("widen(%d, %d, %d) = %d, want %d", tt.v, tt.pad, tt.bound, got, tt.want)
+		}
+	}
+}
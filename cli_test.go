@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// buildCLI builds the file2ddl binary into a temporary directory
+func buildCLI(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds and runs the binary")
+	}
+	bin := filepath.Join(t.TempDir(), "file2ddl")
+	if out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build error = %v\n%s", err, out)
+	}
+	return bin
+}
+
+// TestCLIStdoutIsOnlyOutput runs the binary with -v on files that cause
+// warnings and checks that stdout holds nothing but the output, which
+// parses as SQL, JSON or YAML, while the diagnostics went to stderr
+func TestCLIStdoutIsOnlyOutput(t *testing.T) {
+	bin := buildCLI(t)
+	// The empty column and the NUL byte cause warnings
+	warned := filepath.Join(t.TempDir(), "warned.csv")
+	if err := os.WriteFile(warned, []byte("id,empty,note\n1,,a\x00\n2,,b\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		parse func(stdout []byte) error
+	}{
+		{name: "ddl", args: []string{"-format", "ddl", "-with-comments", "-with-checks"}, parse: parseSQL},
+		{name: "json", args: []string{"-format", "json", "-stats"}, parse: parseJSON},
+		{name: "flavors json", args: []string{"-format", "json", "-flavor", "postgresql,snowflake"}, parse: parseJSON},
+		{name: "jsonschema", args: []string{"-format", "jsonschema"}, parse: parseJSON},
+		{name: "dbt", args: []string{"-format", "dbt", "-stats"}, parse: parseYAML},
+	}
+	for _, tt := range tests {
+		for _, input := range []string{"testdata/sample.csv", "testdata/quoted_sample.csv", warned} {
+			t.Run(tt.name+" "+filepath.Base(input), func(t *testing.T) {
+				args := append([]string{"-delim", ",", "-quotes", "double", "-v", "-plain"}, tt.args...)
+				cmd := exec.Command(bin, append(args, input)...)
+				var stdout, stderr bytes.Buffer
+				cmd.Stdout, cmd.Stderr = &stdout, &stderr
+				if err := cmd.Run(); err != nil {
+					t.Fatalf("file2ddl error = %v\n%s", err, stderr.String())
+				}
+				if err := tt.parse(stdout.Bytes()); err != nil {
+					t.Errorf("stdout does not parse: %v\n%s", err, stdout.String())
+				}
+				if !strings.Contains(stderr.String(), "DEBUG: ") {
+					t.Errorf("stderr = %q, want the DEBUG lines of -v", stderr.String())
+				}
+				if input == warned && !strings.Contains(stderr.String(), "WARNING: column note holds 1 NUL byte") {
+					t.Errorf("stderr = %q, want the NUL byte warning", stderr.String())
+				}
+				if strings.Contains(stderr.String(), "\x1b[") {
+					t.Errorf("stderr = %q, want no escape codes with -plain", stderr.String())
+				}
+			})
+		}
+	}
+}
+
+// TestCLIErrorsGoToStderr checks that a failing run writes nothing to stdout
+func TestCLIErrorsGoToStderr(t *testing.T) {
+	bin := buildCLI(t)
+	cmd := exec.Command(bin, "-delim", ",", "-format", "ddl", "testdata/invalid_sample.csv")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err == nil {
+		t.Fatal("file2ddl error = nil, want a field count error")
+	}
+	if stdout.Len() > 0 {
+		t.Errorf("stdout = %q, want nothing", stdout.String())
+	}
+	if !strings.HasPrefix(stderr.String(), "Error: ") {
+		t.Errorf("stderr = %q, want the error", stderr.String())
+	}
+}
+
+// TestCLICommentsPerFlavor checks that -with-comments writes comments after
+// the table of each flavor listed, and is refused when any of them is one
+// without COMMENT ON
+func TestCLICommentsPerFlavor(t *testing.T) {
+	bin := buildCLI(t)
+	args := []string{"-delim", ",", "-format", "ddl", "-with-comments", "-no-banner"}
+	cmd := exec.Command(bin, append(args, "-flavor", "postgresql,snowflake", "testdata/sample.csv")...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("file2ddl error = %v\n%s", err, stderr.String())
+	}
+	for _, flavor := range []string{"postgresql", "snowflake"} {
+		section := strings.SplitN(stdout.String(), "-- "+flavor+"\n", 2)
+		if len(section) < 2 {
+			t.Fatalf("stdout has no %s section:\n%s", flavor, stdout.String())
+		}
+		table, _, _ := strings.Cut(section[1], "\n-- ")
+		if !strings.Contains(table, "COMMENT ON TABLE ") {
+			t.Errorf("%s section has no COMMENT ON TABLE:\n%s", flavor, table)
+		}
+	}
+
+	cmd = exec.Command(bin, append(args, "-flavor", "postgresql,mariadb", "testdata/sample.csv")...)
+	stderr.Reset()
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err == nil || !strings.Contains(stderr.String(), "-with-comments is not supported for mariadb") {
+		t.Errorf("file2ddl error = %v, stderr = %q, want -with-comments refused for mariadb", err, stderr.String())
+	}
+}
+
+// parseSQL checks that every line is part of a statement or a comment: a
+// statement starts with a keyword and runs to a line ending in a semicolon
+func parseSQL(stdout []byte) error {
+	inStatement := false
+	for i, line := range strings.Split(strings.TrimSuffix(string(stdout), "\n"), "\n") {
+		switch {
+		case inStatement:
+		case line == "" || strings.HasPrefix(line, "-- "):
+			continue
+		case strings.HasPrefix(line, "CREATE ") || strings.HasPrefix(line, "ALTER ") || strings.HasPrefix(line, "COMMENT ON "):
+			inStatement = true
+		default:
+			return fmt.Errorf("line %d is not SQL: %q", i+1, line)
+		}
+		if strings.HasSuffix(line, ";") {
+			inStatement = false
+		}
+	}
+	if inStatement {
+		return fmt.Errorf("statement without a semicolon at the end")
+	}
+	return nil
+}
+
+// parseJSON checks that stdout is exactly one JSON document
+func parseJSON(stdout []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(stdout))
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("more after the JSON document")
+	}
+	return nil
+}
+
+// parseYAML checks that stdout is one YAML document holding a mapping
+func parseYAML(stdout []byte) error {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(stdout, &doc); err != nil {
+		return err
+	}
+	if doc["version"] == nil {
+		return fmt.Errorf("no version key")
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// minCodeDistinct is the number of distinct values a column needs before it
+// is reported as a code column, so that a free-text column holding only a
+// couple of short words that happen to be codes (e.g. "NO") does not qualify
+const minCodeDistinct = 5
+
+// countryCodes holds the ISO 3166-1 alpha-2 country codes
+var countryCodes = codeSet(`
+	AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ
+	BL BM BN BO BQ BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR
+	CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR
+	GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU
+	ID IE IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN KP KR KW KY KZ
+	LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ
+	MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF
+	PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI
+	SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR
+	TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW
+`)
+
+// currencyCodes holds the active ISO 4217 currency codes
+var currencyCodes = codeSet(`
+	AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB
+	BRL BSD BTN BWP BYN BZD CAD CDF CHF CLP CNY COP CRC CUP CVE CZK DJF DKK DOP
+	DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD HKD HNL HTG HUF
+	IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT LAK
+	LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MYR MZN
+	NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF
+	SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND
+	TOP TRY TTD TWD TZS UAH UGX USD UYU UZS VES VND VUV WST XAF XCD XCG XOF XPF
+	YER ZAR ZMW ZWG
+`)
+
+// codeSet builds a lookup set from a whitespace-separated list of codes
+func codeSet(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, code := range strings.Fields(list) {
+		set[code] = true
+	}
+	return set
+}
+
+// observeCode records whether the value is a country or currency code,
+// keeping up to minCodeDistinct of the distinct codes seen
+func (c *columnAnalysis) observeCode(value string) {
+	matched := false
+	if countryCodes[value] {
+		c.CountryCount++
+		matched = true
+	}
+	if currencyCodes[value] {
+		c.CurrencyCount++
+		matched = true
+	}
+	if !matched || len(c.CodeValues) >= minCodeDistinct || c.CodeValues[value] {
+		return
+	}
+	if c.CodeValues == nil {
+		c.CodeValues = make(map[string]bool)
+	}
+	c.CodeValues[strings.Clone(value)] = true
+}
+
+// detectCodeColumns reclassifies string columns as char(n) when every
+// non-null value belongs to the ISO country or currency code list and the
+// column holds at least minCodeDistinct distinct codes
+func detectCodeColumns(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) {
+	charType := typeIndex(analyzer, "char")
+	if charType < 0 || result.RowCount == 0 {
+		return
+	}
+
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		if analyzer.GetTypes()[col.TypeIndex].Name != "varchar" {
+			continue
+		}
+
+		// Nulls are not observed, so only the values are checked
+		values := result.RowCount - col.EmptyCount
+		switch {
+		case values == 0:
+			continue
+		case col.CountryCount == values:
+			col.CodeList = "ISO 3166-1 alpha-2"
+		case col.CurrencyCount == values:
+			col.CodeList = "ISO 4217"
+		default:
+			continue
+		}
+		if len(col.CodeValues) < minCodeDistinct {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "DEBUG: field %s not detected as %s codes: only %d distinct values\n",
+					col.Name, col.CodeList, len(col.CodeValues))
+			}
+			col.CodeList = ""
+			continue
+		}
+		col.TypeIndex = charType
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: field %s reclassified as char(%d) (%s)\n", col.Name, col.MaxLength, col.CodeList)
+		}
+	}
+}
+
+// codeCheck returns the CHECK constraint enforcing a code column's length,
+// since char(n) silently pads shorter values
+func codeCheck(col columnAnalysis) string {
+	return fmt.Sprintf("CHECK (char_length(%s) = %d)", col.Name, col.MaxLength)
+}
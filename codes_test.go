@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestDetectCodeColumns(t *testing.T) {
+	input := strings.Join([]string{
+		"id|country|currency|answer|few",
+		"1|US|USD|OK|US",
+		"2|DE|EUR|NO|DE",
+		"3|FR|EUR|NO|US",
+		"4|JP|JPY|OK|DE",
+		"5|BR|BRL|NO|US",
+		"6|NO|NOK|OK|DE",
+		"7|||OK|US",
+	}, "\n")
+
+	analyzer := &dbtypes.PostgreSQLAnalyzer{Char: true}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}, DetectCodes: true}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	expected := map[string]string{
+		"id":       "smallint",
+		"country":  "char(2)",
+		"currency": "char(3)",
+		"answer":   "varchar(2)",
+		"few":      "varchar(2)",
+	}
+	for _, col := range result.Columns {
+		if got := columnTypeName(col, analyzer); got != expected[col.Name] {
+			t.Errorf("Column %s: got type %s, want %s", col.Name, got, expected[col.Name])
+		}
+	}
+
+	country := result.Columns[1]
+	if country.CodeList != "ISO 3166-1 alpha-2" {
+		t.Errorf("country code list = %q, want ISO 3166-1 alpha-2", country.CodeList)
+	}
+	if got, want := codeCheck(country), "CHECK (char_length(country) = 2)"; got != want {
+		t.Errorf("codeCheck() = %q, want %q", got, want)
+	}
+	if result.Columns[2].CodeList != "ISO 4217" {
+		t.Errorf("currency code list = %q, want ISO 4217", result.Columns[2].CodeList)
+	}
+}
+
+func TestDetectCodeColumnsRequiresFlag(t *testing.T) {
+	input := "country\nUS\nDE\nFR\nJP\nBR\n"
+
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if got := columnTypeName(result.Columns[0], analyzer); got != "varchar(2)" {
+		t.Errorf("country: got type %s, want varchar(2)", got)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// confidenceHalfValues is the number of non-null values that gives a column
+// half the confidence its values can give; a hundred give 0.91
+const confidenceHalfValues = 10
+
+// sampledConfidence scales the confidence of the columns of a partial read,
+// whose unread rows may hold values of other types
+const sampledConfidence = 0.9
+
+// columnConfidence is how far the inferred type of a column can be trusted
+type columnConfidence struct {
+	Score  float64 // 0 to 1, to two decimals
+	Label  string  // "high", "medium" or "low"
+	Values int     // non-null values examined
+	Direct int     // of which parsed as the column's type, or as one widening to it without falling back to a string
+}
+
+// directMatch reports whether a value parsed as type from matches a column
+// of type to directly, as a smallint does an integer column, rather than by
+// promotion to a string type, as an integer does a varchar column
+func directMatch(analyzer dbtypes.TypeAnalyzer, from, to string) bool {
+	return from == to || !isStringType(to) && dbtypes.IsCompatible(analyzer, from, to)
+}
+
+// newColumnConfidence scores the type inferred for a column from the number
+// of its values, the fraction of them matching the type directly, and
+// whether only part of the input was read
+func newColumnConfidence(col columnAnalysis, rows int, analyzer dbtypes.TypeAnalyzer, sampled bool) *columnConfidence {
+	types := analyzer.GetTypes()
+	c := &columnConfidence{Values: rows - col.EmptyCount}
+	for i, n := range col.TypeCounts {
+		if directMatch(analyzer, types[i].Name, types[col.TypeIndex].Name) {
+			c.Direct += n
+		}
+	}
+	if c.Values > 0 {
+		score := float64(c.Values) / float64(c.Values+confidenceHalfValues) * float64(c.Direct) / float64(c.Values)
+		if sampled {
+			score *= sampledConfidence
+		}
+		c.Score = math.Round(score*100) / 100
+	}
+	switch {
+	case c.Score >= 0.8:
+		c.Label = "high"
+	case c.Score >= 0.5:
+		c.Label = "medium"
+	default:
+		c.Label = "low"
+	}
+	return c
+}
+
+// String describes the confidence, e.g. "confidence 0.23 (low)"
+func (c *columnConfidence) String() string {
+	return fmt.Sprintf("confidence %.2f (%s)", c.Score, c.Label)
+}
+
+// addConfidence scores the type inferred for every column, before detectors
+// that check every value, such as -detect-epoch, retype any of them
+func addConfidence(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, sampled bool) {
+	for i := range result.Columns {
+		result.Columns[i].Confidence = newColumnConfidence(result.Columns[i], result.RowCount, analyzer, sampled)
+	}
+}
+
+// lowConfidenceError describes the columns of low confidence, nil when there
+// are none
+func lowConfidenceError(result *fileAnalysis) error {
+	var low []string
+	for _, col := range result.Columns {
+		if c := col.Confidence; c != nil && c.Label == "low" {
+			low = append(low, fmt.Sprintf("%s (%.2f, %d of %s matching its type)", col.Name, c.Score, c.Direct, countOf(c.Values, "value")))
+		}
+	}
+	if len(low) == 0 {
+		return nil
+	}
+	return fmt.Errorf("low confidence in the types of %s: %s", countOf(len(low), "column"), strings.Join(low, ", "))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestColumnConfidence(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("id,sparse,mixed,code\n")
+	for i := 1; i <= 100; i++ {
+		sparse := ""
+		if i <= 3 {
+			sparse = fmt.Sprint(i)
+		}
+		mixed := fmt.Sprint(i)
+		if i%4 == 0 {
+			mixed = "n/a"
+		}
+		fmt.Fprintf(&b, "%d,%s,%s,c%d\n", i*1000, sparse, mixed, i)
+	}
+	input := b.String()
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+
+	tests := []struct {
+		sampled bool
+		want    []string
+	}{
+		// id mixes smallint and integer values, which match integer directly;
+		// mixed holds 75 integers matching its varchar type only by promotion
+		{false, []string{"confidence 0.91 (high)", "confidence 0.23 (low)", "confidence 0.23 (low)", "confidence 0.91 (high)"}},
+		{true, []string{"confidence 0.82 (high)", "confidence 0.21 (low)", "confidence 0.20 (low)", "confidence 0.82 (high)"}},
+	}
+	for _, tt := range tests {
+		result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}, Sampled: tt.sampled}, analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		for i, col := range result.Columns {
+			if got := col.Confidence.String(); got != tt.want[i] {
+				t.Errorf("sampled %v: column %s %s, want %s", tt.sampled, col.Name, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestConfidenceBeforeReclassification(t *testing.T) {
+	// Epoch detection retypes the integers, which still count as matching
+	input := "id,created\n1,1700000000\n2,1700000100\n3,1700000200\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}, DetectEpoch: true, EpochMinYear: 2000, EpochMaxYear: 2100}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if got := columnTypeName(result.Columns[1], analyzer); !strings.HasPrefix(got, "timestamp") {
+		t.Fatalf("created type = %s, want a timestamp", got)
+	}
+	if c := result.Columns[1].Confidence; c.Direct != 3 || c.Values != 3 {
+		t.Errorf("created confidence = %+v, want 3 of 3 values matching", *c)
+	}
+}
+
+func TestStrictLowConfidence(t *testing.T) {
+	input := "id,note\n1,a\n2,\n3,\n"
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ","}, StrictConfidence: true}
+	_, err := analyzeFileTypes(strings.NewReader(input), opts, &dbtypes.PostgreSQLAnalyzer{})
+	want := "low confidence in the types of 2 columns: id (0.23, 3 of 3 values matching its type), note (0.09, 1 of 1 value matching its type)"
+	if err == nil || err.Error() != want {
+		t.Errorf("analyzeFileTypes() error = %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// controlChecker counts the control characters in values, other than tabs,
+// which free text holds and loaders read, and the delimiter and record
+// separator, which a quoted field may hold, and optionally strips them or
+// replaces them
+type controlChecker struct {
+	allowed     map[rune]bool
+	strip       bool
+	replacement string
+}
+
+func newControlChecker(opts analysisOptions) *controlChecker {
+	c := &controlChecker{allowed: map[rune]bool{'\t': true}, strip: opts.StripControl, replacement: opts.ControlReplacement}
+	if r := []rune(opts.Delimiter); len(r) == 1 && opts.DelimiterRegex == nil {
+		c.allowed[r[0]] = true
+	}
+	for _, r := range opts.RecordSeparator {
+		c.allowed[r] = true
+	}
+	// TSV writes line breaks in fields as escapes, on purpose
+	if opts.TSVEscapes {
+		c.allowed['\n'], c.allowed['\r'] = true, true
+	}
+	return c
+}
+
+// check counts the control characters of a value of the column at a line,
+// returning the value with them stripped or replaced when asked to
+func (c *controlChecker) check(col *columnAnalysis, value string, line int) string {
+	if strings.IndexFunc(value, func(r rune) bool { return unicode.IsControl(r) && !c.allowed[r] }) < 0 {
+		return value
+	}
+	found := 0
+	var b strings.Builder
+	for _, r := range value {
+		if !unicode.IsControl(r) || c.allowed[r] {
+			b.WriteRune(r)
+			continue
+		}
+		found++
+		if r == 0 {
+			col.NULCount++
+		}
+		if c.strip {
+			b.WriteString(c.replacement)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	col.ControlCount += found
+	if len(col.ControlLines) < maxOutlierLines {
+		col.ControlLines = append(col.ControlLines, line)
+	}
+	if !c.strip {
+		return value
+	}
+	return b.String()
+}
+
+// controlWarnings describes the columns holding control characters, those
+// holding NUL bytes first, since PostgreSQL rejects them in any text value
+// and COPY fails on them whatever the column's type
+func controlWarnings(result *fileAnalysis, stripped bool) []string {
+	var nul, other []string
+	for _, col := range result.Columns {
+		if col.ControlCount == 0 {
+			continue
+		}
+		lineList := make([]string, len(col.ControlLines))
+		for i, line := range col.ControlLines {
+			lineList[i] = fmt.Sprint(line)
+		}
+		lineWord := "lines"
+		if len(lineList) == 1 {
+			lineWord = "line"
+		}
+		at := fmt.Sprintf("%s %s", lineWord, strings.Join(lineList, ", "))
+		if col.NULCount > 0 {
+			nul = append(nul, fmt.Sprintf("column %s holds %s (%s), which PostgreSQL rejects in text values; remove them from the file before loading it",
+				col.Name, countOf(col.NULCount, "NUL byte"), at))
+		}
+		if others := col.ControlCount - col.NULCount; others > 0 {
+			other = append(other, fmt.Sprintf("column %s holds %s (%s), which can break loading",
+				col.Name, countOf(others, "control character"), at))
+		}
+	}
+	if stripped && len(nul)+len(other) > 0 {
+		other = append(other, "control characters were stripped before inference with -strip-control-chars, but the file still holds them")
+	}
+	return append(nul, other...)
+}
+
+// countOf writes a count of things, e.g. "1 NUL byte" or "1,024 NUL bytes"
+func countOf(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return groupDigits(n) + " " + thing + "s"
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestControlCharacters(t *testing.T) {
+	input := "id,note,code\n1,a\x00b,x\n2,tab\there,y\x0b\n3,\"quoted,comma\",z\x00\x00\n"
+	tests := []struct {
+		name         string
+		opts         analysisOptions
+		wantCounts   [3]int
+		wantNULs     [3]int
+		wantLength   int // of note
+		wantWarnings []string
+	}{
+		{
+			name:       "reported",
+			opts:       analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}},
+			wantCounts: [3]int{0, 1, 3},
+			wantNULs:   [3]int{0, 1, 2},
+			wantLength: 12,
+			wantWarnings: []string{
+				"column note holds 1 NUL byte (line 2), which PostgreSQL rejects in text values; remove them from the file before loading it",
+				"column code holds 2 NUL bytes (lines 3, 4), which PostgreSQL rejects in text values; remove them from the file before loading it",
+				"column code holds 1 control character (lines 3, 4), which can break loading",
+			},
+		},
+		{
+			name:       "stripped",
+			opts:       analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}, StripControl: true},
+			wantCounts: [3]int{0, 1, 3},
+			wantNULs:   [3]int{0, 1, 2},
+			wantLength: 12,
+			wantWarnings: []string{
+				"column note holds 1 NUL byte (line 2), which PostgreSQL rejects in text values; remove them from the file before loading it",
+				"column code holds 2 NUL bytes (lines 3, 4), which PostgreSQL rejects in text values; remove them from the file before loading it",
+				"column code holds 1 control character (lines 3, 4), which can break loading",
+				"control characters were stripped before inference with -strip-control-chars, but the file still holds them",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeFileTypes(strings.NewReader(input), tt.opts, &dbtypes.PostgreSQLAnalyzer{})
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			for i, col := range result.Columns {
+				if col.ControlCount != tt.wantCounts[i] || col.NULCount != tt.wantNULs[i] {
+					t.Errorf("column %s control = %d, NUL = %d, want %d, %d", col.Name, col.ControlCount, col.NULCount, tt.wantCounts[i], tt.wantNULs[i])
+				}
+			}
+			if got := result.Columns[1].MaxLength; got != tt.wantLength {
+				t.Errorf("note MaxLength = %d, want %d", got, tt.wantLength)
+			}
+			if len(result.Warnings) < len(tt.wantWarnings) {
+				t.Fatalf("warnings = %q, want them to start with %q", result.Warnings, tt.wantWarnings)
+			}
+			for i, want := range tt.wantWarnings {
+				if result.Warnings[i] != want {
+					t.Errorf("warning %d = %q, want %q", i, result.Warnings[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestControlReplacement(t *testing.T) {
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ","}, StripControl: true, ControlReplacement: " "}
+	result, err := analyzeFileTypes(strings.NewReader("code\n1\x0b2\n"), opts, &dbtypes.PostgreSQLAnalyzer{})
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	// "1 2" is text where "12" would be an integer
+	if got := result.Columns[0].MaxLength; got != 3 {
+		t.Errorf("MaxLength = %d, want 3 after replacing by a space", got)
+	}
+	result, err = analyzeFileTypes(strings.NewReader("code\n1\x0b2\n"), analysisOptions{Options: analyze.Options{Delimiter: ","}, StripControl: true}, &dbtypes.PostgreSQLAnalyzer{})
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if typeName := (&dbtypes.PostgreSQLAnalyzer{}).GetTypes()[result.Columns[0].TypeIndex].Name; typeName != "smallint" {
+		t.Errorf("type = %s, want smallint once the vertical tab is stripped", typeName)
+	}
+}
@@ -0,0 +1,66 @@
+package dbtypes
+
+// ArrowType describes an Arrow field's type by the name of its flatbuffer
+// type, e.g. "int", "utf8" or "timestamp", and that type's parameters
+type ArrowType struct {
+	Type      string
+	BitWidth  int    // bits of an int
+	Signed    bool   // whether an int is signed
+	Precision int    // total digits of a decimal
+	Scale     int    // digits after the decimal point of a decimal
+	Unit      string // SECOND, MILLISECOND, MICROSECOND or NANOSECOND for a timestamp
+	Timezone  string // time zone of a timestamp, "" for wall-clock times
+	Length    int    // longest string seen by a scan, 0 when unknown
+}
+
+// FracDigits returns the fractional-second digits of a timestamp's unit
+func (t ArrowType) FracDigits() int {
+	switch t.Unit {
+	case "MILLISECOND":
+		return 3
+	case "MICROSECOND":
+		return 6
+	case "NANOSECOND":
+		return 9
+	}
+	return 0
+}
+
+// arrowTypeNames returns the names of the types able to hold the Arrow
+// type's values, best first
+func arrowTypeNames(t ArrowType) []string {
+	switch t.Type {
+	case "bool":
+		return []string{"boolean", "text"}
+	case "int":
+		switch {
+		case t.BitWidth <= 8 || (t.BitWidth == 16 && t.Signed):
+			return []string{"smallint", "integer", "bigint", "numeric", "text"}
+		case t.BitWidth == 16 || (t.BitWidth == 32 && t.Signed):
+			return []string{"integer", "bigint", "numeric", "text"}
+		case t.BitWidth == 32 || t.Signed:
+			return []string{"bigint", "numeric", "text"}
+		}
+		return []string{"numeric", "text"}
+	case "floatingpoint", "decimal":
+		return []string{"numeric", "text"}
+	case "date":
+		return []string{"date", "text"}
+	case "timestamp":
+		return []string{"timestamp", "text"}
+	case "utf8", "largeutf8", "utf8view":
+		if t.Length > 0 {
+			return []string{"varchar", "text"}
+		}
+	case "binary", "largebinary", "binaryview", "fixedsizebinary":
+		return []string{"bytea", "text"}
+	}
+	// Times, intervals, durations and nested types are kept as text
+	return []string{"text"}
+}
+
+// MapArrowType returns the index of the analyzer's type for values of the
+// Arrow type, taking the best type the flavor offers
+func MapArrowType(analyzer TypeAnalyzer, t ArrowType) int {
+	return firstType(analyzer, arrowTypeNames(t))
+}
@@ -0,0 +1,55 @@
+package dbtypes
+
+import "testing"
+
+func TestMapArrowType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		analyzer TypeAnalyzer
+		arrow    ArrowType
+		expected string
+	}{
+		{"bool", &PostgreSQLAnalyzer{}, ArrowType{Type: "bool"}, "boolean"},
+		{"int8", &PostgreSQLAnalyzer{}, ArrowType{Type: "int", BitWidth: 8, Signed: true}, "smallint"},
+		{"int16", &PostgreSQLAnalyzer{}, ArrowType{Type: "int", BitWidth: 16, Signed: true}, "smallint"},
+		{"uint16", &PostgreSQLAnalyzer{}, ArrowType{Type: "int", BitWidth: 16}, "integer"},
+		{"int32", &PostgreSQLAnalyzer{}, ArrowType{Type: "int", BitWidth: 32, Signed: true}, "integer"},
+		{"int64", &PostgreSQLAnalyzer{}, ArrowType{Type: "int", BitWidth: 64, Signed: true}, "bigint"},
+		{"uint64", &PostgreSQLAnalyzer{}, ArrowType{Type: "int", BitWidth: 64}, "numeric"},
+		{"float64", &PostgreSQLAnalyzer{}, ArrowType{Type: "floatingpoint"}, "numeric"},
+		{"decimal128", &PostgreSQLAnalyzer{}, ArrowType{Type: "decimal", Precision: 12, Scale: 4}, "numeric"},
+		{"date32", &PostgreSQLAnalyzer{}, ArrowType{Type: "date"}, "date"},
+		{"timestamp", &PostgreSQLAnalyzer{}, ArrowType{Type: "timestamp", Unit: "MICROSECOND", Timezone: "UTC"}, "timestamp"},
+		{"utf8", &PostgreSQLAnalyzer{}, ArrowType{Type: "utf8"}, "text"},
+		{"scanned large_utf8", &PostgreSQLAnalyzer{}, ArrowType{Type: "largeutf8", Length: 20}, "varchar"},
+		{"binary with bytea", &PostgreSQLAnalyzer{Bytea: true}, ArrowType{Type: "binary"}, "bytea"},
+		{"list", &SnowflakeAnalyzer{}, ArrowType{Type: "list"}, "text"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.analyzer.GetTypes()[MapArrowType(tc.analyzer, tc.arrow)].Name
+			if got != tc.expected {
+				t.Errorf("MapArrowType(%+v) = %s, want %s", tc.arrow, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestArrowType_FracDigits(t *testing.T) {
+	testCases := []struct {
+		unit     string
+		expected int
+	}{
+		{"SECOND", 0},
+		{"MILLISECOND", 3},
+		{"MICROSECOND", 6},
+		{"NANOSECOND", 9},
+	}
+
+	for _, tc := range testCases {
+		if got := (ArrowType{Type: "timestamp", Unit: tc.unit}).FracDigits(); got != tc.expected {
+			t.Errorf("FracDigits(%s) = %d, want %d", tc.unit, got, tc.expected)
+		}
+	}
+}
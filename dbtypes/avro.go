@@ -0,0 +1,65 @@
+package dbtypes
+
+// AvroType describes an Avro field by its type, with any logical type
+// annotating it. Unions are resolved to their non-null branch beforehand.
+type AvroType struct {
+	Type      string // a primitive type name, or record, enum, array, map or fixed
+	Logical   string // the logicalType attribute, or "" when unannotated
+	Precision int    // total digits of a decimal
+	Scale     int    // digits after the decimal point of a decimal
+	Length    int    // longest string or enum symbol, 0 when unknown
+}
+
+// FracDigits returns the fractional-second digits of a timestamp's unit
+func (t AvroType) FracDigits() int {
+	switch t.Logical {
+	case "timestamp-millis", "local-timestamp-millis":
+		return 3
+	case "timestamp-micros", "local-timestamp-micros":
+		return 6
+	case "timestamp-nanos", "local-timestamp-nanos":
+		return 9
+	}
+	return 0
+}
+
+// avroTypeNames returns the names of the types able to hold the Avro type's
+// values, best first
+func avroTypeNames(t AvroType) []string {
+	switch t.Logical {
+	case "decimal":
+		return []string{"numeric", "text"}
+	case "date":
+		return []string{"date", "text"}
+	case "timestamp-millis", "timestamp-micros", "timestamp-nanos",
+		"local-timestamp-millis", "local-timestamp-micros", "local-timestamp-nanos":
+		return []string{"timestamp", "text"}
+	case "uuid", "time-millis", "time-micros", "duration":
+		return []string{"text"}
+	}
+
+	switch t.Type {
+	case "boolean":
+		return []string{"boolean", "text"}
+	case "int":
+		return []string{"integer", "bigint", "numeric", "text"}
+	case "long":
+		return []string{"bigint", "numeric", "text"}
+	case "float", "double":
+		return []string{"numeric", "text"}
+	case "bytes", "fixed":
+		return []string{"bytea", "text"}
+	case "string", "enum":
+		if t.Length > 0 {
+			return []string{"varchar", "text"}
+		}
+	}
+	// Records, arrays and maps have no column equivalent
+	return []string{"text"}
+}
+
+// MapAvroType returns the index of the analyzer's type for values of the
+// Avro type, taking the best type the flavor offers
+func MapAvroType(analyzer TypeAnalyzer, t AvroType) int {
+	return firstType(analyzer, avroTypeNames(t))
+}
@@ -0,0 +1,55 @@
+package dbtypes
+
+import "testing"
+
+func TestMapAvroType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		analyzer TypeAnalyzer
+		avro     AvroType
+		expected string
+	}{
+		{"boolean", &PostgreSQLAnalyzer{}, AvroType{Type: "boolean"}, "boolean"},
+		{"int", &PostgreSQLAnalyzer{}, AvroType{Type: "int"}, "integer"},
+		{"long", &PostgreSQLAnalyzer{}, AvroType{Type: "long"}, "bigint"},
+		{"double", &PostgreSQLAnalyzer{}, AvroType{Type: "double"}, "numeric"},
+		{"decimal", &PostgreSQLAnalyzer{}, AvroType{Type: "bytes", Logical: "decimal", Precision: 9, Scale: 2}, "numeric"},
+		{"date", &PostgreSQLAnalyzer{}, AvroType{Type: "int", Logical: "date"}, "date"},
+		{"timestamp", &PostgreSQLAnalyzer{}, AvroType{Type: "long", Logical: "timestamp-micros"}, "timestamp"},
+		{"uuid", &PostgreSQLAnalyzer{}, AvroType{Type: "string", Logical: "uuid", Length: 36}, "text"},
+		{"string", &PostgreSQLAnalyzer{}, AvroType{Type: "string"}, "text"},
+		{"scanned string", &PostgreSQLAnalyzer{}, AvroType{Type: "string", Length: 12}, "varchar"},
+		{"enum", &PostgreSQLAnalyzer{}, AvroType{Type: "enum", Length: 7}, "varchar"},
+		{"bytes with bytea", &PostgreSQLAnalyzer{Bytea: true}, AvroType{Type: "bytes"}, "bytea"},
+		{"bytes without bytea", &PostgreSQLAnalyzer{}, AvroType{Type: "bytes"}, "text"},
+		{"record", &PostgreSQLAnalyzer{}, AvroType{Type: "record"}, "text"},
+		{"array", &SnowflakeAnalyzer{}, AvroType{Type: "array"}, "text"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.analyzer.GetTypes()[MapAvroType(tc.analyzer, tc.avro)].Name
+			if got != tc.expected {
+				t.Errorf("MapAvroType(%+v) = %s, want %s", tc.avro, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAvroType_FracDigits(t *testing.T) {
+	testCases := []struct {
+		avro     AvroType
+		expected int
+	}{
+		{AvroType{Type: "long", Logical: "timestamp-millis"}, 3},
+		{AvroType{Type: "long", Logical: "local-timestamp-micros"}, 6},
+		{AvroType{Type: "long", Logical: "timestamp-nanos"}, 9},
+		{AvroType{Type: "int", Logical: "date"}, 0},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.avro.FracDigits(); got != tc.expected {
+			t.Errorf("FracDigits(%+v) = %d, want %d", tc.avro, got, tc.expected)
+		}
+	}
+}
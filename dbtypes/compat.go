@@ -0,0 +1,115 @@
+package dbtypes
+
+import (
+	"fmt"
+	"slices"
+)
+
+// IsCompatible reports whether values of type from can be loaded into a
+// column of type to by the analyzer's compatibility matrix. Every type is
+// compatible with itself.
+func IsCompatible(analyzer TypeAnalyzer, from, to string) bool {
+	return from == to || slices.Contains(analyzer.GetTypeCompatibility()[from], to)
+}
+
+// CompatibleTypes returns the types values of the named type can be loaded
+// into, itself first and the rest in the analyzer's order of preference
+func CompatibleTypes(analyzer TypeAnalyzer, name string) []string {
+	targets := analyzer.GetTypeCompatibility()[name]
+	compatible := []string{name}
+	for _, t := range analyzer.GetTypes() {
+		if t.Name != name && slices.Contains(targets, t.Name) {
+			compatible = append(compatible, t.Name)
+		}
+	}
+	return compatible
+}
+
+// CommonType returns the most specific type that values of both types can
+// be loaded into: of the types both are compatible with, the one compatible
+// with all the others. smallint and integer give integer, date and smallint
+// give varchar.
+func CommonType(analyzer TypeAnalyzer, a, b string) (string, error) {
+	for _, name := range []string{a, b} {
+		if !slices.ContainsFunc(analyzer.GetTypes(), func(t DataType) bool { return t.Name == name }) {
+			return "", fmt.Errorf("%s is not a type of the flavor", name)
+		}
+	}
+	if a == b {
+		return a, nil
+	}
+
+	compatibility := analyzer.GetTypeCompatibility()
+	compatible := func(from, to string) bool {
+		return from == to || slices.Contains(compatibility[from], to)
+	}
+	var common []string
+	for _, t := range analyzer.GetTypes() {
+		if compatible(a, t.Name) && compatible(b, t.Name) {
+			common = append(common, t.Name)
+		}
+	}
+	for _, candidate := range common {
+		least := true
+		for _, other := range common {
+			if !compatible(candidate, other) {
+				least = false
+				break
+			}
+		}
+		if least {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%s and %s have no common type", a, b)
+}
+
+// ValidateCompatibility checks an analyzer's compatibility matrix: every
+// type has an entry listing itself, every target is one of the analyzer's
+// types, no two types widen to each other, and every type widens to the
+// last, most general type, so that CommonType always has an answer.
+func ValidateCompatibility(analyzer TypeAnalyzer) error {
+	types := analyzer.GetTypes()
+	if len(types) == 0 {
+		return fmt.Errorf("the flavor has no types")
+	}
+	known := make(map[string]bool)
+	for _, t := range types {
+		known[t.Name] = true
+	}
+	compatibility := analyzer.GetTypeCompatibility()
+	terminal := types[len(types)-1].Name
+	for _, t := range types {
+		targets, ok := compatibility[t.Name]
+		if !ok {
+			return fmt.Errorf("type %s has no compatibility entry", t.Name)
+		}
+		if !slices.Contains(targets, t.Name) {
+			return fmt.Errorf("type %s is not compatible with itself", t.Name)
+		}
+		if !slices.Contains(targets, terminal) {
+			return fmt.Errorf("type %s does not widen to %s", t.Name, terminal)
+		}
+		for _, target := range targets {
+			if !known[target] {
+				return fmt.Errorf("type %s widens to %s, which is not a type of the flavor", t.Name, target)
+			}
+			if target != t.Name && slices.Contains(compatibility[target], t.Name) {
+				return fmt.Errorf("types %s and %s widen to each other", t.Name, target)
+			}
+		}
+	}
+	for name := range compatibility {
+		if !known[name] {
+			return fmt.Errorf("compatibility entry %s is not a type of the flavor", name)
+		}
+	}
+	for i, a := range types {
+		for _, b := range types[i+1:] {
+			if _, err := CommonType(analyzer, a.Name, b.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
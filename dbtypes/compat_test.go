@@ -0,0 +1,104 @@
+package dbtypes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateCompatibility(t *testing.T) {
+	options := []PostgreSQLAnalyzer{{}, {PostGIS: true}, {Bytea: true, XML: true}, {PostGIS: true, Bytea: true, XML: true, Char: true}}
+	for _, opts := range options {
+		for _, analyzer := range []TypeAnalyzer{&PostgreSQLAnalyzer{opts.PostGIS, opts.Bytea, opts.XML, opts.Char}, &SnowflakeAnalyzer{opts}} {
+			if err := ValidateCompatibility(analyzer); err != nil {
+				t.Errorf("ValidateCompatibility(%T %+v) = %v, want nil", analyzer, opts, err)
+			}
+		}
+	}
+}
+
+// brokenAnalyzer has the PostgreSQL types with a compatibility matrix
+// replaced for testing validation
+type brokenAnalyzer struct {
+	PostgreSQLAnalyzer
+	compatibility map[string][]string
+}
+
+func (b *brokenAnalyzer) GetTypeCompatibility() map[string][]string {
+	return b.compatibility
+}
+
+func TestValidateCompatibilityErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(map[string][]string)
+		want   string
+	}{
+		{"missing entry", func(m map[string][]string) { delete(m, "date") }, "type date has no compatibility entry"},
+		{"unknown target", func(m map[string][]string) { m["date"] = []string{"date", "datetime", "text"} }, "date widens to datetime"},
+		{"no terminal", func(m map[string][]string) { m["boolean"] = []string{"boolean"} }, "type boolean does not widen to text"},
+		{"cycle", func(m map[string][]string) { m["integer"] = append(m["integer"], "smallint") }, "widen to each other"},
+		{"unknown entry", func(m map[string][]string) { m["money"] = []string{"money", "text"} }, "compatibility entry money"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &brokenAnalyzer{compatibility: (&PostgreSQLAnalyzer{}).GetTypeCompatibility()}
+			tt.change(analyzer.compatibility)
+			err := ValidateCompatibility(analyzer)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ValidateCompatibility() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommonType(t *testing.T) {
+	analyzer := &PostgreSQLAnalyzer{PostGIS: true}
+	tests := []struct {
+		a, b, want string
+	}{
+		{"smallint", "smallint", "smallint"},
+		{"smallint", "integer", "integer"},
+		{"bigint", "smallint", "bigint"},
+		{"integer", "numeric", "numeric"},
+		{"boolean", "smallint", "varchar"},
+		{"date", "smallint", "varchar"},
+		{"timestamp", "date", "date"},
+		{"geometry", "varchar", "varchar"},
+		{"numeric", "text", "text"},
+	}
+	for _, tt := range tests {
+		got, err := CommonType(analyzer, tt.a, tt.b)
+		if err != nil || got != tt.want {
+			t.Errorf("CommonType(%s, %s) = %s, %v, want %s", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+
+	if _, err := CommonType(analyzer, "smallint", "money"); err == nil {
+		t.Error("CommonType() of an unknown type: error = nil, want error")
+	}
+}
+
+func TestIsCompatible(t *testing.T) {
+	analyzer := &PostgreSQLAnalyzer{}
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"smallint", "smallint", true},
+		{"smallint", "bigint", true},
+		{"bigint", "smallint", false},
+		{"numeric", "varchar", true},
+		{"text", "varchar", false},
+	}
+	for _, tt := range tests {
+		if got := IsCompatible(analyzer, tt.from, tt.to); got != tt.want {
+			t.Errorf("IsCompatible(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	want := []string{"timestamp", "date", "varchar", "text"}
+	if got := CompatibleTypes(analyzer, "timestamp"); !reflect.DeepEqual(got, want) {
+		t.Errorf("CompatibleTypes(timestamp) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+package dbtypes
+
+import "fmt"
+
+// exasolMaxLength is the longest VARCHAR Exasol declares, 2,000,000
+// characters
+const exasolMaxLength = 2000000
+
+// exasolCharLength is the longest CHAR Exasol declares, 2,000 characters
+const exasolCharLength = 2000
+
+// exasolPrecision is the most digits an Exasol DECIMAL holds
+const exasolPrecision = 36
+
+// exasolTypeNames maps canonical type names to their Exasol spelling.
+// Exasol keeps every number that is not a double as a DECIMAL, so integers
+// are spelled as the DECIMAL its INTEGER and BIGINT aliases stand for.
+var exasolTypeNames = map[string]string{
+	"integer": "decimal(18,0)",
+	"bigint":  "decimal(36,0)",
+	"numeric": "decimal",
+	"float":   "double precision",
+}
+
+// exasolReservedWords are the Exasol reserved words beyond the common SQL
+// keywords every flavor quotes that are likely column names
+var exasolReservedWords = map[string]bool{
+	"action": true, "attribute": true, "begin": true, "char": true,
+	"condition": true, "current": true, "data": true, "date": true, "day": true,
+	"end": true, "file": true, "first": true, "global": true, "hour": true,
+	"index": true, "last": true, "level": true, "local": true, "log": true,
+	"low": true, "high": true, "minute": true, "month": true, "names": true,
+	"new": true, "next": true, "number": true, "object": true, "old": true,
+	"output": true, "path": true, "position": true, "prior": true, "profile": true,
+	"random": true, "range": true, "result": true, "row": true, "rows": true,
+	"schema": true, "second": true, "session": true, "source": true, "space": true,
+	"state": true, "style": true, "system": true, "text": true,
+	"time": true, "timestamp": true, "transaction": true,
+	"value": true, "values": true, "view": true, "year": true, "zone": true,
+}
+
+// ExasolAnalyzer implements TypeAnalyzer for Exasol. It keeps integers as
+// DECIMAL(18,0), or DECIMAL(36,0) beyond 32 bits, sizes VARCHAR in
+// characters up to 2,000,000, and writes plain names in upper case, as
+// Exasol stores them. Double precision is offered for overrides; no text
+// value is inferred as one.
+type ExasolAnalyzer struct {
+	Char bool // offer char(n) for columns of fixed-width codes
+}
+
+// GetTypes returns the Exasol data types in order of preference
+func (e *ExasolAnalyzer) GetTypes() []DataType {
+	types := []DataType{
+		{Name: "boolean"},
+		{Name: "integer"},
+		{Name: "bigint"},
+		// A DECIMAL without a precision holds 18 digits and no fraction, so
+		// the precision and scale are always written, the most digits when
+		// no precision was declared
+		{Name: "numeric", HasPrecisionScale: true, Format: func(spelling string, params TypeParams) string {
+			precision := params.Precision
+			if precision == 0 {
+				precision = exasolPrecision
+			}
+			return fmt.Sprintf("%s(%d,%d)", spelling, min(precision, exasolPrecision), min(params.Scale, exasolPrecision))
+		}},
+		{Name: "float"},
+		{Name: "timestamp", MaxPrecision: 9},
+		{Name: "date"},
+	}
+	if e.Char {
+		types = append(types, DataType{Name: "char", HasLength: true, MaxLength: exasolCharLength})
+	}
+	types = append(types,
+		DataType{Name: "varchar", HasLength: true, MaxLength: exasolMaxLength, InferLength: exasolMaxLength},
+		// Exasol has no type beyond VARCHAR, so the longest holds what
+		// does not fit a shorter one
+		DataType{Name: "text", Format: func(string, TypeParams) string {
+			return fmt.Sprintf("varchar(%d)", exasolMaxLength)
+		}},
+	)
+	for i := range types {
+		types[i].Priority = i + 1
+	}
+	return types
+}
+
+// GetTypeCompatibility returns the Exasol type compatibility matrix, with
+// every number widening to double precision
+func (e *ExasolAnalyzer) GetTypeCompatibility() map[string][]string {
+	compatibility := map[string][]string{
+		"boolean":   {"boolean", "varchar", "text"},
+		"integer":   {"integer", "bigint", "numeric", "float", "varchar", "text"},
+		"bigint":    {"bigint", "numeric", "float", "varchar", "text"},
+		"numeric":   {"numeric", "float", "varchar", "text"},
+		"float":     {"float", "varchar", "text"},
+		"timestamp": {"timestamp", "date", "varchar", "text"},
+		"date":      {"date", "varchar", "text"},
+		"varchar":   {"varchar", "text"},
+		"text":      {"text"},
+	}
+	if e.Char {
+		compatibility["char"] = []string{"char", "varchar", "text"}
+	}
+	return compatibility
+}
+
+// LengthSemantics returns "chars", since Exasol's VARCHAR(n) holds n
+// characters
+func (e *ExasolAnalyzer) LengthSemantics() string {
+	return "chars"
+}
+
+// IdentifierLimit returns 128, the longest identifier Exasol accepts
+func (e *ExasolAnalyzer) IdentifierLimit() int {
+	return 128
+}
+
+// LengthThresholds returns the 2,000,000-character limit of an Exasol
+// VARCHAR value, counted in bytes, so values of many multi-byte characters
+// are warned about early
+func (e *ExasolAnalyzer) LengthThresholds() []LengthThreshold {
+	return []LengthThreshold{{Bytes: exasolMaxLength, Limit: "an Exasol VARCHAR value"}}
+}
+
+// TypeName returns the Exasol spelling of a canonical type name
+func (e *ExasolAnalyzer) TypeName(name string) string {
+	if renamed, ok := exasolTypeNames[name]; ok {
+		return renamed
+	}
+	return name
+}
+
+// ReservedWord reports whether Exasol reserves a lower-case name, which
+// must then be quoted to name a column
+func (e *ExasolAnalyzer) ReservedWord(name string) bool {
+	return exasolReservedWords[name]
+}
+
+// IdentifierCase returns "upper", since Exasol folds unquoted names to
+// upper case
+func (e *ExasolAnalyzer) IdentifierCase() string {
+	return "upper"
+}
@@ -0,0 +1,44 @@
+package dbtypes
+
+import "testing"
+
+func TestExasolAnalyzer_GetTypes(t *testing.T) {
+	tests := []struct {
+		analyzer *ExasolAnalyzer
+		expected []string
+	}{
+		{&ExasolAnalyzer{}, []string{"boolean", "integer", "bigint", "numeric", "float", "timestamp", "date", "varchar", "text"}},
+		{&ExasolAnalyzer{Char: true}, []string{"boolean", "integer", "bigint", "numeric", "float", "timestamp", "date", "char", "varchar", "text"}},
+	}
+	for _, tt := range tests {
+		types := tt.analyzer.GetTypes()
+		if len(types) != len(tt.expected) {
+			t.Fatalf("Expected %d types, got %d", len(tt.expected), len(types))
+		}
+		for i, expected := range tt.expected {
+			if types[i].Name != expected {
+				t.Errorf("Expected type %s at position %d, got %s", expected, i, types[i].Name)
+			}
+			if types[i].Priority != i+1 {
+				t.Errorf("Expected priority %d for %s, got %d", i+1, types[i].Name, types[i].Priority)
+			}
+		}
+		if err := ValidateCompatibility(tt.analyzer); err != nil {
+			t.Errorf("ValidateCompatibility() with Char %v = %v, want nil", tt.analyzer.Char, err)
+		}
+	}
+}
+
+func TestExasolAnalyzer_Spelling(t *testing.T) {
+	analyzer := &ExasolAnalyzer{Char: true}
+	params := TypeParams{Length: 20, Scale: 2, FracDigits: 3}
+	expected := []string{"boolean", "decimal(18,0)", "decimal(36,0)", "decimal(36,2)", "double precision", "timestamp(3)", "date", "char(20)", "varchar(20)", "varchar(2000000)"}
+	for i, dataType := range analyzer.GetTypes() {
+		if got := dataType.Spec(analyzer.TypeName(dataType.Name), params); got != expected[i] {
+			t.Errorf("Spec(%s) = %s, want %s", dataType.Name, got, expected[i])
+		}
+	}
+	if got := analyzer.IdentifierCase(); got != "upper" {
+		t.Errorf("IdentifierCase() = %s, want upper", got)
+	}
+}
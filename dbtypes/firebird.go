@@ -0,0 +1,103 @@
+package dbtypes
+
+import "fmt"
+
+// firebirdMaxLength is the longest VARCHAR Firebird declares, 32,765 bytes;
+// longer values are BLOB SUB_TYPE TEXT
+const firebirdMaxLength = 32765
+
+// firebirdPrecision is the most digits a Firebird 3 DECIMAL holds
+const firebirdPrecision = 18
+
+// firebirdTypeNames maps canonical type names to their Firebird spelling
+var firebirdTypeNames = map[string]string{
+	"numeric": "decimal",
+	"text":    "blob sub_type text",
+}
+
+// firebirdReservedWords are the Firebird 3 reserved words beyond the common
+// SQL keywords every flavor quotes, many of them likely column names
+var firebirdReservedWords = map[string]bool{
+	"add": true, "admin": true, "alter": true, "any": true, "at": true, "avg": true,
+	"begin": true, "bigint": true, "blob": true, "by": true, "char": true,
+	"character": true, "close": true, "commit": true, "connect": true, "count": true,
+	"current": true, "cursor": true, "date": true, "day": true, "dec": true,
+	"decimal": true, "declare": true, "delete": true, "double": true, "drop": true,
+	"end": true, "escape": true, "execute": true, "exists": true, "external": true,
+	"extract": true, "filter": true, "float": true, "function": true, "global": true,
+	"hour": true, "index": true, "insert": true, "int": true, "integer": true,
+	"into": true, "long": true, "lower": true, "max": true, "merge": true, "min": true,
+	"minute": true, "month": true, "national": true, "no": true, "numeric": true,
+	"of": true, "open": true, "parameter": true, "plan": true, "position": true,
+	"precision": true, "real": true, "release": true, "returns": true, "row": true,
+	"rows": true, "second": true, "set": true, "smallint": true, "start": true,
+	"sum": true, "time": true, "timestamp": true, "trigger": true, "update": true,
+	"upper": true, "value": true, "values": true, "varchar": true, "variable": true,
+	"varying": true, "view": true, "while": true, "year": true,
+}
+
+// FirebirdAnalyzer implements TypeAnalyzer for Firebird and InterBase. It
+// infers the canonical types, sizing VARCHAR in bytes up to 32,765 with
+// longer text as BLOB SUB_TYPE TEXT, and declares DECIMAL at Firebird 3's
+// 18 digits.
+type FirebirdAnalyzer struct{}
+
+// GetTypes returns the Firebird data types in order of preference
+func (f *FirebirdAnalyzer) GetTypes() []DataType {
+	types := []DataType{
+		{Name: "boolean"},
+		{Name: "smallint"},
+		{Name: "integer"},
+		{Name: "bigint"},
+		// A DECIMAL without a precision holds 9 digits and no fraction, so
+		// the precision and scale are always written
+		{Name: "numeric", HasPrecisionScale: true, Format: func(spelling string, params TypeParams) string {
+			return fmt.Sprintf("%s(%d,%d)", spelling, firebirdPrecision, min(params.Scale, firebirdPrecision))
+		}},
+		// Firebird keeps 4 fractional digits and takes no precision
+		{Name: "timestamp", MaxPrecision: 4, Format: func(spelling string, _ TypeParams) string { return spelling }},
+		{Name: "date"},
+		{Name: "varchar", HasLength: true, MaxLength: firebirdMaxLength, InferLength: firebirdMaxLength},
+		{Name: "text"},
+	}
+	for i := range types {
+		types[i].Priority = i + 1
+	}
+	return types
+}
+
+// GetTypeCompatibility returns the Firebird type compatibility matrix,
+// which widens as PostgreSQL's does
+func (f *FirebirdAnalyzer) GetTypeCompatibility() map[string][]string {
+	return map[string][]string{
+		"boolean":   {"boolean", "varchar", "text"},
+		"smallint":  {"smallint", "integer", "bigint", "numeric", "varchar", "text"},
+		"integer":   {"integer", "bigint", "numeric", "varchar", "text"},
+		"bigint":    {"bigint", "numeric", "varchar", "text"},
+		"numeric":   {"numeric", "varchar", "text"},
+		"timestamp": {"timestamp", "date", "varchar", "text"},
+		"date":      {"date", "varchar", "text"},
+		"varchar":   {"varchar", "text"},
+		"text":      {"text"},
+	}
+}
+
+// IdentifierLimit returns 31, the bytes of an identifier Firebird 3 and
+// InterBase accept
+func (f *FirebirdAnalyzer) IdentifierLimit() int {
+	return 31
+}
+
+// TypeName returns the Firebird spelling of a canonical type name
+func (f *FirebirdAnalyzer) TypeName(name string) string {
+	if renamed, ok := firebirdTypeNames[name]; ok {
+		return renamed
+	}
+	return name
+}
+
+// ReservedWord reports whether Firebird reserves a lower-case name, which
+// must then be quoted, and so matched in its case, to name a column
+func (f *FirebirdAnalyzer) ReservedWord(name string) bool {
+	return firebirdReservedWords[name]
+}
@@ -0,0 +1,76 @@
+package dbtypes
+
+import "testing"
+
+func TestFirebirdAnalyzer_GetTypes(t *testing.T) {
+	analyzer := &FirebirdAnalyzer{}
+	types := analyzer.GetTypes()
+
+	expectedOrder := []string{"boolean", "smallint", "integer", "bigint", "numeric", "timestamp", "date", "varchar", "text"}
+	if len(types) != len(expectedOrder) {
+		t.Fatalf("Expected %d types, got %d", len(expectedOrder), len(types))
+	}
+	for i, expected := range expectedOrder {
+		if types[i].Name != expected {
+			t.Errorf("Expected type %s at position %d, got %s", expected, i, types[i].Name)
+		}
+		if types[i].Priority != i+1 {
+			t.Errorf("Expected priority %d for %s, got %d", i+1, types[i].Name, types[i].Priority)
+		}
+	}
+	if varchar := types[7]; varchar.MaxLength != 32765 || varchar.InferLength != 32765 {
+		t.Errorf("Expected varchar to hold 32765 bytes, got %+v", varchar)
+	}
+	if _, chars := TypeAnalyzer(analyzer).(LengthCounter); chars {
+		t.Errorf("Expected Firebird to measure varchar in bytes")
+	}
+}
+
+func TestFirebirdAnalyzer_GetTypeCompatibility(t *testing.T) {
+	compatibility := (&FirebirdAnalyzer{}).GetTypeCompatibility()
+	if len(compatibility) != 9 {
+		t.Errorf("Expected 9 type mappings, got %d", len(compatibility))
+	}
+	want := map[string][]string{
+		"boolean":  {"boolean", "varchar", "text"},
+		"integer":  {"integer", "bigint", "numeric", "varchar", "text"},
+		"numeric":  {"numeric", "varchar", "text"},
+		"date":     {"date", "varchar", "text"},
+		"varchar":  {"varchar", "text"},
+		"smallint": {"smallint", "integer", "bigint", "numeric", "varchar", "text"},
+	}
+	for from, expected := range want {
+		got := compatibility[from]
+		if len(got) != len(expected) {
+			t.Errorf("Expected %v compatible types for %s, got %v", expected, from, got)
+			continue
+		}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Errorf("Expected compatible type %s at position %d for %s, got %s", expected[i], i, from, got[i])
+			}
+		}
+	}
+	if err := ValidateCompatibility(&FirebirdAnalyzer{}); err != nil {
+		t.Errorf("ValidateCompatibility() = %v, want nil", err)
+	}
+}
+
+func TestFirebirdAnalyzer_Spelling(t *testing.T) {
+	analyzer := &FirebirdAnalyzer{}
+	params := TypeParams{Length: 20, Scale: 2, FracDigits: 3}
+	expected := []string{"boolean", "smallint", "integer", "bigint", "decimal(18,2)", "timestamp", "date", "varchar(20)", "blob sub_type text"}
+	for i, dataType := range analyzer.GetTypes() {
+		if got := dataType.Spec(analyzer.TypeName(dataType.Name), params); got != expected[i] {
+			t.Errorf("Spec(%s) = %s, want %s", dataType.Name, got, expected[i])
+		}
+	}
+	if got := analyzer.IdentifierLimit(); got != 31 {
+		t.Errorf("IdentifierLimit() = %d, want 31", got)
+	}
+	for word, reserved := range map[string]bool{"date": true, "value": true, "amount": false} {
+		if got := analyzer.ReservedWord(word); got != reserved {
+			t.Errorf("ReservedWord(%q) = %v, want %v", word, got, reserved)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package dbtypes
+
+// GreenplumAnalyzer implements TypeAnalyzer for Greenplum. It infers
+// PostgreSQL's types, which Greenplum shares, and holds how CREATE TABLE
+// spreads the rows across segments.
+type GreenplumAnalyzer struct {
+	PostgreSQLAnalyzer
+	DistributedBy       string // column the rows are distributed by; empty means the primary key or a likely key
+	DistributedRandomly bool   // distribute the rows randomly whatever the columns
+}
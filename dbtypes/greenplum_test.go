@@ -0,0 +1,31 @@
+package dbtypes
+
+import "testing"
+
+func TestGreenplumAnalyzer_GetTypes(t *testing.T) {
+	analyzer, err := ForFlavor("greenplum")
+	if err != nil {
+		t.Fatalf("ForFlavor() error = %v, want nil", err)
+	}
+	greenplum, ok := analyzer.(*GreenplumAnalyzer)
+	if !ok {
+		t.Fatalf("Expected a *GreenplumAnalyzer, got %T", analyzer)
+	}
+	greenplum.Char = true
+	types := greenplum.GetTypes()
+	expected := (&PostgreSQLAnalyzer{Char: true}).GetTypes()
+	if len(types) != len(expected) {
+		t.Fatalf("Expected %d types, got %d", len(expected), len(types))
+	}
+	for i := range expected {
+		if types[i].Name != expected[i].Name || types[i].MaxLength != expected[i].MaxLength {
+			t.Errorf("Expected type %+v at position %d, got %+v", expected[i], i, types[i])
+		}
+	}
+	if err := ValidateCompatibility(greenplum); err != nil {
+		t.Errorf("ValidateCompatibility() = %v, want nil", err)
+	}
+	if limit := greenplum.IdentifierLimit(); limit != 63 {
+		t.Errorf("Expected PostgreSQL's 63-byte identifiers, got %d", limit)
+	}
+}
@@ -0,0 +1,97 @@
+package dbtypes
+
+import (
+	"cmp"
+	"fmt"
+)
+
+// hanaMaxLength is the longest NVARCHAR SAP HANA declares, 5,000
+// characters; longer values are NCLOB
+const hanaMaxLength = 5000
+
+// hanaPrecision is the most digits a SAP HANA DECIMAL holds
+const hanaPrecision = 38
+
+// hanaTypeNames maps canonical type names to their SAP HANA spelling
+var hanaTypeNames = map[string]string{
+	"numeric": "decimal",
+	"varchar": "nvarchar",
+	"text":    "nclob",
+}
+
+// HanaAnalyzer implements TypeAnalyzer for SAP HANA. It infers the
+// canonical types and spells the ones HANA names differently, with integers
+// from 0 to 255 as TINYINT, text up to NVARCHAR's 5,000 characters as
+// NVARCHAR and longer text as NCLOB.
+type HanaAnalyzer struct {
+	TableType string // COLUMN or ROW, the store CREATE TABLE puts the table in; empty means COLUMN
+}
+
+// GetTypes returns the SAP HANA data types in order of preference
+func (h *HanaAnalyzer) GetTypes() []DataType {
+	types := []DataType{
+		{Name: "boolean"},
+		// HANA's TINYINT holds 0 to 255
+		{Name: "tinyint", Unsigned: true},
+		{Name: "smallint"},
+		{Name: "integer"},
+		{Name: "bigint"},
+		// A DECIMAL without a precision is a floating decimal, so the
+		// precision and scale are always written, the most digits when no
+		// precision was declared
+		{Name: "numeric", HasPrecisionScale: true, Format: func(spelling string, params TypeParams) string {
+			precision := min(cmp.Or(params.Precision, hanaPrecision), hanaPrecision)
+			return fmt.Sprintf("%s(%d,%d)", spelling, precision, min(params.Scale, precision))
+		}},
+		// HANA keeps 7 fractional digits and takes no precision
+		{Name: "timestamp", MaxPrecision: 7, Format: func(spelling string, _ TypeParams) string { return spelling }},
+		{Name: "date"},
+		{Name: "varchar", HasLength: true, MaxLength: hanaMaxLength, InferLength: hanaMaxLength},
+		{Name: "text"},
+	}
+	for i := range types {
+		types[i].Priority = i + 1
+	}
+	return types
+}
+
+// GetTypeCompatibility returns the SAP HANA type compatibility matrix,
+// which widens as PostgreSQL's does
+func (h *HanaAnalyzer) GetTypeCompatibility() map[string][]string {
+	return map[string][]string{
+		"boolean":   {"boolean", "varchar", "text"},
+		"tinyint":   {"tinyint", "smallint", "integer", "bigint", "numeric", "varchar", "text"},
+		"smallint":  {"smallint", "integer", "bigint", "numeric", "varchar", "text"},
+		"integer":   {"integer", "bigint", "numeric", "varchar", "text"},
+		"bigint":    {"bigint", "numeric", "varchar", "text"},
+		"numeric":   {"numeric", "varchar", "text"},
+		"timestamp": {"timestamp", "date", "varchar", "text"},
+		"date":      {"date", "varchar", "text"},
+		"varchar":   {"varchar", "text"},
+		"text":      {"text"},
+	}
+}
+
+// LengthSemantics returns "chars", since HANA's NVARCHAR(n) holds n
+// characters
+func (h *HanaAnalyzer) LengthSemantics() string {
+	return "chars"
+}
+
+// IdentifierLimit returns 127, the longest identifier HANA accepts
+func (h *HanaAnalyzer) IdentifierLimit() int {
+	return 127
+}
+
+// LengthThresholds returns the 2 GiB limit of a HANA NCLOB value
+func (h *HanaAnalyzer) LengthThresholds() []LengthThreshold {
+	return []LengthThreshold{{Bytes: 1<<31 - 1, Limit: "a SAP HANA NCLOB value"}}
+}
+
+// TypeName returns the SAP HANA spelling of a canonical type name
+func (h *HanaAnalyzer) TypeName(name string) string {
+	if renamed, ok := hanaTypeNames[name]; ok {
+		return renamed
+	}
+	return name
+}
@@ -0,0 +1,83 @@
+package dbtypes
+
+import "testing"
+
+func TestHanaAnalyzer_GetTypes(t *testing.T) {
+	analyzer := &HanaAnalyzer{}
+	types := analyzer.GetTypes()
+
+	expectedOrder := []string{"boolean", "tinyint", "smallint", "integer", "bigint", "numeric", "timestamp", "date", "varchar", "text"}
+	if len(types) != len(expectedOrder) {
+		t.Fatalf("Expected %d types, got %d", len(expectedOrder), len(types))
+	}
+	for i, expected := range expectedOrder {
+		if types[i].Name != expected {
+			t.Errorf("Expected type %s at position %d, got %s", expected, i, types[i].Name)
+		}
+		if types[i].Priority != i+1 {
+			t.Errorf("Expected priority %d for %s, got %d", i+1, types[i].Name, types[i].Priority)
+		}
+	}
+	if varchar := types[8]; varchar.MaxLength != 5000 || varchar.InferLength != 5000 {
+		t.Errorf("Expected varchar to hold 5000 characters, got %+v", varchar)
+	}
+}
+
+func TestHanaAnalyzer_GetTypeCompatibility(t *testing.T) {
+	compatibility := (&HanaAnalyzer{}).GetTypeCompatibility()
+	if len(compatibility) != 10 {
+		t.Errorf("Expected 10 type mappings, got %d", len(compatibility))
+	}
+	want := map[string][]string{
+		"boolean":   {"boolean", "varchar", "text"},
+		"tinyint":   {"tinyint", "smallint", "integer", "bigint", "numeric", "varchar", "text"},
+		"smallint":  {"smallint", "integer", "bigint", "numeric", "varchar", "text"},
+		"timestamp": {"timestamp", "date", "varchar", "text"},
+		"text":      {"text"},
+	}
+	for from, expected := range want {
+		got := compatibility[from]
+		if len(got) != len(expected) {
+			t.Errorf("Expected %v compatible types for %s, got %v", expected, from, got)
+			continue
+		}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Errorf("Expected compatible type %s at position %d for %s, got %s", expected[i], i, from, got[i])
+			}
+		}
+	}
+	if err := ValidateCompatibility(&HanaAnalyzer{}); err != nil {
+		t.Errorf("ValidateCompatibility() = %v, want nil", err)
+	}
+}
+
+func TestHanaAnalyzer_Spelling(t *testing.T) {
+	analyzer := &HanaAnalyzer{}
+	types := analyzer.GetTypes()
+	params := TypeParams{Length: 20, Precision: 10, Scale: 2, FracDigits: 3}
+	expected := []string{"boolean", "tinyint", "smallint", "integer", "bigint", "decimal(10,2)", "timestamp", "date", "nvarchar(20)", "nclob"}
+	for i, dataType := range types {
+		if got := dataType.Spec(analyzer.TypeName(dataType.Name), params); got != expected[i] {
+			t.Errorf("Spec(%s) = %s, want %s", dataType.Name, got, expected[i])
+		}
+	}
+	for _, tt := range []struct {
+		params TypeParams
+		want   string
+	}{
+		{TypeParams{Scale: 2}, "decimal(38,2)"},
+		{TypeParams{}, "decimal(38,0)"},
+		{TypeParams{Precision: 50, Scale: 40}, "decimal(38,38)"},
+	} {
+		if got := types[5].Spec("decimal", tt.params); got != tt.want {
+			t.Errorf("Spec(numeric, %+v) = %s, want %s", tt.params, got, tt.want)
+		}
+	}
+	if !types[1].Unsigned {
+		t.Errorf("Expected tinyint to hold 0 to 255, got %+v", types[1])
+	}
+	if got := analyzer.IdentifierLimit(); got != 127 {
+		t.Errorf("IdentifierLimit() = %d, want 127", got)
+	}
+}
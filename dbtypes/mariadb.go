@@ -0,0 +1,140 @@
+package dbtypes
+
+import "fmt"
+
+// mariadbMaxLength is the longest VARCHAR MariaDB declares in utf8mb4,
+// 16,383 characters of up to 4 bytes within its 65,535-byte row; longer
+// values are LONGTEXT
+const mariadbMaxLength = 16383
+
+// mariadbPrecision and mariadbScale are the most digits, and digits after
+// the point, a MariaDB DECIMAL holds
+const (
+	mariadbPrecision = 65
+	mariadbScale     = 30
+)
+
+// mariadbTypeNames maps canonical type names to their MariaDB spelling
+var mariadbTypeNames = map[string]string{
+	"integer":   "int",
+	"numeric":   "decimal",
+	"timestamp": "datetime",
+	"text":      "longtext",
+}
+
+// mariadbReservedWords are the MariaDB reserved words beyond the common SQL
+// keywords every flavor quotes, many of them likely column names
+var mariadbReservedWords = map[string]bool{
+	"add": true, "alter": true, "between": true, "by": true, "case": true,
+	"change": true, "condition": true, "current_date": true, "database": true,
+	"databases": true, "delete": true, "describe": true, "div": true, "drop": true,
+	"else": true, "exists": true, "explain": true, "for": true, "foreign": true,
+	"index": true, "inner": true, "insert": true, "interval": true, "into": true,
+	"is": true, "join": true, "key": true, "keys": true, "kill": true, "left": true,
+	"like": true, "lock": true, "long": true, "match": true, "mod": true, "natural": true,
+	"on": true, "option": true, "outer": true, "range": true, "read": true,
+	"references": true, "release": true, "rename": true, "repeat": true, "replace": true,
+	"require": true, "return": true, "right": true, "rows": true, "schema": true,
+	"set": true, "show": true, "signal": true, "spatial": true,
+	"then": true, "trigger": true, "update": true, "usage": true, "use": true,
+	"using": true, "values": true, "when": true, "while": true, "write": true,
+}
+
+// MariaDBAnalyzer implements TypeAnalyzer for MariaDB. It infers the
+// canonical types, spelled as MariaDB names them, 8-bit integers as
+// TINYINT, signed or unsigned, and UUIDs, which MariaDB 10.7 and later
+// store in their own type and earlier versions as CHAR(36). Identifiers are
+// quoted with backticks.
+type MariaDBAnalyzer struct {
+	UUIDAsChar      bool // spell uuid as char(36), for MariaDB before 10.7
+	UnsignedTinyInt bool // infer tinyint unsigned, 0 to 255, rather than -128 to 127
+}
+
+// GetTypes returns the MariaDB data types in order of preference
+func (m *MariaDBAnalyzer) GetTypes() []DataType {
+	types := []DataType{
+		{Name: "boolean"},
+		{Name: "tinyint", Unsigned: m.UnsignedTinyInt},
+		{Name: "smallint"},
+		{Name: "integer"},
+		{Name: "bigint"},
+		// A DECIMAL without a precision holds 10 digits and no fraction, so
+		// the precision and scale are always written, the most digits when
+		// no precision was declared
+		{Name: "numeric", HasPrecisionScale: true, Format: func(spelling string, params TypeParams) string {
+			precision := params.Precision
+			if precision == 0 {
+				precision = mariadbPrecision
+			}
+			return fmt.Sprintf("%s(%d,%d)", spelling, min(precision, mariadbPrecision), min(params.Scale, mariadbScale))
+		}},
+		{Name: "timestamp", MaxPrecision: 6},
+		{Name: "date"},
+		{Name: "uuid"},
+		{Name: "varchar", HasLength: true, MaxLength: mariadbMaxLength, InferLength: mariadbMaxLength},
+		{Name: "text"},
+	}
+	for i := range types {
+		types[i].Priority = i + 1
+	}
+	return types
+}
+
+// GetTypeCompatibility returns the MariaDB type compatibility matrix, which
+// widens as PostgreSQL's does, with UUIDs widening to text
+func (m *MariaDBAnalyzer) GetTypeCompatibility() map[string][]string {
+	return map[string][]string{
+		"boolean":   {"boolean", "varchar", "text"},
+		"tinyint":   {"tinyint", "smallint", "integer", "bigint", "numeric", "varchar", "text"},
+		"smallint":  {"smallint", "integer", "bigint", "numeric", "varchar", "text"},
+		"integer":   {"integer", "bigint", "numeric", "varchar", "text"},
+		"bigint":    {"bigint", "numeric", "varchar", "text"},
+		"numeric":   {"numeric", "varchar", "text"},
+		"timestamp": {"timestamp", "date", "varchar", "text"},
+		"date":      {"date", "varchar", "text"},
+		"uuid":      {"uuid", "varchar", "text"},
+		"varchar":   {"varchar", "text"},
+		"text":      {"text"},
+	}
+}
+
+// LengthSemantics returns "chars", since MariaDB's VARCHAR(n) holds n
+// characters
+func (m *MariaDBAnalyzer) LengthSemantics() string {
+	return "chars"
+}
+
+// IdentifierLimit returns 64, the longest identifier MariaDB accepts
+func (m *MariaDBAnalyzer) IdentifierLimit() int {
+	return 64
+}
+
+// LengthThresholds returns the 4 GiB limit of a MariaDB LONGTEXT value
+func (m *MariaDBAnalyzer) LengthThresholds() []LengthThreshold {
+	return []LengthThreshold{{Bytes: 1<<32 - 1, Limit: "a MariaDB LONGTEXT value"}}
+}
+
+// TypeName returns the MariaDB spelling of a canonical type name
+func (m *MariaDBAnalyzer) TypeName(name string) string {
+	if name == "uuid" && m.UUIDAsChar {
+		return "char(36)"
+	}
+	if name == "tinyint" && m.UnsignedTinyInt {
+		return "tinyint unsigned"
+	}
+	if renamed, ok := mariadbTypeNames[name]; ok {
+		return renamed
+	}
+	return name
+}
+
+// ReservedWord reports whether MariaDB reserves a lower-case name, which
+// must then be quoted to name a column
+func (m *MariaDBAnalyzer) ReservedWord(name string) bool {
+	return mariadbReservedWords[name]
+}
+
+// IdentifierQuote returns the backtick MariaDB quotes identifiers with
+func (m *MariaDBAnalyzer) IdentifierQuote() string {
+	return "`"
+}
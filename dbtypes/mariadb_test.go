@@ -0,0 +1,50 @@
+package dbtypes
+
+import "testing"
+
+func TestMariaDBAnalyzer_GetTypes(t *testing.T) {
+	analyzer := &MariaDBAnalyzer{}
+	types := analyzer.GetTypes()
+
+	expectedOrder := []string{"boolean", "tinyint", "smallint", "integer", "bigint", "numeric", "timestamp", "date", "uuid", "varchar", "text"}
+	if len(types) != len(expectedOrder) {
+		t.Fatalf("Expected %d types, got %d", len(expectedOrder), len(types))
+	}
+	for i, expected := range expectedOrder {
+		if types[i].Name != expected {
+			t.Errorf("Expected type %s at position %d, got %s", expected, i, types[i].Name)
+		}
+		if types[i].Priority != i+1 {
+			t.Errorf("Expected priority %d for %s, got %d", i+1, types[i].Name, types[i].Priority)
+		}
+	}
+	if err := ValidateCompatibility(analyzer); err != nil {
+		t.Errorf("ValidateCompatibility() = %v, want nil", err)
+	}
+}
+
+func TestMariaDBAnalyzer_Spelling(t *testing.T) {
+	params := TypeParams{Length: 20, Scale: 2, FracDigits: 3}
+	tests := []struct {
+		analyzer *MariaDBAnalyzer
+		expected []string
+	}{
+		{&MariaDBAnalyzer{}, []string{"boolean", "tinyint", "smallint", "int", "bigint", "decimal(65,2)", "datetime(3)", "date", "uuid", "varchar(20)", "longtext"}},
+		{&MariaDBAnalyzer{UUIDAsChar: true}, []string{"boolean", "tinyint", "smallint", "int", "bigint", "decimal(65,2)", "datetime(3)", "date", "char(36)", "varchar(20)", "longtext"}},
+		{&MariaDBAnalyzer{UnsignedTinyInt: true}, []string{"boolean", "tinyint unsigned", "smallint", "int", "bigint", "decimal(65,2)", "datetime(3)", "date", "uuid", "varchar(20)", "longtext"}},
+	}
+	for _, tt := range tests {
+		for i, dataType := range tt.analyzer.GetTypes() {
+			if got := dataType.Spec(tt.analyzer.TypeName(dataType.Name), params); got != tt.expected[i] {
+				t.Errorf("Spec(%s) of %+v = %s, want %s", dataType.Name, *tt.analyzer, got, tt.expected[i])
+			}
+		}
+	}
+	numeric := (&MariaDBAnalyzer{}).GetTypes()[5]
+	if got := numeric.Spec("decimal", TypeParams{Precision: 76, Scale: 40}); got != "decimal(65,30)" {
+		t.Errorf("Spec(numeric) beyond MariaDB's digits = %s, want decimal(65,30)", got)
+	}
+	if got := (&MariaDBAnalyzer{}).IdentifierQuote(); got != "`" {
+		t.Errorf("IdentifierQuote() = %s, want a backtick", got)
+	}
+}
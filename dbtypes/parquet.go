@@ -0,0 +1,75 @@
+package dbtypes
+
+// ParquetType describes a Parquet column by its physical type and the
+// logical type annotating it, with legacy converted types translated to
+// their logical equivalent
+type ParquetType struct {
+	Physical  string // BOOLEAN, INT32, INT64, INT96, FLOAT, DOUBLE, BYTE_ARRAY or FIXED_LEN_BYTE_ARRAY
+	Logical   string // STRING, ENUM, JSON, BSON, UUID, DECIMAL, DATE, TIME, TIMESTAMP, INTEGER, or "" when unannotated
+	Unit      string // MILLIS, MICROS or NANOS for TIME and TIMESTAMP
+	Precision int    // total digits of a DECIMAL
+	Scale     int    // digits after the decimal point of a DECIMAL
+	BitWidth  int    // 8, 16, 32 or 64 for INTEGER
+	Signed    bool   // whether an INTEGER is signed
+}
+
+// FracDigits returns the fractional-second digits of a TIMESTAMP's unit
+func (t ParquetType) FracDigits() int {
+	switch {
+	case t.Physical == "INT96":
+		return 9
+	case t.Logical != "TIMESTAMP":
+		return 0
+	case t.Unit == "MILLIS":
+		return 3
+	case t.Unit == "MICROS":
+		return 6
+	}
+	return 9
+}
+
+// parquetTypeNames returns the names of the types able to hold the Parquet
+// type's values, best first
+func parquetTypeNames(t ParquetType) []string {
+	switch t.Logical {
+	case "STRING", "ENUM", "JSON", "UUID", "TIME":
+		return []string{"text"}
+	case "DECIMAL":
+		return []string{"numeric", "text"}
+	case "DATE":
+		return []string{"date", "text"}
+	case "TIMESTAMP":
+		return []string{"timestamp", "text"}
+	case "INTEGER":
+		switch {
+		case t.BitWidth <= 8 || (t.BitWidth == 16 && t.Signed):
+			return []string{"smallint", "integer", "bigint", "numeric", "text"}
+		case t.BitWidth == 16 || (t.BitWidth == 32 && t.Signed):
+			return []string{"integer", "bigint", "numeric", "text"}
+		case t.BitWidth == 32 || t.Signed:
+			return []string{"bigint", "numeric", "text"}
+		}
+		return []string{"numeric", "text"}
+	}
+
+	switch t.Physical {
+	case "BOOLEAN":
+		return []string{"boolean", "text"}
+	case "INT32":
+		return []string{"integer", "bigint", "numeric", "text"}
+	case "INT64":
+		return []string{"bigint", "numeric", "text"}
+	case "INT96":
+		return []string{"timestamp", "text"}
+	case "FLOAT", "DOUBLE":
+		return []string{"numeric", "text"}
+	}
+	// Unannotated byte arrays and BSON hold arbitrary bytes
+	return []string{"bytea", "text"}
+}
+
+// MapParquetType returns the index of the analyzer's type for values of the
+// Parquet type, taking the best type the flavor offers
+func MapParquetType(analyzer TypeAnalyzer, t ParquetType) int {
+	return firstType(analyzer, parquetTypeNames(t))
+}
@@ -0,0 +1,57 @@
+package dbtypes
+
+import "testing"
+
+func TestMapParquetType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		analyzer TypeAnalyzer
+		parquet  ParquetType
+		expected string
+	}{
+		{"boolean", &PostgreSQLAnalyzer{}, ParquetType{Physical: "BOOLEAN"}, "boolean"},
+		{"int32", &PostgreSQLAnalyzer{}, ParquetType{Physical: "INT32"}, "integer"},
+		{"int64", &PostgreSQLAnalyzer{}, ParquetType{Physical: "INT64"}, "bigint"},
+		{"int16", &PostgreSQLAnalyzer{}, ParquetType{Physical: "INT32", Logical: "INTEGER", BitWidth: 16, Signed: true}, "smallint"},
+		{"uint16", &PostgreSQLAnalyzer{}, ParquetType{Physical: "INT32", Logical: "INTEGER", BitWidth: 16}, "integer"},
+		{"uint32", &PostgreSQLAnalyzer{}, ParquetType{Physical: "INT32", Logical: "INTEGER", BitWidth: 32}, "bigint"},
+		{"uint64", &PostgreSQLAnalyzer{}, ParquetType{Physical: "INT64", Logical: "INTEGER", BitWidth: 64}, "numeric"},
+		{"double", &PostgreSQLAnalyzer{}, ParquetType{Physical: "DOUBLE"}, "numeric"},
+		{"decimal", &PostgreSQLAnalyzer{}, ParquetType{Physical: "FIXED_LEN_BYTE_ARRAY", Logical: "DECIMAL", Precision: 12, Scale: 2}, "numeric"},
+		{"date", &PostgreSQLAnalyzer{}, ParquetType{Physical: "INT32", Logical: "DATE"}, "date"},
+		{"timestamp", &PostgreSQLAnalyzer{}, ParquetType{Physical: "INT64", Logical: "TIMESTAMP", Unit: "MICROS"}, "timestamp"},
+		{"int96", &PostgreSQLAnalyzer{}, ParquetType{Physical: "INT96"}, "timestamp"},
+		{"string", &PostgreSQLAnalyzer{}, ParquetType{Physical: "BYTE_ARRAY", Logical: "STRING"}, "text"},
+		{"binary with bytea", &PostgreSQLAnalyzer{Bytea: true}, ParquetType{Physical: "BYTE_ARRAY"}, "bytea"},
+		{"binary without bytea", &PostgreSQLAnalyzer{}, ParquetType{Physical: "BYTE_ARRAY"}, "text"},
+		{"snowflake", &SnowflakeAnalyzer{}, ParquetType{Physical: "INT64", Logical: "TIMESTAMP", Unit: "NANOS"}, "timestamp"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.analyzer.GetTypes()[MapParquetType(tc.analyzer, tc.parquet)].Name
+			if got != tc.expected {
+				t.Errorf("MapParquetType(%+v) = %s, want %s", tc.parquet, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParquetType_FracDigits(t *testing.T) {
+	testCases := []struct {
+		parquet  ParquetType
+		expected int
+	}{
+		{ParquetType{Physical: "INT64", Logical: "TIMESTAMP", Unit: "MILLIS"}, 3},
+		{ParquetType{Physical: "INT64", Logical: "TIMESTAMP", Unit: "MICROS"}, 6},
+		{ParquetType{Physical: "INT64", Logical: "TIMESTAMP", Unit: "NANOS"}, 9},
+		{ParquetType{Physical: "INT96"}, 9},
+		{ParquetType{Physical: "INT32", Logical: "DATE"}, 0},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.parquet.FracDigits(); got != tc.expected {
+			t.Errorf("FracDigits(%+v) = %d, want %d", tc.parquet, got, tc.expected)
+		}
+	}
+}
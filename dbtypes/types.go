@@ -1,46 +1,327 @@
 package dbtypes
 
+import (
+	"fmt"
+	"strings"
+)
+
 // DataType represents a database data type
 type DataType struct {
-	Name     string
-	Priority int // Lower number means higher priority
+	Name              string
+	Priority          int  // Lower number means higher priority
+	MaxPrecision      int  // Largest fractional-second precision for time types, 0 if not parameterized
+	HasLength         bool // Takes a length, as varchar(n) does
+	MaxLength         int  // Longest length the type takes, 0 if it takes none or is unlimited
+	InferLength       int  // Longest value in bytes inferred as the type, longer ones fall to the next type; 0 if unlimited
+	HasPrecisionScale bool // Takes a precision and scale, as numeric(p,s) does
+	Unsigned          bool // Holds no negative values, as SAP HANA's 0 to 255 tinyint does
+
+	// Format spells a column of the type with its parameters, for types
+	// written other than as name(length), name(precision,scale) or
+	// name(fractional digits); nil uses those
+	Format func(spelling string, params TypeParams) string
 }
 
-// TypeAnalyzer defines the interface for database type analysis
+// TypeParams are the parameters of a column's type as observed in its
+// values, 0 where none were
+type TypeParams struct {
+	Length     int // longest value of a type with a length
+	Precision  int // digits of a type with a precision and scale
+	Scale      int // digits after the point of a type with a precision and scale
+	FracDigits int // fractional-second digits of a time type
+}
+
+// Spec spells a column of the type, given the flavor's spelling of its name
+// and the column's parameters. Precision and fractional digits are left out
+// when not observed, so the database's defaults apply.
+func (t DataType) Spec(spelling string, params TypeParams) string {
+	switch {
+	case t.Format != nil:
+		return t.Format(spelling, params)
+	case t.HasLength:
+		return fmt.Sprintf("%s(%d)", spelling, params.Length)
+	case t.HasPrecisionScale && params.Precision > 0:
+		return fmt.Sprintf("%s(%d,%d)", spelling, params.Precision, params.Scale)
+	case t.MaxPrecision > 0 && params.FracDigits > 0:
+		return fmt.Sprintf("%s(%d)", spelling, params.FracDigits)
+	}
+	return spelling
+}
+
+// postgresMaxLength is the longest varchar or char PostgreSQL declares,
+// 10485760 characters
+const postgresMaxLength = 10 << 20
+
+// postgresVarcharLength is the longest value inferred as a PostgreSQL
+// varchar. Longer values are text, which PostgreSQL stores the same way, so
+// that huge free-text columns are not declared with huge lengths.
+const postgresVarcharLength = 64000
+
+// TypeAnalyzer defines the interface for database type analysis.
+// Implementations must be safe for concurrent use: the analyzers of this
+// package only read their configuration, and GetTypes and
+// GetTypeCompatibility return fresh values on every call, so callers may
+// modify what they get.
 type TypeAnalyzer interface {
 	GetTypes() []DataType
 	GetTypeCompatibility() map[string][]string
 }
 
+// ForFlavor returns the analyzer of a database flavor by its name, in any
+// case
+func ForFlavor(flavor string) (TypeAnalyzer, error) {
+	switch strings.ToLower(flavor) {
+	case "postgresql":
+		return &PostgreSQLAnalyzer{}, nil
+	case "snowflake":
+		return &SnowflakeAnalyzer{}, nil
+	case "hana":
+		return &HanaAnalyzer{}, nil
+	case "firebird":
+		return &FirebirdAnalyzer{}, nil
+	case "vertica":
+		return &VerticaAnalyzer{}, nil
+	case "greenplum":
+		return &GreenplumAnalyzer{}, nil
+	case "mariadb":
+		return &MariaDBAnalyzer{}, nil
+	case "exasol":
+		return &ExasolAnalyzer{}, nil
+	}
+	return nil, fmt.Errorf("unsupported database flavor: %s. Supported flavors: postgresql, snowflake, hana, firebird, vertica, greenplum, mariadb, exasol", flavor)
+}
+
 // PostgreSQLAnalyzer implements TypeAnalyzer for PostgreSQL
-type PostgreSQLAnalyzer struct{}
+type PostgreSQLAnalyzer struct {
+	PostGIS bool // offer the PostGIS geometry type for WKT values
+	Bytea   bool // offer bytea for columns of encoded binary data
+	XML     bool // offer xml for columns of XML documents or fragments
+	Char    bool // offer char(n) for columns of fixed-width codes
+}
 
 // GetTypes returns the PostgreSQL data types in order of preference
 func (p *PostgreSQLAnalyzer) GetTypes() []DataType {
-	return []DataType{
-		{Name: "boolean", Priority: 1},
-		{Name: "smallint", Priority: 2},
-		{Name: "integer", Priority: 3},
-		{Name: "bigint", Priority: 4},
-		{Name: "numeric", Priority: 5},
-		{Name: "timestamp", Priority: 6},
-		{Name: "date", Priority: 7},
-		{Name: "varchar", Priority: 8},
-		{Name: "text", Priority: 9},
+	types := []DataType{
+		{Name: "boolean"},
+		{Name: "smallint"},
+		{Name: "integer"},
+		{Name: "bigint"},
+		{Name: "numeric", HasPrecisionScale: true},
+		{Name: "timestamp", MaxPrecision: 6},
+		{Name: "date"},
+	}
+
+	// Optional types sit just before varchar so their values are claimed
+	// before falling back to strings
+	if p.PostGIS {
+		types = append(types, DataType{Name: "geometry"})
+	}
+	if p.Bytea {
+		types = append(types, DataType{Name: "bytea"})
+	}
+	if p.XML {
+		types = append(types, DataType{Name: "xml"})
 	}
+	if p.Char {
+		types = append(types, DataType{Name: "char", HasLength: true, MaxLength: postgresMaxLength})
+	}
+
+	types = append(types, DataType{Name: "varchar", HasLength: true, MaxLength: postgresMaxLength, InferLength: postgresVarcharLength}, DataType{Name: "text"})
+	for i := range types {
+		types[i].Priority = i + 1
+	}
+	return types
 }
 
-// GetTypeCompatibility returns the PostgreSQL type compatibility matrix
+// GetTypeCompatibility returns the PostgreSQL type compatibility matrix: the
+// types values of each type can be loaded into. Any value can be stored as
+// its text, so every type widens to varchar and text.
 func (p *PostgreSQLAnalyzer) GetTypeCompatibility() map[string][]string {
-	return map[string][]string{
-		"boolean":   {"boolean", "text"},
-		"smallint":  {"smallint", "integer", "bigint", "numeric", "text"},
-		"integer":   {"integer", "bigint", "numeric", "text"},
-		"bigint":    {"bigint", "numeric", "text"},
-		"numeric":   {"numeric", "text"},
-		"timestamp": {"timestamp", "date", "text"},
-		"date":      {"date", "text"},
+	compatibility := map[string][]string{
+		"boolean":   {"boolean", "varchar", "text"},
+		"smallint":  {"smallint", "integer", "bigint", "numeric", "varchar", "text"},
+		"integer":   {"integer", "bigint", "numeric", "varchar", "text"},
+		"bigint":    {"bigint", "numeric", "varchar", "text"},
+		"numeric":   {"numeric", "varchar", "text"},
+		"timestamp": {"timestamp", "date", "varchar", "text"},
+		"date":      {"date", "varchar", "text"},
 		"varchar":   {"varchar", "text"},
 		"text":      {"text"},
 	}
+	if p.PostGIS {
+		compatibility["geometry"] = []string{"geometry", "varchar", "text"}
+	}
+	if p.Bytea {
+		compatibility["bytea"] = []string{"bytea", "varchar", "text"}
+	}
+	if p.XML {
+		compatibility["xml"] = []string{"xml", "varchar", "text"}
+	}
+	if p.Char {
+		compatibility["char"] = []string{"char", "varchar", "text"}
+	}
+	return compatibility
+}
+
+// firstType returns the index of the first of the named types the analyzer
+// offers, or of its last, most general type when it offers none of them
+func firstType(analyzer TypeAnalyzer, names []string) int {
+	types := analyzer.GetTypes()
+	for _, name := range names {
+		for i, dataType := range types {
+			if dataType.Name == name {
+				return i
+			}
+		}
+	}
+	return len(types) - 1
+}
+
+// LengthCounter is implemented by analyzers whose database measures varchar
+// lengths in characters rather than bytes. Analyzers that do not implement
+// it are taken to count bytes.
+type LengthCounter interface {
+	LengthSemantics() string
+}
+
+// LengthSemantics returns "chars", since PostgreSQL's varchar(n) holds n
+// characters whatever their encoded size
+func (p *PostgreSQLAnalyzer) LengthSemantics() string {
+	return "chars"
+}
+
+// IdentifierLimiter is implemented by analyzers whose database cuts long
+// identifiers short. Analyzers that do not implement it keep names whole.
+type IdentifierLimiter interface {
+	IdentifierLimit() int
+}
+
+// IdentifierLimit returns 63, the bytes of an identifier PostgreSQL keeps;
+// it drops the rest without an error
+func (p *PostgreSQLAnalyzer) IdentifierLimit() int {
+	return 63
+}
+
+// LengthThreshold is a value length past which a database fails on a value
+// its column's type still declares, such as one too long for an index entry
+type LengthThreshold struct {
+	Bytes int    // longest value that passes, in bytes
+	Limit string // what the threshold limits, e.g. "a PostgreSQL btree index entry"
+	Index bool   // an index on the column fails past it, rather than loading the value
+}
+
+// LengthThresholder is implemented by analyzers whose database has length
+// thresholds below its types' own limits. Analyzers that do not implement it
+// are taken to have none.
+type LengthThresholder interface {
+	LengthThresholds() []LengthThreshold
+}
+
+// LengthThresholds returns PostgreSQL's btree index entry limit of 2,704
+// bytes, a third of an 8 KiB page less overhead, and its 1 GiB limit on any
+// field value
+func (p *PostgreSQLAnalyzer) LengthThresholds() []LengthThreshold {
+	return []LengthThreshold{
+		{Bytes: 2704, Limit: "a PostgreSQL btree index entry", Index: true},
+		{Bytes: 1 << 30, Limit: "a PostgreSQL field value"},
+	}
+}
+
+// KeywordReserver is implemented by analyzers whose database reserves
+// words beyond the common SQL keywords, such as date or value, which must be
+// quoted to name a column. Analyzers that do not implement it reserve none.
+type KeywordReserver interface {
+	ReservedWord(name string) bool
+}
+
+// IdentifierQuoter is implemented by analyzers whose database quotes
+// identifiers with another character than the standard double quote
+type IdentifierQuoter interface {
+	IdentifierQuote() string
+}
+
+// IdentifierCaser is implemented by analyzers whose database folds unquoted
+// identifiers to a case, "upper" or "lower", in which plain names are then
+// written; analyzers that do not implement it keep names as they are
+type IdentifierCaser interface {
+	IdentifierCase() string
+}
+
+// TypeNamer is implemented by analyzers whose database spells some of the
+// inferred types differently from their canonical names
+type TypeNamer interface {
+	TypeName(name string) string
+}
+
+// SnowflakeAnalyzer implements TypeAnalyzer for Snowflake. It infers the same
+// types as PostgreSQL, which Snowflake mostly accepts as aliases, and renames
+// the ones it spells differently.
+type SnowflakeAnalyzer struct {
+	PostgreSQLAnalyzer
+}
+
+// GetTypes returns the Snowflake data types in order of preference
+func (s *SnowflakeAnalyzer) GetTypes() []DataType {
+	types := s.PostgreSQLAnalyzer.GetTypes()
+	for i := range types {
+		switch {
+		case types[i].Name == "timestamp":
+			types[i].MaxPrecision = 9
+		case types[i].Name == "numeric":
+			// A NUMBER without a precision is NUMBER(38,0), which rounds
+			// away fractions, so the precision and scale are always
+			// written, the most digits when no precision was declared
+			types[i].Format = func(spelling string, params TypeParams) string {
+				precision := params.Precision
+				if precision == 0 {
+					precision = snowflakePrecision
+				}
+				return fmt.Sprintf("%s(%d,%d)", spelling, min(precision, snowflakePrecision), min(params.Scale, snowflakePrecision-1))
+			}
+		case types[i].HasLength:
+			types[i].MaxLength = snowflakeMaxLength
+		}
+		// A varchar of any length is the same type to Snowflake, so values
+		// are varchar up to its limit rather than falling to text, itself
+		// a varchar without a length
+		if types[i].Name == "varchar" {
+			types[i].InferLength = snowflakeMaxLength
+		}
+	}
+	return types
+}
+
+// snowflakeMaxLength is the longest varchar or char Snowflake declares,
+// 16 MiB
+const snowflakeMaxLength = 16 << 20
+
+// snowflakePrecision is the most digits a Snowflake NUMBER holds
+const snowflakePrecision = 38
+
+// snowflakeTypeNames maps canonical type names to their Snowflake spelling
+var snowflakeTypeNames = map[string]string{
+	"numeric":   "number",
+	"timestamp": "timestamp_ntz",
+	"bytea":     "binary",
+	"xml":       "variant",
+	"text":      "varchar",
+}
+
+// IdentifierLimit returns 255, the longest identifier Snowflake accepts
+func (s *SnowflakeAnalyzer) IdentifierLimit() int {
+	return 255
+}
+
+// LengthThresholds returns the 16 MiB limit of a Snowflake VARCHAR value,
+// which every text type is, whatever its declared length
+func (s *SnowflakeAnalyzer) LengthThresholds() []LengthThreshold {
+	return []LengthThreshold{{Bytes: snowflakeMaxLength, Limit: "a Snowflake VARCHAR value"}}
+}
+
+// TypeName returns the Snowflake spelling of a canonical type name
+func (s *SnowflakeAnalyzer) TypeName(name string) string {
+	if renamed, ok := snowflakeTypeNames[name]; ok {
+		return renamed
+	}
+	return name
 }
@@ -1,6 +1,9 @@
 package dbtypes
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+)
 
 func TestPostgreSQLAnalyzer_GetTypes(t *testing.T) {
 	analyzer := &PostgreSQLAnalyzer{}
@@ -18,6 +21,9 @@ func TestPostgreSQLAnalyzer_GetTypes(t *testing.T) {
 		if types[i].Name != expected {
 			t.Errorf("Expected type %s at position %d, got %s", expected, i, types[i].Name)
 		}
+		if types[i].Priority != i+1 {
+			t.Errorf("Expected priority %d for %s, got %d", i+1, types[i].Name, types[i].Priority)
+		}
 	}
 }
 
@@ -36,8 +42,8 @@ func TestPostgreSQLAnalyzer_GetTypeCompatibility(t *testing.T) {
 		fromType   string
 		expectedTo []string
 	}{
-		{"boolean", []string{"boolean", "text"}},
-		{"smallint", []string{"smallint", "integer", "bigint", "numeric", "text"}},
+		{"boolean", []string{"boolean", "varchar", "text"}},
+		{"smallint", []string{"smallint", "integer", "bigint", "numeric", "varchar", "text"}},
 		{"varchar", []string{"varchar", "text"}},
 		{"text", []string{"text"}},
 	}
@@ -61,3 +67,164 @@ func TestPostgreSQLAnalyzer_GetTypeCompatibility(t *testing.T) {
 		}
 	}
 }
+
+func TestPostgreSQLAnalyzer_TimestampPrecision(t *testing.T) {
+	analyzer := &PostgreSQLAnalyzer{}
+	for _, typ := range analyzer.GetTypes() {
+		want := 0
+		if typ.Name == "timestamp" {
+			want = 6
+		}
+		if typ.MaxPrecision != want {
+			t.Errorf("Type %s: got MaxPrecision %d, want %d", typ.Name, typ.MaxPrecision, want)
+		}
+	}
+}
+
+func TestPostgreSQLAnalyzer_PostGIS(t *testing.T) {
+	analyzer := &PostgreSQLAnalyzer{PostGIS: true}
+	types := analyzer.GetTypes()
+
+	expectedOrder := []string{"boolean", "smallint", "integer", "bigint", "numeric", "timestamp", "date", "geometry", "varchar", "text"}
+	if len(types) != len(expectedOrder) {
+		t.Fatalf("Expected %d types, got %d", len(expectedOrder), len(types))
+	}
+	for i, expected := range expectedOrder {
+		if types[i].Name != expected {
+			t.Errorf("Expected type %s at position %d, got %s", expected, i, types[i].Name)
+		}
+		if types[i].Priority != i+1 {
+			t.Errorf("Expected priority %d for %s, got %d", i+1, types[i].Name, types[i].Priority)
+		}
+	}
+
+	compatibility := analyzer.GetTypeCompatibility()
+	if got := compatibility["geometry"]; len(got) != 3 || got[0] != "geometry" || got[1] != "varchar" || got[2] != "text" {
+		t.Errorf("Expected geometry compatibility [geometry varchar text], got %v", got)
+	}
+}
+
+func TestPostgreSQLAnalyzer_OptionalTypes(t *testing.T) {
+	analyzer := &PostgreSQLAnalyzer{PostGIS: true, Bytea: true, XML: true, Char: true}
+	types := analyzer.GetTypes()
+
+	expectedOrder := []string{"boolean", "smallint", "integer", "bigint", "numeric", "timestamp", "date", "geometry", "bytea", "xml", "char", "varchar", "text"}
+	if len(types) != len(expectedOrder) {
+		t.Fatalf("Expected %d types, got %d", len(expectedOrder), len(types))
+	}
+	for i, expected := range expectedOrder {
+		if types[i].Name != expected {
+			t.Errorf("Expected type %s at position %d, got %s", expected, i, types[i].Name)
+		}
+	}
+
+	compatibility := analyzer.GetTypeCompatibility()
+	for _, name := range []string{"geometry", "bytea", "xml"} {
+		if got := compatibility[name]; len(got) != 3 || got[0] != name || got[1] != "varchar" || got[2] != "text" {
+			t.Errorf("Expected %s compatibility [%s varchar text], got %v", name, name, got)
+		}
+	}
+}
+
+func TestSnowflakeAnalyzer(t *testing.T) {
+	analyzer := &SnowflakeAnalyzer{}
+	types := analyzer.GetTypes()
+	if len(types) != 9 {
+		t.Fatalf("Expected 9 types, got %d", len(types))
+	}
+	if types[5].Name != "timestamp" || types[5].MaxPrecision != 9 {
+		t.Errorf("Expected timestamp with max precision 9, got %+v", types[5])
+	}
+
+	names := map[string]string{"numeric": "number", "timestamp": "timestamp_ntz", "text": "varchar", "smallint": "smallint"}
+	for name, expected := range names {
+		if got := analyzer.TypeName(name); got != expected {
+			t.Errorf("TypeName(%s) = %s, want %s", name, got, expected)
+		}
+	}
+
+	// A bare NUMBER holds no fraction, so the scale is always written
+	numeric := types[4]
+	for _, tt := range []struct {
+		params   TypeParams
+		expected string
+	}{
+		{TypeParams{Scale: 1}, "number(38,1)"},
+		{TypeParams{}, "number(38,0)"},
+		{TypeParams{Precision: 10, Scale: 2}, "number(10,2)"},
+		{TypeParams{Precision: 50, Scale: 40}, "number(38,37)"},
+	} {
+		if got := numeric.Spec("number", tt.params); got != tt.expected {
+			t.Errorf("Spec(numeric) with %+v = %s, want %s", tt.params, got, tt.expected)
+		}
+	}
+}
+
+func TestParameterizedTypes(t *testing.T) {
+	tests := []struct {
+		name          string
+		analyzer      TypeAnalyzer
+		lengthTypes   map[string]int
+		precisionType string
+	}{
+		{"postgresql", &PostgreSQLAnalyzer{Char: true}, map[string]int{"varchar": 10485760, "char": 10485760}, "numeric"},
+		{"snowflake", &SnowflakeAnalyzer{PostgreSQLAnalyzer{Char: true}}, map[string]int{"varchar": 16777216, "char": 16777216}, "numeric"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, dataType := range tt.analyzer.GetTypes() {
+				maxLength, hasLength := tt.lengthTypes[dataType.Name]
+				if dataType.HasLength != hasLength || dataType.MaxLength != maxLength {
+					t.Errorf("%s: HasLength %v, MaxLength %d, want %v, %d", dataType.Name, dataType.HasLength, dataType.MaxLength, hasLength, maxLength)
+				}
+				if dataType.HasPrecisionScale != (dataType.Name == tt.precisionType) {
+					t.Errorf("%s: HasPrecisionScale %v", dataType.Name, dataType.HasPrecisionScale)
+				}
+			}
+		})
+	}
+}
+
+func TestDataTypeSpec(t *testing.T) {
+	params := TypeParams{Length: 20, Precision: 10, Scale: 2, FracDigits: 3}
+	tests := []struct {
+		dataType DataType
+		spelling string
+		params   TypeParams
+		expected string
+	}{
+		{DataType{Name: "varchar", HasLength: true}, "varchar", params, "varchar(20)"},
+		{DataType{Name: "numeric", HasPrecisionScale: true}, "number", params, "number(10,2)"},
+		{DataType{Name: "numeric", HasPrecisionScale: true}, "numeric", TypeParams{}, "numeric"},
+		{DataType{Name: "timestamp", MaxPrecision: 6}, "timestamp", params, "timestamp(3)"},
+		{DataType{Name: "timestamp", MaxPrecision: 6}, "timestamp", TypeParams{}, "timestamp"},
+		{DataType{Name: "integer"}, "integer", params, "integer"},
+		{DataType{Name: "text"}, "varchar", params, "varchar"},
+		{
+			DataType{Name: "varchar", HasLength: true, Format: func(spelling string, p TypeParams) string {
+				return fmt.Sprintf("%s(%d char)", spelling, p.Length)
+			}},
+			"varchar2", params, "varchar2(20 char)",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.dataType.Spec(tt.spelling, tt.params); got != tt.expected {
+			t.Errorf("Spec(%s, %+v) = %s, want %s", tt.spelling, tt.params, got, tt.expected)
+		}
+	}
+}
+
+func TestIdentifierLimit(t *testing.T) {
+	tests := []struct {
+		analyzer TypeAnalyzer
+		expected int
+	}{
+		{&PostgreSQLAnalyzer{}, 63},
+		{&SnowflakeAnalyzer{}, 255},
+	}
+	for _, tt := range tests {
+		if got := tt.analyzer.(IdentifierLimiter).IdentifierLimit(); got != tt.expected {
+			t.Errorf("%T.IdentifierLimit() = %d, want %d", tt.analyzer, got, tt.expected)
+		}
+	}
+}
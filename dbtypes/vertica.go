@@ -0,0 +1,85 @@
+package dbtypes
+
+import "fmt"
+
+// verticaMaxLength is the longest VARCHAR Vertica declares, 65,000 octets;
+// longer values are LONG VARCHAR
+const verticaMaxLength = 65000
+
+// verticaLongMaxLength is the longest LONG VARCHAR Vertica declares,
+// 32,000,000 octets
+const verticaLongMaxLength = 32000000
+
+// verticaTypeNames maps canonical type names to their Vertica spelling
+var verticaTypeNames = map[string]string{
+	"bigint": "int",
+	"text":   "long varchar",
+}
+
+// VerticaAnalyzer implements TypeAnalyzer for Vertica. Vertica has a single
+// 64-bit integer type, so every integer is a bigint, spelled INT, and it
+// sizes VARCHAR in octets up to 65,000, with longer text as LONG VARCHAR.
+// Float and timestamptz are offered for overrides; no text value is
+// inferred as either.
+type VerticaAnalyzer struct{}
+
+// GetTypes returns the Vertica data types in order of preference
+func (v *VerticaAnalyzer) GetTypes() []DataType {
+	types := []DataType{
+		{Name: "boolean"},
+		{Name: "bigint"},
+		{Name: "numeric", HasPrecisionScale: true},
+		{Name: "float"},
+		{Name: "timestamp", MaxPrecision: 6},
+		{Name: "timestamptz", MaxPrecision: 6},
+		{Name: "date"},
+		{Name: "varchar", HasLength: true, MaxLength: verticaMaxLength, InferLength: verticaMaxLength},
+		// A LONG VARCHAR without a length holds 1 MB, so the length is
+		// always written, the largest when no value was measured
+		{Name: "text", HasLength: true, MaxLength: verticaLongMaxLength, Format: func(spelling string, params TypeParams) string {
+			if params.Length == 0 {
+				params.Length = verticaLongMaxLength
+			}
+			return fmt.Sprintf("%s(%d)", spelling, params.Length)
+		}},
+	}
+	for i := range types {
+		types[i].Priority = i + 1
+	}
+	return types
+}
+
+// GetTypeCompatibility returns the Vertica type compatibility matrix, with
+// a single integer type widening to numeric
+func (v *VerticaAnalyzer) GetTypeCompatibility() map[string][]string {
+	return map[string][]string{
+		"boolean":     {"boolean", "varchar", "text"},
+		"bigint":      {"bigint", "numeric", "float", "varchar", "text"},
+		"numeric":     {"numeric", "float", "varchar", "text"},
+		"float":       {"float", "varchar", "text"},
+		"timestamp":   {"timestamp", "timestamptz", "date", "varchar", "text"},
+		"timestamptz": {"timestamptz", "varchar", "text"},
+		"date":        {"date", "varchar", "text"},
+		"varchar":     {"varchar", "text"},
+		"text":        {"text"},
+	}
+}
+
+// IdentifierLimit returns 128, the bytes of an identifier Vertica accepts
+func (v *VerticaAnalyzer) IdentifierLimit() int {
+	return 128
+}
+
+// LengthThresholds returns the 32,000,000-octet limit of a Vertica LONG
+// VARCHAR value
+func (v *VerticaAnalyzer) LengthThresholds() []LengthThreshold {
+	return []LengthThreshold{{Bytes: verticaLongMaxLength, Limit: "a Vertica LONG VARCHAR value"}}
+}
+
+// TypeName returns the Vertica spelling of a canonical type name
+func (v *VerticaAnalyzer) TypeName(name string) string {
+	if renamed, ok := verticaTypeNames[name]; ok {
+		return renamed
+	}
+	return name
+}
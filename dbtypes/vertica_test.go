@@ -0,0 +1,76 @@
+package dbtypes
+
+import "testing"
+
+func TestVerticaAnalyzer_GetTypes(t *testing.T) {
+	analyzer := &VerticaAnalyzer{}
+	types := analyzer.GetTypes()
+
+	expectedOrder := []string{"boolean", "bigint", "numeric", "float", "timestamp", "timestamptz", "date", "varchar", "text"}
+	if len(types) != len(expectedOrder) {
+		t.Fatalf("Expected %d types, got %d", len(expectedOrder), len(types))
+	}
+	for i, expected := range expectedOrder {
+		if types[i].Name != expected {
+			t.Errorf("Expected type %s at position %d, got %s", expected, i, types[i].Name)
+		}
+		if types[i].Priority != i+1 {
+			t.Errorf("Expected priority %d for %s, got %d", i+1, types[i].Name, types[i].Priority)
+		}
+	}
+	if varchar := types[7]; varchar.MaxLength != 65000 || varchar.InferLength != 65000 {
+		t.Errorf("Expected varchar to hold 65000 octets, got %+v", varchar)
+	}
+	if _, chars := TypeAnalyzer(analyzer).(LengthCounter); chars {
+		t.Errorf("Expected Vertica to measure varchar in bytes")
+	}
+}
+
+func TestVerticaAnalyzer_GetTypeCompatibility(t *testing.T) {
+	analyzer := &VerticaAnalyzer{}
+	compatibility := analyzer.GetTypeCompatibility()
+	if len(compatibility) != 9 {
+		t.Errorf("Expected 9 type mappings, got %d", len(compatibility))
+	}
+	want := map[string][]string{
+		"boolean":   {"boolean", "varchar", "text"},
+		"bigint":    {"bigint", "numeric", "float", "varchar", "text"},
+		"timestamp": {"timestamp", "timestamptz", "date", "varchar", "text"},
+		"text":      {"text"},
+	}
+	for from, expected := range want {
+		got := compatibility[from]
+		if len(got) != len(expected) {
+			t.Errorf("Expected %v compatible types for %s, got %v", expected, from, got)
+			continue
+		}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Errorf("Expected compatible type %s at position %d for %s, got %s", expected[i], i, from, got[i])
+			}
+		}
+	}
+	if err := ValidateCompatibility(analyzer); err != nil {
+		t.Errorf("ValidateCompatibility() = %v, want nil", err)
+	}
+
+	// Values inferred as integers and decimals still meet at numeric
+	if got, err := CommonType(analyzer, "bigint", "numeric"); err != nil || got != "numeric" {
+		t.Errorf("CommonType(bigint, numeric) = %s, %v, want numeric", got, err)
+	}
+}
+
+func TestVerticaAnalyzer_Spelling(t *testing.T) {
+	analyzer := &VerticaAnalyzer{}
+	params := TypeParams{Length: 20, Precision: 10, Scale: 2, FracDigits: 3}
+	expected := []string{"boolean", "int", "numeric(10,2)", "float", "timestamp(3)", "timestamptz(3)", "date", "varchar(20)", "long varchar(20)"}
+	for i, dataType := range analyzer.GetTypes() {
+		if got := dataType.Spec(analyzer.TypeName(dataType.Name), params); got != expected[i] {
+			t.Errorf("Spec(%s) = %s, want %s", dataType.Name, got, expected[i])
+		}
+	}
+	text := analyzer.GetTypes()[8]
+	if got := text.Spec("long varchar", TypeParams{}); got != "long varchar(32000000)" {
+		t.Errorf("Spec(text) without a length = %s, want long varchar(32000000)", got)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// unescapeSeparator interprets Go escape sequences such as \t or \x1f in a
+// delimiter given on the command line, so control characters need no shell
+// quoting. Strings that are not valid escapes, such as a lone backslash,
+// are taken literally.
+func unescapeSeparator(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	if unquoted, err := strconv.Unquote(`"` + s + `"`); err == nil && unquoted != "" {
+		return unquoted
+	}
+	return s
+}
+
+// parseRecordSeparator returns the character given by -record-sep, literally
+// or as an escape sequence. Go has no \0 escape, but NUL-separated records
+// are common enough, from find -print0 and the like, to take it for \x00.
+func parseRecordSeparator(s string) (string, error) {
+	if s == `\0` {
+		return "\x00", nil
+	}
+	sep := unescapeSeparator(s)
+	if utf8.RuneCountInString(sep) != 1 {
+		return "", fmt.Errorf("-record-sep must be a single character, got %q", s)
+	}
+	return sep, nil
+}
+
+// parseDelimiterRegex checks a -delim-regex pattern, rejecting patterns
+// that can match an empty string, which would split between characters
+func parseDelimiterRegex(pattern string) error {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return fmt.Errorf("invalid -delim-regex: %v", err)
+	}
+	if matchesEmpty(re) {
+		return fmt.Errorf("-delim-regex %q can match an empty string", pattern)
+	}
+	return nil
+}
+
+// matchesEmpty reports whether a parsed regexp can match without consuming
+// input; zero-width assertions such as ^ and \b count as empty matches
+func matchesEmpty(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpNoMatch, syntax.OpLiteral, syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return false
+	case syntax.OpCapture, syntax.OpPlus:
+		return matchesEmpty(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min == 0 || matchesEmpty(re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !matchesEmpty(sub) {
+				return false
+			}
+		}
+		return true
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if matchesEmpty(sub) {
+				return true
+			}
+		}
+		return false
+	}
+	// Empty matches, stars, quests and assertions
+	return true
+}
+
+// candidateDelimiters are the characters commonly used to separate fields,
+// counted in the first line when the file does not split into columns
+var candidateDelimiters = []rune{',', ';', '\t', '|', ':'}
+
+// delimiterHint asks whether the delimiter is right after a file split into
+// too few columns, naming the candidate delimiter that occurs most often in
+// its first line
+func delimiterHint(symptom, firstLine, delimiter string, delimiterRegex *regexp.Regexp) string {
+	question := fmt.Sprintf("%s; is the delimiter really %s?", symptom, quoteDelimiter(delimiter))
+	if delimiterRegex != nil {
+		question = fmt.Sprintf("%s; is -delim-regex %q right?", symptom, delimiterRegex.String())
+	}
+
+	var best rune
+	bestCount := 0
+	for _, c := range candidateDelimiters {
+		if string(c) == delimiter {
+			continue
+		}
+		if n := strings.Count(firstLine, string(c)); n > bestCount {
+			best, bestCount = c, n
+		}
+	}
+	if bestCount == 0 {
+		return question
+	}
+	characters := "characters"
+	if bestCount == 1 {
+		characters = "character"
+	}
+	return fmt.Sprintf("%s The first line contains %d %s %s", question, bestCount, strconv.QuoteRune(best), characters)
+}
+
+// quoteDelimiter quotes a delimiter for messages, as a character when it is one
+func quoteDelimiter(delimiter string) string {
+	if runes := []rune(delimiter); len(runes) == 1 {
+		return strconv.QuoteRune(runes[0])
+	}
+	return strconv.Quote(delimiter)
+}
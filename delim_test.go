@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestUnescapeSeparator(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{",", ","},
+		{"~|~", "~|~"},
+		{`\t`, "\t"},
+		{`\x1f`, "\x1f"},
+		{`\u001e`, "\x1e"},
+		{`\`, `\`},
+		{`"`, `"`},
+	}
+
+	for _, tt := range tests {
+		if got := unescapeSeparator(tt.input); got != tt.want {
+			t.Errorf("unescapeSeparator(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseRecordSeparator(t *testing.T) {
+	if got, err := parseRecordSeparator(`\x1e`); err != nil || got != "\x1e" {
+		t.Errorf(`parseRecordSeparator(\x1e) = %q, %v, want "\x1e"`, got, err)
+	}
+	if got, err := parseRecordSeparator(`\0`); err != nil || got != "\x00" {
+		t.Errorf(`parseRecordSeparator(\0) = %q, %v, want "\x00"`, got, err)
+	}
+	if got, err := parseRecordSeparator(";"); err != nil || got != ";" {
+		t.Errorf("parseRecordSeparator(;) = %q, %v, want \";\"", got, err)
+	}
+	if _, err := parseRecordSeparator("\r\n"); err == nil {
+		t.Errorf(`parseRecordSeparator(\r\n) error = nil, want an error`)
+	}
+}
+
+func TestParseDelimiterRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		errText string
+	}{
+		{`\s*\|\s*`, ""},
+		{`[;,]+`, ""},
+		{`(~\|~|\x1f)`, ""},
+		{`,*`, "can match an empty string"},
+		{`\s?`, "can match an empty string"},
+		{`\b`, "can match an empty string"},
+		{`a|`, "can match an empty string"},
+		{`(,){0,2}`, "can match an empty string"},
+		{`[`, "invalid -delim-regex"},
+	}
+
+	for _, tt := range tests {
+		err := parseDelimiterRegex(tt.pattern)
+		if tt.errText == "" && err != nil {
+			t.Errorf("parseDelimiterRegex(%q) error = %v, want nil", tt.pattern, err)
+		}
+		if tt.errText != "" && (err == nil || !strings.Contains(err.Error(), tt.errText)) {
+			t.Errorf("parseDelimiterRegex(%q) error = %v, want containing %q", tt.pattern, err, tt.errText)
+		}
+	}
+}
+
+func TestAnalyzeLegacySeparators(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  analysisOptions
+	}{
+		{"multi-character delimiter", "id~|~name~|~joined\n1~|~Alice~|~2024-01-15\n2~|~Bob~|~2024-02-01\n",
+			analysisOptions{Options: analyze.Options{Delimiter: "~|~", Quotes: "none"}}},
+		{"unit and record separators", "id\x1fname\x1fjoined\x1e1\x1fAlice\x1f2024-01-15\x1e2\x1fBob\x1f2024-02-01\x1e",
+			analysisOptions{Options: analyze.Options{Delimiter: "\x1f", Quotes: "none", RecordSeparator: "\x1e"}}},
+		{"NUL-separated records", "id,name,joined\x001,Alice,2024-01-15\x002,Bob,2024-02-01\x00",
+			analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none", RecordSeparator: "\x00"}}},
+		{"regex delimiter", "id | name|joined\n1 |Alice  |  2024-01-15\n2|Bob|2024-02-01\n",
+			analysisOptions{Options: analyze.Options{DelimiterRegex: regexp.MustCompile(`\s*\|\s*`), Quotes: "none"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &dbtypes.PostgreSQLAnalyzer{}
+			result, err := analyzeFileTypes(strings.NewReader(tt.input), tt.opts, analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			var got []string
+			for _, col := range result.Columns {
+				got = append(got, col.Name+" "+columnTypeName(col, analyzer))
+			}
+			want := []string{"id smallint", "name varchar(5)", "joined date"}
+			if !reflect.DeepEqual(got, want) || result.RowCount != 2 {
+				t.Errorf("columns = %q with %d rows, want %q with 2 rows", got, result.RowCount, want)
+			}
+		})
+	}
+}
+
+func TestWrongDelimiterWarning(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  analysisOptions
+		want  []string
+	}{
+		{
+			name:  "one text column",
+			input: "id;name;amount\n1;ada;2\n",
+			opts:  analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}},
+			want:  []string{"only 1 column detected; is the delimiter really ','? The first line contains 2 ';' characters"},
+		},
+		{
+			name:  "tab separated",
+			input: "id\tname\n1\tada\n",
+			opts:  analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}},
+			want:  []string{`only 1 column detected; is the delimiter really '|'? The first line contains 1 '\t' character`},
+		},
+		{
+			name:  "no candidate delimiter",
+			input: "name\nada\n",
+			opts:  analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}},
+			want:  []string{"only 1 column detected; is the delimiter really ','?"},
+		},
+		{
+			name:  "a regexp",
+			input: "id;name\n1;ada\n",
+			opts:  analysisOptions{Options: analyze.Options{DelimiterRegex: regexp.MustCompile(`\s+`), Quotes: "none"}},
+			want:  []string{`only 1 column detected; is -delim-regex "\\s+" right? The first line contains 1 ';' character`},
+		},
+		{
+			name:  "one integer column",
+			input: "id\n1\n2\n",
+			opts:  analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}},
+		},
+		{
+			name:  "most rows skipped with one field",
+			input: "id,name\n" + strings.Repeat("1;ada\n", 10) + "2,grace\n",
+			opts:  analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none", SkipBadRows: true}},
+			want: []string{
+				"skipped 10 rows without 2 fields; rows by field count: 1 field: 10 rows, lines 2 to 11; 2 fields: 1 row",
+				"90% of rows have 1 field; is the delimiter really ','?",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeFileTypes(strings.NewReader(tt.input), tt.opts, &dbtypes.PostgreSQLAnalyzer{})
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			if !reflect.DeepEqual(result.Warnings, tt.want) {
+				t.Errorf("warnings = %q, want %q", result.Warnings, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordLimits(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	tests := []struct {
+		name    string
+		input   string
+		opts    analysisOptions
+		rows    int
+		errText string
+		warning string
+	}{
+		{
+			name:    "record too long",
+			input:   "id,note\n1,a\n2," + long + "\n3,b\n",
+			opts:    analysisOptions{Options: analyze.Options{MaxRecordBytes: 50}},
+			errText: "line 3 is 102 bytes long, over the -max-record-bytes limit of 50",
+		},
+		{
+			name:    "field too long",
+			input:   "id,note\n1,a\n2," + long + "\n",
+			opts:    analysisOptions{Options: analyze.Options{MaxRecordBytes: 500, MaxFieldBytes: 20}},
+			errText: "line 3 has a field 2 of 100 bytes, over the -max-field-bytes limit of 20",
+		},
+		{
+			name:    "skipped, including a long last record without a newline",
+			input:   "id,note\n1,a\n2," + long + "\n3,b\n4," + long,
+			opts:    analysisOptions{Options: analyze.Options{MaxRecordBytes: 10, SkipBadRows: true}},
+			rows:    2,
+			warning: "skipped 2 rows that could not be read, the first because line 3 is 102 bytes long, over the -max-record-bytes limit of 10",
+		},
+		{
+			name:    "record separator",
+			input:   "id,note;1,a;2," + long + ";3,b;",
+			opts:    analysisOptions{Options: analyze.Options{RecordSeparator: ";", MaxRecordBytes: 10, SkipBadRows: true}},
+			rows:    2,
+			warning: "skipped 1 rows that could not be read, the first because record 3 is 102 bytes long, over the -max-record-bytes limit of 10",
+		},
+		{
+			name:    "field too long in a record",
+			input:   "id,note;1,a;2," + long + ";",
+			opts:    analysisOptions{Options: analyze.Options{RecordSeparator: ";", MaxFieldBytes: 20}},
+			errText: "record 3 has a field 2 of 100 bytes, over the -max-field-bytes limit of 20",
+		},
+		{
+			name:  "within the limits",
+			input: "id,note\n1," + long + "\n",
+			opts:  analysisOptions{Options: analyze.Options{MaxRecordBytes: 102, MaxFieldBytes: 100}},
+			rows:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Delimiter, tt.opts.Quotes = ",", "none"
+			result, err := analyzeFileTypes(strings.NewReader(tt.input), tt.opts, &dbtypes.PostgreSQLAnalyzer{})
+			if tt.errText != "" {
+				var rowErr *analyze.RowError
+				if !errors.As(err, &rowErr) || err.Error() != tt.errText {
+					t.Errorf("analyzeFileTypes() error = %v, want RowError %q", err, tt.errText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			if result.RowCount != tt.rows {
+				t.Errorf("rows = %d, want %d", result.RowCount, tt.rows)
+			}
+			if warning := strings.Join(result.Warnings, "\n"); warning != tt.warning {
+				t.Errorf("warnings = %q, want %q", warning, tt.warning)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"file2ddl/dbtypes"
+)
+
+// detectEpochColumns reclassifies integer columns as timestamp when every
+// value falls within the epoch range for the given years, either as seconds
+// or as milliseconds. A single value cannot distinguish an id from an epoch,
+// so this runs over the whole column after the file has been scanned.
+func detectEpochColumns(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, minYear, maxYear int) {
+	timestampType := typeIndex(analyzer, "timestamp")
+	if timestampType < 0 {
+		return
+	}
+
+	minSeconds := time.Date(minYear, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	maxSeconds := time.Date(maxYear+1, 1, 1, 0, 0, 0, 0, time.UTC).Unix() - 1
+
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		typeName := analyzer.GetTypes()[col.TypeIndex].Name
+		if typeName != "integer" && typeName != "bigint" {
+			continue
+		}
+		// Nulls say nothing about the unit, so only the values are checked
+		if col.IntCount == 0 || col.IntCount != result.RowCount-col.EmptyCount {
+			continue
+		}
+
+		switch {
+		case col.IntMin >= minSeconds && col.IntMax <= maxSeconds:
+			col.EpochUnit = "seconds"
+		case col.IntMin >= minSeconds*1000 && col.IntMax <= maxSeconds*1000+999:
+			col.EpochUnit = "milliseconds"
+			col.FracDigits = 3
+		default:
+			continue
+		}
+		col.TypeIndex = timestampType
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: field %s reclassified as timestamp (epoch %s)\n", col.Name, col.EpochUnit)
+		}
+	}
+}
+
+// maxCounterexamples caps the sample values kept per column to explain why a
+// column-level detection did not fire
+const maxCounterexamples = 5
+
+// compactDateThreshold is the fraction of matching values above which a
+// column that narrowly failed compact date detection is reported in verbose
+// mode
+const compactDateThreshold = 0.9
+
+// observeCompactDate records whether the value is a valid YYYYMMDD date or
+// YYYYMM month, keeping a few counterexamples of each
+func (c *columnAnalysis) observeCompactDate(value string) {
+	if _, err := time.Parse("20060102", value); err == nil {
+		c.CompactDays++
+	} else if len(c.DayMisses) < maxCounterexamples {
+		c.DayMisses = append(c.DayMisses, strings.Clone(value))
+	}
+	if _, err := time.Parse("200601", value); err == nil {
+		c.CompactMonths++
+	} else if len(c.MonthMisses) < maxCounterexamples {
+		c.MonthMisses = append(c.MonthMisses, strings.Clone(value))
+	}
+}
+
+// detectCompactDateColumns reclassifies integer columns as date when every
+// value is a valid YYYYMMDD date, or failing that a valid YYYYMM month. Columns
+// where most but not all values match are reported in verbose mode along with
+// the values that prevented detection.
+func detectCompactDateColumns(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) {
+	dateType := typeIndex(analyzer, "date")
+	if dateType < 0 || result.RowCount == 0 {
+		return
+	}
+
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		// Flavors with a single integer type hold the values as bigint
+		typeName := analyzer.GetTypes()[col.TypeIndex].Name
+		if typeName != "integer" && typeName != "bigint" {
+			continue
+		}
+
+		// Nulls are not observed, so only the values are checked
+		values := result.RowCount - col.EmptyCount
+		switch {
+		case values == 0:
+			continue
+		case col.CompactDays == values:
+			col.CompactFormat = "YYYYMMDD"
+		case col.CompactMonths == values:
+			col.CompactFormat = "YYYYMM"
+		default:
+			if verbose {
+				reportNearCompactDate(col, "YYYYMMDD", col.CompactDays, col.DayMisses, values)
+				reportNearCompactDate(col, "YYYYMM", col.CompactMonths, col.MonthMisses, values)
+			}
+			continue
+		}
+		col.TypeIndex = dateType
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: field %s reclassified as date (compact %s)\n", col.Name, col.CompactFormat)
+		}
+	}
+}
+
+// reportNearCompactDate prints the counterexamples for a column where most
+// values matched a compact date format
+func reportNearCompactDate(col *columnAnalysis, format string, matched int, misses []string, rows int) {
+	if matched == 0 || float64(matched)/float64(rows) < compactDateThreshold {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "DEBUG: field %s not detected as %s: %d of %d values matched; counterexamples: %s\n",
+		col.Name, format, matched, rows, strings.Join(misses, ", "))
+}
+
+// geoPoint is a pair of numeric columns that look like the two halves of a
+// point
+type geoPoint struct {
+	Latitude  string
+	Longitude string
+}
+
+var (
+	// latitudeName and longitudeName match column names like "lat",
+	// "pickup_latitude" or "LON", capturing the shared prefix
+	latitudeName  = regexp.MustCompile(`(?i)^(.*?)(lat|latitude)$`)
+	longitudeName = regexp.MustCompile(`(?i)^(.*?)(lon|lng|long|longitude)$`)
+)
+
+// detectGeoColumns handles WKT columns for flavors without a geometry type
+// and pairs up latitude/longitude columns as candidate points
+func detectGeoColumns(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) {
+	// Flavors with a geometry type claim WKT values during inference; the
+	// rest get text so the values still load
+	if typeIndex(analyzer, "geometry") < 0 && result.RowCount > 0 {
+		textType := len(analyzer.GetTypes()) - 1
+		for i := range result.Columns {
+			col := &result.Columns[i]
+			if col.WKTCount == 0 || col.WKTCount != result.RowCount-col.EmptyCount {
+				continue
+			}
+			col.TypeIndex = textType
+			result.warnf("column %s holds WKT geometries but the flavor has no geometry type; reported as %s",
+				col.Name, analyzer.GetTypes()[textType].Name)
+		}
+	}
+
+	for _, lat := range result.Columns {
+		latMatch := latitudeName.FindStringSubmatch(lat.Name)
+		if latMatch == nil || !isCoordinate(lat, result.RowCount, 90) {
+			continue
+		}
+		for _, lon := range result.Columns {
+			lonMatch := longitudeName.FindStringSubmatch(lon.Name)
+			if lonMatch == nil || !strings.EqualFold(latMatch[1], lonMatch[1]) || !isCoordinate(lon, result.RowCount, 180) {
+				continue
+			}
+			result.GeoPoints = append(result.GeoPoints, geoPoint{Latitude: lat.Name, Longitude: lon.Name})
+			break
+		}
+	}
+}
+
+// isCoordinate reports whether every value in the column, of rows rows, is a
+// number within plus or minus limit degrees; nulls are no values
+func isCoordinate(col columnAnalysis, rows int, limit float64) bool {
+	values := rows - col.EmptyCount
+	return values > 0 && col.NumCount == values && col.NumMin >= -limit && col.NumMax <= limit
+}
+
+// observeBinary records whether the value looks like hex or base64 encoded
+// binary data
+func (c *columnAnalysis) observeBinary(value string) {
+	c.TotalLength += len(value)
+	if isHex(value) {
+		c.HexCount++
+	}
+	if isBase64(value) {
+		c.Base64Count++
+	}
+}
+
+// isHex reports whether the value is a non-empty, even-length string of hex
+// digits
+func isHex(value string) bool {
+	if value == "" || len(value)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if strings.IndexByte("0123456789abcdefABCDEF", value[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isBase64 reports whether the value is non-empty, correctly padded standard
+// base64
+func isBase64(value string) bool {
+	if value == "" || len(value)%4 != 0 {
+		return false
+	}
+	_, err := base64.StdEncoding.Strict().DecodeString(value)
+	return err == nil
+}
+
+// detectBinaryColumns reclassifies string columns as bytea when every
+// non-null value is hex, or failing that base64, and the values are long
+// enough on average
+// that short codes such as "CAFE" do not qualify
+func detectBinaryColumns(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, minLength int) {
+	byteaType := typeIndex(analyzer, "bytea")
+	if byteaType < 0 || result.RowCount == 0 {
+		return
+	}
+
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		typeName := analyzer.GetTypes()[col.TypeIndex].Name
+		if typeName != "varchar" && typeName != "text" {
+			continue
+		}
+		// Nulls are not observed, so only the values are measured and checked
+		values := result.RowCount - col.EmptyCount
+		if values == 0 || float64(col.TotalLength)/float64(values) <= float64(minLength) {
+			continue
+		}
+
+		switch {
+		case col.HexCount == values:
+			col.BinaryEncoding = "hex"
+		case col.Base64Count == values:
+			col.BinaryEncoding = "base64"
+		default:
+			continue
+		}
+		col.TypeIndex = byteaType
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: field %s reclassified as bytea (%s encoded)\n", col.Name, col.BinaryEncoding)
+		}
+	}
+}
+
+// isXML reports whether the value is a well-formed XML document or fragment.
+// Only values starting with '<' are considered, and at most maxBytes of each
+// value are checked; a value cut off at the limit passes if its prefix is
+// well-formed so far.
+func isXML(value string, maxBytes int) bool {
+	if !strings.HasPrefix(strings.TrimLeft(value, " \t"), "<") {
+		return false
+	}
+
+	truncated := maxBytes > 0 && len(value) > maxBytes
+	if truncated {
+		value = value[:maxBytes]
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(value))
+	elements := 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return elements > 0
+		}
+		if err != nil {
+			// Hitting the end of a truncated value mid-element is expected
+			return truncated && elements > 0 && decoder.InputOffset() >= int64(len(value))
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			elements++
+		}
+	}
+}
+
+// detectXMLColumns reclassifies columns where every non-null value is XML as
+// xml. Columns that mix XML with other values fall back to text, since a
+// partly XML column is usually free-form markup that outgrows any varchar
+// size.
+func detectXMLColumns(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) {
+	xmlType := typeIndex(analyzer, "xml")
+	if xmlType < 0 || result.RowCount == 0 {
+		return
+	}
+	textType := len(analyzer.GetTypes()) - 1
+
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		switch {
+		case col.XMLCount == 0:
+			continue
+		case col.XMLCount == result.RowCount-col.EmptyCount:
+			col.TypeIndex = xmlType
+		default:
+			col.TypeIndex = textType
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: field %s reclassified as %s (%d of %d values are XML)\n",
+				col.Name, analyzer.GetTypes()[col.TypeIndex].Name, col.XMLCount, result.RowCount)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestDetectEpochColumns(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	input := strings.Join([]string{
+		"id,created,created_ms,small,mixed",
+		"1,1710930600,1710930600123,1,1710930600",
+		"2,1710934200,1710934200456,2,1710930600123",
+		"3,946684800,946684800000,3,42",
+	}, "\n")
+
+	tests := []struct {
+		name     string
+		detect   bool
+		expected map[string]string
+		units    map[string]string
+	}{
+		{
+			name:   "disabled",
+			detect: false,
+			expected: map[string]string{
+				"id": "smallint", "created": "integer", "created_ms": "bigint", "small": "smallint", "mixed": "bigint",
+			},
+		},
+		{
+			name:   "enabled",
+			detect: true,
+			expected: map[string]string{
+				"id": "smallint", "created": "timestamp", "created_ms": "timestamp", "small": "smallint", "mixed": "bigint",
+			},
+			units: map[string]string{"created": "seconds", "created_ms": "milliseconds"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, DetectEpoch: tt.detect, EpochMinYear: 1990, EpochMaxYear: 2035}
+			result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			for _, col := range result.Columns {
+				if got := analyzer.GetTypes()[col.TypeIndex].Name; got != tt.expected[col.Name] {
+					t.Errorf("Column %s: got type %s, want %s", col.Name, got, tt.expected[col.Name])
+				}
+				if col.EpochUnit != tt.units[col.Name] {
+					t.Errorf("Column %s: got epoch unit %q, want %q", col.Name, col.EpochUnit, tt.units[col.Name])
+				}
+			}
+		})
+	}
+}
+
+func TestDetectEpochColumnsWithNulls(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	input := "id,created\n1,1710930600\n2,\n3,1710934200\n"
+
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, DetectEpoch: true, EpochMinYear: 1990, EpochMaxYear: 2035}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	created := result.Columns[1]
+	if got := analyzer.GetTypes()[created.TypeIndex].Name; got != "timestamp" || created.EpochUnit != "seconds" {
+		t.Errorf("created: got type %s, epoch unit %q, want timestamp in seconds", got, created.EpochUnit)
+	}
+}
+
+func TestDetectEpochColumnsOutOfRange(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	input := "created\n1710930600\n2524608000\n" // 2050-01-01
+
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, DetectEpoch: true, EpochMinYear: 1990, EpochMaxYear: 2035}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if got := analyzer.GetTypes()[result.Columns[0].TypeIndex].Name; got != "bigint" {
+		t.Errorf("got type %s, want bigint", got)
+	}
+}
+
+func TestDetectCompactDateColumns(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	input := strings.Join([]string{
+		"order_date,period,bad_day,id",
+		"20240320,202403,20240230,1",
+		"20231231,202312,20240101,2",
+		"19991001,199910,20240102,3",
+	}, "\n")
+
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, DetectCompact: true}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	expected := map[string]struct {
+		typeName string
+		format   string
+	}{
+		"order_date": {"date", "YYYYMMDD"},
+		"period":     {"date", "YYYYMM"},
+		"bad_day":    {"integer", ""},
+		"id":         {"smallint", ""},
+	}
+	for _, col := range result.Columns {
+		want := expected[col.Name]
+		if got := analyzer.GetTypes()[col.TypeIndex].Name; got != want.typeName {
+			t.Errorf("Column %s: got type %s, want %s", col.Name, got, want.typeName)
+		}
+		if col.CompactFormat != want.format {
+			t.Errorf("Column %s: got compact format %q, want %q", col.Name, col.CompactFormat, want.format)
+		}
+	}
+
+	badDay := result.Columns[2]
+	if len(badDay.DayMisses) != 1 || badDay.DayMisses[0] != "20240230" {
+		t.Errorf("bad_day counterexamples = %v, want [20240230]", badDay.DayMisses)
+	}
+
+	// A flavor with a single integer type holds them as bigint
+	vertica := &dbtypes.VerticaAnalyzer{}
+	result, err = analyzeFileTypes(strings.NewReader(input), opts, vertica)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	for i, want := range []string{"date", "date", "bigint", "bigint"} {
+		if got := vertica.GetTypes()[result.Columns[i].TypeIndex].Name; got != want {
+			t.Errorf("vertica column %s: got type %s, want %s", result.Columns[i].Name, got, want)
+		}
+	}
+}
+
+func TestDetectCompactDateColumnsWithNulls(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	input := "order_date,period\n20240320,202403\n,NULL\n20231231,202312\n"
+
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none", NullTokens: splitNullTokens("NULL")}, DetectCompact: true}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	for i, want := range []string{"YYYYMMDD", "YYYYMM"} {
+		col := result.Columns[i]
+		if got := analyzer.GetTypes()[col.TypeIndex].Name; got != "date" || col.CompactFormat != want {
+			t.Errorf("Column %s: got type %s, compact format %q, want date as %s", col.Name, got, col.CompactFormat, want)
+		}
+		if i == 0 && len(col.DayMisses) > 0 {
+			t.Errorf("Column %s: nulls kept as counterexamples %v", col.Name, col.DayMisses)
+		}
+	}
+}
+
+func TestDetectGeoColumns(t *testing.T) {
+	input := strings.Join([]string{
+		"id;shape;pickup_lat;pickup_lon;latitude;longitude;notes",
+		"1;POINT(-71.06 42.36);42.36;-71.06;42.1;-71.2;a",
+		"2;LINESTRING(0 0, 1 1);42.37;-71.05;42.2;-71.3;b",
+	}, "\n")
+
+	t.Run("postgis", func(t *testing.T) {
+		analyzer := &dbtypes.PostgreSQLAnalyzer{PostGIS: true}
+		opts := analysisOptions{Options: analyze.Options{Delimiter: ";", Quotes: "none"}, DetectGeo: true}
+		result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		if got := analyzer.GetTypes()[result.Columns[1].TypeIndex].Name; got != "geometry" {
+			t.Errorf("shape: got type %s, want geometry", got)
+		}
+		if got := analyzer.GetTypes()[result.Columns[6].TypeIndex].Name; got != "varchar" {
+			t.Errorf("notes: got type %s, want varchar", got)
+		}
+
+		want := []geoPoint{{"pickup_lat", "pickup_lon"}, {"latitude", "longitude"}}
+		if len(result.GeoPoints) != len(want) {
+			t.Fatalf("GeoPoints = %v, want %v", result.GeoPoints, want)
+		}
+		for i := range want {
+			if result.GeoPoints[i] != want[i] {
+				t.Errorf("GeoPoints[%d] = %v, want %v", i, result.GeoPoints[i], want[i])
+			}
+		}
+	})
+
+	t.Run("without geometry type", func(t *testing.T) {
+		analyzer := &dbtypes.PostgreSQLAnalyzer{}
+		opts := analysisOptions{Options: analyze.Options{Delimiter: ";", Quotes: "none"}, DetectGeo: true}
+		result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		if got := analyzer.GetTypes()[result.Columns[1].TypeIndex].Name; got != "text" {
+			t.Errorf("shape: got type %s, want text", got)
+		}
+		if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "column shape holds WKT geometries") {
+			t.Errorf("Warnings = %v, want a warning about shape", result.Warnings)
+		}
+	})
+
+	t.Run("with nulls", func(t *testing.T) {
+		analyzer := &dbtypes.PostgreSQLAnalyzer{}
+		opts := analysisOptions{Options: analyze.Options{Delimiter: ";", Quotes: "none"}, DetectGeo: true}
+		withNulls := input + "\n3;;;-71.04;;;c"
+		result, err := analyzeFileTypes(strings.NewReader(withNulls), opts, analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		if got := analyzer.GetTypes()[result.Columns[1].TypeIndex].Name; got != "text" {
+			t.Errorf("shape: got type %s, want text", got)
+		}
+		want := []geoPoint{{"pickup_lat", "pickup_lon"}, {"latitude", "longitude"}}
+		if len(result.GeoPoints) != len(want) {
+			t.Errorf("GeoPoints = %v, want %v", result.GeoPoints, want)
+		}
+	})
+
+	t.Run("out of range coordinates", func(t *testing.T) {
+		analyzer := &dbtypes.PostgreSQLAnalyzer{}
+		opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, DetectGeo: true}
+		result, err := analyzeFileTypes(strings.NewReader("lat,lon\n95,10\n"), opts, analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		if len(result.GeoPoints) != 0 {
+			t.Errorf("GeoPoints = %v, want none", result.GeoPoints)
+		}
+	})
+}
+
+func TestDetectBinaryColumns(t *testing.T) {
+	hexValue := strings.Repeat("deadBEEF", 6)
+	base64Value := "SGVsbG8sIFdvcmxkISBUaGlzIGlzIGEgYmluYXJ5IHBheWxvYWQu"
+	input := strings.Join([]string{
+		"hex_blob,b64_blob,short_code,mixed",
+		hexValue + "," + base64Value + ",CAFE," + base64Value,
+		hexValue + "00," + base64Value + ",BEEF,not base64 at all but long enough to qualify",
+	}, "\n")
+
+	analyzer := &dbtypes.PostgreSQLAnalyzer{Bytea: true}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, DetectBinary: true, BinaryMinLength: 32}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	expected := map[string]struct {
+		typeName string
+		encoding string
+	}{
+		"hex_blob":   {"bytea", "hex"},
+		"b64_blob":   {"bytea", "base64"},
+		"short_code": {"varchar", ""},
+		"mixed":      {"varchar", ""},
+	}
+	for _, col := range result.Columns {
+		want := expected[col.Name]
+		if got := analyzer.GetTypes()[col.TypeIndex].Name; got != want.typeName {
+			t.Errorf("Column %s: got type %s, want %s", col.Name, got, want.typeName)
+		}
+		if col.BinaryEncoding != want.encoding {
+			t.Errorf("Column %s: got encoding %q, want %q", col.Name, col.BinaryEncoding, want.encoding)
+		}
+	}
+}
+
+func TestDetectBinaryColumnsWithNulls(t *testing.T) {
+	// Three values of 40 bytes average over 32 whatever the empty rows
+	hexValue := strings.Repeat("deadBEEF", 5)
+	input := "id,blob\n1," + hexValue + "\n2,\n3," + hexValue + "\n4,\n5," + hexValue + "\n"
+
+	analyzer := &dbtypes.PostgreSQLAnalyzer{Bytea: true}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, DetectBinary: true, BinaryMinLength: 32}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	col := result.Columns[1]
+	if got := analyzer.GetTypes()[col.TypeIndex].Name; got != "bytea" || col.BinaryEncoding != "hex" {
+		t.Errorf("blob: got type %s, encoding %q, want bytea as hex", got, col.BinaryEncoding)
+	}
+}
+
+func TestBinaryEncodings(t *testing.T) {
+	tests := []struct {
+		value  string
+		hex    bool
+		base64 bool
+	}{
+		{"CAFE", true, true},
+		{"cafe0", false, false},
+		{"SGVsbG8=", false, true},
+		{"SGVsbG8", false, false},
+		{"SGV=bG8=", false, false},
+		{"SGVsbG9=", false, false}, // non-zero trailing bits
+		{"", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := isHex(tt.value); got != tt.hex {
+			t.Errorf("isHex(%q) = %v, want %v", tt.value, got, tt.hex)
+		}
+		if got := isBase64(tt.value); got != tt.base64 {
+			t.Errorf("isBase64(%q) = %v, want %v", tt.value, got, tt.base64)
+		}
+	}
+}
+
+func TestIsXML(t *testing.T) {
+	long := "<doc>" + strings.Repeat("<item>value</item>", 100) + "</doc>"
+
+	tests := []struct {
+		name     string
+		value    string
+		maxBytes int
+		expected bool
+	}{
+		{"element", "<a>text</a>", 0, true},
+		{"declaration", `<?xml version="1.0"?><a b="c"/>`, 0, true},
+		{"fragment", "<a/><b>x</b>", 0, true},
+		{"unclosed", "<a><b></a>", 0, false},
+		{"missing close", "<a>text", 0, false},
+		{"not markup", "a < b", 0, false},
+		{"no elements", "<!-- comment -->", 0, false},
+		{"json", `{"a": 1}`, 0, false},
+		{"truncated well-formed prefix", long, 64, true},
+		{"truncated malformed prefix", "<a><b></a>" + long, 64, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isXML(tt.value, tt.maxBytes); got != tt.expected {
+				t.Errorf("isXML(%q) = %v, want %v", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDetectXMLColumns(t *testing.T) {
+	input := strings.Join([]string{
+		"id|payload|mixed|notes",
+		"1|<order id=\"1\"><total>5</total></order>|<a/>|plain",
+		"2|<order id=\"2\"/>|not xml|text",
+		"3||<b/>|more",
+	}, "\n")
+
+	analyzer := &dbtypes.PostgreSQLAnalyzer{XML: true}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}, DetectXML: true, XMLMaxBytes: 1024}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	expected := map[string]string{"id": "smallint", "payload": "xml", "mixed": "text", "notes": "varchar"}
+	for _, col := range result.Columns {
+		if got := analyzer.GetTypes()[col.TypeIndex].Name; got != expected[col.Name] {
+			t.Errorf("Column %s: got type %s, want %s", col.Name, got, expected[col.Name])
+		}
+	}
+}
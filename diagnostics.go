@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// plain disables the color and emphasis of diagnostics, set with -plain.
+// Whatever it is, diagnostics go to stderr, keeping stdout for the output.
+var plain bool
+
+// emphasize reports whether diagnostics written to f may carry color and
+// emphasis escape codes: f is a terminal, and neither -plain nor the
+// NO_COLOR convention turned them off
+func emphasize(f *os.File) bool {
+	return !plain && os.Getenv("NO_COLOR") == "" && isTerminal(f)
+}
+
+// printWarning writes a warning to stderr, its label in bold yellow when
+// emphasized
+func printWarning(warning string) {
+	label := "WARNING:"
+	if emphasize(os.Stderr) {
+		label = "\x1b[1;33m" + label + "\x1b[0m"
+	}
+	fmt.Fprintf(os.Stderr, "%s %s\n", label, warning)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+
+	"file2ddl/dbtypes"
+)
+
+// distributionClause returns Greenplum's clause spreading the table's rows
+// across segments, to follow the rest of CREATE TABLE, and a comment giving
+// the reason, to follow the statement. Greenplum needs the distribution key
+// to be part of the primary key, so a table with one is distributed by it;
+// otherwise the rows go by -distributed-by's column, or by the first column
+// whose values were all present and distinct, which spread evenly, and
+// randomly when there is none or -distributed-randomly asked. Other flavors
+// have no clause.
+func distributionClause(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, primaryKey string) (clause, comment string, err error) {
+	gp, ok := analyzer.(*dbtypes.GreenplumAnalyzer)
+	if !ok {
+		return "", "", nil
+	}
+	column, reason := gp.DistributedBy, "given with -distributed-by"
+	switch {
+	case gp.DistributedRandomly && primaryKey != "":
+		return "", "", fmt.Errorf("-distributed-randomly cannot distribute a table with primary key %s, which Greenplum distributes by its key", primaryKey)
+	case gp.DistributedRandomly:
+		return "\nDISTRIBUTED RANDOMLY", "-- distributed randomly, as given with -distributed-randomly\n", nil
+	case primaryKey != "" && column != "" && column != primaryKey:
+		return "", "", fmt.Errorf("-distributed-by %s must be the primary key %s, which Greenplum requires to hold the distribution key", column, primaryKey)
+	case primaryKey != "":
+		column, reason = primaryKey, "the primary key, which Greenplum requires to hold the distribution key"
+	case column != "":
+		if !hasColumn(result, column) {
+			return "", "", fmt.Errorf("distributed-by column %s not found", column)
+		}
+	default:
+		for _, col := range result.Columns {
+			if likelyKey(col, analyzer.GetTypes()[col.TypeIndex], result.RowCount) {
+				column = col.Name
+				reason = fmt.Sprintf("all %s values are present and distinct, so its rows spread evenly across segments", groupDigits(result.RowCount))
+				break
+			}
+		}
+		if column == "" {
+			return "\nDISTRIBUTED RANDOMLY", "-- distributed randomly: no column's values are all present and distinct to spread its rows evenly by\n", nil
+		}
+	}
+	return fmt.Sprintf("\nDISTRIBUTED BY (%s)", flavorIdentifier(analyzer, column)), fmt.Sprintf("-- distributed by %s: %s\n", column, reason), nil
+}
+
+// hasColumn reports whether the table has the named column, from the file
+// or added to it
+func hasColumn(result *fileAnalysis, name string) bool {
+	for _, col := range result.Columns {
+		if col.Name == name {
+			return true
+		}
+	}
+	for _, col := range result.AddedColumns {
+		if col.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// likelyKey reports whether a column of rowCount rows is likely a key: an
+// integer or short text column whose values were all present and distinct
+func likelyKey(col columnAnalysis, dataType dbtypes.DataType, rowCount int) bool {
+	switch dataType.Name {
+	case "tinyint", "smallint", "integer", "bigint", "char", "varchar":
+	default:
+		return false
+	}
+	if !col.Distinct || rowCount < 2 {
+		return false
+	}
+	return !dataType.HasLength || col.MaxLength <= maxKeyLength
+}
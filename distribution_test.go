@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestDistributionClause(t *testing.T) {
+	input := "note,code,id\nx,A,1\n,B,2\ny,A,3\n"
+	tests := []struct {
+		name       string
+		analyzer   dbtypes.TypeAnalyzer
+		primaryKey string
+		want       string
+		wantErr    string
+	}{
+		{
+			name:     "likely key",
+			analyzer: &dbtypes.GreenplumAnalyzer{},
+			want:     ")\nDISTRIBUTED BY (id);\n-- distributed by id: all 3 values are present and distinct, so its rows spread evenly across segments\n",
+		},
+		{
+			name:       "primary key",
+			analyzer:   &dbtypes.GreenplumAnalyzer{},
+			primaryKey: "code",
+			want:       ")\nDISTRIBUTED BY (code);\n-- distributed by code: the primary key, which Greenplum requires to hold the distribution key\n",
+		},
+		{
+			name:     "distributed by",
+			analyzer: &dbtypes.GreenplumAnalyzer{DistributedBy: "note"},
+			want:     ")\nDISTRIBUTED BY (note);\n-- distributed by note: given with -distributed-by\n",
+		},
+		{
+			name:     "distributed randomly",
+			analyzer: &dbtypes.GreenplumAnalyzer{DistributedRandomly: true},
+			want:     ")\nDISTRIBUTED RANDOMLY;\n-- distributed randomly, as given with -distributed-randomly\n",
+		},
+		{
+			name:     "postgresql",
+			analyzer: &dbtypes.PostgreSQLAnalyzer{},
+			want:     "    id smallint NOT NULL\n);\n",
+		},
+		{
+			name:       "distributed by another column than the primary key",
+			analyzer:   &dbtypes.GreenplumAnalyzer{DistributedBy: "note"},
+			primaryKey: "id",
+			wantErr:    "-distributed-by note must be the primary key id",
+		},
+		{
+			name:       "distributed randomly with a primary key",
+			analyzer:   &dbtypes.GreenplumAnalyzer{DistributedRandomly: true},
+			primaryKey: "id",
+			wantErr:    "-distributed-randomly cannot distribute a table with primary key id",
+		},
+		{
+			name:     "missing column",
+			analyzer: &dbtypes.GreenplumAnalyzer{DistributedBy: "region"},
+			wantErr:  "distributed-by column region not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}, DetectKeys: true}, tt.analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			got, err := createTableSQL(result, tt.analyzer, "orders", tt.primaryKey)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("createTableSQL() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("createTableSQL() error = %v, want nil", err)
+			}
+			if !strings.HasSuffix(got, tt.want) {
+				t.Errorf("createTableSQL() = %q, want it to end with %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistributionClauseWithoutKey(t *testing.T) {
+	input := "code,note\nA,x\nA,y\n"
+	analyzer := &dbtypes.GreenplumAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}, DetectKeys: true}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	// note's values are distinct, but text too long to be a likely key is
+	// not, and neither is a column of a single repeated code
+	result.Columns[1].MaxLength = maxKeyLength + 1
+	clause, comment, err := distributionClause(result, analyzer, "")
+	if err != nil {
+		t.Fatalf("distributionClause() error = %v, want nil", err)
+	}
+	if clause != "\nDISTRIBUTED RANDOMLY" || !strings.HasPrefix(comment, "-- distributed randomly: ") {
+		t.Errorf("distributionClause() = %q, %q, want a random distribution", clause, comment)
+	}
+}
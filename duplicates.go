@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// maxDuplicateExamples caps the duplicate rows reported by line number
+const maxDuplicateExamples = 5
+
+// duplicateReport counts the rows of a file that repeat an earlier row
+type duplicateReport struct {
+	Rows     int             // rows identical to an earlier row
+	Examples []duplicatePair // the first few of them
+}
+
+// duplicatePair is a duplicate row and the earlier row it repeats
+type duplicatePair struct {
+	Line      int `json:"line"`
+	FirstLine int `json:"first_line"`
+}
+
+// duplicateDetector finds repeated rows by keeping a 64-bit hash of every
+// row seen, so memory grows by the hash and a line number per distinct row
+// rather than by the row itself. Two different rows with the same hash
+// would be counted as duplicates, which is vanishingly unlikely below
+// billions of rows. Past the memory budget the hashes are kept in a Bloom
+// filter, and then not at all.
+type duplicateDetector struct {
+	seen   *hashSet
+	report *duplicateReport
+}
+
+func newDuplicateDetector(budget *memoryBudget) *duplicateDetector {
+	return &duplicateDetector{seen: newHashSet(budget), report: &duplicateReport{}}
+}
+
+// hashRecord hashes the fields of a record. Each field is prefixed with its
+// length, so that fields containing the delimiter cannot run together.
+func hashRecord(fields []string) uint64 {
+	h := fnv.New64a()
+	var length [8]byte
+	for _, field := range fields {
+		binary.LittleEndian.PutUint64(length[:], uint64(len(field)))
+		h.Write(length[:])
+		h.Write([]byte(field))
+	}
+	return h.Sum64()
+}
+
+// observe records the row read from line, counting it if an earlier row had
+// the same fields
+func (d *duplicateDetector) observe(fields []string, line int) {
+	first, seen := d.seen.add(hashRecord(fields), line)
+	if !seen {
+		return
+	}
+	d.report.Rows++
+	if first > 0 && len(d.report.Examples) < maxDuplicateExamples {
+		d.report.Examples = append(d.report.Examples, duplicatePair{Line: line, FirstLine: first})
+	}
+}
+
+// budgetWarnings describes how the memory budget limited the count
+func (d *duplicateDetector) budgetWarnings() []string {
+	var warnings []string
+	if line := d.seen.modeLine[approximateHashes]; line > 0 {
+		warnings = append(warnings, budgetWarning(d.seen.budget, "duplicate rows were counted approximately from line %s on, so up to about 1%% of the rows after it may be counted as duplicates wrongly", groupDigits(line)))
+	}
+	if line := d.seen.modeLine[noHashes]; line > 0 {
+		warnings = append(warnings, budgetWarning(d.seen.budget, "duplicate rows were not counted from line %s on", groupDigits(line)))
+	}
+	return warnings
+}
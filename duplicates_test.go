@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestDetectDuplicates(t *testing.T) {
+	// Quoting does not matter once the fields are split
+	input := "id,name\n1,\"a b\"\n2,ab\n1,a b\n\n2,ab\n1,\"a b\"\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}, DetectDuplicates: true}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	want := &duplicateReport{Rows: 3, Examples: []duplicatePair{{4, 2}, {6, 3}, {7, 2}}}
+	if !reflect.DeepEqual(result.Duplicates, want) {
+		t.Errorf("Duplicates = %+v, want %+v", result.Duplicates, want)
+	}
+
+	var buf bytes.Buffer
+	printText(&buf, result, analyzer)
+	if !strings.Contains(buf.String(), "Duplicate rows: 3 (line 4 repeats line 2, line 6 repeats line 3, line 7 repeats line 2)\n") {
+		t.Errorf("printText() = %q, want the duplicate rows", buf.String())
+	}
+
+	// Without the option nothing is counted
+	result, err = analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}}, analyzer)
+	if err != nil || result.Duplicates != nil {
+		t.Errorf("Duplicates = %+v, %v, want nil", result.Duplicates, err)
+	}
+}
+
+func TestDuplicateDetectorExamples(t *testing.T) {
+	// Field boundaries matter
+	d := newDuplicateDetector(&memoryBudget{})
+	d.observe([]string{"ab", "c"}, 1)
+	d.observe([]string{"a", "bc"}, 2)
+	for line := 3; line < 10; line++ {
+		d.observe([]string{"a", "bc"}, line)
+	}
+	if d.report.Rows != 7 || len(d.report.Examples) != maxDuplicateExamples {
+		t.Errorf("report = %+v, want 7 rows and %d examples", d.report, maxDuplicateExamples)
+	}
+	if d.report.Examples[0] != (duplicatePair{Line: 3, FirstLine: 2}) {
+		t.Errorf("first example = %+v, want line 3 repeating line 2", d.report.Examples[0])
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxExampleLength is the number of characters of an example value shown
+// before it is cut off with an ellipsis
+const maxExampleLength = 40
+
+// columnExamples are sample values of a column for a reviewer, captured with
+// -examples. Values are kept cut to maxExampleLength characters.
+type columnExamples struct {
+	First          string      // first non-empty value
+	Shortest       string      // shortest non-empty value, the first of that length
+	Longest        string      // longest value, the first of that length
+	ShortestLength int         // length of the shortest value in bytes
+	LongestLength  int         // length of the longest value in bytes
+	Promotions     []promotion // the value that moved the column to each type it went through
+}
+
+// promotion is a value that moved a column to a wider type
+type promotion struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Line  int    `json:"line"`
+}
+
+// exampleValue cuts a value to maxExampleLength characters, marking the cut
+// with an ellipsis, and copies it out of the line it was read from
+func exampleValue(value string) string {
+	if utf8.RuneCountInString(value) <= maxExampleLength {
+		return strings.Clone(value)
+	}
+	return string([]rune(value)[:maxExampleLength]) + "…"
+}
+
+// observe records a non-empty value as a candidate example
+func (e *columnExamples) observe(value string) {
+	if e.First == "" {
+		e.First = exampleValue(value)
+	}
+	if e.ShortestLength == 0 || len(value) < e.ShortestLength {
+		e.Shortest, e.ShortestLength = exampleValue(value), len(value)
+	}
+	if len(value) > e.LongestLength {
+		e.Longest, e.LongestLength = exampleValue(value), len(value)
+	}
+}
+
+// promoted records the value read from line that moved the column to type
+func (e *columnExamples) promoted(typeName, value string, line int) {
+	e.Promotions = append(e.Promotions, promotion{Type: typeName, Value: exampleValue(value), Line: line})
+}
+
+// lines describes the examples for the text report, quoting values so that
+// empty values show and control characters are escaped
+func (e *columnExamples) lines() []string {
+	var lines []string
+	if e.First != "" {
+		lines = append(lines, fmt.Sprintf("examples: first %s, shortest %s, longest %s",
+			strconv.Quote(e.First), strconv.Quote(e.Shortest), strconv.Quote(e.Longest)))
+	}
+	if len(e.Promotions) > 0 {
+		var steps []string
+		for _, p := range e.Promotions {
+			steps = append(steps, fmt.Sprintf("%s by %s (line %d)", p.Type, strconv.Quote(p.Value), p.Line))
+		}
+		lines = append(lines, "promoted to "+strings.Join(steps, ", "))
+	}
+	return lines
+}
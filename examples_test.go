@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestColumnExamples(t *testing.T) {
+	long := strings.Repeat("é", 45)
+	input := "id|note\n7|\n12|hi\n70000|" + long + "\n3|a\tb\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}, Examples: true}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	cut := strings.Repeat("é", maxExampleLength) + "…"
+	want := []columnExamples{
+		{First: "7", Shortest: "7", Longest: "70000", ShortestLength: 1, LongestLength: 5,
+			Promotions: []promotion{{"smallint", "7", 2}, {"integer", "70000", 4}}},
+		{First: "hi", Shortest: "hi", Longest: cut, ShortestLength: 2, LongestLength: 90,
+			Promotions: []promotion{{"varchar", "hi", 3}}},
+	}
+	for i, col := range result.Columns {
+		if !reflect.DeepEqual(*col.Examples, want[i]) {
+			t.Errorf("column %s examples = %+v, want %+v", col.Name, *col.Examples, want[i])
+		}
+	}
+
+	wantLines := []string{
+		`examples: first "hi", shortest "hi", longest "` + cut + `"`,
+		`promoted to varchar by "hi" (line 3)`,
+	}
+	if got := result.Columns[1].Examples.lines(); !reflect.DeepEqual(got, wantLines) {
+		t.Errorf("lines() = %q, want %q", got, wantLines)
+	}
+
+	// Control characters are escaped in the text report
+	e := &columnExamples{}
+	e.observe("a\tb\x00")
+	if got := e.lines()[0]; got != `examples: first "a\tb\x00", shortest "a\tb\x00", longest "a\tb\x00"` {
+		t.Errorf("lines() = %q", got)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"file2ddl/dbtypes"
+)
+
+// hiveTimestampLayouts are the layouts of the date and timestamp values the
+// text SerDes read; values written otherwise read as nulls
+var hiveTimestampLayouts = map[string]string{
+	"date":      "2006-01-02",
+	"timestamp": "2006-01-02 15:04:05",
+}
+
+// externalColumnType maps a column's inferred type to an Athena (Hive) or,
+// with spectrum set, a Redshift Spectrum type for its text as the file holds
+// it, reporting false when dates and timestamps fell back to strings because
+// the SerDe would not read them: OpenCSVSerde, which csv says reads the
+// file, reads none, and LazySimpleSerDe only ISO values. Detected epochs and compact dates keep
+// their integer type, and encoded binaries, integer literals, percentages
+// and accounting numbers are strings.
+func externalColumnType(col columnAnalysis, analyzer dbtypes.TypeAnalyzer, spectrum, csv bool) (string, bool) {
+	typeName := analyzer.GetTypes()[col.TypeIndex].Name
+	if col.LiteralBase != "" || col.Percentages || col.Accounting {
+		return externalStringType(col, spectrum), true
+	}
+	if col.EpochUnit != "" || col.CompactFormat != "" {
+		typeName = "bigint"
+	}
+	if layout, ok := hiveTimestampLayouts[typeName]; ok && (csv || col.TimeLayout != layout) {
+		return externalStringType(col, spectrum), false
+	}
+	if !spectrum {
+		col.TypeIndex = typeIndex(analyzer, typeName)
+		_, hiveType := sparkType(col, analyzer)
+		return hiveType, true
+	}
+	switch typeName {
+	case "boolean", "smallint", "integer", "bigint", "date", "timestamp":
+		return typeName, true
+	case "tinyint":
+		// Redshift has no 8-bit integer
+		return "smallint", true
+	case "numeric":
+		precision := max(col.NumDigits+col.NumScale, 1)
+		if precision > sparkMaxPrecision {
+			return "double precision", true
+		}
+		return fmt.Sprintf("decimal(%d,%d)", precision, col.NumScale), true
+	}
+	return externalStringType(col, spectrum), true
+}
+
+// externalStringType is STRING for Athena and a varchar as long as the
+// longest value for Redshift Spectrum, which counts bytes
+func externalStringType(col columnAnalysis, spectrum bool) string {
+	if !spectrum {
+		return "STRING"
+	}
+	if col.MaxBytes > 0 && col.MaxBytes <= redshiftMaxLength {
+		return fmt.Sprintf("varchar(%d)", col.MaxBytes)
+	}
+	return fmt.Sprintf("varchar(%d)", redshiftMaxLength)
+}
+
+// redshiftMaxLength is the longest varchar Redshift declares, in bytes
+const redshiftMaxLength = 65535
+
+// externalSQL returns a CREATE EXTERNAL TABLE registering the files under an
+// S3 location with the settings the file was analyzed with, in the dialect
+// of Athena or Redshift Spectrum. Unquoted files are read by
+// LazySimpleSerDe, which takes the null string, and quoted ones by
+// OpenCSVSerde, the text SerDe that reads quotes. Like COPY, the SerDes
+// read a single null string, so the most frequent of the null tokens is
+// used and the warnings name the rest, and the dates and timestamps they
+// cannot read.
+func externalSQL(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table, location, dialect string, opts analysisOptions) (string, []string, error) {
+	if opts.DelimiterRegex != nil {
+		return "", nil, fmt.Errorf("-format external needs -delim, since the SerDes do not split fields by a regular expression")
+	}
+	if opts.RecordSeparator != "" {
+		return "", nil, fmt.Errorf("-format external cannot read a file with -record-sep, since the SerDes only end rows at newlines")
+	}
+	if len(opts.Delimiter) != 1 {
+		return "", nil, fmt.Errorf("-format external needs a single-byte delimiter, since the SerDes do not take %q", opts.Delimiter)
+	}
+	spectrum := dialect == "spectrum"
+	quote := map[string]string{"single": "'", "double": `"`}[opts.Quotes]
+	csv := quote != ""
+
+	var columns, unreadable, warnings []string
+	for _, col := range result.Columns {
+		typeName, ok := externalColumnType(col, analyzer, spectrum, csv)
+		if !ok {
+			unreadable = append(unreadable, col.Name)
+		}
+		name := sparkDDLName(col.Name)
+		if spectrum {
+			name = quoteIdentifier(col.Name)
+		}
+		columns = append(columns, fmt.Sprintf("    %s %s", name, typeName))
+	}
+	if len(unreadable) > 0 {
+		reason := "are not written as yyyy-MM-dd or yyyy-MM-dd HH:mm:ss"
+		if csv {
+			reason = "are read by OpenCSVSerde, which takes them only as numbers of days or milliseconds"
+		}
+		warnings = append(warnings, fmt.Sprintf("dates and timestamps of %s %s, so they are declared as strings",
+			strings.Join(unreadable, ", "), reason))
+	}
+
+	var serde string
+	var serdeProperties, tableProperties []string
+	if csv {
+		serde = "org.apache.hadoop.hive.serde2.OpenCSVSerde"
+		serdeProperties = []string{"'separatorChar' = " + hiveLiteral(opts.Delimiter), "'quoteChar' = " + hiveLiteral(quote)}
+	} else {
+		null, nullWarnings := nullString(result, opts.NullTokens, "LazySimpleSerDe")
+		warnings = append(warnings, nullWarnings...)
+		serde = "org.apache.hadoop.hive.serde2.lazy.LazySimpleSerDe"
+		serdeProperties = []string{"'field.delim' = " + hiveLiteral(opts.Delimiter)}
+		// Spectrum takes the null string as a table property
+		nullFormat := "'serialization.null.format' = " + hiveLiteral(null)
+		if spectrum {
+			tableProperties = append(tableProperties, nullFormat)
+		} else {
+			serdeProperties = append(serdeProperties, nullFormat)
+		}
+	}
+	if !result.NoHeader {
+		tableProperties = append([]string{"'skip.header.line.count' = '1'"}, tableProperties...)
+	}
+
+	var b strings.Builder
+	name := quoteQualified(table)
+	if !spectrum {
+		parts := strings.Split(table, ".")
+		for i, part := range parts {
+			parts[i] = sparkDDLName(part)
+		}
+		name = strings.Join(parts, ".")
+	}
+	fmt.Fprintf(&b, "CREATE EXTERNAL TABLE %s (\n%s\n)\n", name, strings.Join(columns, ",\n"))
+	fmt.Fprintf(&b, "ROW FORMAT SERDE '%s'\nWITH SERDEPROPERTIES (%s)\n", serde, strings.Join(serdeProperties, ", "))
+	fmt.Fprintf(&b, "STORED AS TEXTFILE\nLOCATION %s", hiveLiteral(location))
+	if len(tableProperties) > 0 {
+		keyword := "TBLPROPERTIES"
+		if spectrum {
+			keyword = "TABLE PROPERTIES"
+		}
+		fmt.Fprintf(&b, "\n%s (%s)", keyword, strings.Join(tableProperties, ", "))
+	}
+	b.WriteString(";\n")
+	return b.String(), warnings, nil
+}
+
+// hiveLiteral quotes a string literal with backslash escapes, writing
+// control characters such as the \001 Hive delimiter as octal escapes
+func hiveLiteral(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '\'':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case unicode.IsControl(r) && r < 0x80:
+			fmt.Fprintf(&b, `\%03o`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
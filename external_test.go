@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestExternalSQL(t *testing.T) {
+	input := "id\tname\tday\tamount\tseen\n1\tAnn\t2024-03-01\t1.50\t03/20/2024 10:30\n2\tNULL\t2024-03-02\t\t03/21/2024 10:30\n"
+	tests := []struct {
+		name         string
+		dialect      string
+		opts         analysisOptions
+		want         string
+		wantWarnings []string
+	}{
+		{
+			name:    "athena",
+			dialect: "athena",
+			opts:    analysisOptions{Options: analyze.Options{Delimiter: "\t", NullTokens: []string{"NULL"}}},
+			want: "CREATE EXTERNAL TABLE lake.orders (\n    id SMALLINT,\n    name STRING,\n    day DATE,\n    amount DECIMAL(3,2),\n    seen STRING\n)\n" +
+				"ROW FORMAT SERDE 'org.apache.hadoop.hive.serde2.lazy.LazySimpleSerDe'\n" +
+				"WITH SERDEPROPERTIES ('field.delim' = '\\t', 'serialization.null.format' = '')\n" +
+				"STORED AS TEXTFILE\nLOCATION 's3://bucket/orders/'\nTBLPROPERTIES ('skip.header.line.count' = '1');\n",
+			wantWarnings: []string{
+				"dates and timestamps of seen are not written as yyyy-MM-dd or yyyy-MM-dd HH:mm:ss, so they are declared as strings",
+				`LazySimpleSerDe reads a single null string, "", so 1 "NULL" values will not load as nulls`,
+			},
+		},
+		{
+			name:    "spectrum",
+			dialect: "spectrum",
+			opts:    analysisOptions{Options: analyze.Options{Delimiter: "\t"}, Header: "no"},
+			want: "CREATE EXTERNAL TABLE lake.orders (\n    column_1 varchar(2),\n    column_2 varchar(4),\n    column_3 varchar(10),\n" +
+				"    column_4 varchar(6),\n    column_5 varchar(16)\n)\n" +
+				"ROW FORMAT SERDE 'org.apache.hadoop.hive.serde2.lazy.LazySimpleSerDe'\n" +
+				"WITH SERDEPROPERTIES ('field.delim' = '\\t')\n" +
+				"STORED AS TEXTFILE\nLOCATION 's3://bucket/orders/'\nTABLE PROPERTIES ('serialization.null.format' = '');\n",
+		},
+		{
+			name:    "quoted",
+			dialect: "athena",
+			opts:    analysisOptions{Options: analyze.Options{Delimiter: "\t", Quotes: "single"}},
+			want: "CREATE EXTERNAL TABLE lake.orders (\n    id SMALLINT,\n    name STRING,\n    day STRING,\n    amount DECIMAL(3,2),\n    seen STRING\n)\n" +
+				"ROW FORMAT SERDE 'org.apache.hadoop.hive.serde2.OpenCSVSerde'\n" +
+				"WITH SERDEPROPERTIES ('separatorChar' = '\\t', 'quoteChar' = '\\'')\n" +
+				"STORED AS TEXTFILE\nLOCATION 's3://bucket/orders/'\nTBLPROPERTIES ('skip.header.line.count' = '1');\n",
+			wantWarnings: []string{
+				"dates and timestamps of day, seen are read by OpenCSVSerde, which takes them only as numbers of days or milliseconds, so they are declared as strings",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &dbtypes.PostgreSQLAnalyzer{}
+			result, err := analyzeFileTypes(strings.NewReader(input), tt.opts, analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			got, warnings, err := externalSQL(result, analyzer, "lake.orders", "s3://bucket/orders/", tt.dialect, tt.opts)
+			if err != nil {
+				t.Fatalf("externalSQL() error = %v, want nil", err)
+			}
+			if got != tt.want {
+				t.Errorf("externalSQL() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if strings.Join(warnings, "\n") != strings.Join(tt.wantWarnings, "\n") {
+				t.Errorf("externalSQL() warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestExternalSQLErrors(t *testing.T) {
+	result := &fileAnalysis{}
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	for _, opts := range []analysisOptions{
+		{Options: analyze.Options{Delimiter: "~|~"}},
+		{Options: analyze.Options{Delimiter: ",", RecordSeparator: "\x1e"}},
+	} {
+		if _, _, err := externalSQL(result, analyzer, "t", "s3://b/", "athena", opts); err == nil {
+			t.Errorf("externalSQL() with %+v error = nil, want an error", opts)
+		}
+	}
+}
+
+func TestHiveLiteral(t *testing.T) {
+	tests := map[string]string{
+		",":    "','",
+		"\t":   `'\t'`,
+		"\x01": `'\001'`,
+		`'`:    `'\''`,
+		`\`:    `'\\'`,
+	}
+	for s, want := range tests {
+		if got := hiveLiteral(s); got != want {
+			t.Errorf("hiveLiteral(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"file2ddl/dbtypes"
+)
+
+// flavorResult is the analysis of a file mapped onto one database flavor
+type flavorResult struct {
+	Flavor   string
+	Analyzer dbtypes.TypeAnalyzer
+	Result   *fileAnalysis
+}
+
+// uuidLength is the length of a UUID written as text, in characters and
+// bytes
+const uuidLength = 36
+
+// mapAnalysis maps an analysis made with one analyzer onto another flavor's
+// types. Each column keeps its type if the target flavor has it, and
+// otherwise takes the first type in its compatibility list that the target
+// has, falling back to the target's catch-all type. A type with a length is
+// skipped for columns whose lengths were not measured, except for UUIDs,
+// whose text is always 36 characters. Lengths are measured as the target
+// counts them, in bytes or characters, unless semantics, the
+// -length-semantics given, fixes them for every flavor; lengths sized below
+// the longest value, as by -varchar-percentile, are kept as they are.
+func mapAnalysis(result *fileAnalysis, from, to dbtypes.TypeAnalyzer, semantics string) *fileAnalysis {
+	mapped := *result
+	mapped.Columns = make([]columnAnalysis, len(result.Columns))
+	remeasure := result.LengthSemantics != "" && semantics == "" && lengthSemantics(to) != result.LengthSemantics
+	if remeasure {
+		mapped.LengthSemantics = lengthSemantics(to)
+	}
+	for i, col := range result.Columns {
+		name := from.GetTypes()[col.TypeIndex].Name
+		col.TypeIndex = len(to.GetTypes()) - 1
+		for _, candidate := range dbtypes.CompatibleTypes(from, name) {
+			if index := typeIndex(to, candidate); index >= 0 {
+				if candidate != name && to.GetTypes()[index].HasLength && col.MaxLength == 0 {
+					if name != "uuid" {
+						continue
+					}
+					col.MaxLength, col.MaxBytes, col.MaxChars = uuidLength, uuidLength, uuidLength
+				}
+				col.TypeIndex = index
+				break
+			}
+		}
+		if remeasure && col.MaxLength > 0 {
+			longest, target := col.MaxBytes, col.MaxChars
+			if result.LengthSemantics == "chars" {
+				longest, target = col.MaxChars, col.MaxBytes
+			}
+			if col.MaxLength == longest {
+				col.MaxLength = target
+			}
+		}
+		mapped.Columns[i] = col
+	}
+	return &mapped
+}
+
+// printFlavorsText writes the column report with one type column per flavor
+func printFlavorsText(w io.Writer, flavors []flavorResult) {
+	fmt.Fprintln(w, "Column Analysis:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{"column"}
+	for _, f := range flavors {
+		header = append(header, f.Flavor)
+	}
+	withStats := len(flavors[0].Result.Columns) > 0 && flavors[0].Result.Columns[0].Stats != nil
+	if withStats {
+		header = append(header, "stats")
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for i, col := range flavors[0].Result.Columns {
+		row := []string{col.Name}
+		for _, f := range flavors {
+			row = append(row, columnTypeName(f.Result.Columns[i], f.Analyzer))
+		}
+		if withStats {
+			stats := col.Stats.String()
+			if col.Confidence != nil {
+				stats += "; " + col.Confidence.String()
+			}
+			row = append(row, stats)
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+}
+
+// printFlavorsJSON writes the JSON report of the first flavor with each
+// column's type under every flavor nested in its types object
+func printFlavorsJSON(w io.Writer, flavors []flavorResult) error {
+	report := newJSONReport(flavors[0].Result, flavors[0].Analyzer)
+	for i := range report.Columns {
+		report.Columns[i].Types = make(map[string]string)
+		for _, f := range flavors {
+			report.Columns[i].Types[f.Flavor] = columnTypeName(f.Result.Columns[i], f.Analyzer)
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// flavorsDDL returns a CREATE TABLE statement per flavor, each headed by a
+// comment naming the flavor and followed by the statements comments returns
+// for it, when comments is not nil
+func flavorsDDL(flavors []flavorResult, table, primaryKey string, comments func(*fileAnalysis, dbtypes.TypeAnalyzer) string) (string, error) {
+	var statements []string
+	for _, f := range flavors {
+		createSQL, err := createTableSQL(f.Result, f.Analyzer, table, primaryKey)
+		if err != nil {
+			return "", err
+		}
+		if comments != nil {
+			createSQL += "\n" + comments(f.Result, f.Analyzer)
+		}
+		statements = append(statements, fmt.Sprintf("-- %s\n%s", f.Flavor, createSQL))
+	}
+	return strings.Join(statements, "\n"), nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestMapAnalysis(t *testing.T) {
+	input := "id|shape|total\n1|POINT(1 2)|9.5\n2|POINT(3 4)|10\n"
+	from := &dbtypes.PostgreSQLAnalyzer{PostGIS: true}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}, DetectGeo: true}, from)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	// A flavor without the geometry type falls back along the compatibility list
+	to := &dbtypes.SnowflakeAnalyzer{}
+	mapped := mapAnalysis(result, from, to, "")
+	expected := []string{"smallint", "varchar", "number(38,1)"}
+	for i, want := range expected {
+		if got := columnTypeName(mapped.Columns[i], to); got != want {
+			t.Errorf("column %s: got %s, want %s", mapped.Columns[i].Name, got, want)
+		}
+	}
+	if got := columnTypeName(result.Columns[1], from); got != "geometry" {
+		t.Errorf("mapping changed the original analysis: shape is %s, want geometry", got)
+	}
+}
+
+func TestMapAnalysisLengthSemantics(t *testing.T) {
+	file, err := os.Open("testdata/multibyte.csv")
+	if err != nil {
+		t.Fatalf("Failed to open test file: %v", err)
+	}
+	defer file.Close()
+	pg := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(file, analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, LengthSemantics: "chars"}, pg)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	// Lengths follow the target's counting, in characters or bytes, unless
+	// -length-semantics fixed them
+	tests := []struct {
+		to        dbtypes.TypeAnalyzer
+		semantics string
+		want      string
+	}{
+		{pg, "", "varchar(11)"},
+		{&dbtypes.SnowflakeAnalyzer{}, "", "varchar(11)"},
+		{&dbtypes.VerticaAnalyzer{}, "", "varchar(15)"},
+		{&dbtypes.FirebirdAnalyzer{}, "", "varchar(15)"},
+		{&dbtypes.VerticaAnalyzer{}, "chars", "varchar(11)"},
+	}
+	for _, tt := range tests {
+		mapped := mapAnalysis(result, pg, tt.to, tt.semantics)
+		if got := columnTypeName(mapped.Columns[2], tt.to); got != tt.want {
+			t.Errorf("greeting under %T with -length-semantics %q: got %s, want %s", tt.to, tt.semantics, got, tt.want)
+		}
+	}
+	if got := result.Columns[2].MaxLength; got != 11 {
+		t.Errorf("mapping changed the original analysis: greeting length is %d, want 11", got)
+	}
+}
+
+func TestMapAnalysisUUID(t *testing.T) {
+	input := "ext_id\n550e8400-e29b-41d4-a716-446655440000\n6ba7b810-9dad-11d1-80b4-00c04fd430c8\n"
+	from := &dbtypes.MariaDBAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}}, from)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	// A uuid's length is not measured, but its text is always 36 characters
+	tests := []struct {
+		to   dbtypes.TypeAnalyzer
+		want string
+	}{
+		{&dbtypes.PostgreSQLAnalyzer{}, "varchar(36)"},
+		{&dbtypes.VerticaAnalyzer{}, "varchar(36)"},
+		{&dbtypes.ExasolAnalyzer{}, "varchar(36)"},
+		{&dbtypes.FirebirdAnalyzer{}, "varchar(36)"},
+		{&dbtypes.MariaDBAnalyzer{}, "uuid"},
+	}
+	for _, tt := range tests {
+		mapped := mapAnalysis(result, from, tt.to, "")
+		if got := columnTypeName(mapped.Columns[0], tt.to); got != tt.want {
+			t.Errorf("ext_id under %T: got %s, want %s", tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestVarcharLimitByFlavor(t *testing.T) {
+	input := "id,body\n1," + strings.Repeat("x", 70000) + "\n2,short\n"
+	tests := []struct {
+		analyzer dbtypes.TypeAnalyzer
+		want     string
+	}{
+		{&dbtypes.PostgreSQLAnalyzer{}, "text"},
+		{&dbtypes.SnowflakeAnalyzer{}, "varchar(70000)"},
+		{&dbtypes.HanaAnalyzer{}, "nclob"},
+		{&dbtypes.FirebirdAnalyzer{}, "blob sub_type text"},
+		{&dbtypes.VerticaAnalyzer{}, "long varchar(70000)"},
+		{&dbtypes.MariaDBAnalyzer{}, "longtext"},
+		{&dbtypes.ExasolAnalyzer{}, "varchar(70000)"},
+	}
+	for _, tt := range tests {
+		result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none", MaxRecordBytes: 1 << 20}}, tt.analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		if got := columnTypeName(result.Columns[1], tt.analyzer); got != tt.want {
+			t.Errorf("%T: body is %s, want %s", tt.analyzer, got, tt.want)
+		}
+	}
+}
+
+func TestPrintFlavors(t *testing.T) {
+	input := "id|created\n1|2024-03-20 10:30:00.123\n"
+	pg := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, pg)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	snowflake := &dbtypes.SnowflakeAnalyzer{}
+	flavors := []flavorResult{
+		{Flavor: "postgresql", Analyzer: pg, Result: mapAnalysis(result, pg, pg, "")},
+		{Flavor: "snowflake", Analyzer: snowflake, Result: mapAnalysis(result, pg, snowflake, "")},
+	}
+
+	var buf bytes.Buffer
+	printFlavorsText(&buf, flavors)
+	want := "Column Analysis:\n" +
+		"column   postgresql    snowflake\n" +
+		"id       smallint      smallint\n" +
+		"created  timestamp(3)  timestamp_ntz(3)\n"
+	if buf.String() != want {
+		t.Errorf("printFlavorsText() =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := printFlavorsJSON(&buf, flavors); err != nil {
+		t.Fatalf("printFlavorsJSON() error = %v, want nil", err)
+	}
+	var report jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if got := report.Columns[1].Types; got["postgresql"] != "timestamp(3)" || got["snowflake"] != "timestamp_ntz(3)" {
+		t.Errorf("created types = %v, want postgresql timestamp(3) and snowflake timestamp_ntz(3)", got)
+	}
+}
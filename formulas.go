@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unwrapExcelFormula returns the value inside an Excel string formula such
+// as ="0123", which exports write to keep Excel from dropping leading
+// zeros, undoubling the quotes it holds. It reports false for any other
+// value, including one whose inner quotes are not doubled.
+func unwrapExcelFormula(value string) (string, bool) {
+	if len(value) < 3 || !strings.HasPrefix(value, `="`) || !strings.HasSuffix(value, `"`) {
+		return value, false
+	}
+	inner := value[2 : len(value)-1]
+	if !strings.Contains(inner, `"`) {
+		return inner, true
+	}
+	if strings.Count(strings.ReplaceAll(inner, `""`, ""), `"`) > 0 {
+		return value, false
+	}
+	return strings.ReplaceAll(inner, `""`, `"`), true
+}
+
+// excelFormulaWarnings warns about the columns holding values wrapped as
+// Excel formulas, which are strings with their wrappers unless
+// -unwrap-excel-formulas removed them
+func excelFormulaWarnings(result *fileAnalysis, unwrapped bool) []string {
+	if unwrapped {
+		return nil
+	}
+	var warnings []string
+	for _, col := range result.Columns {
+		if col.ExcelFormulas > 0 {
+			warnings = append(warnings, fmt.Sprintf(`column %s holds %s wrapped as Excel formulas such as ="0123", measured with the wrapper; use -unwrap-excel-formulas to read the values inside`,
+				col.Name, countOf(col.ExcelFormulas, "value")))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestUnwrapExcelFormula(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{`="0123"`, "0123", true},
+		{`=""`, "", true},
+		{`="say ""hi"""`, `say "hi"`, true},
+		{`="a"b"`, `="a"b"`, false},
+		{`=SUM(A1:A3)`, `=SUM(A1:A3)`, false},
+		{`="`, `="`, false},
+		{"0123", "0123", false},
+	}
+	for _, tt := range tests {
+		got, ok := unwrapExcelFormula(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("unwrapExcelFormula(%q) = %q, %v, want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExcelFormulaAnalysis(t *testing.T) {
+	input := "zip,name\n=\"02134\",Ann\n\"=\"\"10001\"\"\",Bob\n"
+	tests := []struct {
+		unwrap      bool
+		wantType    string
+		wantWarning string
+	}{
+		{unwrap: false, wantType: "varchar(8)", wantWarning: `column zip holds 2 values wrapped as Excel formulas such as ="0123", measured with the wrapper; use -unwrap-excel-formulas to read the values inside`},
+		{unwrap: true, wantType: "smallint"},
+	}
+	for _, tt := range tests {
+		analyzer := &dbtypes.PostgreSQLAnalyzer{}
+		opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}, UnwrapExcel: tt.unwrap}
+		result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		if got := columnTypeName(result.Columns[0], analyzer); got != tt.wantType {
+			t.Errorf("unwrap %v: type = %s, want %s", tt.unwrap, got, tt.wantType)
+		}
+		if got := result.Columns[0].ExcelFormulas; got != 2 {
+			t.Errorf("unwrap %v: ExcelFormulas = %d, want 2", tt.unwrap, got)
+		}
+		if got := strings.Join(result.Warnings, "\n"); got != tt.wantWarning {
+			t.Errorf("unwrap %v: warnings = %q, want %q", tt.unwrap, got, tt.wantWarning)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+// goldenCase is a full output compared with a file under testdata/golden
+type goldenCase struct {
+	name   string
+	input  string
+	opts   analysisOptions
+	render func(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) error
+}
+
+var goldenCases = []goldenCase{
+	{
+		name:  "orders_text",
+		input: "testdata/orders.csv",
+		opts:  analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}},
+		render: func(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) error {
+			addColumnStats(result, analyzer)
+			printText(w, result, analyzer)
+			return nil
+		},
+	},
+	{
+		name:  "orders_json",
+		input: "testdata/orders.csv",
+		opts:  analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, Examples: true},
+		render: func(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) error {
+			addColumnStats(result, analyzer)
+			return printJSON(w, result, analyzer)
+		},
+	},
+	{
+		name:  "orders_flavors_json",
+		input: "testdata/orders.csv",
+		opts:  analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}},
+		render: func(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) error {
+			return printFlavorsJSON(w, goldenFlavors(result, analyzer))
+		},
+	},
+	{
+		name:  "orders_dbt",
+		input: "testdata/orders.csv",
+		opts:  analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}},
+		render: func(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) error {
+			addColumnStats(result, analyzer)
+			printDBT(w, result, analyzer, "raw", "orders")
+			return nil
+		},
+	},
+	{
+		name:  "orders_ddl",
+		input: "testdata/orders.csv",
+		opts:  analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, DetectKeys: true, TrackValues: true},
+		render: func(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) error {
+			flavors := goldenFlavors(result, analyzer)
+			sql, err := flavorsDDL(flavors, "orders", "id", nil)
+			if err != nil {
+				return err
+			}
+			sql += "\n" + commentSQL(result, analyzer, "orders", "orders.csv", time.Time{})
+			for _, f := range flavors {
+				sql += "\n-- " + f.Flavor + "\n" + checkSQL(f.Result, f.Analyzer, "orders", 0.5)
+			}
+			for _, f := range flavors {
+				sql += "\n-- " + f.Flavor + "\n" + indexSQL(f.Result, f.Analyzer, "orders", "id", 0)
+			}
+			_, err = io.WriteString(w, sql)
+			return err
+		},
+	},
+	{
+		name:  "quoted_text",
+		input: "testdata/quoted_sample.csv",
+		opts:  analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}},
+		render: func(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) error {
+			addColumnStats(result, analyzer)
+			printText(w, result, analyzer)
+			return nil
+		},
+	},
+}
+
+// goldenFlavors maps an analysis onto PostgreSQL and Snowflake, as a
+// comma-separated -flavor does
+func goldenFlavors(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) []flavorResult {
+	snowflake := &dbtypes.SnowflakeAnalyzer{}
+	return []flavorResult{
+		{Flavor: "postgresql", Analyzer: analyzer, Result: result},
+		{Flavor: "snowflake", Analyzer: snowflake, Result: mapAnalysis(result, analyzer, snowflake, "")},
+	}
+}
+
+// TestGolden compares full outputs with testdata/golden, running each twice
+// so that map iteration order leaking into them shows as a difference. Set
+// UPDATE_GOLDEN=1 to rewrite the files from the current outputs.
+func TestGolden(t *testing.T) {
+	update := os.Getenv("UPDATE_GOLDEN") != ""
+	for _, tt := range goldenCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var outputs [2]string
+			for i := range outputs {
+				outputs[i] = renderGolden(t, tt)
+			}
+			if outputs[0] != outputs[1] {
+				t.Fatalf("output differs between runs:\n%s\nthen\n%s", outputs[0], outputs[1])
+			}
+
+			path := filepath.Join("testdata", "golden", tt.name+".golden")
+			if update {
+				if err := os.WriteFile(path, []byte(outputs[0]), 0o644); err != nil {
+					t.Fatalf("Failed to write golden file: %v", err)
+				}
+				return
+			}
+			golden, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read golden file: %v", err)
+			}
+			if outputs[0] != string(golden) {
+				t.Errorf("output =\n%s\nwant\n%s", outputs[0], golden)
+			}
+		})
+	}
+}
+
+func renderGolden(t *testing.T, tt goldenCase) string {
+	t.Helper()
+	file, err := os.Open(tt.input)
+	if err != nil {
+		t.Fatalf("Failed to open test file: %v", err)
+	}
+	defer file.Close()
+
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(file, tt.opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	var buf bytes.Buffer
+	if err := tt.render(&buf, result, analyzer); err != nil {
+		t.Fatalf("render error = %v, want nil", err)
+	}
+	return buf.String()
+}
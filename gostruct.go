@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"file2ddl/dbtypes"
+)
+
+// goInitialisms are name parts written in upper case in Go identifiers
+var goInitialisms = map[string]bool{
+	"ID": true, "URL": true, "URI": true, "UUID": true, "IP": true, "API": true,
+	"HTTP": true, "JSON": true, "XML": true, "SQL": true, "UTC": true,
+}
+
+// goFieldName converts a column header to an exported CamelCase identifier,
+// e.g. "customer_id" becomes "CustomerID"
+func goFieldName(header string) string {
+	parts := strings.FieldsFunc(header, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, part := range parts {
+		if upper := strings.ToUpper(part); goInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(part)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	name := b.String()
+	if name == "" {
+		return "Column"
+	}
+	// Identifiers cannot start with a digit
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "Col" + name
+	}
+	return name
+}
+
+// goType maps a column's inferred type to a Go type. Columns with empty
+// values become pointers so that a missing value is distinguishable from
+// the zero value.
+func goType(col columnAnalysis, analyzer dbtypes.TypeAnalyzer, rows int) string {
+	var typeName string
+	switch analyzer.GetTypes()[col.TypeIndex].Name {
+	case "boolean":
+		typeName = "bool"
+	case "tinyint":
+		typeName = "int8"
+		if analyzer.GetTypes()[col.TypeIndex].Unsigned {
+			typeName = "uint8"
+		}
+	case "smallint":
+		typeName = "int16"
+	case "integer":
+		typeName = "int32"
+	case "bigint":
+		typeName = "int64"
+	case "numeric":
+		typeName = "float64"
+	case "timestamp", "date":
+		typeName = "time.Time"
+	default:
+		typeName = "string"
+	}
+	if !col.notNull(rows) {
+		return "*" + typeName
+	}
+	return typeName
+}
+
+// printGoStruct writes a Go struct type mirroring the file's columns, with
+// csv and db tags carrying the original column names
+func printGoStruct(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table string) error {
+	var fields bytes.Buffer
+	usesTime := false
+	seen := make(map[string]int)
+	for _, col := range result.Columns {
+		name := goFieldName(col.Name)
+		// Headers like "a-b" and "a_b" map to the same name, so number the
+		// repeats
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name += strconv.Itoa(n)
+		}
+		typeName := goType(col, analyzer, result.RowCount)
+		if strings.Contains(typeName, "time.Time") {
+			usesTime = true
+		}
+		tag := fmt.Sprintf("csv:%s db:%s", strconv.Quote(col.Name), strconv.Quote(col.Name))
+		if strings.Contains(tag, "`") {
+			tag = strconv.Quote(tag)
+		} else {
+			tag = "`" + tag + "`"
+		}
+		if col.Stats != nil {
+			fmt.Fprintf(&fields, "\t%s %s %s // %s\n", name, typeName, tag, col.Stats)
+		} else {
+			fmt.Fprintf(&fields, "\t%s %s %s\n", name, typeName, tag)
+		}
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintln(&src, "package main")
+	if usesTime {
+		fmt.Fprintln(&src, `import "time"`)
+	}
+	typeName := goFieldName(table)
+	fmt.Fprintf(&src, "// %s is a row of the %s file\n", typeName, table)
+	fmt.Fprintf(&src, "type %s struct {\n%s}\n", typeName, fields.String())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("generated Go struct does not compile: %v", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
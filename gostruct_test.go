@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestGoFieldName(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"id", "ID"},
+		{"customer_id", "CustomerID"},
+		{"first name", "FirstName"},
+		{"createdAt", "CreatedAt"},
+		{"Order-Total", "OrderTotal"},
+		{"2024 sales", "Col2024Sales"},
+		{"%", "Column"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := goFieldName(tt.header); got != tt.expected {
+				t.Errorf("goFieldName(%q) = %q, want %q", tt.header, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPrintGoStruct(t *testing.T) {
+	input := strings.Join([]string{
+		"order_id|amount|placed|note|note-",
+		"1|9.99|2024-03-20|gift|a",
+		"70000|5|2024-03-21||b",
+	}, "\n")
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	if err := printGoStruct(&buf, result, analyzer, "daily_orders"); err != nil {
+		t.Fatalf("printGoStruct() error = %v, want nil", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "orders.go", buf.Bytes(), 0); err != nil {
+		t.Fatalf("output is not valid Go: %v\n%s", err, buf.String())
+	}
+
+	for _, want := range []string{
+		`import "time"`,
+		"type DailyOrders struct {",
+		"OrderID int32     `csv:\"order_id\" db:\"order_id\"`",
+		"Amount  float64   `csv:\"amount\" db:\"amount\"`",
+		"Placed  time.Time `csv:\"placed\" db:\"placed\"`",
+		"Note    *string   `csv:\"note\" db:\"note\"`",
+		"Note2   string    `csv:\"note-\" db:\"note-\"`",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
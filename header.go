@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// replayRecords reads records pushed back onto it before continuing with
+// the records of the reader it wraps, reporting their original lines and
+// quoting
+type replayRecords struct {
+	recordReader
+	records [][]string
+	quoted  [][]bool
+	lines   []int
+	line    int
+	current []bool // quoting of the record last replayed
+}
+
+// push queues a copy of a record read from line to be read again, with
+// which of its fields were quoted
+func (r *replayRecords) push(record []string, quoted []bool, line int) {
+	r.records = append(r.records, cloneValues(record))
+	r.quoted = append(r.quoted, slices.Clone(quoted))
+	r.lines = append(r.lines, line)
+}
+
+func (r *replayRecords) Read() ([]string, error) {
+	if len(r.records) > 0 {
+		record := r.records[0]
+		r.line, r.current = r.lines[0], r.quoted[0]
+		r.records, r.quoted, r.lines = r.records[1:], r.quoted[1:], r.lines[1:]
+		return record, nil
+	}
+	r.line = 0
+	return r.recordReader.Read()
+}
+
+func (r *replayRecords) Quoted() []bool {
+	if r.line > 0 {
+		return r.current
+	}
+	return quotedFields(r.recordReader)
+}
+
+func (r *replayRecords) Line() int {
+	if r.line > 0 {
+		return r.line
+	}
+	return r.recordReader.Line()
+}
+
+// numberedHeaders names the columns of a file without a header row
+func numberedHeaders(n int) []string {
+	headers := make([]string, n)
+	for i := range headers {
+		headers[i] = fmt.Sprintf("column_%d", i+1)
+	}
+	return headers
+}
+
+// cellKind classifies a cell for header detection: "" when empty, "text"
+// for strings, otherwise the name of the type it parses as
+func cellKind(value string, analyzer dbtypes.TypeAnalyzer, opts *analysisOptions) string {
+	if strings.TrimSpace(value) == "" {
+		return ""
+	}
+	switch name := analyzer.GetTypes()[inferType(value, analyzer, opts)].Name; name {
+	case "varchar", "text":
+		return "text"
+	default:
+		return name
+	}
+}
+
+// dataLikeCells returns the cells of a record that parse as numbers, dates,
+// booleans or other non-text types, and the number of non-empty cells
+func dataLikeCells(record []string, analyzer dbtypes.TypeAnalyzer, opts *analysisOptions) ([]string, int) {
+	var cells []string
+	nonEmpty := 0
+	for _, cell := range record {
+		switch cellKind(cell, analyzer, opts) {
+		case "":
+		case "text":
+			nonEmpty++
+		default:
+			nonEmpty++
+			cells = append(cells, cell)
+		}
+	}
+	return cells, nonEmpty
+}
+
+// looksLikeData reports whether most of a record's non-empty cells parse
+// as something other than text
+func looksLikeData(cells []string, nonEmpty int) bool {
+	return nonEmpty > 0 && len(cells)*2 > nonEmpty
+}
+
+// detectHeader decides whether the first record of a file is a header: it
+// must look like text rather than data, and differ in the kinds of its
+// cells from the second record, when there is one. The reason is reported
+// by verbose output.
+func detectHeader(first, second []string, analyzer dbtypes.TypeAnalyzer, opts *analysisOptions) (bool, string) {
+	cells, nonEmpty := dataLikeCells(first, analyzer, opts)
+	if looksLikeData(cells, nonEmpty) {
+		return false, fmt.Sprintf("%d of %d cells of the first row parse as data: %s", len(cells), nonEmpty, strings.Join(cells, ", "))
+	}
+	if second == nil {
+		return true, "the first row is text and there is no second row to compare it with"
+	}
+
+	differing, compared := 0, 0
+	for i := 0; i < len(first) && i < len(second); i++ {
+		firstKind, secondKind := cellKind(first[i], analyzer, opts), cellKind(second[i], analyzer, opts)
+		if firstKind == "" || secondKind == "" {
+			continue
+		}
+		compared++
+		if firstKind != secondKind {
+			differing++
+		}
+	}
+	if differing == 0 {
+		return false, fmt.Sprintf("the first row is text but has the same kinds of values as the second in all %d comparable columns", compared)
+	}
+	return true, fmt.Sprintf("the first row is text and differs in kind from the second in %d of %d comparable columns", differing, compared)
+}
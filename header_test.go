@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestDetectHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  []string
+		second []string
+		want   bool
+	}{
+		{"names over data", []string{"id", "joined", "active"}, []string{"1", "2024-01-15", "true"}, true},
+		{"data over data", []string{"12345", "2024-01-01", "Alice"}, []string{"12346", "2024-01-02", "Bob"}, false},
+		{"text over text", []string{"Alice", "London"}, []string{"Bob", "Paris"}, false},
+		{"text over partly empty data", []string{"id", "note"}, []string{"7", ""}, true},
+		{"names without data", []string{"id", "name"}, nil, true},
+		{"data without more rows", []string{"1", "2", "x"}, nil, false},
+	}
+
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := detectHeader(tt.first, tt.second, analyzer, &analysisOptions{})
+			if got != tt.want {
+				t.Errorf("detectHeader() = %v (%s), want %v", got, reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeHeaderModes(t *testing.T) {
+	headerless := "12345,2024-01-01,true\n12346,2024-01-02,false\n"
+	withHeader := "id,joined,active\n12345,2024-01-01,true\n"
+
+	tests := []struct {
+		name     string
+		input    string
+		header   string
+		columns  []string
+		rows     int
+		warnings []string
+	}{
+		{"headerless taken as header", headerless, "yes", []string{"12345", "2024-01-01", "true"}, 1, []string{
+			"the header row looks like data, 3 of its 3 cells parse as numbers, dates or booleans (12345, 2024-01-01, true); if the file has no header, use -header no or -header auto"}},
+		{"no header", headerless, "no", []string{"column_1", "column_2", "column_3"}, 2, nil},
+		{"auto without header", headerless, "auto", []string{"column_1", "column_2", "column_3"}, 2, []string{
+			"the first row does not look like a header, so it was read as data and the columns named column_1 to column_3"}},
+		{"auto with header", withHeader, "auto", []string{"id", "joined", "active"}, 1, nil},
+		{"default with header", withHeader, "", []string{"id", "joined", "active"}, 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &dbtypes.PostgreSQLAnalyzer{}
+			opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, Header: tt.header}
+			result, err := analyzeFileTypes(strings.NewReader(tt.input), opts, analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			var columns []string
+			for _, col := range result.Columns {
+				columns = append(columns, col.Name)
+			}
+			if !reflect.DeepEqual(columns, tt.columns) || result.RowCount != tt.rows {
+				t.Errorf("columns = %q with %d rows, want %q with %d rows", columns, result.RowCount, tt.columns, tt.rows)
+			}
+			if !reflect.DeepEqual(result.Warnings, tt.warnings) {
+				t.Errorf("Warnings = %q, want %q", result.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestAnalyzeHeaderAutoLineNumbers(t *testing.T) {
+	// The rows read to decide are replayed with the lines they came from
+	input := "1,2\n\n3,4,5\n"
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, Header: "auto"}
+	_, err := analyzeFileTypes(strings.NewReader(input), opts, &dbtypes.PostgreSQLAnalyzer{})
+	if err == nil || err.Error() != "line 3 has 3 fields, expected 2" {
+		t.Errorf("analyzeFileTypes() error = %v, want line 3 has 3 fields, expected 2", err)
+	}
+}
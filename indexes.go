@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// maxKeyLength is the longest varchar or char value of a column suggested as
+// a key, enough for codes and UUIDs but not free text that happens to differ
+const maxKeyLength = 64
+
+// brinMinRows is the number of rows from which an ordered PostgreSQL column
+// is suggested a BRIN index, which summarizes ranges of blocks, rather than
+// a btree
+const brinMinRows = 100000
+
+// keyDetector finds the columns whose values are all present and distinct by
+// keeping a 64-bit hash of every value of a column until it sees a null or a
+// repeat, so memory grows only for the columns that might be keys. As with
+// duplicate rows, two values with the same hash would rule a column out,
+// which is vanishingly unlikely. Past the memory budget a column's hashes
+// are kept in a Bloom filter, whose false positives only rule out columns,
+// and then not at all, which leaves the column unsuggested.
+type keyDetector struct {
+	seen        []*hashSet // by column, nil once a null or repeat was seen
+	approximate int        // columns whose hashes moved to a Bloom filter
+	dropped     int        // columns whose hashes were dropped
+}
+
+func newKeyDetector(columns int, budget *memoryBudget) *keyDetector {
+	k := &keyDetector{seen: make([]*hashSet, columns)}
+	for i := range k.seen {
+		k.seen[i] = newHashSet(budget)
+	}
+	return k
+}
+
+// observe records the value of column i read from line, ruling the column
+// out if it is null or was seen before
+func (k *keyDetector) observe(i int, field string, null bool, line int) {
+	set := k.seen[i]
+	if set == nil || set.mode == noHashes {
+		return
+	}
+	if null {
+		set.free()
+		k.seen[i] = nil
+		return
+	}
+	mode := set.mode
+	if _, seen := set.add(hashRecord([]string{field}), line); seen {
+		set.free()
+		k.seen[i] = nil
+	}
+	if set.mode != mode {
+		switch set.mode {
+		case approximateHashes:
+			k.approximate++
+		case noHashes:
+			k.dropped++
+		}
+	}
+}
+
+// finish marks the columns whose values were all distinct
+func (k *keyDetector) finish(columns []columnAnalysis) {
+	for i := range columns {
+		set := k.seen[i]
+		columns[i].Distinct = set != nil && set.mode != noHashes && set.size() > 0
+	}
+}
+
+// budgetWarnings describes how the memory budget limited the search
+func (k *keyDetector) budgetWarnings(budget *memoryBudget) []string {
+	var warnings []string
+	if k.approximate > 0 {
+		warnings = append(warnings, budgetWarning(budget, "-suggest-indexes checked the values of %s approximately, so up to about 1%% of their distinct values may have been taken for repeats, ruling the column out as a key", countOf(k.approximate, "column")))
+	}
+	if k.dropped > 0 {
+		warnings = append(warnings, budgetWarning(budget, "-suggest-indexes stopped checking the values of %s, which are not suggested as keys", countOf(k.dropped, "column")))
+	}
+	return warnings
+}
+
+// indexSQL returns advisory CREATE INDEX statements for the table, each
+// after a comment giving the reason: a btree on columns whose values are
+// all present and distinct, which are likely keys, and on date and
+// timestamp columns whose values run in file order, allowing a tolerance
+// fraction of them to be out of order, which are likely filtered by range.
+// Large ordered PostgreSQL columns get a BRIN index instead, and keys too
+// long for the flavor's index entries are noted rather than indexed. Snowflake's
+// standard tables take no indexes, so its statements are commented out, to
+// be used with a hybrid table, as they are for Vertica, which has none, and
+// Exasol, which makes its own.
+func indexSQL(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table, primaryKey string, tolerance float64) string {
+	var noIndexes string
+	switch analyzer.(type) {
+	case *dbtypes.SnowflakeAnalyzer:
+		noIndexes = "-- Snowflake takes indexes only on hybrid tables\n"
+	case *dbtypes.VerticaAnalyzer:
+		noIndexes = "-- Vertica has no indexes; sort a projection by these columns instead\n"
+	case *dbtypes.ExasolAnalyzer:
+		noIndexes = "-- Exasol creates its indexes itself, on the columns tables are joined by\n"
+	}
+	_, postgres := analyzer.(*dbtypes.PostgreSQLAnalyzer)
+	limit := identifierLimit(analyzer)
+	taken := make(map[string]bool)
+	var b strings.Builder
+	for _, col := range result.Columns {
+		dataType := analyzer.GetTypes()[col.TypeIndex]
+		var reason, method string
+		switch dataType.Name {
+		case "tinyint", "smallint", "integer", "bigint", "char", "varchar":
+			if !col.Distinct || result.RowCount < 2 || col.Name == primaryKey {
+				continue
+			}
+			// A key too long for an index entry cannot be indexed as it is
+			if threshold, ok := indexThreshold(analyzer); ok && col.MaxBytes > threshold.Bytes {
+				fmt.Fprintf(&b, "-- %s: not indexed, its values of up to %s bytes are over the %s bytes of %s\n",
+					col.Name, groupDigits(col.MaxBytes), groupDigits(threshold.Bytes), threshold.Limit)
+				continue
+			}
+			if dataType.HasLength && col.MaxLength > maxKeyLength {
+				continue
+			}
+			reason = fmt.Sprintf("all %s values are present and distinct, so it is likely a key", groupDigits(result.RowCount))
+		case "date", "timestamp":
+			if col.TimeCount < 2 || col.Earliest.At.Equal(col.Latest.At) || float64(col.OutOfOrder) > tolerance*float64(col.TimeCount) {
+				continue
+			}
+			reason = "values run in file order, so it is likely filtered by range"
+			if col.OutOfOrder > 0 {
+				reason = fmt.Sprintf("all but %s values run in file order, so it is likely filtered by range", groupDigits(col.OutOfOrder))
+			}
+			if postgres && result.RowCount >= brinMinRows {
+				reason += fmt.Sprintf("; BRIN stays small over %s rows in order", groupDigits(result.RowCount))
+				method = " USING brin"
+			}
+		default:
+			continue
+		}
+
+		name := table + "_" + col.Name + "_idx"
+		if limit > 0 && len(name) > limit {
+			name = shortName(name, limit, taken)
+		}
+		taken[name] = true
+		statement := fmt.Sprintf("CREATE INDEX %s ON %s%s (%s);", flavorIdentifier(analyzer, name), flavorIdentifier(analyzer, table), method, flavorIdentifier(analyzer, col.Name))
+		if noIndexes != "" {
+			statement = "-- " + statement
+		}
+		fmt.Fprintf(&b, "-- %s: %s\n%s\n", col.Name, reason, statement)
+	}
+	if b.Len() == 0 {
+		return "-- no indexes suggested\n"
+	}
+	return noIndexes + b.String()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestKeyDetector(t *testing.T) {
+	input := "id,code,note,kind\n1,a1,x,A\n2,a2,,B\n3,a3,y,A\n"
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}, DetectKeys: true}, &dbtypes.PostgreSQLAnalyzer{})
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	want := map[string]bool{"id": true, "code": true, "note": false, "kind": false}
+	for _, col := range result.Columns {
+		if col.Distinct != want[col.Name] {
+			t.Errorf("column %s: Distinct = %v, want %v", col.Name, col.Distinct, want[col.Name])
+		}
+	}
+}
+
+func TestIndexSQL(t *testing.T) {
+	input := "id,ref,created_at,note\n1,r1,2024-03-01,long enough\n2,r2,2024-03-02,words\n3,r3,2024-03-02,words\n"
+	tests := []struct {
+		name       string
+		analyzer   dbtypes.TypeAnalyzer
+		primaryKey string
+		rows       int
+		want       string
+	}{
+		{
+			name:     "postgresql",
+			analyzer: &dbtypes.PostgreSQLAnalyzer{},
+			want: "-- id: all 3 values are present and distinct, so it is likely a key\nCREATE INDEX events_id_idx ON events (id);\n" +
+				"-- ref: all 3 values are present and distinct, so it is likely a key\nCREATE INDEX events_ref_idx ON events (ref);\n" +
+				"-- created_at: values run in file order, so it is likely filtered by range\nCREATE INDEX events_created_at_idx ON events (created_at);\n",
+		},
+		{
+			name:       "primary key",
+			analyzer:   &dbtypes.PostgreSQLAnalyzer{},
+			primaryKey: "id",
+			want: "-- ref: all 3 values are present and distinct, so it is likely a key\nCREATE INDEX events_ref_idx ON events (ref);\n" +
+				"-- created_at: values run in file order, so it is likely filtered by range\nCREATE INDEX events_created_at_idx ON events (created_at);\n",
+		},
+		{
+			name:       "brin",
+			analyzer:   &dbtypes.PostgreSQLAnalyzer{},
+			primaryKey: "id",
+			rows:       brinMinRows,
+			want: "-- ref: all 100,000 values are present and distinct, so it is likely a key\nCREATE INDEX events_ref_idx ON events (ref);\n" +
+				"-- created_at: values run in file order, so it is likely filtered by range; BRIN stays small over 100,000 rows in order\n" +
+				"CREATE INDEX events_created_at_idx ON events USING brin (created_at);\n",
+		},
+		{
+			name:       "snowflake",
+			analyzer:   &dbtypes.SnowflakeAnalyzer{},
+			primaryKey: "id",
+			rows:       brinMinRows,
+			want: "-- Snowflake takes indexes only on hybrid tables\n" +
+				"-- ref: all 100,000 values are present and distinct, so it is likely a key\n-- CREATE INDEX events_ref_idx ON events (ref);\n" +
+				"-- created_at: values run in file order, so it is likely filtered by range\n-- CREATE INDEX events_created_at_idx ON events (created_at);\n",
+		},
+		{
+			name:       "vertica",
+			analyzer:   &dbtypes.VerticaAnalyzer{},
+			primaryKey: "id",
+			rows:       brinMinRows,
+			want: "-- Vertica has no indexes; sort a projection by these columns instead\n" +
+				"-- ref: all 100,000 values are present and distinct, so it is likely a key\n-- CREATE INDEX events_ref_idx ON events (ref);\n" +
+				"-- created_at: values run in file order, so it is likely filtered by range\n-- CREATE INDEX events_created_at_idx ON events (created_at);\n",
+		},
+		{
+			name:       "exasol",
+			analyzer:   &dbtypes.ExasolAnalyzer{},
+			primaryKey: "id",
+			want: "-- Exasol creates its indexes itself, on the columns tables are joined by\n" +
+				"-- ref: all 3 values are present and distinct, so it is likely a key\n-- CREATE INDEX EVENTS_REF_IDX ON EVENTS (REF);\n" +
+				"-- created_at: values run in file order, so it is likely filtered by range\n-- CREATE INDEX EVENTS_CREATED_AT_IDX ON EVENTS (CREATED_AT);\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}, DetectKeys: true}, tt.analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			if tt.rows > 0 {
+				result.RowCount = tt.rows
+			}
+			if got := indexSQL(result, tt.analyzer, "events", tt.primaryKey, 0.01); got != tt.want {
+				t.Errorf("indexSQL() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexSQLNone(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader("kind,day\nA,2024-03-02\nA,2024-03-01\n"), analysisOptions{Options: analyze.Options{Delimiter: ","}, DetectKeys: true}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if got, want := indexSQL(result, analyzer, "events", "", 0.01), "-- no indexes suggested\n"; got != want {
+		t.Errorf("indexSQL() = %q, want %q", got, want)
+	}
+}
+
+func TestIndexSQLOverThreshold(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	long := strings.Repeat("x", 3000)
+	input := "id,doc\n1,a" + long + "\n2,b" + long + "\n"
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}, DetectKeys: true}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	want := "-- doc: not indexed, its values of up to 3,001 bytes are over the 2,704 bytes of a PostgreSQL btree index entry\n"
+	if got := indexSQL(result, analyzer, "events", "id", 0.01); got != want {
+		t.Errorf("indexSQL() =\n%s\nwant\n%s", got, want)
+	}
+}
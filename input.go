@@ -0,0 +1,284 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// inputOptions controls how the file to analyze is fetched
+type inputOptions struct {
+	HeadBytes   int64         // fetch only this many bytes of the file, 0 for all
+	HTTPTimeout time.Duration // limit on the whole HTTP request, including the body
+	AWSRegion   string        // overrides the region from the default AWS configuration
+	ZipEntry    string        // entry to read from a .zip archive with several files
+
+	RecordSeparator string // ends records instead of a newline, for dropping a partial record
+}
+
+// isRemote reports whether the input is a URL rather than a local path
+func isRemote(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") ||
+		strings.HasPrefix(location, "s3://")
+}
+
+// inputName returns the file name of a local path, HTTP URL or S3 URL
+func inputName(location string) string {
+	if isRemote(location) {
+		if u, err := url.Parse(location); err == nil {
+			return path.Base(u.Path)
+		}
+	}
+	return path.Base(strings.ReplaceAll(location, `\`, "/"))
+}
+
+// openInput opens a local file, an http(s) URL or an s3://bucket/key URL for
+// reading, returning the name to report it by. Gzip-compressed inputs,
+// recognized by a .gz extension, are decompressed, and for .zip archives the
+// single entry, or the one named by ZipEntry, is read. With HeadBytes set
+// only a prefix is read, and a line cut off at the end of the prefix is
+// dropped.
+func openInput(ctx context.Context, location string, opts inputOptions) (io.ReadCloser, string, error) {
+	name := inputName(location)
+	isZip := strings.HasSuffix(strings.ToLower(name), ".zip")
+
+	// An archive's directory is at its end, so it is always fetched whole
+	fetchOpts := opts
+	if isZip {
+		fetchOpts.HeadBytes = 0
+	}
+	var body io.ReadCloser
+	var err error
+	switch {
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		body, err = openHTTP(ctx, location, fetchOpts)
+	case strings.HasPrefix(location, "s3://"):
+		body, err = openS3(ctx, location, fetchOpts)
+	default:
+		body, err = os.Open(location)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if isZip {
+		entry, entryName, err := openZipEntry(body, opts.ZipEntry)
+		if err != nil {
+			body.Close()
+			return nil, "", fmt.Errorf("%s: %v", name, err)
+		}
+		// A local archive is read in place, so it stays open with the entry
+		body = readCloser{entry, body}
+		name += "!" + entryName
+	}
+
+	// Plain local files are returned as is, so formats needing random
+	// access can read them in place
+	gzipped := strings.HasSuffix(name, ".gz")
+	if !gzipped && opts.HeadBytes <= 0 {
+		return body, name, nil
+	}
+
+	fetched := &countingReader{r: body}
+	var r io.Reader = fetched
+	if opts.HeadBytes > 0 {
+		r = io.LimitReader(r, opts.HeadBytes)
+	}
+	if gzipped {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			body.Close()
+			return nil, "", fmt.Errorf("error reading gzip input: %v", err)
+		}
+		r = gz
+	}
+	if opts.HeadBytes <= 0 {
+		return readCloser{r, body}, name, nil
+	}
+
+	// The prefix is small enough to hold, and holding it lets the last
+	// partial line be dropped
+	data, err := io.ReadAll(r)
+	body.Close()
+	truncated := fetched.n >= opts.HeadBytes
+	if err != nil && !(truncated && errors.Is(err, io.ErrUnexpectedEOF)) {
+		return nil, "", fmt.Errorf("error reading input: %v", err)
+	}
+	if truncated {
+		sep := "\n"
+		if opts.RecordSeparator != "" {
+			sep = opts.RecordSeparator
+		}
+		if i := bytes.LastIndex(data, []byte(sep)); i >= 0 {
+			data = data[:i+len(sep)]
+		} else {
+			data = nil
+		}
+	}
+	return io.NopCloser(bytes.NewReader(data)), name, nil
+}
+
+// openZip opens a zip archive, whose directory is at its end
+func openZip(archive io.Reader) (*zip.Reader, error) {
+	r, size, err := readerAt(archive)
+	if err != nil {
+		return nil, err
+	}
+	return zip.NewReader(r, size)
+}
+
+// readerAt gives random access to an input, reading a local file in place
+// and any other input into memory
+func readerAt(r io.Reader) (io.ReaderAt, int64, error) {
+	if file, ok := r.(*os.File); ok {
+		info, err := file.Stat()
+		if err != nil {
+			return nil, 0, err
+		}
+		return file, info.Size(), nil
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// openZipEntry opens a file inside a zip archive: the named entry, or the
+// only file when no name is given. Entries may sit in directories and are
+// named by their full path in the archive.
+func openZipEntry(archive io.Reader, entryName string) (io.Reader, string, error) {
+	zr, err := openZip(archive)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading archive: %v", err)
+	}
+	var files []*zip.File
+	var names []string
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		files = append(files, f)
+		names = append(names, f.Name)
+	}
+
+	var chosen *zip.File
+	switch {
+	case entryName != "":
+		for _, f := range files {
+			if f.Name == entryName {
+				chosen = f
+			}
+		}
+		if chosen == nil {
+			return nil, "", fmt.Errorf("archive has no entry %s; entries: %s", entryName, strings.Join(names, ", "))
+		}
+	case len(files) == 1:
+		chosen = files[0]
+	case len(files) == 0:
+		return nil, "", fmt.Errorf("archive is empty")
+	default:
+		return nil, "", fmt.Errorf("archive has %d entries, pick one with -zip-entry: %s", len(files), strings.Join(names, ", "))
+	}
+
+	rc, err := chosen.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading %s: %v", chosen.Name, err)
+	}
+	return rc, chosen.Name, nil
+}
+
+// openHTTP fetches the URL with a single GET, asking for just the prefix
+// when HeadBytes is set
+func openHTTP(ctx context.Context, location string, opts inputOptions) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %s: %v", location, err)
+	}
+	if opts.HeadBytes > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", opts.HeadBytes-1))
+	}
+	client := &http.Client{Timeout: opts.HTTPTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %v", location, err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, fmt.Errorf("error fetching %s: HTTP %s", location, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// parseS3URL splits an s3://bucket/key URL into its bucket and key
+func parseS3URL(location string) (bucket, key string, err error) {
+	rest := strings.TrimPrefix(location, "s3://")
+	bucket, key, _ = strings.Cut(rest, "/")
+	if bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid S3 URL %s: want s3://bucket/key", location)
+	}
+	return bucket, key, nil
+}
+
+// openS3 fetches the object using the default AWS credential chain
+func openS3(ctx context.Context, location string, opts inputOptions) (io.ReadCloser, error) {
+	bucket, key, err := parseS3URL(location)
+	if err != nil {
+		return nil, err
+	}
+	var loadOpts []func(*config.LoadOptions) error
+	if opts.AWSRegion != "" {
+		loadOpts = append(loadOpts, config.WithRegion(opts.AWSRegion))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("error loading AWS configuration: %v", err)
+	}
+
+	input := &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)}
+	if opts.HeadBytes > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=0-%d", opts.HeadBytes-1))
+	}
+	out, err := s3.NewFromConfig(cfg).GetObject(ctx, input)
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) {
+			return nil, fmt.Errorf("error fetching %s: %s: %s", location, apiErr.ErrorCode(), apiErr.ErrorMessage())
+		}
+		return nil, fmt.Errorf("error fetching %s: %v", location, err)
+	}
+	return out.Body, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// readCloser reads from one reader and closes another, for wrapped bodies
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const inputSample = "id,name\n1,Alice\n2,Bob\n3,Carol\n"
+
+func readInput(t *testing.T, location string, opts inputOptions) (string, error) {
+	t.Helper()
+	r, _, err := openInput(context.Background(), location, opts)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	return string(data), err
+}
+
+func TestOpenInputHTTP(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(inputSample))
+	w.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data.csv":
+			http.ServeContent(w, r, "data.csv", time.Time{}, strings.NewReader(inputSample))
+		case "/data.csv.gz":
+			w.Write(gz.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		path      string
+		headBytes int64
+		want      string
+		errText   string
+	}{
+		{"whole file", "/data.csv", 0, inputSample, ""},
+		{"prefix drops partial line", "/data.csv", 20, "id,name\n1,Alice\n", ""},
+		{"prefix longer than file", "/data.csv", 1000, inputSample, ""},
+		{"gzip", "/data.csv.gz", 0, inputSample, ""},
+		{"missing", "/missing.csv", 0, "", "404 Not Found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readInput(t, server.URL+tt.path, inputOptions{HeadBytes: tt.headBytes, HTTPTimeout: time.Minute})
+			if tt.errText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errText) {
+					t.Errorf("openInput() error = %v, want error containing %q", err, tt.errText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("openInput() error = %v, want nil", err)
+			}
+			if got != tt.want {
+				t.Errorf("openInput() read %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenInputPrefixRecordSeparator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.txt")
+	if err := os.WriteFile(path, []byte("id,name\x1e1,Alice\x1e2,Bob\x1e"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readInput(t, path, inputOptions{HeadBytes: 20, RecordSeparator: "\x1e"})
+	if err != nil {
+		t.Fatalf("openInput() error = %v, want nil", err)
+	}
+	if want := "id,name\x1e1,Alice\x1e"; got != want {
+		t.Errorf("openInput() read %q, want %q", got, want)
+	}
+}
+
+func TestOpenInputLocalGzipPrefix(t *testing.T) {
+	var rows strings.Builder
+	rows.WriteString("id,name\n")
+	for i := 0; i < 1000; i++ {
+		rows.WriteString("1,some longer name to fill the stream\n")
+	}
+	path := filepath.Join(t.TempDir(), "big.csv.gz")
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(rows.String()))
+	w.Close()
+	if err := os.WriteFile(path, gz.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readInput(t, path, inputOptions{HeadBytes: int64(gz.Len() / 2)})
+	if err != nil {
+		t.Fatalf("openInput() error = %v, want nil", err)
+	}
+	if !strings.HasPrefix(rows.String(), got) || !strings.HasSuffix(got, "\n") || len(got) == 0 {
+		t.Errorf("openInput() read %d bytes that are not a whole-line prefix of the file", len(got))
+	}
+}
+
+func writeZip(t *testing.T, entries map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "archive.zip")
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	// Write entries in name order so the listings in errors are stable
+	for _, name := range slices.Sorted(maps.Keys(entries)) {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(entries[name]))
+	}
+	w.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenInputZip(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  map[string]string
+		entry    string
+		wantName string
+		errText  string
+	}{
+		{"single entry", map[string]string{"orders.csv": inputSample}, "", "archive.zip!orders.csv", ""},
+		{"nested entry", map[string]string{"export/": "", "export/2024/orders.csv": inputSample}, "", "archive.zip!export/2024/orders.csv", ""},
+		{"named entry", map[string]string{"a.csv": inputSample, "b.csv": "x\n"}, "a.csv", "archive.zip!a.csv", ""},
+		{"ambiguous", map[string]string{"a.csv": inputSample, "b.csv": "x\n"}, "", "", "pick one with -zip-entry: a.csv, b.csv"},
+		{"missing entry", map[string]string{"a.csv": inputSample}, "b.csv", "", "archive has no entry b.csv; entries: a.csv"},
+		{"empty", map[string]string{"docs/": ""}, "", "", "archive.zip: archive is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeZip(t, tt.entries)
+			r, name, err := openInput(context.Background(), path, inputOptions{ZipEntry: tt.entry})
+			if tt.errText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errText) {
+					t.Fatalf("openInput() error = %v, want containing %q", err, tt.errText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("openInput() error = %v, want nil", err)
+			}
+			defer r.Close()
+			data, _ := io.ReadAll(r)
+			if name != tt.wantName {
+				t.Errorf("openInput() name = %q, want %q", name, tt.wantName)
+			}
+			if string(data) != inputSample {
+				t.Errorf("openInput() read %q, want %q", data, inputSample)
+			}
+		})
+	}
+}
+
+func TestOpenInputZipHTTPPrefix(t *testing.T) {
+	path := writeZip(t, map[string]string{"orders.csv": inputSample})
+	archive, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "archive.zip", time.Time{}, bytes.NewReader(archive))
+	}))
+	defer server.Close()
+
+	got, err := readInput(t, server.URL+"/archive.zip", inputOptions{HeadBytes: 20})
+	if err != nil {
+		t.Fatalf("openInput() error = %v, want nil", err)
+	}
+	if want := "id,name\n1,Alice\n"; got != want {
+		t.Errorf("openInput() read %q, want %q", got, want)
+	}
+}
+
+func TestParseS3URL(t *testing.T) {
+	bucket, key, err := parseS3URL("s3://my-bucket/exports/2024/orders.csv")
+	if err != nil || bucket != "my-bucket" || key != "exports/2024/orders.csv" {
+		t.Errorf("parseS3URL() = %q, %q, %v, want my-bucket, exports/2024/orders.csv", bucket, key, err)
+	}
+	if _, _, err := parseS3URL("s3://my-bucket"); err == nil {
+		t.Error("parseS3URL() without a key error = nil, want error")
+	}
+}
+
+func TestInputName(t *testing.T) {
+	tests := map[string]string{
+		"testdata/orders.csv":                        "orders.csv",
+		"https://example.com/exports/orders.csv?x=1": "orders.csv",
+		"s3://bucket/exports/orders.csv.gz":          "orders.csv.gz",
+	}
+	for location, want := range tests {
+		if got := inputName(location); got != want {
+			t.Errorf("inputName(%q) = %q, want %q", location, got, want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"file2ddl/dbtypes"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect of the generated documents
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is a JSON Schema object schema describing a row of the file
+type jsonSchema struct {
+	Schema     string               `json:"$schema"`
+	Title      string               `json:"title"`
+	Type       string               `json:"type"`
+	Properties jsonSchemaProperties `json:"properties"`
+	Required   []string             `json:"required"`
+}
+
+// jsonSchemaProperty is the schema of a single column
+type jsonSchemaProperty struct {
+	Type      string `json:"type"`
+	Format    string `json:"format,omitempty"`
+	MaxLength int    `json:"maxLength,omitempty"`
+
+	Description string `json:"description,omitempty"`
+}
+
+// jsonSchemaProperties holds the column schemas in column order, which a map
+// would lose
+type jsonSchemaProperties struct {
+	Names   []string
+	Schemas []jsonSchemaProperty
+}
+
+func (p jsonSchemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range p.Names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(p.Schemas[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// columnJSONSchema maps a column's inferred type to a JSON Schema type,
+// with a format hint for dates and the observed maximum length for strings
+func columnJSONSchema(col columnAnalysis, analyzer dbtypes.TypeAnalyzer) jsonSchemaProperty {
+	switch analyzer.GetTypes()[col.TypeIndex].Name {
+	case "boolean":
+		return jsonSchemaProperty{Type: "boolean"}
+	case "tinyint", "smallint", "integer", "bigint":
+		return jsonSchemaProperty{Type: "integer"}
+	case "numeric":
+		return jsonSchemaProperty{Type: "number"}
+	case "timestamp":
+		return jsonSchemaProperty{Type: "string", Format: "date-time"}
+	case "date":
+		return jsonSchemaProperty{Type: "string", Format: "date"}
+	case "varchar", "char":
+		return jsonSchemaProperty{Type: "string", MaxLength: col.MaxLength}
+	}
+	return jsonSchemaProperty{Type: "string"}
+}
+
+// printJSONSchema writes the column report as a JSON Schema for an object
+// with one property per column. Columns without empty values are required.
+func printJSONSchema(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table string) error {
+	schema := jsonSchema{Schema: jsonSchemaDraft, Title: table, Type: "object", Required: []string{}}
+	for _, col := range result.Columns {
+		schema.Properties.Names = append(schema.Properties.Names, col.Name)
+		property := columnJSONSchema(col, analyzer)
+		if col.Stats != nil {
+			property.Description = col.Stats.String()
+		}
+		schema.Properties.Schemas = append(schema.Properties.Schemas, property)
+		if col.notNull(result.RowCount) {
+			schema.Required = append(schema.Required, col.Name)
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestPrintJSONSchema(t *testing.T) {
+	input := strings.Join([]string{
+		"id|total|placed|shipped|active|note",
+		"1|9.50|2024-03-20|2024-03-20T10:30:00Z|true|gift",
+		"2|120.125|2024-03-21|2024-03-21T11:00:00Z|false|",
+	}, "\n")
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	if err := printJSONSchema(&buf, result, analyzer, "orders"); err != nil {
+		t.Fatalf("printJSONSchema() error = %v, want nil", err)
+	}
+
+	// Compiling validates the document against the draft 2020-12 metaschema
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	compiler.AssertFormat = true
+	if err := compiler.AddResource("orders.json", bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("AddResource() error = %v", err)
+	}
+	schema, err := compiler.Compile("orders.json")
+	if err != nil {
+		t.Fatalf("output is not a valid JSON Schema: %v\n%s", err, buf.String())
+	}
+
+	var row interface{}
+	valid := `{"id": 3, "total": 1.5, "placed": "2024-03-22", "shipped": "2024-03-22T09:00:00Z", "active": true}`
+	if err := json.Unmarshal([]byte(valid), &row); err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Validate(row); err != nil {
+		t.Errorf("valid row rejected: %v", err)
+	}
+
+	invalid := `{"id": 3.5, "total": 1.5, "placed": "2024-03-22", "shipped": "2024-03-22T09:00:00Z", "active": true, "note": "toolong"}`
+	if err := json.Unmarshal([]byte(invalid), &row); err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Validate(row); err == nil {
+		t.Error("row with a fractional id and an overlong note was accepted")
+	}
+
+	var doc struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(doc.Required, ","); got != "id,total,placed,shipped,active" {
+		t.Errorf("required = %s, want id,total,placed,shipped,active", got)
+	}
+	if strings.Index(buf.String(), `"id"`) > strings.Index(buf.String(), `"note"`) {
+		t.Error("properties are not in column order")
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"file2ddl/dbtypes"
+)
+
+// maxTruncatedLines caps the line numbers listed for values that a varchar
+// sized by -varchar-percentile would truncate
+const maxTruncatedLines = 10
+
+// lengthSemantics returns how the flavor's database measures varchar
+// lengths: "chars" when its analyzer says so, otherwise "bytes"
+func lengthSemantics(analyzer dbtypes.TypeAnalyzer) string {
+	if counter, ok := analyzer.(dbtypes.LengthCounter); ok {
+		return counter.LengthSemantics()
+	}
+	return "bytes"
+}
+
+// valueLength measures a value in characters with "chars" semantics and in
+// bytes otherwise
+func valueLength(value, semantics string) int {
+	if semantics == "chars" {
+		return utf8.RuneCountInString(value)
+	}
+	return len(value)
+}
+
+// valueAt is the length of a value and the line it was read from
+type valueAt struct {
+	Length int
+	Line   int
+}
+
+// observeLength records the length of a varchar value read from line in the
+// column's length histogram, which is bounded since varchar values are at
+// most the flavor's InferLength long, and keeps the lines of the longest
+// values
+func (c *columnAnalysis) observeLength(length, line int) {
+	if c.LengthCounts == nil {
+		c.LengthCounts = make(map[int]int)
+	}
+	c.LengthCounts[length]++
+
+	if len(c.LongValues) < maxTruncatedLines {
+		c.LongValues = append(c.LongValues, valueAt{length, line})
+		return
+	}
+	// Replace the shortest kept value; ties keep the earlier line
+	shortest := 0
+	for i, v := range c.LongValues {
+		if v.Length < c.LongValues[shortest].Length {
+			shortest = i
+		}
+	}
+	if length > c.LongValues[shortest].Length {
+		c.LongValues[shortest] = valueAt{length, line}
+	}
+}
+
+// lengthPercentile returns the smallest length that p percent of the values
+// counted in the histogram fit in, by the nearest-rank method
+func lengthPercentile(counts map[int]int, p float64) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	rank := max(int(math.Ceil(p/100*float64(total))), 1)
+	seen := 0
+	for _, length := range slices.Sorted(maps.Keys(counts)) {
+		seen += counts[length]
+		if seen >= rank {
+			return length
+		}
+	}
+	return 0
+}
+
+// lengthStats is the distribution of a varchar column's value lengths
+type lengthStats struct {
+	P50, P95, P99, Max int
+}
+
+// newLengthStats returns the length distribution of a column, or nil when
+// it is not a varchar column or no lengths were recorded
+func newLengthStats(col columnAnalysis, analyzer dbtypes.TypeAnalyzer) *lengthStats {
+	if analyzer.GetTypes()[col.TypeIndex].Name != "varchar" || len(col.LengthCounts) == 0 {
+		return nil
+	}
+	return &lengthStats{
+		P50: lengthPercentile(col.LengthCounts, 50),
+		P95: lengthPercentile(col.LengthCounts, 95),
+		P99: lengthPercentile(col.LengthCounts, 99),
+		Max: lengthPercentile(col.LengthCounts, 100),
+	}
+}
+
+// sizeVarchars sizes each varchar column to the given percentile of its
+// value lengths instead of the longest value, warning about the values that
+// would not fit and listing the lines of up to maxTruncatedLines of them
+// from the current file
+func sizeVarchars(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, percentile float64) {
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		if analyzer.GetTypes()[col.TypeIndex].Name != "varchar" || len(col.LengthCounts) == 0 {
+			continue
+		}
+		size := lengthPercentile(col.LengthCounts, percentile)
+		if size >= col.MaxLength {
+			continue
+		}
+
+		longer := 0
+		for length, n := range col.LengthCounts {
+			if length > size {
+				longer += n
+			}
+		}
+		var lines []int
+		for _, v := range col.LongValues {
+			if v.Length > size {
+				lines = append(lines, v.Line)
+			}
+		}
+		slices.Sort(lines)
+		lineList := make([]string, len(lines))
+		for j, line := range lines {
+			lineList[j] = fmt.Sprint(line)
+		}
+
+		values := "values are"
+		if longer == 1 {
+			values = "value is"
+		}
+		message := fmt.Sprintf("column %s sized to varchar(%d), the p%g length; %d %s longer, up to %d, and would be truncated",
+			col.Name, size, percentile, longer, values, col.MaxLength)
+		if len(lines) > 0 {
+			message += fmt.Sprintf(" (lines %s)", strings.Join(lineList, ", "))
+		}
+		result.warnf("%s", message)
+		col.MaxLength = size
+	}
+}
+
+// lengthThresholds returns the flavor's length thresholds, none when its
+// analyzer has none
+func lengthThresholds(analyzer dbtypes.TypeAnalyzer) []dbtypes.LengthThreshold {
+	if thresholder, ok := analyzer.(dbtypes.LengthThresholder); ok {
+		return thresholder.LengthThresholds()
+	}
+	return nil
+}
+
+// indexThreshold returns the flavor's threshold on indexed values, and
+// false if it has none
+func indexThreshold(analyzer dbtypes.TypeAnalyzer) (dbtypes.LengthThreshold, bool) {
+	for _, threshold := range lengthThresholds(analyzer) {
+		if threshold.Index {
+			return threshold, true
+		}
+	}
+	return dbtypes.LengthThreshold{}, false
+}
+
+// checkLengthThresholds records on each column the flavor's thresholds its
+// longest value exceeds, returning a warning for each, since the index or
+// load they limit would fail later
+func checkLengthThresholds(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) []string {
+	var warnings []string
+	for _, threshold := range lengthThresholds(analyzer) {
+		for i := range result.Columns {
+			col := &result.Columns[i]
+			if col.MaxBytes <= threshold.Bytes {
+				continue
+			}
+			col.OverLimits = append(col.OverLimits, threshold.Limit)
+			fails := "loading them will fail"
+			if threshold.Index {
+				fails = "an index on the column will fail"
+			}
+			warnings = append(warnings, fmt.Sprintf("column %s holds values of up to %s bytes, over the %s bytes of %s; %s",
+				col.Name, groupDigits(col.MaxBytes), groupDigits(threshold.Bytes), threshold.Limit, fails))
+		}
+	}
+	return warnings
+}
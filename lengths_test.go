@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestLengthPercentile(t *testing.T) {
+	// 90 values of length 10, 9 of length 40 and one of 8000
+	counts := map[int]int{10: 90, 40: 9, 8000: 1}
+	tests := []struct {
+		p    float64
+		want int
+	}{
+		{50, 10},
+		{90, 10},
+		{95, 40},
+		{99, 40},
+		{99.5, 8000},
+		{100, 8000},
+	}
+
+	for _, tt := range tests {
+		if got := lengthPercentile(counts, tt.p); got != tt.want {
+			t.Errorf("lengthPercentile(p%g) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestObserveLengthKeepsLongest(t *testing.T) {
+	var col columnAnalysis
+	for line := 2; line < 40; line++ {
+		length := 5
+		if line%3 == 0 {
+			length = line
+		}
+		col.observeLength(length, line)
+	}
+	col.observeLength(12, 40) // ties with the shortest kept value, on line 12, which stays
+
+	var lines []int
+	for _, v := range col.LongValues {
+		lines = append(lines, v.Line)
+	}
+	want := []int{12, 15, 18, 21, 24, 27, 30, 33, 36, 39}
+	slices.Sort(lines)
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines of the longest values = %v, want %v", lines, want)
+	}
+	if col.LengthCounts[5] != 25 || col.LengthCounts[12] != 2 {
+		t.Errorf("LengthCounts = %v, want 25 of length 5 and 2 of length 12", col.LengthCounts)
+	}
+}
+
+func TestSizeVarchars(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("id|note|code\n")
+	for i := 1; i <= 100; i++ {
+		note := "short"
+		switch i {
+		case 7:
+			note = strings.Repeat("x", 8000)
+		case 50:
+			note = strings.Repeat("y", 20)
+		}
+		fmt.Fprintf(&b, "%d|%s|c%d\n", i, note, i%10)
+	}
+
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(b.String()), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	sizeVarchars(result, analyzer, 98)
+
+	if got := columnTypeName(result.Columns[1], analyzer); got != "varchar(5)" {
+		t.Errorf("note type = %s, want varchar(5)", got)
+	}
+	// Every code fits its p98 length, so the column keeps its size
+	if got := columnTypeName(result.Columns[2], analyzer); got != "varchar(2)" {
+		t.Errorf("code type = %s, want varchar(2)", got)
+	}
+	wantWarnings := []string{"column note sized to varchar(5), the p98 length; 2 values are longer, up to 8000, and would be truncated (lines 8, 51)"}
+	if !reflect.DeepEqual(result.Warnings, wantWarnings) {
+		t.Errorf("Warnings = %q, want %q", result.Warnings, wantWarnings)
+	}
+
+	// Stats still report the longest value
+	stats := newLengthStats(result.Columns[1], analyzer)
+	if want := (lengthStats{P50: 5, P95: 5, P99: 20, Max: 8000}); stats == nil || *stats != want {
+		t.Errorf("newLengthStats() = %+v, want %+v", stats, want)
+	}
+}
+
+func TestLengthSemantics(t *testing.T) {
+	// Each city and greeting has multibyte characters; the codes are ASCII
+	tests := []struct {
+		semantics string
+		want      map[string]int
+	}{
+		{"bytes", map[string]int{"city": 10, "greeting": 15, "code": 2}},
+		{"chars", map[string]int{"city": 9, "greeting": 11, "code": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.semantics, func(t *testing.T) {
+			file, err := os.Open("testdata/multibyte.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, LengthSemantics: tt.semantics}
+			result, err := analyzeFileTypes(file, opts, &dbtypes.PostgreSQLAnalyzer{})
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			for _, col := range result.Columns[1:] {
+				if col.MaxLength != tt.want[col.Name] {
+					t.Errorf("column %s: max length = %d, want %d", col.Name, col.MaxLength, tt.want[col.Name])
+				}
+				if got := lengthPercentile(col.LengthCounts, 100); got != tt.want[col.Name] {
+					t.Errorf("column %s: longest length in the histogram = %d, want %d", col.Name, got, tt.want[col.Name])
+				}
+			}
+			// Both measures are kept whichever is used
+			if greeting := result.Columns[2]; greeting.MaxBytes != 15 || greeting.MaxChars != 11 {
+				t.Errorf("greeting: %d bytes, %d characters, want 15 and 11", greeting.MaxBytes, greeting.MaxChars)
+			}
+		})
+	}
+}
+
+func TestDefaultLengthSemantics(t *testing.T) {
+	for _, analyzer := range []dbtypes.TypeAnalyzer{&dbtypes.PostgreSQLAnalyzer{}, &dbtypes.SnowflakeAnalyzer{}} {
+		if got := lengthSemantics(analyzer); got != "chars" {
+			t.Errorf("lengthSemantics(%T) = %s, want chars", analyzer, got)
+		}
+	}
+}
+
+func TestQuotedFieldLengths(t *testing.T) {
+	// Lengths are those of the values as loaded: without the enclosing
+	// quotes, with doubled quotes collapsed, and with the quotes of a file
+	// read with -quotes none, which loads them as they are
+	tests := []struct {
+		name      string
+		field     string
+		quotes    string
+		semantics string
+		expected  int
+	}{
+		{"doubled quotes", `"She said ""hi"""`, "double", "chars", 13},
+		{"embedded delimiter", `"12 Main St, Apt 4"`, "double", "chars", 17},
+		{"single quotes", `'It''s'`, "single", "chars", 4},
+		{"stray quotes", `say "hi"`, "none", "chars", 8},
+		{"quoted multibyte in chars", `"café, bar"`, "double", "chars", 9},
+		{"quoted multibyte in bytes", `"café, bar"`, "double", "bytes", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "id,value\n1," + tt.field + "\n"
+			opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: tt.quotes}, LengthSemantics: tt.semantics}
+			result, err := analyzeFileTypes(strings.NewReader(input), opts, &dbtypes.PostgreSQLAnalyzer{})
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			if got := result.Columns[1].MaxLength; got != tt.expected {
+				t.Errorf("MaxLength of %s = %d, want %d", tt.field, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckLengthThresholds(t *testing.T) {
+	result := &fileAnalysis{Columns: []columnAnalysis{{Name: "code", MaxBytes: 12}, {Name: "doc", MaxBytes: 5000}}}
+	tests := []struct {
+		analyzer dbtypes.TypeAnalyzer
+		want     []string
+	}{
+		{&dbtypes.PostgreSQLAnalyzer{}, []string{"column doc holds values of up to 5,000 bytes, over the 2,704 bytes of a PostgreSQL btree index entry; an index on the column will fail"}},
+		{&dbtypes.SnowflakeAnalyzer{}, nil},
+	}
+	for _, tt := range tests {
+		result.Columns[1].OverLimits = nil
+		got := checkLengthThresholds(result, tt.analyzer)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("checkLengthThresholds(%T) = %q, want %q", tt.analyzer, got, tt.want)
+		}
+		if len(result.Columns[0].OverLimits) > 0 || len(result.Columns[1].OverLimits) != len(tt.want) {
+			t.Errorf("%T: OverLimits = %q, %q", tt.analyzer, result.Columns[0].OverLimits, result.Columns[1].OverLimits)
+		}
+	}
+}
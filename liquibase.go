@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"file2ddl/dbtypes"
+)
+
+// liquibaseSchemaLocation points validators at the changelog XSD
+const liquibaseSchemaLocation = "http://www.liquibase.org/xml/ns/dbchangelog " +
+	"http://www.liquibase.org/xml/ns/dbchangelog/dbchangelog-4.20.xsd"
+
+// liquibaseChangeLog is a Liquibase databaseChangeLog document
+type liquibaseChangeLog struct {
+	XMLName        xml.Name           `xml:"databaseChangeLog"`
+	Namespace      string             `xml:"xmlns,attr"`
+	XSI            string             `xml:"xmlns:xsi,attr"`
+	SchemaLocation string             `xml:"xsi:schemaLocation,attr"`
+	ChangeSet      liquibaseChangeSet `xml:"changeSet"`
+}
+
+// liquibaseChangeSet creates the table unless it already exists
+type liquibaseChangeSet struct {
+	ID            string                 `xml:"id,attr"`
+	Author        string                 `xml:"author,attr"`
+	PreConditions liquibasePreConditions `xml:"preConditions"`
+	CreateTable   liquibaseCreateTable   `xml:"createTable"`
+}
+
+// liquibasePreConditions marks the changeSet as ran when the table exists
+type liquibasePreConditions struct {
+	OnFail      string `xml:"onFail,attr"`
+	TableExists struct {
+		TableName string `xml:"tableName,attr"`
+	} `xml:"not>tableExists"`
+}
+
+type liquibaseCreateTable struct {
+	TableName string            `xml:"tableName,attr"`
+	Columns   []liquibaseColumn `xml:"column"`
+}
+
+type liquibaseColumn struct {
+	Name        string               `xml:"name,attr"`
+	Type        string               `xml:"type,attr"`
+	Remarks     string               `xml:"remarks,attr,omitempty"`
+	Constraints *liquibaseConstraint `xml:"constraints"`
+}
+
+type liquibaseConstraint struct {
+	PrimaryKey bool `xml:"primaryKey,attr,omitempty"`
+	Nullable   bool `xml:"nullable,attr"`
+}
+
+// printLiquibase writes the column report as a Liquibase changelog with a
+// single changeSet creating the table. Columns without empty values are
+// NOT NULL, and the primaryKey column, if any, must be one of the file's
+// columns.
+func printLiquibase(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table, primaryKey, id, author string) error {
+	changeLog := liquibaseChangeLog{
+		Namespace:      "http://www.liquibase.org/xml/ns/dbchangelog",
+		XSI:            "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: liquibaseSchemaLocation,
+		ChangeSet: liquibaseChangeSet{
+			ID:            id,
+			Author:        author,
+			PreConditions: liquibasePreConditions{OnFail: "MARK_RAN"},
+			CreateTable:   liquibaseCreateTable{TableName: table},
+		},
+	}
+	changeLog.ChangeSet.PreConditions.TableExists.TableName = table
+
+	foundKey := false
+	for _, col := range result.Columns {
+		column := liquibaseColumn{Name: col.Name, Type: columnTypeName(col, analyzer)}
+		if col.Stats != nil {
+			column.Remarks = col.Stats.String()
+		}
+		switch {
+		case col.Name == primaryKey:
+			column.Constraints = &liquibaseConstraint{PrimaryKey: true}
+			foundKey = true
+		case col.notNull(result.RowCount):
+			column.Constraints = &liquibaseConstraint{}
+		}
+		changeLog.ChangeSet.CreateTable.Columns = append(changeLog.ChangeSet.CreateTable.Columns, column)
+	}
+	if primaryKey != "" && !foundKey {
+		return fmt.Errorf("primary key column %s not found", primaryKey)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(changeLog); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
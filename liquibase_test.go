@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestPrintLiquibase(t *testing.T) {
+	file, err := os.Open("testdata/orders.csv")
+	if err != nil {
+		t.Fatalf("Failed to open test file: %v", err)
+	}
+	defer file.Close()
+
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(file, analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	if err := printLiquibase(&buf, result, analyzer, "orders", "id", "create-orders", "etl"); err != nil {
+		t.Fatalf("printLiquibase() error = %v, want nil", err)
+	}
+	golden, err := os.ReadFile("testdata/orders_liquibase.golden")
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if buf.String() != string(golden) {
+		t.Errorf("printLiquibase() =\n%s\nwant\n%s", buf.String(), golden)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+// parseIntegerLiteral reads a 0x-prefixed hex or 0b-prefixed binary integer
+// literal that fits in a bigint, returning its value and "hex" or "binary"
+func parseIntegerLiteral(value string) (int64, string, bool) {
+	if len(value) < 3 || value[0] != '0' {
+		return 0, "", false
+	}
+	var base int
+	var digits, name string
+	switch value[1] {
+	case 'x', 'X':
+		base, digits, name = 16, "0123456789abcdefABCDEF", "hex"
+	case 'b', 'B':
+		base, digits, name = 2, "01", "binary"
+	default:
+		return 0, "", false
+	}
+	// ParseInt would take underscores and signs too
+	if strings.Trim(value[2:], digits) != "" {
+		return 0, "", false
+	}
+	n, err := strconv.ParseInt(value[2:], base, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return n, name, true
+}
+
+// observeIntegerLiteral records the value if it is a hex or binary integer
+// literal, in the column's range of literal values
+func (c *columnAnalysis) observeIntegerLiteral(value string) {
+	n, base, ok := parseIntegerLiteral(value)
+	if !ok {
+		return
+	}
+	if c.HexLiterals+c.BinaryLiterals == 0 || n < c.LiteralMin {
+		c.LiteralMin = n
+	}
+	if c.HexLiterals+c.BinaryLiterals == 0 || n > c.LiteralMax {
+		c.LiteralMax = n
+	}
+	if base == "hex" {
+		c.HexLiterals++
+	} else {
+		c.BinaryLiterals++
+	}
+}
+
+// detectIntegerLiteralColumns reclassifies string columns as the smallest
+// integer type holding their values when every value is a hex or binary
+// literal or a decimal integer, and at least one is a literal. The file
+// still holds the literals, so they need converting when loaded. Snowflake
+// has no function reading base 2, so binary literals are left as strings.
+func detectIntegerLiteralColumns(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) {
+	_, snowflake := analyzer.(*dbtypes.SnowflakeAnalyzer)
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		if !isStringType(analyzer.GetTypes()[col.TypeIndex].Name) {
+			continue
+		}
+		literals := col.HexLiterals + col.BinaryLiterals
+		if literals == 0 || literals+col.IntCount != result.RowCount-col.EmptyCount {
+			continue
+		}
+		if snowflake && col.BinaryLiterals > 0 {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "DEBUG: field %s not reclassified as an integer: Snowflake cannot convert binary literals\n", col.Name)
+			}
+			continue
+		}
+
+		low, high := col.LiteralMin, col.LiteralMax
+		if col.IntCount > 0 {
+			low, high = min(low, col.IntMin), max(high, col.IntMax)
+		}
+		integerType := integerTypeIndex(analyzer, low, high)
+		if integerType < 0 {
+			continue
+		}
+		switch {
+		case col.BinaryLiterals == 0:
+			col.LiteralBase = "hex"
+		case col.HexLiterals == 0:
+			col.LiteralBase = "binary"
+		default:
+			col.LiteralBase = "hex and binary"
+		}
+		col.TypeIndex = integerType
+		col.IntMin, col.IntMax, col.IntCount = low, high, col.IntCount+literals
+		col.MaxLength, col.MaxBytes, col.MaxChars = 0, 0, 0
+		col.LengthCounts, col.LongValues = nil, nil
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: field %s reclassified as %s (%s literals)\n", col.Name, analyzer.GetTypes()[integerType].Name, col.LiteralBase)
+		}
+	}
+}
+
+// integerTypeIndex returns the index of the flavor's narrowest integer type
+// holding the range, or -1 if it has none
+func integerTypeIndex(analyzer dbtypes.TypeAnalyzer, low, high int64) int {
+	isTinyInt := analyze.IsTinyInt
+	if index := typeIndex(analyzer, "tinyint"); index >= 0 && analyzer.GetTypes()[index].Unsigned {
+		isTinyInt = analyze.IsUnsignedTinyInt
+	}
+	for _, fits := range []struct {
+		name string
+		is   func(string) bool
+	}{
+		{"tinyint", isTinyInt},
+		{"smallint", analyze.IsSmallInt},
+		{"integer", analyze.IsInteger},
+		{"bigint", analyze.IsBigInt},
+	} {
+		if index := typeIndex(analyzer, fits.name); index >= 0 && fits.is(strconv.FormatInt(low, 10)) && fits.is(strconv.FormatInt(high, 10)) {
+			return index
+		}
+	}
+	return -1
+}
+
+// literalNote tells how the values of a column of integer literals are
+// written, since they need a base conversion when loaded
+func literalNote(col columnAnalysis) string {
+	note := col.LiteralBase + " literals"
+	if col.IntCount > col.HexLiterals+col.BinaryLiterals {
+		note += " mixed with decimal"
+	}
+	return note + ", converted to base 10 when loading"
+}
+
+// literalExpression converts the text of a column of integer literals to a
+// bigint, telling the bases apart by prefix unless every value was seen to
+// be written in the one base
+func literalExpression(col columnAnalysis, value string, snowflake bool) string {
+	hex := fmt.Sprintf("CAST(CAST('x' || lpad(substring(%s, 3), 16, '0') AS bit(64)) AS bigint)", value)
+	binary := fmt.Sprintf("CAST(CAST(lpad(substring(%s, 3), 64, '0') AS bit(64)) AS bigint)", value)
+	if snowflake {
+		hex = fmt.Sprintf("TO_NUMBER(SUBSTR(%s, 3), '%s')", value, strings.Repeat("X", 16))
+	}
+	if literals := col.HexLiterals + col.BinaryLiterals; literals > 0 && col.IntCount == literals {
+		switch col.LiteralBase {
+		case "hex":
+			return hex
+		case "binary":
+			return binary
+		}
+	}
+	var b strings.Builder
+	b.WriteString("CASE")
+	if col.LiteralBase != "binary" {
+		fmt.Fprintf(&b, " WHEN %s ILIKE '0x%%' THEN %s", value, hex)
+	}
+	if col.LiteralBase != "hex" {
+		fmt.Fprintf(&b, " WHEN %s ILIKE '0b%%' THEN %s", value, binary)
+	}
+	fmt.Fprintf(&b, " ELSE CAST(%s AS bigint) END", value)
+	return b.String()
+}
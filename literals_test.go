@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestParseIntegerLiteral(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+		base  string
+		ok    bool
+	}{
+		{"0x1A2B", 0x1a2b, "hex", true},
+		{"0XFF", 255, "hex", true},
+		{"0b1010", 10, "binary", true},
+		{"0x7FFFFFFFFFFFFFFF", 1<<63 - 1, "hex", true},
+		{"0x8000000000000000", 0, "", false}, // over a bigint
+		{"0x", 0, "", false},
+		{"0x1_000", 0, "", false},
+		{"0b102", 0, "", false},
+		{"-0x10", 0, "", false},
+		{"1234", 0, "", false},
+		{"0o17", 0, "", false},
+	}
+	for _, tt := range tests {
+		got, base, ok := parseIntegerLiteral(tt.value)
+		if got != tt.want || base != tt.base || ok != tt.ok {
+			t.Errorf("parseIntegerLiteral(%q) = %d, %q, %v, want %d, %q, %v", tt.value, got, base, ok, tt.want, tt.base, tt.ok)
+		}
+	}
+}
+
+func TestDetectIntegerLiterals(t *testing.T) {
+	input := "small,wide,mixed,bits,text\n0x1A,0xFFFFFFFF,0x10,0b1010,0x1\n0xff,0x1,42,0b1,abc\n,0x2,-7,0b0,0x2\n"
+	tests := []struct {
+		name     string
+		analyzer dbtypes.TypeAnalyzer
+		want     []string
+		bases    []string
+	}{
+		{
+			name:     "postgresql",
+			analyzer: &dbtypes.PostgreSQLAnalyzer{},
+			want:     []string{"smallint", "bigint", "smallint", "smallint", "varchar"},
+			bases:    []string{"hex", "hex", "hex", "binary", ""},
+		},
+		{
+			name:     "snowflake",
+			analyzer: &dbtypes.SnowflakeAnalyzer{},
+			want:     []string{"smallint", "bigint", "smallint", "varchar", "varchar"},
+			bases:    []string{"hex", "hex", "hex", "", ""},
+		},
+		{
+			name:     "vertica",
+			analyzer: &dbtypes.VerticaAnalyzer{},
+			want:     []string{"bigint", "bigint", "bigint", "bigint", "varchar"},
+			bases:    []string{"hex", "hex", "hex", "binary", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}, DetectHex: true}, tt.analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			for i, col := range result.Columns {
+				if got := tt.analyzer.GetTypes()[col.TypeIndex].Name; got != tt.want[i] || col.LiteralBase != tt.bases[i] {
+					t.Errorf("column %s = %s (%q), want %s (%q)", col.Name, got, col.LiteralBase, tt.want[i], tt.bases[i])
+				}
+			}
+			if mixed := result.Columns[2]; mixed.IntMin != -7 || mixed.IntMax != 42 {
+				t.Errorf("mixed range = %d to %d, want -7 to 42", mixed.IntMin, mixed.IntMax)
+			}
+			if got, want := literalNote(result.Columns[2]), "hex literals mixed with decimal, converted to base 10 when loading"; got != want {
+				t.Errorf("literalNote() = %q, want %q", got, want)
+			}
+		})
+	}
+
+	// Without the option hex values are strings
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}}, &dbtypes.PostgreSQLAnalyzer{})
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if result.Columns[0].LiteralBase != "" || result.Columns[0].TypeIndex != typeIndex(&dbtypes.PostgreSQLAnalyzer{}, "varchar") {
+		t.Errorf("column small reclassified without -detect-hex")
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"file2ddl/dbtypes"
+)
+
+// splitNullTokens splits a -null list, dropping the empty token, since
+// empty fields are nulls anyway
+func splitNullTokens(spec string) []string {
+	var tokens []string
+	for _, token := range strings.Split(spec, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// stageOptions says where Snowflake loads the file from and what its COPY
+// INTO does with bad rows and loaded files, and where Exasol's IMPORT reads
+// it
+type stageOptions struct {
+	File    string // path of the file as given, which Exasol reads from the client
+	Stage   string // stage holding the file, e.g. @landing/orders/; "" for the table's stage
+	Pattern string // regular expression matching the staged files to load, "" for all
+	OnError string // ON_ERROR of the COPY INTO, e.g. CONTINUE; "" for ABORT_STATEMENT
+	Purge   bool   // remove the files from the stage once loaded
+}
+
+// onErrorPattern matches the ON_ERROR values of Snowflake's COPY INTO
+var onErrorPattern = regexp.MustCompile(`^(?i:CONTINUE|ABORT_STATEMENT|SKIP_FILE(_[0-9]+%?)?)$`)
+
+// filePattern returns a COPY INTO pattern matching the staged copy of the
+// named file, which PUT compresses with gzip by default
+func filePattern(name string) string {
+	name = strings.TrimSuffix(path.Base(name), ".gz")
+	return ".*" + regexp.QuoteMeta(name) + "([.]gz)?"
+}
+
+// loadSQL returns a statement loading the file into the table with the
+// settings it was analyzed with, so that the values the analysis read as
+// nulls load as nulls: PostgreSQL gets a COPY from STDIN, and Snowflake a
+// file format holding the settings and a COPY INTO using it to load from a
+// stage. Vertica gets a COPY from the client's STDIN, and Exasol an IMPORT
+// of the local file. PostgreSQL, Vertica and Exasol read a single null
+// string, so the most frequent of the null tokens is used and the warnings
+// name the rest.
+func loadSQL(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table string, opts analysisOptions, stage stageOptions) (string, []string, error) {
+	if opts.DelimiterRegex != nil {
+		return "", nil, fmt.Errorf("-with-load needs -delim, since loaders do not split fields by a regular expression")
+	}
+	// Fields are loaded by position, so the columns are listed in file
+	// order whatever -column-order says
+	fileOrder := slices.Clone(result.Columns)
+	slices.SortStableFunc(fileOrder, func(a, b columnAnalysis) int {
+		return a.Ordinal - b.Ordinal
+	})
+	var columns []string
+	for _, col := range fileOrder {
+		columns = append(columns, flavorIdentifier(analyzer, col.Name))
+	}
+	quote := map[string]string{"single": "'", "double": `"`}[opts.Quotes]
+
+	if _, ok := analyzer.(*dbtypes.SnowflakeAnalyzer); ok {
+		// Backslashes are data to the analysis, not escapes
+		options := []string{"TYPE = CSV", "FIELD_DELIMITER = " + escapedLiteral(opts.Delimiter), "ESCAPE_UNENCLOSED_FIELD = NONE"}
+		if opts.RecordSeparator != "" {
+			options = append(options, "RECORD_DELIMITER = "+escapedLiteral(opts.RecordSeparator))
+		}
+		if !result.NoHeader {
+			options = append(options, "SKIP_HEADER = 1")
+		}
+		if quote != "" {
+			options = append(options, "FIELD_OPTIONALLY_ENCLOSED_BY = "+escapedLiteral(quote))
+		}
+		// Unenclosed empty fields are nulls by EMPTY_FIELD_AS_NULL; an
+		// enclosed one only by NULL_IF
+		var nulls []string
+		if quote != "" && !opts.QuotedEmpty {
+			nulls = append(nulls, "''")
+		}
+		for _, token := range opts.NullTokens {
+			nulls = append(nulls, escapedLiteral(token))
+		}
+		if len(nulls) > 0 {
+			options = append(options, fmt.Sprintf("NULL_IF = (%s)", strings.Join(nulls, ", ")))
+		}
+		options = append(options, "EMPTY_FIELD_AS_NULL = TRUE")
+
+		format := quoteIdentifier(table + "_format")
+		from := stage.Stage
+		if from == "" {
+			from = "@%" + quoteIdentifier(table)
+		}
+		onError := strings.ToUpper(stage.OnError)
+		if onError == "" {
+			onError = "ABORT_STATEMENT"
+		}
+		if strings.Contains(onError, "%") {
+			onError = "'" + onError + "'"
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "CREATE FILE FORMAT %s\n    %s;\n\n", format, strings.Join(options, "\n    "))
+		fmt.Fprintf(&b, "COPY INTO %s (%s)\nFROM %s\n", quoteIdentifier(table), strings.Join(columns, ", "), from)
+		if stage.Pattern != "" {
+			fmt.Fprintf(&b, "PATTERN = %s\n", escapedLiteral(stage.Pattern))
+		}
+		fmt.Fprintf(&b, "FILE_FORMAT = (FORMAT_NAME = %s)\nON_ERROR = %s\nPURGE = %s;\n", format, onError, strings.ToUpper(fmt.Sprint(stage.Purge)))
+		return b.String(), nil, nil
+	}
+
+	if _, ok := analyzer.(*dbtypes.VerticaAnalyzer); ok {
+		return verticaLoadSQL(result, table, columns, quote, opts)
+	}
+	if _, ok := analyzer.(*dbtypes.ExasolAnalyzer); ok {
+		return exasolLoadSQL(result, analyzer, table, columns, stage.File, quote, opts)
+	}
+
+	if opts.RecordSeparator != "" {
+		return "", nil, fmt.Errorf("-with-load cannot load a file with -record-sep into postgresql, whose COPY only ends rows at newlines")
+	}
+	if len(opts.Delimiter) != 1 {
+		return "", nil, fmt.Errorf("-with-load needs a single-byte delimiter for postgresql, whose COPY does not take %q", opts.Delimiter)
+	}
+	null, warnings := nullString(result, opts.NullTokens, "COPY")
+	options := []string{"FORMAT csv", "DELIMITER " + postgresLiteral(opts.Delimiter)}
+	if !result.NoHeader {
+		options = append(options, "HEADER true")
+	}
+	// CSV mode always has a quote character, so a file read without quotes
+	// gets one that text does not hold, and loads its quotes as they are
+	if quote == "" {
+		options = append(options, "QUOTE "+postgresLiteral("\b"))
+	} else {
+		options = append(options, "QUOTE "+postgresLiteral(quote))
+	}
+	options = append(options, "NULL "+postgresLiteral(null))
+	// COPY reads quoted values as strings even when they match the null
+	// string, unless told otherwise
+	if quote != "" && (null != "" || !opts.QuotedEmpty) && len(columns) > 0 {
+		options = append(options, fmt.Sprintf("FORCE_NULL (%s)", strings.Join(columns, ", ")))
+	}
+	return fmt.Sprintf("COPY %s (%s)\nFROM STDIN WITH (%s);\n",
+		quoteIdentifier(table), strings.Join(columns, ", "), strings.Join(options, ", ")), warnings, nil
+}
+
+// verticaLoadSQL returns a Vertica COPY loading the file from the client's
+// STDIN. Backslashes are data to the analysis, so COPY is told there is no
+// escape character.
+func verticaLoadSQL(result *fileAnalysis, table string, columns []string, quote string, opts analysisOptions) (string, []string, error) {
+	if len(opts.Delimiter) != 1 {
+		return "", nil, fmt.Errorf("-with-load needs a single-byte delimiter for vertica, whose COPY does not take %q", opts.Delimiter)
+	}
+	null, warnings := nullString(result, opts.NullTokens, "COPY")
+	options := []string{"DELIMITER " + postgresLiteral(opts.Delimiter)}
+	if quote != "" {
+		options = append(options, "ENCLOSED BY "+postgresLiteral(quote))
+	}
+	options = append(options, "NO ESCAPE", "NULL "+postgresLiteral(null))
+	if opts.RecordSeparator != "" {
+		options = append(options, "RECORD TERMINATOR "+postgresLiteral(opts.RecordSeparator))
+	}
+	if !result.NoHeader {
+		options = append(options, "SKIP 1")
+	}
+	options = append(options, "ABORT ON ERROR")
+	return fmt.Sprintf("COPY %s (%s)\nFROM LOCAL STDIN\n%s;\n",
+		quoteIdentifier(table), strings.Join(columns, ", "), strings.Join(options, "\n")), warnings, nil
+}
+
+// exasolLoadSQL returns an Exasol IMPORT of the local file, as EXAplus and
+// the JDBC driver send it. Exasol reads empty fields as nulls, so only a
+// null token needs naming, and a file without quotes gets an empty column
+// delimiter, so its quote characters load as they are.
+func exasolLoadSQL(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table string, columns []string, file, quote string, opts analysisOptions) (string, []string, error) {
+	if opts.RecordSeparator != "" {
+		return "", nil, fmt.Errorf("-with-load cannot load a file with -record-sep into exasol, whose IMPORT only ends rows at newlines")
+	}
+	options := []string{"COLUMN SEPARATOR = " + quoteLiteral(opts.Delimiter), "COLUMN DELIMITER = " + quoteLiteral(quote)}
+	if !result.NoHeader {
+		options = append(options, "SKIP = 1")
+	}
+	// The empty field is always a null, so the null string is the most
+	// frequent token
+	null := ""
+	for _, token := range opts.NullTokens {
+		if n := result.NullCounts[token]; n > 0 && (null == "" || n > result.NullCounts[null]) {
+			null = token
+		}
+	}
+	var warnings []string
+	for _, token := range opts.NullTokens {
+		if n := result.NullCounts[token]; token != null && n > 0 {
+			warnings = append(warnings, fmt.Sprintf("IMPORT reads a single null string, %q, so %s %q values will not load as nulls", null, groupDigits(n), token))
+		}
+	}
+	if null != "" {
+		options = append(options, "NULL = "+quoteLiteral(null))
+	}
+	return fmt.Sprintf("IMPORT INTO %s (%s)\nFROM LOCAL CSV FILE %s\n%s;\n",
+		flavorIdentifier(analyzer, table), strings.Join(columns, ", "), quoteLiteral(file), strings.Join(options, "\n")), warnings, nil
+}
+
+// nullString picks the null string of a reader taking a single one, such
+// as a PostgreSQL COPY: the empty field or null token the file used most,
+// the empty field on a tie. The warnings count the values of the other
+// tokens, which will load as strings.
+func nullString(result *fileAnalysis, tokens []string, reader string) (string, []string) {
+	null := ""
+	for _, token := range tokens {
+		if result.NullCounts[token] > result.NullCounts[null] {
+			null = token
+		}
+	}
+	var warnings []string
+	for _, token := range append([]string{""}, tokens...) {
+		if n := result.NullCounts[token]; token != null && n > 0 {
+			name := fmt.Sprintf("%q", token)
+			if token == "" {
+				name = "empty"
+			}
+			warnings = append(warnings, fmt.Sprintf("%s reads a single null string, %q, so %s %s values will not load as nulls", reader, null, groupDigits(n), name))
+		}
+	}
+	return null, warnings
+}
+
+// postgresLiteral quotes a string literal of a COPY option, as an escape
+// string when it holds control characters such as a tab
+func postgresLiteral(s string) string {
+	if strings.IndexFunc(s, unicode.IsControl) < 0 {
+		return quoteLiteral(s)
+	}
+	return "E" + escapedLiteral(s)
+}
+
+// mariadbLiteral quotes a string literal for MariaDB, which reads the
+// backslashes of every literal as escapes
+func mariadbLiteral(s string) string {
+	return quoteLiteral(strings.ReplaceAll(s, `\`, `\\`))
+}
+
+// escapedLiteral quotes a string literal with backslash escapes, as
+// Snowflake reads every literal and PostgreSQL an escape string
+func escapedLiteral(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '\'':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case unicode.IsControl(r) && r < utf8.RuneSelf:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
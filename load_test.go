@@ -0,0 +1,201 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestNullTokens(t *testing.T) {
+	input := "id,amount,note\n1,1.5,NULL\n2,N/A,\n3,NULL,NULL\n"
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double", NullTokens: splitNullTokens("NULL, N/A")}}
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	amount := result.Columns[1]
+	if got := analyzer.GetTypes()[amount.TypeIndex].Name; got != "numeric" || amount.EmptyCount != 2 {
+		t.Errorf("amount = %s with %d nulls, want numeric with 2", got, amount.EmptyCount)
+	}
+	if want := map[string]int{"NULL": 3, "N/A": 1, "": 1}; !reflect.DeepEqual(result.NullCounts, want) {
+		t.Errorf("NullCounts = %v, want %v", result.NullCounts, want)
+	}
+
+	tests := []struct {
+		name     string
+		analyzer dbtypes.TypeAnalyzer
+		expected string
+		warnings []string
+	}{
+		{
+			name:     "postgresql",
+			analyzer: analyzer,
+			expected: `COPY events (id, amount, note)
+FROM STDIN WITH (FORMAT csv, DELIMITER ',', HEADER true, QUOTE '"', NULL 'NULL', FORCE_NULL (id, amount, note));
+`,
+			warnings: []string{
+				`COPY reads a single null string, "NULL", so 1 empty values will not load as nulls`,
+				`COPY reads a single null string, "NULL", so 1 "N/A" values will not load as nulls`,
+			},
+		},
+		{
+			name:     "vertica",
+			analyzer: &dbtypes.VerticaAnalyzer{},
+			expected: `COPY events (id, amount, note)
+FROM LOCAL STDIN
+DELIMITER ','
+ENCLOSED BY '"'
+NO ESCAPE
+NULL 'NULL'
+SKIP 1
+ABORT ON ERROR;
+`,
+			warnings: []string{
+				`COPY reads a single null string, "NULL", so 1 empty values will not load as nulls`,
+				`COPY reads a single null string, "NULL", so 1 "N/A" values will not load as nulls`,
+			},
+		},
+		{
+			name:     "snowflake",
+			analyzer: &dbtypes.SnowflakeAnalyzer{},
+			expected: `CREATE FILE FORMAT events_format
+    TYPE = CSV
+    FIELD_DELIMITER = ','
+    ESCAPE_UNENCLOSED_FIELD = NONE
+    SKIP_HEADER = 1
+    FIELD_OPTIONALLY_ENCLOSED_BY = '"'
+    NULL_IF = ('', 'NULL', 'N/A')
+    EMPTY_FIELD_AS_NULL = TRUE;
+
+COPY INTO events (id, amount, note)
+FROM @%events
+FILE_FORMAT = (FORMAT_NAME = events_format)
+ON_ERROR = ABORT_STATEMENT
+PURGE = FALSE;
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings, err := loadSQL(result, tt.analyzer, "events", opts, stageOptions{})
+			if err != nil {
+				t.Fatalf("loadSQL() error = %v, want nil", err)
+			}
+			if got != tt.expected {
+				t.Errorf("loadSQL() =\n%s\nwant\n%s", got, tt.expected)
+			}
+			if !reflect.DeepEqual(warnings, tt.warnings) {
+				t.Errorf("loadSQL() warnings = %q, want %q", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestLoadSQLWithoutTokens(t *testing.T) {
+	result := &fileAnalysis{NoHeader: true, Columns: []columnAnalysis{{Name: "id"}, {Name: "note"}}}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: "\t", Quotes: "double"}, QuotedEmpty: true}
+	want := "COPY t (id, note)\nFROM STDIN WITH (FORMAT csv, DELIMITER E'\\t', QUOTE '\"', NULL '');\n"
+	got, warnings, err := loadSQL(result, &dbtypes.PostgreSQLAnalyzer{}, "t", opts, stageOptions{})
+	if err != nil || got != want || warnings != nil {
+		t.Errorf("loadSQL() = %q, %q, %v, want %q", got, warnings, err, want)
+	}
+
+	opts.Quotes = "none"
+	want = "COPY t (id, note)\nFROM STDIN WITH (FORMAT csv, DELIMITER E'\\t', QUOTE E'\\x08', NULL '');\n"
+	if got, _, _ := loadSQL(result, &dbtypes.PostgreSQLAnalyzer{}, "t", opts, stageOptions{}); got != want {
+		t.Errorf("loadSQL() without quotes = %q, want %q", got, want)
+	}
+
+	opts.RecordSeparator = "\x1e"
+	want = "COPY t (id, note)\nFROM LOCAL STDIN\nDELIMITER E'\\t'\nNO ESCAPE\nNULL ''\nRECORD TERMINATOR E'\\x1e'\nABORT ON ERROR;\n"
+	if got, _, _ := loadSQL(result, &dbtypes.VerticaAnalyzer{}, "t", opts, stageOptions{}); got != want {
+		t.Errorf("loadSQL() into vertica with a record separator = %q, want %q", got, want)
+	}
+	opts.RecordSeparator = ""
+
+	opts.Delimiter = "~|~"
+	for _, analyzer := range []dbtypes.TypeAnalyzer{&dbtypes.PostgreSQLAnalyzer{}, &dbtypes.VerticaAnalyzer{}} {
+		if _, _, err := loadSQL(result, analyzer, "t", opts, stageOptions{}); err == nil {
+			t.Errorf("loadSQL() into %T with a multi-character delimiter error = nil, want an error", analyzer)
+		}
+	}
+}
+
+func TestLoadSQLExasol(t *testing.T) {
+	input := "id,date,note\n1,2024-03-01,NULL\n2,2024-03-02,N/A\n3,2024-03-03,NULL\n4,2024-03-04,\n"
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ";", Quotes: "double", NullTokens: splitNullTokens("NULL,N/A")}}
+	input = strings.ReplaceAll(input, ",", ";")
+	analyzer := &dbtypes.ExasolAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	got, warnings, err := loadSQL(result, analyzer, "events", opts, stageOptions{File: "data/events.csv"})
+	if err != nil {
+		t.Fatalf("loadSQL() error = %v, want nil", err)
+	}
+	want := `IMPORT INTO EVENTS (ID, "DATE", NOTE)
+FROM LOCAL CSV FILE 'data/events.csv'
+COLUMN SEPARATOR = ';'
+COLUMN DELIMITER = '"'
+SKIP = 1
+NULL = 'NULL';
+`
+	if got != want {
+		t.Errorf("loadSQL() =\n%s\nwant\n%s", got, want)
+	}
+	// Empty fields are nulls to Exasol whatever the null string
+	wantWarnings := []string{`IMPORT reads a single null string, "NULL", so 1 "N/A" values will not load as nulls`}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("loadSQL() warnings = %q, want %q", warnings, wantWarnings)
+	}
+
+	result.NoHeader = true
+	opts.Quotes, opts.NullTokens = "none", nil
+	want = "IMPORT INTO EVENTS (ID, \"DATE\", NOTE)\nFROM LOCAL CSV FILE 'events.csv'\nCOLUMN SEPARATOR = ';'\nCOLUMN DELIMITER = '';\n"
+	if got, _, _ := loadSQL(result, analyzer, "events", opts, stageOptions{File: "events.csv"}); got != want {
+		t.Errorf("loadSQL() without quotes = %q, want %q", got, want)
+	}
+	opts.RecordSeparator = "\x1e"
+	if _, _, err := loadSQL(result, analyzer, "events", opts, stageOptions{}); err == nil {
+		t.Errorf("loadSQL() into exasol with a record separator error = nil, want an error")
+	}
+}
+
+func TestLoadSQLStage(t *testing.T) {
+	result := &fileAnalysis{NoHeader: true, Columns: []columnAnalysis{{Name: "id"}, {Name: "note"}}}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}
+	stage := stageOptions{Stage: "@landing/orders/", Pattern: filePattern("data/orders.csv.gz"), OnError: "skip_file_5%", Purge: true}
+	want := `CREATE FILE FORMAT orders_format
+    TYPE = CSV
+    FIELD_DELIMITER = '|'
+    ESCAPE_UNENCLOSED_FIELD = NONE
+    EMPTY_FIELD_AS_NULL = TRUE;
+
+COPY INTO orders (id, note)
+FROM @landing/orders/
+PATTERN = '.*orders\\.csv([.]gz)?'
+FILE_FORMAT = (FORMAT_NAME = orders_format)
+ON_ERROR = 'SKIP_FILE_5%'
+PURGE = TRUE;
+`
+	got, _, err := loadSQL(result, &dbtypes.SnowflakeAnalyzer{}, "orders", opts, stage)
+	if err != nil || got != want {
+		t.Errorf("loadSQL() = %s, %v, want %s", got, err, want)
+	}
+}
+
+func TestOnErrorPattern(t *testing.T) {
+	for value, want := range map[string]bool{
+		"continue": true, "ABORT_STATEMENT": true, "skip_file": true, "skip_file_10": true, "skip_file_10%": true,
+		"skip": false, "skip_file_%": false, "abort": false,
+	} {
+		if got := onErrorPattern.MatchString(value); got != want {
+			t.Errorf("onErrorPattern.MatchString(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
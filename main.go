@@ -1,334 +1,2016 @@
 package main
 
 import (
-	"bufio"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"file2ddl/analyze"
 	"file2ddl/dbtypes"
 )
 
 var verbose bool
 
+// toolVersion is reported in generated DDL comments; release builds set it
+// with -ldflags "-X main.toolVersion=..."
+var toolVersion = "dev"
+
+// outputFormats are the values -format takes
+var outputFormats = []string{"text", "json", "dbt", "gostruct", "avro", "jsonschema", "spark", "sqlalchemy", "typescript", "proto", "liquibase", "migration", "ddl", "typed-view", "external", "bqschema"}
+
 // DataType represents a PostgreSQL data type
 type DataType struct {
 	Name     string
 	Priority int // Lower number means higher priority
 }
 
+// analysisOptions controls how a file is split into fields and how the
+// results are interpreted. How records are read and single values parsed is
+// the embedded analyze.Options of the library, so that the tool and the
+// library read files the same way. Fields with a yaml tag are set by the
+// flag it names, through runOptions.
+type analysisOptions struct {
+	analyze.Options    `yaml:",inline"`
+	QuotedEmpty        bool                    `yaml:"quoted-empty-is-empty"` // read a quoted empty field as an empty string rather than a null
+	ExpectedCols       int                     `yaml:"ncols"`
+	LengthSemantics    string                  // "bytes" or "chars": how varchar lengths are measured; "" means bytes
+	Header             string                  `yaml:"header"`               // "yes", "no" or "auto": whether the first record names the columns; "" means yes
+	EmptyColumnType    string                  `yaml:"empty-column-type"`    // type reported for columns without values, and every column when there are no data rows
+	DetectEpoch        bool                    `yaml:"detect-epoch"`         // reclassify integer columns of Unix timestamps as timestamp
+	EpochMinYear       int                     `yaml:"epoch-min-year"`       // earliest year accepted by epoch detection
+	EpochMaxYear       int                     `yaml:"epoch-max-year"`       // latest year accepted by epoch detection
+	DetectCompact      bool                    `yaml:"detect-compact-dates"` // reclassify integer columns of YYYYMMDD/YYYYMM values as date
+	DetectHex          bool                    `yaml:"detect-hex"`           // reclassify string columns of 0x hex and 0b binary literals as integers
+	StripPercent       bool                    `yaml:"strip-percent"`        // read numbers ending in a percent sign as numeric percentages
+	PercentFraction    bool                    `yaml:"percent-as-fraction"`  // declare percentages as the fractions they stand for, divided by 100
+	AccountingNumbers  bool                    `yaml:"accounting-numbers"`   // read negatives in parentheses or with a trailing minus, and thousands separators
+	ColumnFormats      map[string]columnFormat // layouts declared by overrides for date and timestamp columns, by name
+	Location           *time.Location          // zone of timestamps written without an offset; nil means UTC
+	DetectGeo          bool                    `yaml:"detect-geo"`               // detect WKT geometry columns and latitude/longitude pairs
+	DetectBinary       bool                    `yaml:"detect-binary"`            // reclassify columns of base64 or hex encoded data as binary
+	BinaryMinLength    int                     `yaml:"binary-min-length"`        // average value length a column needs to count as binary
+	DetectXML          bool                    `yaml:"detect-xml"`               // reclassify columns of well-formed XML as xml
+	XMLMaxBytes        int                     `yaml:"xml-max-bytes"`            // bytes of each value checked for XML well-formedness
+	DetectCodes        bool                    `yaml:"detect-codes"`             // reclassify columns of ISO country or currency codes as char(n)
+	DetectDuplicates   bool                    `yaml:"detect-duplicates"`        // count rows that repeat an earlier row
+	StartLine          int                     `yaml:"start-line"`               // first line of delimited text analyzed after the header; 0 means the first
+	EndLine            int                     `yaml:"end-line"`                 // last line of delimited text analyzed; 0 means the last
+	NormalizePunct     bool                    `yaml:"normalize-punctuation"`    // replace smart quotes, dashes and no-break spaces by ASCII before inference
+	UnwrapExcel        bool                    `yaml:"unwrap-excel-formulas"`    // read values wrapped as Excel formulas, ="0123", as the values inside
+	NameHints          nameHintFlag            `yaml:"name-hints"`               // "warn" or "prefer": compare column types with the types their names suggest; "" means off
+	StripControl       bool                    `yaml:"strip-control-chars"`      // strip control characters from values before inference
+	ControlReplacement string                  `yaml:"control-char-replacement"` // what stripped control characters are replaced by
+	DetectKeys         bool                    // find the columns whose values are all present and distinct
+	TrackValues        bool                    // keep the distinct values of columns with few of them
+	Examples           bool                    `yaml:"examples"`              // capture example values of each column
+	OutlierFraction    float64                 `yaml:"outlier-fraction"`      // warn about columns forced to their type by fewer values than this fraction
+	StrictOutliers     bool                    `yaml:"strict-outliers"`       // treat such columns as errors instead of warning
+	StrictEmpty        bool                    `yaml:"strict-empty-columns"`  // treat columns without values as errors instead of warning
+	StrictConfidence   bool                    `yaml:"strict-low-confidence"` // treat columns of low confidence in their type as errors
+	Sampled            bool                    // only part of the input is read, with -head-bytes or a line or byte range
+	MemoryBudget       int64                   // most bytes the duplicate, key and value trackers may hold; 0 means no limit
+	Checkpoint         *checkpointer           // saves the analysis every so many rows and resumes it, with -checkpoint; nil means off
+}
+
+// location returns the zone of timestamps written without an offset
+func (o *analysisOptions) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+// recordUnit is what line numbers count in messages: lines, or records when
+// a separator other than the newline ends them
+func (o *analysisOptions) recordUnit() string {
+	if o.RecordSeparator != "" {
+		return "record"
+	}
+	return "line"
+}
+
+// columnAnalysis holds the inference results for a single column
+type columnAnalysis struct {
+	Name       string
+	SourceName string // name of the column in the file when overrides renamed it
+	Ordinal    int    // 1-based position of the column in the file
+	TypeIndex  int
+	MaxLength  int // longest value of a varchar or char column, measured by the length semantics
+	MaxBytes   int // longest value of a varchar or char column in bytes
+	MaxChars   int // longest value of a varchar or char column in characters
+	FracDigits int // fractional-second digits needed by timestamp values
+
+	Earliest   *observedTime // earliest date or timestamp value seen
+	Latest     *observedTime // latest date or timestamp value seen
+	TimeCount  int           // number of date and timestamp values seen
+	OutOfOrder int           // date and timestamp values earlier than the latest before them
+	TimeLayout string        // Go layout every date and timestamp value matched, empty if they differed
+	MixedTimes bool          // date and timestamp values matched different layouts
+
+	IntCount  int     // number of values that parsed as 64-bit integers
+	IntMin    int64   // smallest integer value seen
+	IntMax    int64   // largest integer value seen
+	NumCount  int     // number of values that parsed as numbers
+	NumMin    float64 // smallest numeric value seen
+	NumMax    float64 // largest numeric value seen
+	NumDigits int     // most digits before the decimal point in a numeric value
+	NumScale  int     // most digits after the decimal point in a numeric value
+	Precision int     // precision declared by the schema of a typed input or for percentages, with NumScale as its scale
+	WKTCount  int     // number of values that are WKT geometries
+
+	HexCount       int    // values that are even-length hex strings
+	Base64Count    int    // values that are strict, padded base64
+	TotalLength    int    // sum of value lengths, for averages
+	BinaryEncoding string // "hex" or "base64" when detected as encoded binary
+
+	XMLCount   int // values that are well-formed XML
+	Normalized int // smart punctuation characters replaced by ASCII, with -normalize-punctuation
+
+	ExcelFormulas int // values wrapped as Excel formulas, ="0123", unwrapped with -unwrap-excel-formulas
+
+	OverLimits []string // the flavors' length thresholds its longest value exceeds, e.g. "a PostgreSQL btree index entry"
+
+	NameHint      string // type the column's name suggests, with -name-hints
+	BooleanByName bool   // a column of 0 and 1 declared boolean by its name, with -name-hints prefer
+
+	ControlCount int      // control characters other than the delimiter and record separator
+	NULCount     int      // NUL bytes among them, which PostgreSQL rejects in text
+	ControlLines []int    // lines of the first values holding control characters, up to maxOutlierLines
+	EmptyCount   int      // values that are empty, i.e. nulls once loaded
+	Distinct     bool     // every value was present and no two were equal, found with -suggest-indexes
+	Values       []string // sorted distinct values of a column with few of them, kept with -with-checks
+	NoValues     bool     // every data row was empty, so the type is the -empty-column-type fallback
+	Nullable     bool     // declared nullable by the schema of a typed input
+
+	LengthCounts map[int]int // varchar values by length
+	LongValues   []valueAt   // lengths and lines of the longest varchar values, up to maxTruncatedLines
+
+	TypeCounts []int        // non-empty values by the type each parsed as, indexed like the analyzer's types
+	TypeLines  [][]int      // lines of the first values of each type, up to maxOutlierLines, indexed like TypeCounts
+	Stats      *columnStats // completeness, set with -stats
+
+	Examples *columnExamples // sample values, captured with -examples
+
+	Confidence *columnConfidence // how far the inferred type can be trusted, nil for typed inputs
+
+	CountryCount  int             // values that are ISO 3166-1 alpha-2 country codes
+	CurrencyCount int             // values that are ISO 4217 currency codes
+	CodeValues    map[string]bool // distinct codes seen, up to minCodeDistinct
+	CodeList      string          // code list name when detected as a code column
+
+	EnumSymbols []string // symbols of an enum declared by the schema of a typed input
+
+	EpochUnit string // "seconds" or "milliseconds" when detected as a Unix timestamp
+
+	CompactDays   int      // values that parse as YYYYMMDD dates
+	CompactMonths int      // values that parse as YYYYMM months
+	DayMisses     []string // sample values that are not YYYYMMDD dates
+	MonthMisses   []string // sample values that are not YYYYMM months
+	CompactFormat string   // "YYYYMMDD" or "YYYYMM" when detected as a compact date
+
+	HexLiterals    int    // values that are 0x-prefixed hex integer literals
+	BinaryLiterals int    // values that are 0b-prefixed binary integer literals
+	LiteralMin     int64  // smallest value of the literals seen
+	LiteralMax     int64  // largest value of the literals seen
+	LiteralBase    string // "hex", "binary" or "hex and binary" when detected as integer literals
+
+	PercentCount    int  // values that are numbers ending in a percent sign, counted with -strip-percent
+	Percentages     bool // a numeric column holding percentages
+	PercentFraction bool // percentages declared as fractions, divided by 100 when loading
+
+	AccountingCount int  // values in accounting notation, counted with -accounting-numbers
+	Accounting      bool // a number column holding values in accounting notation
+}
+
+// notNull reports whether the column can be declared NOT NULL: rows were
+// seen, none of them empty, and no schema declares the column nullable
+func (c columnAnalysis) notNull(rows int) bool {
+	return rows > 0 && c.EmptyCount == 0 && !c.Nullable
+}
+
+// observeNumber records the value in the column's numeric range if it parses
+// as a number, and in its integer range if it parses as a 64-bit integer
+func (c *columnAnalysis) observeNumber(value string) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	if c.NumCount == 0 || f < c.NumMin {
+		c.NumMin = f
+	}
+	if c.NumCount == 0 || f > c.NumMax {
+		c.NumMax = f
+	}
+	c.NumCount++
+	if intDigits, scale, ok := decimalDigits(value, f); ok {
+		c.NumDigits = max(c.NumDigits, intDigits)
+		c.NumScale = max(c.NumScale, scale)
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return
+	}
+	if c.IntCount == 0 || n < c.IntMin {
+		c.IntMin = n
+	}
+	if c.IntCount == 0 || n > c.IntMax {
+		c.IntMax = n
+	}
+	c.IntCount++
+}
+
+// decimalDigits returns the number of digits before and after the decimal
+// point in a numeric value, or false for infinities and NaN. Trailing zeros
+// are kept since "9.50" usually means a scale of two.
+func decimalDigits(value string, f float64) (intDigits, scale int, ok bool) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, 0, false
+	}
+	digits := strings.TrimLeft(value, "+-")
+	if strings.ContainsAny(digits, "eExXpP_") {
+		// Spell out exponents and other notations in plain decimal
+		digits = strings.TrimPrefix(strconv.FormatFloat(f, 'f', -1, 64), "-")
+	}
+	whole, frac, _ := strings.Cut(digits, ".")
+	whole = strings.TrimLeft(whole, "0")
+	return max(len(whole), 1), len(frac), true
+}
+
+// fileAnalysis holds the inference results for a whole file
+type fileAnalysis struct {
+	Columns           []columnAnalysis
+	RowCount          int
+	BlankLines        int        // blank lines skipped while reading
+	HeaderRepeats     int        // rows identical to the header skipped while reading
+	HeaderRepeatLines []int      // lines of the first skipped header rows, up to maxOutlierLines
+	Warnings          []string   // problems worth reporting that did not stop the analysis
+	GeoPoints         []geoPoint // latitude/longitude column pairs that could form a point
+
+	Duplicates      *duplicateReport // repeated rows, counted with -detect-duplicates
+	LengthSemantics string           // "bytes" or "chars": how MaxLength was measured, "" for typed inputs
+	Location        *time.Location   // zone assumed for timestamps without an offset, nil for UTC
+	PartitionKey    string           // date or timestamp column in file order, set with -suggest-partitioning
+	NoHeader        bool             // the first row was read as data
+	NullCounts      map[string]int   // nulls by the token they were written as, "" for empty fields, with -null
+	AddedColumns    []addedColumn    // columns the DDL adds to the file's, with -add-columns and friends
+	MemoryBudget    int64            // -memory-budget, 0 without one
+	PeakMemory      int64            // most bytes the duplicate, key and value trackers held at once
+}
+
+// warnf records a warning about the analysis
+func (r *fileAnalysis) warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
 // getAnalyzer returns the appropriate TypeAnalyzer based on the database flavor
 func getAnalyzer(flavor string) (dbtypes.TypeAnalyzer, error) {
-	switch strings.ToLower(flavor) {
-	case "postgresql":
-		return &dbtypes.PostgreSQLAnalyzer{}, nil
-	default:
-		return nil, fmt.Errorf("unsupported database flavor: %s. Supported flavors: postgresql", flavor)
+	return dbtypes.ForFlavor(flavor)
+}
+
+// parseMajorMinor returns the major and minor numbers of a version such as
+// 10.6 or 11.4.2
+func parseMajorMinor(version string) (major, minor int, ok bool) {
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, 0, false
+	}
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		numbers[i] = n
 	}
+	return numbers[0], numbers[1], true
 }
 
 func main() {
 	// Define command line flags
-	delimiter := flag.String("delim", "", "Field delimiter character (required)")
-	flavor := flag.String("flavor", "postgresql", "Database flavor (default: postgresql)")
-	quotes := flag.String("quotes", "none", "Quote character type: none, single, or double (default: none)")
-	ncols := flag.Int("ncols", 0, "Expected number of columns (optional)")
-	verboseFlag := flag.Bool("v", false, "Enable verbose mode with DEBUG output")
+	var o runOptions
+	o.register(flag.CommandLine)
 
 	// Parse flags after getting the file path
-	flag.Parse()
+	if err := o.parse(flag.CommandLine, os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Set global verbose flag
-	verbose = *verboseFlag
+	// Set global verbose and plain flags
+	verbose = o.Verbose
+	plain = o.Plain
 
 	// Get positional arguments first
 	if len(flag.Args()) == 0 {
-		fmt.Println("Error: File path is required as a positional argument")
-		fmt.Println("Usage: file2ddl -delim <delimiter> [-quotes none|single|double] [-ncols <number>] [-v] <file>")
+		fmt.Fprintln(os.Stderr, "Error: File path is required as a positional argument")
+		fmt.Fprintf(os.Stderr, "Usage: file2ddl -delim <delimiter> [-quotes none|single|double] [-ncols <number>] [-format %s] [-o <path>] [-v] <file>\n", strings.Join(outputFormats, "|"))
 		os.Exit(1)
 	}
 	filePath := flag.Args()[0]
 
 	// Debug print for CLI parsing
 	if verbose {
-		fmt.Printf("DEBUG: filePath=%q, delim=%q, quotes=%q, ncols=%d, args=%v\n", filePath, *delimiter, *quotes, *ncols, flag.Args())
+		fmt.Fprintf(os.Stderr, "DEBUG: filePath=%q, delim=%q, quotes=%q, ncols=%d, args=%v\n", filePath, o.Delim, o.Quotes, o.ExpectedCols, flag.Args())
 	}
 
-	// Validate required parameters
-	if *delimiter == "" {
-		fmt.Println("Error: -delim parameter is required")
-		flag.Usage()
+	// Validate ncols parameter if provided
+	if o.ExpectedCols < 0 {
+		fmt.Fprintln(os.Stderr, "Error: ncols must be a positive integer")
+		os.Exit(1)
+	}
+	if o.Interactive && !isTerminal(os.Stdin) {
+		fmt.Fprintln(os.Stderr, "Error: -interactive needs a terminal on stdin")
+		os.Exit(1)
+	}
+	if o.Interactive && o.OverrideFile != "" {
+		fmt.Fprintln(os.Stderr, "Error: -interactive and -override-file cannot be combined")
+		os.Exit(1)
+	}
+	if o.SaveOverridesFile != "" && !o.Interactive {
+		fmt.Fprintln(os.Stderr, "Error: -save-overrides needs -interactive")
+		os.Exit(1)
+	}
+	if o.CheckAppendFile != "" && o.StateFile != "" {
+		fmt.Fprintln(os.Stderr, "Error: -check-append and -state cannot be combined, since the state would already include the file")
+		os.Exit(1)
+	}
+	if o.WithLoad && o.Format != "ddl" && o.Format != "bqschema" {
+		fmt.Fprintln(os.Stderr, "Error: -with-load needs -format ddl or bqschema")
+		os.Exit(1)
+	}
+	if (len(o.AddColumns) > 0 || o.AddSurrogateKey || o.AddAuditColumns) && o.Format != "ddl" && o.Format != "migration" {
+		fmt.Fprintln(os.Stderr, "Error: -add-columns, -add-surrogate-key and -add-audit-columns need -format ddl or migration")
+		os.Exit(1)
+	}
+	if o.WithChecks && o.Format != "ddl" {
+		fmt.Fprintln(os.Stderr, "Error: -with-checks needs -format ddl")
+		os.Exit(1)
+	}
+	if o.CheckHeadroom < 0 {
+		fmt.Fprintln(os.Stderr, "Error: check-headroom must not be negative")
+		os.Exit(1)
+	}
+	if o.SuggestIndexes && o.Format != "ddl" {
+		fmt.Fprintln(os.Stderr, "Error: -suggest-indexes needs -format ddl")
+		os.Exit(1)
+	}
+	if (o.Stage != "" || o.StagePattern != "" || o.LoadOnError != "" || o.LoadPurge) && !o.WithLoad {
+		fmt.Fprintln(os.Stderr, "Error: -stage, -stage-pattern, -load-on-error and -load-purge need -with-load")
+		os.Exit(1)
+	}
+	if o.Stage != "" && !strings.HasPrefix(o.Stage, "@") {
+		fmt.Fprintln(os.Stderr, "Error: -stage must start with @, e.g. @landing/orders/")
+		os.Exit(1)
+	}
+	if o.LoadOnError != "" && !onErrorPattern.MatchString(o.LoadOnError) {
+		fmt.Fprintf(os.Stderr, "Error: unsupported load-on-error: %s. Supported values: continue, abort_statement, skip_file, skip_file_n and skip_file_n%%\n", o.LoadOnError)
+		os.Exit(1)
+	}
+	if o.WithMerge {
+		if o.Format != "ddl" {
+			fmt.Fprintln(os.Stderr, "Error: -with-merge needs -format ddl")
+			os.Exit(1)
+		}
+		if o.MergeTarget == "" || o.MergeKey == "" {
+			fmt.Fprintln(os.Stderr, "Error: -with-merge needs -target and -merge-key")
+			os.Exit(1)
+		}
+	}
+	if o.Format == "external" {
+		if !strings.HasPrefix(o.ExternalLocation, "s3://") {
+			fmt.Fprintln(os.Stderr, "Error: -format external needs an s3:// -location")
+			os.Exit(1)
+		}
+		if o.ExternalDialect != "athena" && o.ExternalDialect != "spectrum" {
+			fmt.Fprintf(os.Stderr, "Error: unsupported external dialect: %s. Supported dialects: athena, spectrum\n", o.ExternalDialect)
+			os.Exit(1)
+		}
+	}
+	if o.Format == "typed-view" && o.RawTable == "" {
+		fmt.Fprintln(os.Stderr, "Error: -format typed-view needs -raw-table")
+		os.Exit(1)
+	}
+	var location *time.Location
+	if o.AssumeTZ != "" {
+		var err error
+		location, err = time.LoadLocation(o.AssumeTZ)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -assume-tz %q: %v\n", o.AssumeTZ, err)
+			os.Exit(1)
+		}
+	}
+	if o.MaxRecordBytes <= 0 || o.MaxFieldBytes <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: max-record-bytes and max-field-bytes must be positive")
+		os.Exit(1)
+	}
+	if o.PartitionTolerance < 0 || o.PartitionTolerance > 1 {
+		fmt.Fprintln(os.Stderr, "Error: partition-tolerance must be between 0 and 1")
+		os.Exit(1)
+	}
+	if o.AppendPad < 0 {
+		fmt.Fprintln(os.Stderr, "Error: append-pad must not be negative")
+		os.Exit(1)
+	}
+	if o.OnBadRow != "error" && o.OnBadRow != "skip" {
+		fmt.Fprintln(os.Stderr, "Error: on-bad-row must be one of: error, skip")
+		os.Exit(1)
+	}
+	if o.OutlierFraction < 0 || o.OutlierFraction > 1 {
+		fmt.Fprintln(os.Stderr, "Error: outlier-fraction must be between 0 and 1")
+		os.Exit(1)
+	}
+	if o.VarcharPercentile < 0 || o.VarcharPercentile > 100 {
+		fmt.Fprintln(os.Stderr, "Error: varchar-percentile must be between 0 and 100")
 		os.Exit(1)
 	}
 
-	// Validate ncols parameter if provided
-	if *ncols < 0 {
-		fmt.Println("Error: ncols must be a positive integer")
+	// Validate the input format
+	if o.InputFormat != "" && o.InputFormat != "delimited" && o.InputFormat != "xlsx" && o.InputFormat != "parquet" && o.InputFormat != "avro" && o.InputFormat != "arrow" {
+		fmt.Fprintln(os.Stderr, "Error: input must be one of: delimited, xlsx, parquet, avro, arrow")
 		os.Exit(1)
 	}
 
-	// Extract the first character of the delimiter string
-	delimChar := string((*delimiter)[0])
+	// Validate the header mode
+	if o.Header != "yes" && o.Header != "no" && o.Header != "auto" {
+		fmt.Fprintln(os.Stderr, "Error: header must be one of: yes, no, auto")
+		os.Exit(1)
+	}
 
 	// Validate quotes parameter
-	if *quotes != "none" && *quotes != "single" && *quotes != "double" {
-		fmt.Println("Error: quotes must be one of: none, single, double")
+	if o.Quotes != "none" && o.Quotes != "single" && o.Quotes != "double" {
+		fmt.Fprintln(os.Stderr, "Error: quotes must be one of: none, single, double")
 		os.Exit(1)
 	}
 
-	// Get the appropriate analyzer
-	analyzer, err := getAnalyzer(*flavor)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
+	if o.PercentFraction && !o.StripPercent {
+		fmt.Fprintln(os.Stderr, "Error: -percent-as-fraction needs -strip-percent")
 		os.Exit(1)
 	}
-
-	// Open the file
-	file, err := os.Open(filePath)
-	if err != nil {
-		fmt.Printf("Error opening file: %v\n", err)
+	if o.ControlReplacement != "" && !o.StripControl {
+		fmt.Fprintln(os.Stderr, "Error: -control-char-replacement needs -strip-control-chars")
+		os.Exit(1)
+	}
+	if o.QuotedEmpty && o.Quotes == "none" {
+		fmt.Fprintln(os.Stderr, "Error: -quoted-empty-is-empty needs -quotes single or double")
 		os.Exit(1)
 	}
-	defer file.Close()
 
-	headers, columnTypes, maxLengths, err := analyzeFileTypes(file, delimChar, *quotes, *ncols, analyzer)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
+	switch o.FormatPreset {
+	case "":
+	case "tsv":
+		if err := checkTSVPreset(o.Delim, o.DelimRegex, o.Quotes); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "Error: -format-preset must be tsv")
 		os.Exit(1)
 	}
 
-	// Print results
-	fmt.Println("Column Analysis:")
-	for i, header := range headers {
-		typeName := analyzer.GetTypes()[columnTypes[i]].Name
-		if typeName == "varchar" {
-			fmt.Printf("%s: varchar(%d)\n", header, maxLengths[i])
-		} else {
-			fmt.Printf("%s: %s\n", header, typeName)
+	var memoryBudgetBytes int64
+	if o.MemoryBudgetFlag != "" {
+		var err error
+		if memoryBudgetBytes, err = parseByteSize(o.MemoryBudgetFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -memory-budget: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Validate the delimiter regex up front, before any input is fetched
+	var delimPattern *regexp.Regexp
+	if o.DelimRegex != "" {
+		if o.Delim != "" {
+			fmt.Fprintln(os.Stderr, "Error: -delim and -delim-regex are mutually exclusive")
+			os.Exit(1)
+		}
+		if o.Quotes != "none" {
+			fmt.Fprintf(os.Stderr, "Error: -delim-regex cannot be combined with -quotes %s\n", o.Quotes)
+			os.Exit(1)
+		}
+		if err := parseDelimiterRegex(o.DelimRegex); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		delimPattern = regexp.MustCompile(o.DelimRegex)
+	}
+	var recordSepChar string
+	if o.RecordSep != "" {
+		var err error
+		if recordSepChar, err = parseRecordSeparator(o.RecordSep); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
 	}
-}
 
-// splitFields splits a line into fields, handling quoted fields
-func splitFields(line, delim, quotes string) []string {
-	if quotes == "none" {
-		return strings.Split(line, delim)
+	// Validate format parameter
+	if !slices.Contains(outputFormats, o.Format) {
+		fmt.Fprintf(os.Stderr, "Error: format must be one of: %s\n", strings.Join(outputFormats, ", "))
+		os.Exit(1)
 	}
 
-	var fields []string
-	var current strings.Builder
-	var inQuote bool
-	var quoteChar rune
+	// Validate the column order
+	if o.ColumnOrder != "file" && o.ColumnOrder != "name" {
+		fmt.Fprintln(os.Stderr, "Error: column-order must be one of: file, name")
+		os.Exit(1)
+	}
 
-	if quotes == "double" {
-		quoteChar = '"'
-	} else {
-		quoteChar = '\''
+	// Validate the migration style
+	if o.MigrationStyle != "flyway" && o.MigrationStyle != "goose" {
+		fmt.Fprintln(os.Stderr, "Error: migration-style must be one of: flyway, goose")
+		os.Exit(1)
 	}
 
-	for i := 0; i < len(line); i++ {
-		r := rune(line[i])
+	// Validate the TypeScript type for big numbers
+	if o.TSBigNumbers != "string" && o.TSBigNumbers != "number" {
+		fmt.Fprintln(os.Stderr, "Error: ts-big-numbers must be one of: string, number")
+		os.Exit(1)
+	}
 
-		if r == quoteChar {
-			if !inQuote {
-				// Start of quoted field
-				inQuote = true
-			} else {
-				// End of quoted field
-				inQuote = false
-			}
-			continue
-		}
+	// Validate the epoch detection range
+	if o.EpochMinYear > o.EpochMaxYear {
+		fmt.Fprintln(os.Stderr, "Error: epoch-min-year must not be after epoch-max-year")
+		os.Exit(1)
+	}
 
-		if r == rune(delim[0]) && !inQuote {
-			fields = append(fields, current.String())
-			current.Reset()
-			continue
-		}
+	// Validate the two-digit year pivot
+	if o.YearPivot < 0 || o.YearPivot > 100 {
+		fmt.Fprintln(os.Stderr, "Error: year-pivot must be between 0 and 100")
+		os.Exit(1)
+	}
 
-		current.WriteRune(r)
+	// Validate the SAP HANA table store
+	if o.HANATableType != "column" && o.HANATableType != "row" {
+		fmt.Fprintln(os.Stderr, "Error: hana-table-type must be one of: column, row")
+		os.Exit(1)
 	}
 
-	// Add the last field
-	fields = append(fields, current.String())
-	return fields
-}
+	// Validate the MariaDB version, whose UUID type came with 10.7
+	mariadbMajor, mariadbMinor, versionOK := parseMajorMinor(o.MariaDBVersion)
+	if !versionOK {
+		fmt.Fprintln(os.Stderr, "Error: mariadb-version must be a version such as 10.6 or 11.4.2")
+		os.Exit(1)
+	}
 
-// analyzeFileTypes reads the file and analyzes the types of each column
-func analyzeFileTypes(file *os.File, delimiter, quotes string, expectedCols int, analyzer dbtypes.TypeAnalyzer) ([]string, []int, []int, error) {
-	scanner := bufio.NewScanner(file)
-	var headers []string
-	var columnTypes []int
-	var maxLengths []int
-	lineNum := 0
+	// A Greenplum table is distributed one way only
+	if o.DistributedBy != "" && o.DistributedRandomly {
+		fmt.Fprintln(os.Stderr, "Error: distributed-by and distributed-randomly cannot be used together")
+		os.Exit(1)
+	}
 
-	// Read headers if file is not empty
-	if scanner.Scan() {
-		lineNum++
-		headers = splitFields(scanner.Text(), delimiter, quotes)
-		columnTypes = make([]int, len(headers))
-		maxLengths = make([]int, len(headers))
-		for i := range columnTypes {
-			columnTypes[i] = 0 // Start with the most specific type (boolean)
-			maxLengths[i] = 0
+	// Get the appropriate analyzers; the file is analyzed with the first
+	// and the results mapped onto the rest
+	var flavors []flavorResult
+	for _, name := range strings.Split(o.Flavor, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		flavorAnalyzer, err := getAnalyzer(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
 
-		// If ncols was specified, validate header count
-		if expectedCols > 0 && len(headers) != expectedCols {
-			return nil, nil, nil, fmt.Errorf("header line has %d fields, expected %d", len(headers), expectedCols)
+		// Offer the PostGIS geometry type when detecting geometries
+		// and bytea, xml and char when detecting encoded binary data, XML and codes
+		var pg *dbtypes.PostgreSQLAnalyzer
+		switch a := flavorAnalyzer.(type) {
+		case *dbtypes.PostgreSQLAnalyzer:
+			pg = a
+		case *dbtypes.SnowflakeAnalyzer:
+			pg = &a.PostgreSQLAnalyzer
+		case *dbtypes.GreenplumAnalyzer:
+			pg = &a.PostgreSQLAnalyzer
+			a.DistributedBy, a.DistributedRandomly = o.DistributedBy, o.DistributedRandomly
+		case *dbtypes.ExasolAnalyzer:
+			a.Char = o.DetectCodes
+		}
+		if pg != nil {
+			pg.PostGIS = o.DetectGeo
+			pg.Bytea = o.DetectBinary
+			pg.XML = o.DetectXML
+			pg.Char = o.DetectCodes
 		}
+		// MariaDB before 10.7 stores UUIDs as text
+		if mariadb, ok := flavorAnalyzer.(*dbtypes.MariaDBAnalyzer); ok {
+			mariadb.UUIDAsChar = mariadbMajor < 10 || mariadbMajor == 10 && mariadbMinor < 7
+			mariadb.UnsignedTinyInt = o.MariaDBUnsigned
+		}
+		// SAP HANA tables go in the -hana-table-type store
+		if hana, ok := flavorAnalyzer.(*dbtypes.HanaAnalyzer); ok {
+			hana.TableType = strings.ToUpper(o.HANATableType)
+		}
+		flavors = append(flavors, flavorResult{Flavor: name, Analyzer: flavorAnalyzer})
 	}
+	analyzer := flavors[0].Analyzer
 
-	// Process each line
-	for scanner.Scan() {
-		lineNum++
-		fields := splitFields(scanner.Text(), delimiter, quotes)
-
-		// Validate field count
-		if len(fields) != len(headers) {
-			return nil, nil, nil, fmt.Errorf("line %d has %d fields, expected %d", lineNum, len(fields), len(headers))
+	// Merges and typed views are written in PostgreSQL and Snowflake SQL
+	// only, which Greenplum also reads, loads in those, Vertica's and
+	// Exasol's, and merges in MariaDB's too, whose comments are no COMMENT ON
+	// statements. The statements are written for every flavor listed, so
+	// each must support them; a typed view is written for the first only.
+	for i, f := range flavors {
+		switch f.Analyzer.(type) {
+		case *dbtypes.PostgreSQLAnalyzer, *dbtypes.SnowflakeAnalyzer, *dbtypes.GreenplumAnalyzer:
+			continue
+		}
+		_, vertica := f.Analyzer.(*dbtypes.VerticaAnalyzer)
+		_, exasol := f.Analyzer.(*dbtypes.ExasolAnalyzer)
+		_, mariadb := f.Analyzer.(*dbtypes.MariaDBAnalyzer)
+		var unsupported string
+		switch {
+		case o.WithLoad && o.Format == "ddl" && !vertica && !exasol:
+			unsupported = "-with-load"
+		case o.WithMerge && !mariadb:
+			unsupported = "-with-merge"
+		case o.Format == "typed-view" && i == 0:
+			unsupported = "-format typed-view"
+		case o.WithComments && (o.Format == "ddl" || o.Format == "migration" && i == 0) && mariadb:
+			unsupported = "-with-comments"
 		}
+		if unsupported != "" {
+			fmt.Fprintf(os.Stderr, "Error: %s is not supported for %s\n", unsupported, f.Flavor)
+			os.Exit(1)
+		}
+	}
 
-		// Analyze each field
-		for i, field := range fields {
-			fieldType := inferType(field, analyzer)
-			if fieldType > columnTypes[i] {
-				columnTypes[i] = fieldType
-				if verbose {
-					fmt.Printf("DEBUG: field %s promoted to type %s\n", headers[i], analyzer.GetTypes()[fieldType].Name)
-				}
-			}
-			if analyzer.GetTypes()[fieldType].Name == "varchar" {
-				if len(field) > maxLengths[i] {
-					maxLengths[i] = len(field)
-				}
+	// A Greenplum table given no key to distribute by is distributed by a
+	// column found to hold distinct values
+	distributeByKey := false
+	if (o.Format == "ddl" || o.Format == "migration") && o.PrimaryKey == "" && o.DistributedBy == "" && !o.DistributedRandomly {
+		for _, f := range flavors {
+			if _, ok := f.Analyzer.(*dbtypes.GreenplumAnalyzer); ok {
+				distributeByKey = true
 			}
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, nil, nil, fmt.Errorf("error reading file: %v", err)
+	// Varchar lengths are measured as the first flavor's database does
+	// unless told otherwise
+	semantics := o.LengthSemanticsFlag
+	switch semantics {
+	case "":
+		semantics = lengthSemantics(analyzer)
+	case "bytes", "chars":
+	default:
+		fmt.Fprintln(os.Stderr, "Error: length-semantics must be one of: bytes, chars")
+		os.Exit(1)
 	}
 
-	return headers, columnTypes, maxLengths, nil
-}
+	// Validate the empty column type against the flavor's types
+	if typeIndex(analyzer, o.EmptyColumnType) < 0 {
+		fmt.Fprintf(os.Stderr, "Error: empty-column-type must be one of: %s\n", strings.Join(typeNames(analyzer), ", "))
+		os.Exit(1)
+	}
 
-func inferType(value string, analyzer dbtypes.TypeAnalyzer) int {
-	// Try each type in order of preference
-	types := analyzer.GetTypes()
-	for i, dbType := range types {
-		switch dbType.Name {
-		case "boolean":
-			if isBoolean(value) {
-				return i
-			}
-		case "smallint":
-			if isSmallInt(value) {
-				return i
-			}
-		case "integer":
-			if isInteger(value) {
-				// If it's an integer but not a smallint, it must be an integer
-				if !isSmallInt(value) {
-					return i
-				}
-			}
-		case "bigint":
-			if isBigInt(value) {
-				// If it's a bigint but not an integer, it must be a bigint
-				if !isInteger(value) {
-					return i
-				}
-			}
-		case "numeric":
-			if isNumeric(value) {
-				// If it's numeric but not a bigint, it must be numeric
-				if !isBigInt(value) {
-					return i
-				}
-			}
-		case "timestamp":
-			if isTimestamp(value) {
-				return i
-			}
-		case "date":
-			if isDate(value) {
-				return i
-			}
-		case "varchar":
-			if isVarchar(value) {
-				return i
-			}
-		case "text":
-			return i // text is always valid
+	// Column decisions are read before the analysis, which follows their
+	// date and timestamp layouts; -override flags win over the file
+	overrides := newOverrideFile()
+	if o.OverrideFile != "" {
+		var err error
+		overrides, err = loadOverrides(o.OverrideFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
 	}
-	return len(types) - 1 // Default to text
-}
-
-func isBoolean(value string) bool {
-	value = strings.ToLower(strings.TrimSpace(value))
-	// Only consider explicit boolean values, not numeric 1/0
-	return value == "true" || value == "false" || value == "t" || value == "f"
-}
+	for _, spec := range o.Overrides {
+		name, override, err := parseOverrideFlag(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		entry := overrides.Columns[name]
+		entry.Type, entry.Format = override.Type, override.Format
+		overrides.Columns[name] = entry
+	}
 
-func isSmallInt(value string) bool {
-	num, err := strconv.ParseInt(value, 10, 16)
-	return err == nil && num >= -32768 && num <= 32767
-}
+	if o.StartLine < 0 || o.EndLine < 0 || o.StartByte < 0 || o.EndByte < 0 {
+		fmt.Fprintln(os.Stderr, "Error: start-line, end-line, start-byte and end-byte must not be negative")
+		os.Exit(1)
+	}
+	if o.EndLine > 0 && o.StartLine > o.EndLine || o.EndByte > 0 && o.StartByte >= o.EndByte {
+		fmt.Fprintln(os.Stderr, "Error: a range must start before its end")
+		os.Exit(1)
+	}
+	lineRange := o.StartLine > 0 || o.EndLine > 0
+	byteRanged := o.StartByte > 0 || o.EndByte > 0
+	if lineRange && byteRanged {
+		fmt.Fprintln(os.Stderr, "Error: -start-line and -end-line cannot be combined with -start-byte and -end-byte")
+		os.Exit(1)
+	}
+	if byteRanged && o.HeadBytes > 0 {
+		fmt.Fprintln(os.Stderr, "Error: -start-byte and -end-byte cannot be combined with -head-bytes")
+		os.Exit(1)
+	}
+	partialRange := lineRange || byteRanged
 
-func isInteger(value string) bool {
-	_, err := strconv.ParseInt(value, 10, 32)
-	return err == nil
-}
+	// Open the file, which may also be an http(s) or s3 URL
+	inputOpts := inputOptions{HeadBytes: o.HeadBytes, HTTPTimeout: o.HTTPTimeout, AWSRegion: o.AWSRegion, ZipEntry: o.ZipEntry, RecordSeparator: recordSepChar}
+	file, inputLabel, err := openInput(context.Background(), filePath, inputOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
 
-func isBigInt(value string) bool {
-	_, err := strconv.ParseInt(value, 10, 64)
-	return err == nil
-}
+	// Tell workbooks, Parquet, Avro and Arrow files from delimited text by the
+	// name of the file read
+	if o.InputFormat == "" {
+		switch strings.ToLower(filepath.Ext(strings.TrimSuffix(inputLabel, ".gz"))) {
+		case ".xlsx":
+			o.InputFormat = "xlsx"
+		case ".parquet":
+			o.InputFormat = "parquet"
+		case ".avro":
+			o.InputFormat = "avro"
+		case ".arrow", ".arrows", ".feather", ".ipc":
+			o.InputFormat = "arrow"
+		default:
+			o.InputFormat = "delimited"
+		}
+	}
 
-func isNumeric(value string) bool {
-	_, err := strconv.ParseFloat(value, 64)
-	return err == nil
-}
+	// TSV files name themselves, and need no -delim
+	if o.FormatPreset == "" && o.InputFormat == "delimited" && o.Delim == "" && delimPattern == nil && o.Quotes == "none" && isTSVName(inputLabel) {
+		o.FormatPreset = "tsv"
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: %s read as TSV by its extension\n", inputLabel)
+		}
+	}
+	if o.FormatPreset == "tsv" {
+		o.Delim = `\t`
+	}
 
-func isTimestamp(value string) bool {
-	// Try common timestamp formats
-	formats := []string{
-		"2006-01-02 15:04:05",
-		"2006-01-02T15:04:05",
-		"2006-01-02 15:04:05.000",
-		"2006-01-02T15:04:05.000",
-		time.RFC3339,
+	// Validate required parameters
+	switch o.InputFormat {
+	case "delimited":
+		if o.Delim == "" && delimPattern == nil {
+			fmt.Fprintln(os.Stderr, "Error: -delim or -delim-regex parameter is required")
+			flag.Usage()
+			os.Exit(1)
+		}
+	case "xlsx", "parquet", "avro", "arrow":
+		if o.HeadBytes > 0 {
+			fmt.Fprintf(os.Stderr, "Error: -head-bytes does not apply to %s input\n", o.InputFormat)
+			os.Exit(1)
+		}
+		if partialRange {
+			fmt.Fprintf(os.Stderr, "Error: line and byte ranges do not apply to %s input\n", o.InputFormat)
+			os.Exit(1)
+		}
+		if o.WithLoad {
+			fmt.Fprintf(os.Stderr, "Error: -with-load does not apply to %s input\n", o.InputFormat)
+			os.Exit(1)
+		}
+		if o.Format == "external" {
+			fmt.Fprintf(os.Stderr, "Error: -format external does not apply to %s input\n", o.InputFormat)
+			os.Exit(1)
+		}
 	}
 
-	for _, format := range formats {
-		if _, err := time.Parse(format, value); err == nil {
-			return true
+	// The flags taken as given are already in place; fill in those read from
+	// the others
+	opts := o.analysisOptions
+	opts.Delimiter = unescapeSeparator(o.Delim)
+	opts.DelimiterRegex = delimPattern
+	opts.RecordSeparator = recordSepChar
+	opts.TSVEscapes = o.FormatPreset == "tsv"
+	opts.NullTokens = splitNullTokens(o.Null)
+	opts.SkipBadRows = o.OnBadRow == "skip"
+	opts.LengthSemantics = semantics
+	opts.ColumnFormats = columnFormats(overrides)
+	opts.Location = location
+	// Duplicates among the rows of a partial read say little about the file
+	opts.DetectDuplicates = o.DetectDuplicates && o.HeadBytes == 0 && !partialRange
+	opts.DetectKeys = o.SuggestIndexes || distributeByKey
+	opts.TrackValues = o.WithChecks
+	opts.MemoryBudget = memoryBudgetBytes
+	opts.Examples = o.Examples || o.Interactive
+	opts.Sampled = o.HeadBytes > 0 || partialRange
+	// A checkpoint holds where to read on from in the file and the state of
+	// the rows before, so the file must be read in place and that state not
+	// grow with the rows
+	if o.CheckpointFile != "" {
+		var reason string
+		localFile, ok := file.(*os.File)
+		switch {
+		case o.InputFormat != "delimited" || !ok:
+			reason = "applies to local, uncompressed delimited files"
+		case o.CheckpointRows <= 0:
+			reason = "needs a positive -checkpoint-rows"
+		case o.HeadBytes > 0 || partialRange:
+			reason = "cannot be combined with -head-bytes or line and byte ranges"
+		case o.ManifestFile != "" || (o.Format == "liquibase" && o.ChangeSetID == ""):
+			reason = "cannot be combined with -manifest or a -format liquibase changeSet id derived from the file contents, which hash the whole file"
+		case o.DetectDuplicates || o.SuggestIndexes || o.WithChecks:
+			reason = "cannot be combined with -detect-duplicates, -suggest-indexes or -with-checks, which keep the values of every row"
+		}
+		if reason != "" {
+			fmt.Fprintf(os.Stderr, "Error: -checkpoint %s\n", reason)
+			os.Exit(1)
+		}
+		opts.Checkpoint, err = openCheckpoint(o.CheckpointFile, o.CheckpointRows, filePath, flagFingerprint(o.settings(flag.CommandLine)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if cp := opts.Checkpoint.resumed(); cp != nil {
+			if _, err := localFile.Seek(cp.Offset, io.SeekStart); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: resuming from checkpoint: %v\n", err)
+				os.Exit(1)
+			}
 		}
 	}
-	return false
-}
+	// A byte range is cut from the file before anything reads it, so that
+	// only the range is hashed
+	if byteRanged && o.InputFormat == "delimited" {
+		sep := "\n"
+		if recordSepChar != "" {
+			sep = recordSepChar
+		}
+		ranged, err := byteRange(file, o.StartByte, o.EndByte, o.Header != "no", sep)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		file = readCloser{ranged, file}
+	}
+	// Hash the contents as they are read, to identify Liquibase changeSets
+	// and for the manifest
+	hasher := sha256.New()
+	var content *contentHash
+	var contentWriter io.Writer = io.Discard
+	if o.ManifestFile != "" {
+		content = newContentHash()
+		contentWriter = content
+	}
+	tee := io.TeeReader(file, io.MultiWriter(hasher, contentWriter))
+	var result *fileAnalysis
+	switch o.InputFormat {
+	case "xlsx":
+		var records *xlsxRecords
+		records, err = openWorkbook(tee, o.Sheet)
+		if err == nil {
+			result, err = analyzeRecords(records, opts, analyzer)
+		}
+	case "parquet":
+		// The schema is in the footer, which is all that is read
+		var pf *parquetFile
+		// A local file is read in place, and hashed whole afterwards
+		var src io.Reader = file
+		if _, ok := file.(*os.File); !ok {
+			src = io.TeeReader(file, contentWriter)
+		}
+		pf, err = readParquet(src)
+		if err == nil {
+			hasher.Write(pf.Footer)
+			result = analyzeParquet(pf, analyzer)
+		}
+	case "avro":
+		var af *avroFile
+		af, err = readAvroFile(tee, o.Scan)
+		if err == nil {
+			result = analyzeAvroFile(af, analyzer)
+		}
+	case "arrow":
+		var af *arrowFile
+		af, err = readArrow(tee, o.Scan)
+		if err == nil {
+			result = analyzeArrow(af, analyzer)
+		}
+	default:
+		result, err = analyzeFileTypes(tee, opts, analyzer)
+	}
+	if err != nil {
+		if strings.Contains(inputLabel, "!") {
+			err = fmt.Errorf("%s: %v", inputLabel, err)
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// A completed analysis leaves no checkpoint to resume
+	if opts.Checkpoint != nil {
+		if err := opts.Checkpoint.finish(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// Typed inputs have epoch columns too
+	result.Location = location
+	// Columns keep their position in the file through reordering
+	for i := range result.Columns {
+		result.Columns[i].Ordinal = i + 1
+	}
+	if o.Examples && o.InputFormat != "delimited" && o.InputFormat != "xlsx" {
+		result.warnf("-examples applies to delimited and xlsx input; skipped for %s input", o.InputFormat)
+	}
+	if o.DetectDuplicates && result.Duplicates == nil {
+		if o.HeadBytes > 0 {
+			result.warnf("-detect-duplicates is skipped with -head-bytes, since only part of the file is read")
+		} else if partialRange {
+			result.warnf("-detect-duplicates is skipped with a line or byte range, since only part of the file is read")
+		} else {
+			result.warnf("-detect-duplicates applies to delimited and xlsx input; skipped for %s input", o.InputFormat)
+		}
+	}
+
+	// Name the table after the file unless told otherwise
+	tableName := o.Table
+	if tableName == "" {
+		base := strings.TrimSuffix(path.Base(inputLabel), ".gz")
+		tableName = strings.TrimSuffix(base, filepath.Ext(base))
+	}
 
-func isDate(value string) bool {
-	// Try common date formats
-	formats := []string{
-		"2006-01-02",
-		"01/02/2006",
-		"02/01/2006",
+	// Fold in what earlier runs saw and save the union for the next run
+	if o.StateFile != "" {
+		var state *analysisState
+		if !o.ResetState {
+			state, err = loadState(o.StateFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if state != nil {
+			if err := mergeState(result, state, analyzer); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := saveState(o.StateFile, result, analyzer); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	for _, format := range formats {
-		if _, err := time.Parse(format, value); err == nil {
-			return true
+	if o.VarcharPercentile > 0 {
+		sizeVarchars(result, analyzer, o.VarcharPercentile)
+	}
+	if o.SuggestPartitioning {
+		suggestPartitionKey(result, analyzer, o.PartitionTolerance)
+		if verbose && result.PartitionKey != "" {
+			fmt.Fprintf(os.Stderr, "DEBUG: suggesting %s as the partition key\n", result.PartitionKey)
 		}
 	}
-	return false
-}
 
-func isVarchar(value string) bool {
-	return len(value) <= 64000
+	for _, warning := range result.Warnings {
+		printWarning(warning)
+	}
+
+	if o.Stats {
+		addColumnStats(result, analyzer)
+	}
+
+	// Apply the column decisions, saved or made now
+	if len(overrides.Columns) > 0 {
+		if err := applyOverrides(result, overrides, analyzer); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if o.Interactive {
+		overrides, err := reviewColumns(os.Stdin, os.Stderr, result, analyzer)
+		if err == nil {
+			err = applyOverrides(result, overrides, analyzer)
+		}
+		if err == nil && o.SaveOverridesFile != "" {
+			err = saveOverrides(o.SaveOverridesFile, overrides)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		// Examples were captured for the review
+		if !o.Examples {
+			for i := range result.Columns {
+				result.Columns[i].Examples = nil
+			}
+		}
+	}
+
+	// The affixes go around the final names, which must stay within the
+	// identifier limit of every flavor
+	limit := 0
+	for _, f := range flavors {
+		if l := identifierLimit(f.Analyzer); l > 0 && (limit == 0 || l < limit) {
+			limit = l
+		}
+	}
+	for _, warning := range fitColumnNames(result, o.ColumnPrefix, o.ColumnSuffix, limit) {
+		printWarning(warning)
+		result.Warnings = append(result.Warnings, warning)
+	}
+
+	// Gate appending the file to the table of a previous run
+	if o.CheckAppendFile != "" {
+		previous, err := loadAppendSchema(o.CheckAppendFile, analyzer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if problems := checkAppend(result, previous, analyzer, o.AppendPad); len(problems) > 0 {
+			fmt.Fprintf(os.Stderr, "%s cannot be appended to the table of %s:\n", inputLabel, o.CheckAppendFile)
+			for _, problem := range problems {
+				fmt.Fprintf(os.Stderr, "  %s\n", problem)
+			}
+			os.Exit(1)
+		}
+	}
+
+	if o.ColumnOrder == "name" {
+		sortColumnsByName(result)
+	}
+	if err := addColumns(result, o.AddColumns, o.AddSurrogateKey, o.AddAuditColumns); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// Values too long to index or load are warned about under every flavor
+	for _, f := range flavors {
+		for _, warning := range checkLengthThresholds(result, f.Analyzer) {
+			printWarning(warning)
+			result.Warnings = append(result.Warnings, warning)
+		}
+	}
+	for i := range flavors {
+		flavors[i].Result = mapAnalysis(result, analyzer, flavors[i].Analyzer, o.LengthSemanticsFlag)
+	}
+
+	// Build the CREATE TABLE statement for the DDL formats, one per flavor
+	// when reporting several
+	var createSQL string
+	if o.Format == "migration" || o.Format == "ddl" {
+		// Each flavor's table is followed by its comments
+		var comments func(*fileAnalysis, dbtypes.TypeAnalyzer) string
+		if o.WithComments {
+			generated := time.Now()
+			if o.NoBanner {
+				generated = time.Time{}
+			}
+			comments = func(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) string {
+				return commentSQL(result, analyzer, tableName, inputLabel, generated)
+			}
+		}
+		if len(flavors) > 1 && o.Format == "ddl" {
+			createSQL, err = flavorsDDL(flavors, tableName, o.PrimaryKey, comments)
+		} else {
+			createSQL, err = createTableSQL(result, analyzer, tableName, o.PrimaryKey)
+			if err == nil && comments != nil {
+				createSQL += "\n" + comments(result, analyzer)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if o.WithChecks {
+			for _, f := range flavors {
+				if len(flavors) > 1 {
+					createSQL += "\n-- " + f.Flavor
+				}
+				createSQL += "\n" + checkSQL(f.Result, f.Analyzer, tableName, o.CheckHeadroom)
+			}
+		}
+		if o.SuggestIndexes {
+			for _, f := range flavors {
+				if len(flavors) > 1 {
+					createSQL += "\n-- " + f.Flavor
+				}
+				createSQL += "\n" + indexSQL(f.Result, f.Analyzer, tableName, o.PrimaryKey, o.PartitionTolerance)
+			}
+		}
+		if o.WithLoad {
+			staged := stageOptions{File: filePath, Stage: o.Stage, Pattern: o.StagePattern, OnError: o.LoadOnError, Purge: o.LoadPurge}
+			if staged.Pattern == "" {
+				staged.Pattern = filePattern(inputLabel)
+			}
+			for _, f := range flavors {
+				load, warnings, err := loadSQL(f.Result, f.Analyzer, tableName, opts, staged)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				for _, warning := range warnings {
+					printWarning(warning)
+				}
+				if len(flavors) > 1 {
+					createSQL += "\n-- " + f.Flavor
+				}
+				createSQL += "\n" + load
+			}
+		}
+		if o.WithMerge {
+			keys, err := parseMergeKey(o.MergeKey, result)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			for _, f := range flavors {
+				if len(flavors) > 1 {
+					createSQL += "\n-- " + f.Flavor
+				}
+				createSQL += "\n" + mergeSQL(f.Result, f.Analyzer, tableName, o.MergeTarget, keys)
+			}
+		}
+	}
+
+	if o.ManifestFile != "" {
+		err := content.finish(file)
+		if err == nil {
+			input := manifestInput{Path: filePath, Name: inputLabel, Format: o.InputFormat, HashScope: "whole input"}
+			if o.HeadBytes > 0 {
+				input.HashScope = fmt.Sprintf("first %d bytes (-head-bytes)", o.HeadBytes)
+			}
+			if byteRanged {
+				input.HashScope = "header and records of the byte range (-start-byte, -end-byte)"
+			}
+			flags := o.settings(flag.CommandLine)
+			err = writeManifest(o.ManifestFile, newManifest(result, analyzer, input, content, flags, tableName, time.Now()))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Migrations are files in a directory rather than a report
+	if o.Format == "migration" {
+		dir := o.Output
+		if dir == "" {
+			dir = "."
+		}
+		version := o.MigrationVersion
+		if version == "" {
+			version = time.Now().UTC().Format("20060102150405")
+		}
+		paths, err := writeMigration(dir, o.MigrationStyle, version, tableName, createSQL, analyzer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, path := range paths {
+			fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+		}
+		return
+	}
+
+	// Write to stdout unless an output file was given
+	var out io.Writer = os.Stdout
+	if o.Output != "" {
+		outFile, err := os.Create(o.Output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer outFile.Close()
+		out = outFile
+	}
+
+	// Print results
+	switch o.Format {
+	case "json":
+		if len(flavors) > 1 {
+			err = printFlavorsJSON(out, flavors)
+		} else {
+			err = printJSON(out, result, analyzer)
+		}
+	case "dbt":
+		printDBT(out, result, analyzer, o.DBTSource, tableName)
+	case "gostruct":
+		err = printGoStruct(out, result, analyzer, tableName)
+	case "avro":
+		err = printAvro(out, result, analyzer, tableName)
+	case "jsonschema":
+		err = printJSONSchema(out, result, analyzer, tableName)
+	case "spark":
+		printSpark(out, result, analyzer, o.SparkDDL)
+	case "sqlalchemy":
+		err = printSQLAlchemy(out, result, analyzer, tableName, o.PrimaryKey)
+	case "typescript":
+		printTypeScript(out, result, analyzer, tableName, o.TSBigNumbers)
+	case "proto":
+		printProto(out, result, analyzer, tableName)
+	case "ddl":
+		_, err = io.WriteString(out, createSQL)
+	case "typed-view":
+		view := o.ViewName
+		if view == "" {
+			view = tableName
+		}
+		for i, f := range flavors {
+			if len(flavors) > 1 {
+				if i > 0 {
+					fmt.Fprintln(out)
+				}
+				fmt.Fprintf(out, "-- %s\n", f.Flavor)
+			}
+			fmt.Fprint(out, typedViewSQL(f.Result, f.Analyzer, o.RawTable, view))
+		}
+	case "bqschema":
+		var warnings []string
+		warnings, err = printBQSchema(out, result, analyzer)
+		if err == nil && o.WithLoad {
+			// The command goes to stderr, keeping the output a schema file
+			schemaPath := o.Output
+			if schemaPath == "" {
+				schemaPath = "schema.json"
+			}
+			var command string
+			var loadWarnings []string
+			command, loadWarnings, err = bqLoadCommand(result, tableName, filePath, schemaPath, opts)
+			warnings = append(warnings, loadWarnings...)
+			if err == nil {
+				fmt.Fprint(os.Stderr, command)
+			}
+		}
+		for _, warning := range warnings {
+			printWarning(warning)
+		}
+	case "external":
+		var sql string
+		var warnings []string
+		sql, warnings, err = externalSQL(result, analyzer, tableName, o.ExternalLocation, o.ExternalDialect, opts)
+		for _, warning := range warnings {
+			printWarning(warning)
+		}
+		if err == nil {
+			_, err = io.WriteString(out, sql)
+		}
+	case "liquibase":
+		id := o.ChangeSetID
+		if id == "" {
+			id = fmt.Sprintf("create-%s-%x", tableName, hasher.Sum(nil)[:4])
+		}
+		err = printLiquibase(out, result, analyzer, tableName, o.PrimaryKey, id, o.ChangeSetAuthor)
+	default:
+		if len(flavors) > 1 {
+			printFlavorsText(out, flavors)
+		} else {
+			printText(out, result, analyzer)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// typeIndex returns the index of the named type in the analyzer's type list,
+// or -1 if the flavor has no such type
+func typeIndex(analyzer dbtypes.TypeAnalyzer, name string) int {
+	for i, t := range analyzer.GetTypes() {
+		if t.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// typeNames returns the names of the analyzer's types in order of preference
+func typeNames(analyzer dbtypes.TypeAnalyzer) []string {
+	var names []string
+	for _, t := range analyzer.GetTypes() {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// recordReader reads the records of a file, the first being the header
+type recordReader interface {
+	// Read returns the next record, nil for a blank line, or io.EOF. The
+	// record may be reused by the next Read, so a caller keeping it past
+	// that copies it, and clones the values it keeps with strings.Clone so
+	// that they do not hold on to the whole line.
+	Read() ([]string, error)
+	// Line returns the line number of the record last read
+	Line() int
+}
+
+// cloneValues copies a record and its values, to keep it past the next Read
+func cloneValues(record []string) []string {
+	clone := make([]string, len(record))
+	for i, value := range record {
+		clone[i] = strings.Clone(value)
+	}
+	return clone
+}
+
+// quoteReporter is a recordReader that knows which fields of the record last
+// read were quoted
+type quoteReporter interface {
+	Quoted() []bool
+}
+
+// quotedFields returns which fields of the record last read from r were
+// quoted, nil when r does not know
+func quotedFields(r recordReader) []bool {
+	if reporter, ok := r.(quoteReporter); ok {
+		return reporter.Quoted()
+	}
+	return nil
+}
+
+// textRecords reads the lines of a delimited text file as records, within
+// the line range and numbered on from any checkpoint resumed
+type textRecords struct {
+	*analyze.Records
+	startLine  int  // first line read after the header, 0 for the first line of the file
+	endLine    int  // last line read, 0 for the last line of the file
+	keepHeader bool // read the first record even when the range starts after it
+	headerRead bool
+}
+
+// newTextRecords reads the records of delimited text from r, split as opts
+// describe: at the record separator, within the size limits, on the
+// delimiter and with the quoting
+func newTextRecords(r io.Reader, opts analysisOptions) *textRecords {
+	records := &textRecords{
+		Records:   analyze.NewRecords(r, opts.Options),
+		startLine: opts.StartLine, endLine: opts.EndLine, keepHeader: opts.Header != "no",
+	}
+	// A checkpoint records where the next record starts, so that a resumed
+	// analysis reads on from there
+	if cp := opts.Checkpoint.resumed(); cp != nil {
+		records.StartAt(cp.Line, cp.Offset)
+	}
+	return records
+}
+
+func (t *textRecords) Read() ([]string, error) {
+	for {
+		if !t.Scan() {
+			if err := t.Err(); err != nil {
+				return nil, fmt.Errorf("error reading file: %v", err)
+			}
+			return nil, io.EOF
+		}
+		if t.endLine > 0 && t.Line() > t.endLine {
+			return nil, io.EOF
+		}
+		// Lines before the range are skipped unsplit, but for the header
+		if t.Line() >= t.startLine || (t.keepHeader && !t.headerRead && !t.Empty()) {
+			break
+		}
+	}
+	if !t.Empty() {
+		t.headerRead = true
+	}
+	return t.Split()
+}
+
+// analyzeFileTypes reads the delimited file and analyzes the types of each column
+func analyzeFileTypes(r io.Reader, opts analysisOptions, analyzer dbtypes.TypeAnalyzer) (*fileAnalysis, error) {
+	return analyzeRecords(newTextRecords(r, opts), opts, analyzer)
+}
+
+// analyzeRecords analyzes the types of each column of the records
+func analyzeRecords(records recordReader, opts analysisOptions, analyzer dbtypes.TypeAnalyzer) (*fileAnalysis, error) {
+	result := &fileAnalysis{LengthSemantics: opts.LengthSemantics, Location: opts.Location}
+
+	if verbose && opts.TwoDigitYears {
+		fmt.Fprintf(os.Stderr, "DEBUG: two-digit years %s\n", describeYearPivot(opts.YearPivot))
+	}
+
+	// A resumed analysis reads on from its checkpoint, under the header the
+	// checkpoint was saved with
+	resume := opts.Checkpoint.resumed()
+	if resume != nil {
+		records = opts.Checkpoint.resumeRecords(records, &opts)
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: resuming after %s rows, at line %d\n", groupDigits(resume.RowCount), resume.Line)
+		}
+	}
+
+	// nextRecord reads the next non-blank record, nil at the end of the file
+	nextRecord := func() ([]string, error) {
+		for {
+			record, err := records.Read()
+			if err == io.EOF {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if record != nil {
+				return record, nil
+			}
+			if opts.StrictBlankLines {
+				return nil, fmt.Errorf("%s %d is blank", opts.recordUnit(), records.Line())
+			}
+			result.BlankLines++
+		}
+	}
+
+	// Read headers; a file without even a header line has nothing to analyze
+	headers, err := nextRecord()
+	if err != nil {
+		return nil, err
+	}
+	if headers == nil {
+		return nil, fmt.Errorf("file contains no data")
+	}
+	// The header outlives the record it was read from
+	headers = cloneValues(headers)
+	headerQuoted := slices.Clone(quotedFields(records))
+	headerLine := records.Line()
+	// Kept to suggest another delimiter if the file does not split
+	textInput := textSource(records)
+	delimited := textInput != nil
+	firstLine := strings.Join(headers, opts.Delimiter)
+
+	// With ncols the width is fixed before any row is read, so a file
+	// without a header gets that many columns and every row is held to it,
+	// the first too, which is checked with the rest as a bad row
+	if opts.ExpectedCols > 0 && len(headers) != opts.ExpectedCols && opts.Header != "no" {
+		return nil, fmt.Errorf("header line has %d fields, expected %d", len(headers), opts.ExpectedCols)
+	}
+
+	// The first record names the columns unless told otherwise, or unless
+	// it looks like data when left to decide; records read to decide are
+	// replayed as data
+	replay := &replayRecords{recordReader: records}
+	switch opts.Header {
+	case "no":
+		replay.push(headers, headerQuoted, records.Line())
+		headers = numberedHeaders(cmp.Or(opts.ExpectedCols, len(headers)))
+		result.NoHeader = true
+	case "auto":
+		second, err := nextRecord()
+		if err != nil {
+			return nil, err
+		}
+		isHeader, reason := detectHeader(headers, second, analyzer, &opts)
+		if verbose {
+			if isHeader {
+				fmt.Fprintf(os.Stderr, "DEBUG: first row taken as the header: %s\n", reason)
+			} else {
+				fmt.Fprintf(os.Stderr, "DEBUG: first row read as data: %s\n", reason)
+			}
+		}
+		if !isHeader {
+			result.warnf("the first row does not look like a header, so it was read as data and the columns named column_1 to column_%d", len(headers))
+			replay.push(headers, headerQuoted, headerLine)
+			headers = numberedHeaders(len(headers))
+			result.NoHeader = true
+		}
+		if second != nil {
+			replay.push(second, quotedFields(records), records.Line())
+		}
+	default:
+		if cells, nonEmpty := dataLikeCells(headers, analyzer, &opts); looksLikeData(cells, nonEmpty) {
+			result.warnf("the header row looks like data, %d of its %d cells parse as numbers, dates or booleans (%s); if the file has no header, use -header no or -header auto",
+				len(cells), nonEmpty, strings.Join(cells, ", "))
+		}
+	}
+	records = replay
+
+	// Start with the most specific type (boolean)
+	result.Columns = make([]columnAnalysis, len(headers))
+	for i, header := range headers {
+		result.Columns[i] = columnAnalysis{
+			Name:       header,
+			TypeCounts: make([]int, len(analyzer.GetTypes())),
+			TypeLines:  make([][]int, len(analyzer.GetTypes())),
+		}
+		if opts.Examples {
+			result.Columns[i].Examples = &columnExamples{}
+		}
+	}
+	columns := result.Columns
+
+	// Each column takes the type of its first value and widens to the
+	// common type of that and each later value's type
+	promotions, err := promotionTable(analyzer)
+	if err != nil {
+		return nil, err
+	}
+	typed := make([]bool, len(headers))
+
+	// Columns with a declared layout are parsed with it alone
+	formats := make([]*formatCheck, len(headers))
+	for i, header := range headers {
+		if format, ok := opts.ColumnFormats[header]; ok {
+			formats[i] = &formatCheck{columnFormat: format, TypeIndex: typeIndex(analyzer, format.Type)}
+		}
+	}
+	var widths fieldCounts
+	skipped := 0
+	unreadable := 0
+	var firstUnreadable *analyze.RowError
+	if opts.SkipBadRows {
+		widths = make(fieldCounts)
+	}
+	// Fields written as a null token are nulls like empty ones, counted by
+	// token so that a loader taking a single one can use the most frequent
+	var nullTokens map[string]bool
+	if len(opts.NullTokens) > 0 {
+		nullTokens = make(map[string]bool)
+		for _, token := range opts.NullTokens {
+			nullTokens[token] = true
+		}
+		result.NullCounts = make(map[string]int)
+	}
+	// The trackers whose state grows with the rows share a memory budget
+	budget := &memoryBudget{limit: opts.MemoryBudget}
+	var duplicates *duplicateDetector
+	if opts.DetectDuplicates {
+		duplicates = newDuplicateDetector(budget)
+		result.Duplicates = duplicates.report
+	}
+	var keys *keyDetector
+	if opts.DetectKeys {
+		keys = newKeyDetector(len(headers), budget)
+	}
+	var values *valueTracker
+	if opts.TrackValues {
+		values = newValueTracker(len(headers), budget)
+	}
+	var hints *nameHintChecker
+	if opts.NameHints != "" {
+		hints = newNameHintChecker(headers)
+	}
+	control := newControlChecker(opts)
+	numericType := typeIndex(analyzer, "numeric")
+
+	// A resumed analysis takes up the state of the rows read before its
+	// checkpoint
+	if resume != nil {
+		result.RowCount, result.BlankLines, result.NoHeader = resume.RowCount, resume.BlankLines, resume.NoHeader
+		result.HeaderRepeats, result.HeaderRepeatLines = resume.HeaderRepeats, resume.HeaderRepeatLines
+		result.Warnings = resume.Warnings
+		if result.NullCounts != nil && resume.NullCounts != nil {
+			result.NullCounts = resume.NullCounts
+		}
+		result.Columns, typed, formats = resume.Columns, resume.Typed, resume.Formats
+		columns = result.Columns
+		if widths != nil && resume.Widths != nil {
+			widths = resume.Widths
+		}
+		skipped, unreadable, firstUnreadable = resume.Skipped, resume.Unreadable, resume.FirstUnreadable
+		if hints != nil && resume.HintValues != nil {
+			hints.values = resume.HintValues
+		}
+	}
+	// Checkpoints are saved between records read from the file, not those
+	// replayed ahead of them
+	saveCheckpoint := func() error {
+		cp := &checkpoint{
+			Offset: textInput.Offset(), Line: textInput.Line(),
+			Headers: headers, HeaderQuoted: headerQuoted, HeaderLine: headerLine, NoHeader: result.NoHeader,
+			RowCount: result.RowCount, BlankLines: result.BlankLines,
+			HeaderRepeats: result.HeaderRepeats, HeaderRepeatLines: result.HeaderRepeatLines,
+			NullCounts: result.NullCounts, Warnings: result.Warnings,
+			Columns: columns, Typed: typed, Formats: formats, Widths: widths,
+			Skipped: skipped, Unreadable: unreadable, FirstUnreadable: firstUnreadable,
+		}
+		if hints != nil {
+			cp.HintValues = hints.values
+		}
+		return opts.Checkpoint.save(cp)
+	}
+
+	// Process each line
+	for {
+		if opts.Checkpoint.due(result.RowCount) && textInput != nil && len(replay.records) == 0 {
+			if err := saveCheckpoint(); err != nil {
+				return nil, err
+			}
+		}
+		fields, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *analyze.RowError
+		if errors.As(err, &rowErr) && widths != nil {
+			if unreadable == 0 {
+				firstUnreadable = rowErr
+			}
+			unreadable++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Skip blank lines, which still count towards the line number
+		if fields == nil {
+			if opts.StrictBlankLines {
+				return nil, fmt.Errorf("%s %d is blank", opts.recordUnit(), records.Line())
+			}
+			result.BlankLines++
+			continue
+		}
+
+		// Validate field count
+		if widths != nil {
+			widths.observe(len(fields), records.Line())
+		}
+		if len(fields) != len(headers) {
+			if widths != nil {
+				skipped++
+				continue
+			}
+			if opts.ExpectedCols > 0 {
+				return nil, fmt.Errorf("%s %d has %d fields, expected %d from -ncols", opts.recordUnit(), records.Line(), len(fields), opts.ExpectedCols)
+			}
+			return nil, fmt.Errorf("%s %d has %d fields, expected %d", opts.recordUnit(), records.Line(), len(fields), len(headers))
+		}
+
+		// Concatenated exports repeat the header where each part starts
+		if !result.NoHeader && !opts.KeepHeaderRepeats && slices.Equal(fields, headers) {
+			result.HeaderRepeats++
+			if len(result.HeaderRepeatLines) < maxOutlierLines {
+				result.HeaderRepeatLines = append(result.HeaderRepeatLines, records.Line())
+			}
+			continue
+		}
+		result.RowCount++
+		if duplicates != nil {
+			duplicates.observe(fields, records.Line())
+		}
+
+		// An empty field is a null, unless it was quoted and quoted empty
+		// fields are read as empty strings
+		var quoted []bool
+		if opts.QuotedEmpty {
+			quoted = quotedFields(records)
+		}
+
+		// Analyze each field
+		for i, field := range fields {
+			if opts.NormalizePunct {
+				var n int
+				field, n = normalizePunctuation(field)
+				columns[i].Normalized += n
+			}
+			if inner, ok := unwrapExcelFormula(field); ok {
+				columns[i].ExcelFormulas++
+				if opts.UnwrapExcel {
+					field = inner
+				}
+			}
+			field = control.check(&columns[i], field, records.Line())
+			null := field == "" && (quoted == nil || !quoted[i]) || nullTokens[field]
+			if null && result.NullCounts != nil {
+				if _, ok := result.NullCounts[field]; !ok {
+					field = strings.Clone(field)
+				}
+				result.NullCounts[field]++
+			}
+			if null {
+				field = ""
+			}
+			if keys != nil {
+				keys.observe(i, field, null, records.Line())
+			}
+			if values != nil && !null {
+				values.observe(i, field)
+			}
+			format := formats[i]
+			// The number a percentage or an accounting value is written as
+			// decides its type, while lengths are those of the value as written
+			number := field
+			percent := false
+			if opts.StripPercent && format == nil {
+				if number, percent = stripPercent(field); percent {
+					columns[i].PercentCount++
+				}
+			}
+			if opts.AccountingNumbers && format == nil {
+				if normalized, ok := normalizeAccounting(number); ok {
+					number = normalized
+					columns[i].AccountingCount++
+				}
+			}
+			var fieldType int
+			switch {
+			case format != nil:
+				fieldType = format.TypeIndex
+				if field != "" {
+					if t, ok := format.parse(field, records.Line(), opts.location()); ok {
+						columns[i].observeTime(field, t)
+					}
+				}
+			case percent && numericType >= 0:
+				fieldType = numericType
+			default:
+				fieldType = inferType(number, analyzer, &opts)
+			}
+			// A null says nothing about the type of the column
+			if !null {
+				promoted := fieldType
+				if typed[i] {
+					promoted = promotions[columns[i].TypeIndex][fieldType]
+				}
+				typed[i] = true
+				if promoted != columns[i].TypeIndex {
+					columns[i].TypeIndex = promoted
+					if verbose {
+						fmt.Fprintf(os.Stderr, "DEBUG: field %s promoted to type %s\n", headers[i], analyzer.GetTypes()[promoted].Name)
+					}
+					if columns[i].Examples != nil {
+						columns[i].Examples.promoted(analyzer.GetTypes()[promoted].Name, field, records.Line())
+					}
+				}
+			}
+			if field != "" && columns[i].Examples != nil {
+				columns[i].Examples.observe(field)
+			}
+			// Every value is measured, since a column widened to a type
+			// with a length holds the values of the other types too
+			length := valueLength(field, opts.LengthSemantics)
+			columns[i].MaxLength = max(columns[i].MaxLength, length)
+			columns[i].MaxBytes = max(columns[i].MaxBytes, len(field))
+			columns[i].MaxChars = max(columns[i].MaxChars, utf8.RuneCountInString(field))
+			if field != "" {
+				columns[i].observeLength(length, records.Line())
+			}
+			switch analyzer.GetTypes()[fieldType].Name {
+			case "timestamp":
+				if digits := fractionalDigits(field); digits > columns[i].FracDigits {
+					columns[i].FracDigits = digits
+				}
+				if t, layout, ok := analyze.MatchTimestamp(field, opts.location()); ok && format == nil {
+					columns[i].observeTime(field, t)
+					columns[i].observeLayout(layout)
+				}
+			case "date":
+				if t, layout, ok := analyze.MatchDate(field, opts.Options); ok && format == nil {
+					columns[i].observeTime(field, t)
+					columns[i].observeLayout(layout)
+				}
+			}
+			if hints != nil && !null {
+				hints.observe(i, field, analyzer.GetTypes()[fieldType].Name)
+			}
+			if null {
+				columns[i].EmptyCount++
+			} else {
+				columns[i].TypeCounts[fieldType]++
+				if len(columns[i].TypeLines[fieldType]) < maxOutlierLines {
+					columns[i].TypeLines[fieldType] = append(columns[i].TypeLines[fieldType], records.Line())
+				}
+			}
+			columns[i].observeNumber(number)
+			if opts.DetectGeo && analyze.IsWKT(field) {
+				columns[i].WKTCount++
+			}
+			if opts.DetectBinary && !null {
+				columns[i].observeBinary(field)
+			}
+			if opts.DetectXML && isXML(field, opts.XMLMaxBytes) {
+				columns[i].XMLCount++
+			}
+			if opts.DetectCodes && !null {
+				columns[i].observeCode(field)
+			}
+			if opts.DetectCompact && !null {
+				columns[i].observeCompactDate(field)
+			}
+			if opts.DetectHex {
+				columns[i].observeIntegerLiteral(field)
+			}
+		}
+	}
+
+	for i, format := range formats {
+		if format != nil {
+			columns[i].TimeLayout = format.Layout
+		}
+	}
+	if keys != nil {
+		keys.finish(columns)
+	}
+	if values != nil {
+		values.finish(columns)
+	}
+
+	// Lengths only mean something for the types that take one
+	for i := range columns {
+		if !analyzer.GetTypes()[columns[i].TypeIndex].HasLength {
+			columns[i].MaxLength, columns[i].MaxBytes, columns[i].MaxChars = 0, 0, 0
+			columns[i].LengthCounts, columns[i].LongValues = nil, nil
+		}
+	}
+
+	if result.HeaderRepeats > 0 {
+		result.warnf("%s", headerRepeatWarning(result.HeaderRepeats, result.HeaderRepeatLines))
+	}
+	if verbose && result.BlankLines > 0 {
+		fmt.Fprintf(os.Stderr, "DEBUG: skipped %d blank lines\n", result.BlankLines)
+	}
+	if verbose {
+		for _, col := range columns {
+			if col.MaxBytes > 0 {
+				fmt.Fprintf(os.Stderr, "DEBUG: column %s: longest value %d bytes, %d characters\n", col.Name, col.MaxBytes, col.MaxChars)
+			}
+		}
+	}
+	if skipped > 0 {
+		result.warnf("skipped %s rows without %d fields; rows by field count: %s",
+			groupDigits(skipped), len(headers), widths.summary(len(headers)))
+	}
+	for i, format := range formats {
+		if format != nil && format.Misses > 0 {
+			result.warnf("%s", format.warning(headers[i]))
+		}
+	}
+	if unreadable > 0 {
+		result.warnf("skipped %s rows that could not be read, the first because %v", groupDigits(unreadable), firstUnreadable)
+	}
+	result.Warnings = append(result.Warnings, excelFormulaWarnings(result, opts.UnwrapExcel)...)
+	if duplicates != nil {
+		result.Warnings = append(result.Warnings, duplicates.budgetWarnings()...)
+	}
+	if keys != nil {
+		result.Warnings = append(result.Warnings, keys.budgetWarnings(budget)...)
+	}
+	if values != nil {
+		result.Warnings = append(result.Warnings, values.budgetWarnings()...)
+	}
+	result.MemoryBudget, result.PeakMemory = opts.MemoryBudget, budget.peak
+	if verbose && budget.peak > 0 {
+		fmt.Fprintf(os.Stderr, "DEBUG: trackers held at most %s\n", formatBytes(budget.peak))
+	}
+
+	addConfidence(result, analyzer, opts.Sampled)
+
+	// A wrong delimiter leaves every line in one field
+	if delimited {
+		switch single := widths[1]; {
+		case len(columns) == 1 && isStringType(analyzer.GetTypes()[columns[0].TypeIndex].Name):
+			result.warnf("%s", delimiterHint("only 1 column detected", firstLine, opts.Delimiter, opts.DelimiterRegex))
+		case single != nil && single.Rows*10 > (result.RowCount+skipped)*9:
+			percent := 100 * single.Rows / (result.RowCount + skipped)
+			result.warnf("%s", delimiterHint(fmt.Sprintf("%d%% of rows have 1 field", percent), firstLine, opts.Delimiter, opts.DelimiterRegex))
+		}
+	}
+
+	if opts.DetectEpoch {
+		detectEpochColumns(result, analyzer, opts.EpochMinYear, opts.EpochMaxYear)
+	}
+	if opts.DetectCompact {
+		detectCompactDateColumns(result, analyzer)
+	}
+	if opts.DetectHex {
+		detectIntegerLiteralColumns(result, analyzer)
+	}
+	if opts.StripPercent {
+		detectPercentColumns(result, analyzer, opts.PercentFraction)
+	}
+	if opts.AccountingNumbers {
+		detectAccountingColumns(result, analyzer)
+	}
+	if opts.DetectGeo {
+		detectGeoColumns(result, analyzer)
+	}
+	if opts.DetectBinary {
+		detectBinaryColumns(result, analyzer, opts.BinaryMinLength)
+	}
+	if opts.DetectXML {
+		detectXMLColumns(result, analyzer)
+	}
+	if opts.DetectCodes {
+		detectCodeColumns(result, analyzer)
+	}
+	if hints != nil {
+		result.Warnings = append(result.Warnings, hints.finish(result, analyzer, opts.NameHints == "prefer")...)
+	}
+
+	if opts.OutlierFraction > 0 {
+		for _, outlier := range findOutliers(result, analyzer, opts.OutlierFraction) {
+			if opts.StrictOutliers {
+				return nil, fmt.Errorf("%s", outlier)
+			}
+			result.warnf("%s", outlier)
+		}
+	}
+
+	// Control characters fail loads whatever the type, NUL bytes
+	// most of all, so they are warned about first
+	result.Warnings = append(controlWarnings(result, opts.StripControl), result.Warnings...)
+
+	clampTimestampPrecision(result, analyzer)
+
+	// Without values nothing was promoted, so report the configured
+	// fallback type rather than the one an empty value parses as
+	emptyType := typeIndex(analyzer, opts.EmptyColumnType)
+	if emptyType < 0 {
+		emptyType = len(analyzer.GetTypes()) - 1
+	}
+	if result.RowCount == 0 {
+		result.warnf("file has a header but no data rows; zero rows were analyzed and every column is reported as %s", opts.EmptyColumnType)
+		for i := range columns {
+			columns[i].TypeIndex = emptyType
+		}
+	} else {
+		var empty []string
+		for i := range columns {
+			if columns[i].EmptyCount == result.RowCount {
+				columns[i].TypeIndex = emptyType
+				columns[i].MaxLength = 0
+				columns[i].NoValues = true
+				empty = append(empty, columns[i].Name)
+			}
+		}
+		if len(empty) > 0 {
+			message := fmt.Sprintf("column %s has no non-null values in %d rows and is reported as %s; a wrong delimiter or quote setting can shift data out of columns",
+				empty[0], result.RowCount, opts.EmptyColumnType)
+			if len(empty) > 1 {
+				message = fmt.Sprintf("columns %s have no non-null values in %d rows and are reported as %s; a wrong delimiter or quote setting can shift data out of columns",
+					strings.Join(empty, ", "), result.RowCount, opts.EmptyColumnType)
+			}
+			if opts.StrictEmpty {
+				return nil, fmt.Errorf("%s", message)
+			}
+			result.warnf("%s", message)
+		}
+	}
+	if opts.StrictConfidence {
+		if err := lowConfidenceError(result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// fractionalSeconds matches the fractional part following the seconds of a
+// time of day
+var fractionalSeconds = regexp.MustCompile(`:\d{2}[.,](\d+)`)
+
+// fractionalDigits returns the number of fractional-second digits in a
+// timestamp value
+func fractionalDigits(value string) int {
+	match := fractionalSeconds.FindStringSubmatch(value)
+	if match == nil {
+		return 0
+	}
+	return len(match[1])
+}
+
+// clampTimestampPrecision limits each timestamp column's precision to what
+// the flavor supports, warning about columns whose values carry more digits
+func clampTimestampPrecision(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) {
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		dataType := analyzer.GetTypes()[col.TypeIndex]
+		if dataType.Name != "timestamp" || col.FracDigits <= dataType.MaxPrecision {
+			continue
+		}
+		result.warnf("column %s has values with %d fractional-second digits; precision clamped to %s(%d)",
+			col.Name, col.FracDigits, dataType.Name, dataType.MaxPrecision)
+		col.FracDigits = dataType.MaxPrecision
+	}
+}
+
+// inferType returns the index of the narrowest of the analyzer's types that
+// holds the value, defaulting to the last
+func inferType(value string, analyzer dbtypes.TypeAnalyzer, opts *analysisOptions) int {
+	types := analyzer.GetTypes()
+	if index := analyze.InferIndex(value, types, opts.Options); index >= 0 {
+		return index
+	}
+	return len(types) - 1
+}
+
+// promotionTable returns the index of the common type of any two of the
+// analyzer's types, by their indices
+func promotionTable(analyzer dbtypes.TypeAnalyzer) ([][]int, error) {
+	types := analyzer.GetTypes()
+	table := make([][]int, len(types))
+	for i, a := range types {
+		table[i] = make([]int, len(types))
+		for j, b := range types {
+			common, err := dbtypes.CommonType(analyzer, a.Name, b.Name)
+			if err != nil {
+				return nil, err
+			}
+			table[i][j] = typeIndex(analyzer, common)
+		}
+	}
+	return table, nil
+}
+
+// describeYearPivot explains how two-digit years are expanded
+func describeYearPivot(pivot int) string {
+	switch pivot {
+	case 0:
+		return "00-99 are read as 1900-1999"
+	case 100:
+		return "00-99 are read as 2000-2099"
+	}
+	return fmt.Sprintf("00-%02d are read as 2000-%d, %02d-99 as %d-1999", pivot-1, 2000+pivot-1, pivot, 1900+pivot)
 }
@@ -3,9 +3,12 @@ package main
 import (
 	"bufio"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"file2ddl/analyze"
 	"file2ddl/dbtypes"
 )
 
@@ -36,12 +39,33 @@ func TestTypeInference(t *testing.T) {
 		{"numeric", "123.45", "numeric"},
 		{"timestamp", "2024-03-20 10:30:00", "timestamp"},
 		{"date", "2024-03-20", "date"},
+		{"date_month_abbrev", "Mar 20, 2024", "date"},
+		{"date_month_full", "March 20, 2024", "date"},
+		{"date_day_month_abbrev", "20-Mar-2024", "date"},
+		{"date_weekday_full", "Wednesday, 20 March 2024", "date"},
+		{"date_lowercase_month", "20-mar-2024", "date"},
+		{"date_uppercase_month", "MARCH 20, 2024", "date"},
+		{"date_ordinal_suffix", "March 20th, 2024", "date"},
+		{"date_ordinal_suffix_first", "1st Jan 2024", "date"},
+		{"timestamp_rfc1123", "Wed, 20 Mar 2024 10:30:00 GMT", "timestamp"},
+		{"timestamp_rfc1123z", "Wed, 20 Mar 2024 10:30:00 -0500", "timestamp"},
+		{"not_a_month", "Foo 20, 2024", "varchar"},
+		{"timestamp_12h", "3/20/2024 2:45:00 PM", "timestamp"},
+		{"timestamp_12h_no_seconds", "3/20/2024 2:45 PM", "timestamp"},
+		{"timestamp_12h_lowercase", "03/20/2024 02:45:00 pm", "timestamp"},
+		{"timestamp_12h_dashes", "3-20-2024 2:45:00 AM", "timestamp"},
+		{"timestamp_12h_iso_date", "2024-03-20 2:45 PM", "timestamp"},
+		{"timestamp_12h_fraction", "3/20/2024 2:45:00.123 PM", "timestamp"},
+		{"timestamp_12_am", "3/20/2024 12:15:00 AM", "timestamp"},
+		{"timestamp_12_pm", "3/20/2024 12:15:00 PM", "timestamp"},
+		{"timestamp_13_pm", "3/20/2024 13:15:00 PM", "varchar"},
+		{"timestamp_24h_slash", "3/20/2024 14:45:00", "timestamp"},
 		{"varchar", "Hello, World!", "varchar"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := analyzer.GetTypes()[inferType(tt.value, analyzer)].Name
+			got := analyzer.GetTypes()[inferType(tt.value, analyzer, &analysisOptions{})].Name
 			if got != tt.expected {
 				t.Errorf("inferType(%q) = %v, want %v", tt.value, got, tt.expected)
 			}
@@ -49,6 +73,49 @@ func TestTypeInference(t *testing.T) {
 	}
 }
 
+func TestTwoDigitYears(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+
+	tests := []struct {
+		name     string
+		value    string
+		enabled  bool
+		pivot    int
+		expected string
+	}{
+		{"disabled", "03/20/24", false, 69, "varchar"},
+		{"month first", "03/20/24", true, 69, "date"},
+		{"day first", "20/03/24", true, 69, "date"},
+		{"single digit month and day", "3/5/24", true, 69, "date"},
+		{"month name", "20-Mar-24", true, 69, "date"},
+		{"invalid day", "02/30/24", true, 69, "varchar"},
+		{"leap day in 2000", "02/29/00", true, 69, "date"},
+		{"leap day in 1900", "02/29/00", true, 0, "varchar"},
+		{"four digit year still a date", "03/20/2024", true, 69, "date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &analysisOptions{Options: analyze.Options{TwoDigitYears: tt.enabled, YearPivot: tt.pivot}}
+			got := analyzer.GetTypes()[inferType(tt.value, analyzer, opts)].Name
+			if got != tt.expected {
+				t.Errorf("inferType(%q) = %v, want %v", tt.value, got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("mixed column", func(t *testing.T) {
+		opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none", TwoDigitYears: true, YearPivot: 69}}
+		result, err := analyzeFileTypes(strings.NewReader("d\n03/20/2024\n03/21/24\n"), opts, analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		if got := analyzer.GetTypes()[result.Columns[0].TypeIndex].Name; got != "date" {
+			t.Errorf("got type %s, want date", got)
+		}
+	})
+}
+
 func TestTypeCompatibility(t *testing.T) {
 	analyzer := &dbtypes.PostgreSQLAnalyzer{}
 	compatibility := analyzer.GetTypeCompatibility()
@@ -61,12 +128,12 @@ func TestTypeCompatibility(t *testing.T) {
 		{
 			name:     "timestamp compatibility",
 			types:    compatibility["timestamp"],
-			expected: []string{"timestamp", "date", "text"},
+			expected: []string{"timestamp", "date", "varchar", "text"},
 		},
 		{
 			name:     "smallint compatibility",
 			types:    compatibility["smallint"],
-			expected: []string{"smallint", "integer", "bigint", "numeric", "text"},
+			expected: []string{"smallint", "integer", "bigint", "numeric", "varchar", "text"},
 		},
 	}
 
@@ -85,6 +152,34 @@ func TestTypeCompatibility(t *testing.T) {
 	}
 }
 
+func TestTypePromotion(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	tests := []struct {
+		name     string
+		values   []string
+		expected string
+	}{
+		{"widening integers", []string{"1", "40000", "2"}, "integer"},
+		{"integers and decimals", []string{"1", "2.5"}, "numeric"},
+		{"booleans and numbers", []string{"true", "5"}, "varchar(4)"},
+		{"numbers and dates", []string{"12345678", "2024-03-20"}, "varchar(10)"},
+		{"numbers longer than the text", []string{"12345678", "ab"}, "varchar(8)"},
+		{"timestamps and dates", []string{"2024-03-20 10:30:00", "2024-03-21"}, "date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "v\n" + strings.Join(tt.values, "\n") + "\n"
+			result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			if got := columnTypeName(result.Columns[0], analyzer); got != tt.expected {
+				t.Errorf("got %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestFileAnalysis(t *testing.T) {
 	// Create a temporary file with test data
 	tmpFile := "testdata/sample.csv"
@@ -130,7 +225,7 @@ func TestFileAnalysis(t *testing.T) {
 			file.Seek(0, 0)
 
 			// Analyze the file using the new function
-			headers, columnTypes, maxLengths, err := analyzeFileTypes(file, ",", "none", tc.ncols, analyzer)
+			result, err := analyzeFileTypes(file, analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, ExpectedCols: tc.ncols}, analyzer)
 
 			if tc.wantErr {
 				if err == nil {
@@ -167,16 +262,16 @@ func TestFileAnalysis(t *testing.T) {
 			}
 
 			// Verify the inferred types and max lengths
-			for i, header := range headers {
-				expected := expectedTypes[header]
-				got := analyzer.GetTypes()[columnTypes[i]].Name
+			for _, col := range result.Columns {
+				expected := expectedTypes[col.Name]
+				got := analyzer.GetTypes()[col.TypeIndex].Name
 				if got != expected {
-					t.Errorf("Column %s: got type %s, want %s", header, got, expected)
+					t.Errorf("Column %s: got type %s, want %s", col.Name, got, expected)
 				}
 				if got == "varchar" {
-					expectedLen := expectedMaxLengths[header]
-					if maxLengths[i] != expectedLen {
-						t.Errorf("Column %s: got varchar(%d), want varchar(%d)", header, maxLengths[i], expectedLen)
+					expectedLen := expectedMaxLengths[col.Name]
+					if col.MaxLength != expectedLen {
+						t.Errorf("Column %s: got varchar(%d), want varchar(%d)", col.Name, col.MaxLength, expectedLen)
 					}
 				}
 			}
@@ -199,7 +294,7 @@ func TestInvalidFieldCount(t *testing.T) {
 	analyzer := &dbtypes.PostgreSQLAnalyzer{}
 
 	// Analyze the file
-	_, _, _, err = analyzeFileTypes(file, ",", "none", 0, analyzer)
+	_, err = analyzeFileTypes(file, analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
 	if err == nil {
 		t.Error("analyzeFileTypes() error = nil, want error")
 		return
@@ -228,6 +323,41 @@ func TestGetAnalyzer(t *testing.T) {
 			flavor:  "PostgreSQL",
 			wantErr: false,
 		},
+		{
+			name:    "valid snowflake flavor",
+			flavor:  "snowflake",
+			wantErr: false,
+		},
+		{
+			name:    "valid hana flavor",
+			flavor:  "hana",
+			wantErr: false,
+		},
+		{
+			name:    "valid firebird flavor",
+			flavor:  "firebird",
+			wantErr: false,
+		},
+		{
+			name:    "valid vertica flavor",
+			flavor:  "vertica",
+			wantErr: false,
+		},
+		{
+			name:    "valid greenplum flavor",
+			flavor:  "greenplum",
+			wantErr: false,
+		},
+		{
+			name:    "valid mariadb flavor",
+			flavor:  "mariadb",
+			wantErr: false,
+		},
+		{
+			name:    "valid exasol flavor",
+			flavor:  "exasol",
+			wantErr: false,
+		},
 		{
 			name:        "invalid flavor",
 			flavor:      "mysql",
@@ -260,6 +390,26 @@ func TestGetAnalyzer(t *testing.T) {
 	}
 }
 
+func TestParseMajorMinor(t *testing.T) {
+	tests := []struct {
+		version      string
+		major, minor int
+		ok           bool
+	}{
+		{"10.6", 10, 6, true},
+		{"11.4.2", 11, 4, true},
+		{"10", 0, 0, false},
+		{"10.x", 0, 0, false},
+		{"10.6.1.1", 0, 0, false},
+	}
+	for _, tt := range tests {
+		major, minor, ok := parseMajorMinor(tt.version)
+		if major != tt.major || minor != tt.minor || ok != tt.ok {
+			t.Errorf("parseMajorMinor(%q) = %d, %d, %v, want %d, %d, %v", tt.version, major, minor, ok, tt.major, tt.minor, tt.ok)
+		}
+	}
+}
+
 func TestQuotedFieldHandling(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -267,6 +417,7 @@ func TestQuotedFieldHandling(t *testing.T) {
 		delim    string
 		quotes   string
 		expected []string
+		errText  string
 	}{
 		{
 			name:     "unquoted fields",
@@ -303,6 +454,20 @@ func TestQuotedFieldHandling(t *testing.T) {
 			quotes:   "double",
 			expected: []string{"a,b", "c,d"},
 		},
+		{
+			name:     "multi-character delimiter",
+			input:    "a~|~b~|~~|~c",
+			delim:    "~|~",
+			quotes:   "none",
+			expected: []string{"a", "b", "", "c"},
+		},
+		{
+			name:     "multi-character delimiter inside quotes",
+			input:    `"a~|~b"~|~c`,
+			delim:    "~|~",
+			quotes:   "double",
+			expected: []string{"a~|~b", "c"},
+		},
 		{
 			name:     "quoted fields with spaces",
 			input:    `"a b","c d"`,
@@ -310,11 +475,97 @@ func TestQuotedFieldHandling(t *testing.T) {
 			quotes:   "double",
 			expected: []string{"a b", "c d"},
 		},
+		{
+			name:     "apostrophe in an unquoted field",
+			input:    "1,O'Brien,Dublin",
+			delim:    ",",
+			quotes:   "single",
+			expected: []string{"1", "O'Brien", "Dublin"},
+		},
+		{
+			name:     "doubled quote in a quoted field",
+			input:    "1,'O''Brien','Dublin, Ireland'",
+			delim:    ",",
+			quotes:   "single",
+			expected: []string{"1", "O'Brien", "Dublin, Ireland"},
+		},
+		{
+			name:     "quoted and unquoted apostrophes in one line",
+			input:    "O'Brien,'O''Neil, Jr.',it's,'',''''",
+			delim:    ",",
+			quotes:   "single",
+			expected: []string{"O'Brien", "O'Neil, Jr.", "it's", "", "'"},
+		},
+		{
+			name:     "quote inside a quoted field not followed by the delimiter",
+			input:    "'don't, stop',x",
+			delim:    ",",
+			quotes:   "single",
+			expected: []string{"don't, stop", "x"},
+		},
+		{
+			name:     "inch mark in an unquoted field",
+			input:    `12" pipe,"3/4"" pipe"`,
+			delim:    ",",
+			quotes:   "double",
+			expected: []string{`12" pipe`, `3/4" pipe`},
+		},
+		{
+			name:     "multibyte characters",
+			input:    "'Zürich','São Paulo'",
+			delim:    ",",
+			quotes:   "single",
+			expected: []string{"Zürich", "São Paulo"},
+		},
+		{
+			name:    "unterminated quoted field",
+			input:   `1,"Smith, John,42`,
+			delim:   ",",
+			quotes:  "double",
+			errText: "unterminated quoted field starting at column 2",
+		},
+		{
+			name:     "blanks after a closing quote",
+			input:    `"a" ,"b"  `,
+			delim:    ",",
+			quotes:   "double",
+			expected: []string{"a", "b"},
+		},
+		{
+			name:    "quote before a character other than the delimiter",
+			input:   `"a"b,c`,
+			delim:   ",",
+			quotes:  "double",
+			errText: "unterminated quoted field starting at column 1",
+		},
+		{
+			name:    "empty delimiter unquoted",
+			input:   "a,b",
+			delim:   "",
+			quotes:  "none",
+			errText: "empty delimiter",
+		},
+		{
+			name:    "empty delimiter quoted",
+			input:   `"a",b`,
+			delim:   "",
+			quotes:  "double",
+			errText: "empty delimiter",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fields := splitFields(tt.input, tt.delim, tt.quotes)
+			fields, _, err := analyze.SplitFields(tt.input, tt.delim, tt.quotes)
+			if tt.errText != "" {
+				if err == nil || err.Error() != tt.errText {
+					t.Errorf("analyze.SplitFields() error = %v, want %q", err, tt.errText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("analyze.SplitFields() error = %v, want nil", err)
+			}
 			if len(fields) != len(tt.expected) {
 				t.Errorf("got %d fields, want %d", len(fields), len(tt.expected))
 				return
@@ -328,6 +579,49 @@ func TestQuotedFieldHandling(t *testing.T) {
 	}
 }
 
+func TestQuotedEmptyFields(t *testing.T) {
+	_, quoted, err := analyze.SplitFields(`1,"",,'a'`, ",", "double")
+	if err != nil {
+		t.Fatalf("analyze.SplitFields() error = %v, want nil", err)
+	}
+	if want := []bool{false, true, false, false}; !reflect.DeepEqual(quoted, want) {
+		t.Errorf("analyze.SplitFields() quoted = %v, want %v", quoted, want)
+	}
+	if _, quoted, _ := analyze.SplitFields("1,,2", ",", "none"); quoted != nil {
+		t.Errorf("analyze.SplitFields() quoted = %v without quotes, want nil", quoted)
+	}
+
+	rows := "1,\"\"\n2,\n3,\"x\"\n"
+	tests := []struct {
+		name        string
+		input       string
+		header      string
+		quotedEmpty bool
+		wantEmpty   int
+	}{
+		{"quoted empty is null", "id,note\n" + rows, "", false, 2},
+		{"quoted empty is empty", "id,note\n" + rows, "", true, 1},
+		{"replayed rows keep their quoting", rows, "no", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}, Header: tt.header, QuotedEmpty: tt.quotedEmpty}
+			result, err := analyzeFileTypes(strings.NewReader(tt.input), opts, &dbtypes.PostgreSQLAnalyzer{})
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			note := result.Columns[1]
+			if note.EmptyCount != tt.wantEmpty {
+				t.Errorf("EmptyCount = %d, want %d", note.EmptyCount, tt.wantEmpty)
+			}
+			stats := newColumnStats(note, result.RowCount, &dbtypes.PostgreSQLAnalyzer{}, time.UTC)
+			if stats.NonNullRows != result.RowCount-tt.wantEmpty {
+				t.Errorf("NonNullRows = %d, want %d", stats.NonNullRows, result.RowCount-tt.wantEmpty)
+			}
+		})
+	}
+}
+
 func TestQuotedFileAnalysis(t *testing.T) {
 	// Create a temporary file with test data
 	tmpFile := "testdata/quoted_sample.csv"
@@ -343,7 +637,7 @@ func TestQuotedFileAnalysis(t *testing.T) {
 	analyzer := &dbtypes.PostgreSQLAnalyzer{}
 
 	// Analyze the file using the new function
-	headers, columnTypes, maxLengths, err := analyzeFileTypes(file, ",", "double", 0, analyzer)
+	result, err := analyzeFileTypes(file, analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}}, analyzer)
 	if err != nil {
 		t.Fatalf("Failed to analyze file: %v", err)
 	}
@@ -371,16 +665,16 @@ func TestQuotedFileAnalysis(t *testing.T) {
 	}
 
 	// Verify the inferred types and max lengths
-	for i, header := range headers {
-		expected := expectedTypes[header]
-		got := analyzer.GetTypes()[columnTypes[i]].Name
+	for _, col := range result.Columns {
+		expected := expectedTypes[col.Name]
+		got := analyzer.GetTypes()[col.TypeIndex].Name
 		if got != expected {
-			t.Errorf("Column %s: got type %s, want %s", header, got, expected)
+			t.Errorf("Column %s: got type %s, want %s", col.Name, got, expected)
 		}
 		if got == "varchar" {
-			expectedLen := expectedMaxLengths[header]
-			if maxLengths[i] != expectedLen {
-				t.Errorf("Column %s: got varchar(%d), want varchar(%d)", header, maxLengths[i], expectedLen)
+			expectedLen := expectedMaxLengths[col.Name]
+			if col.MaxLength != expectedLen {
+				t.Errorf("Column %s: got varchar(%d), want varchar(%d)", col.Name, col.MaxLength, expectedLen)
 			}
 		}
 	}
@@ -388,16 +682,16 @@ func TestQuotedFileAnalysis(t *testing.T) {
 	// Verify that quoted fields with commas are handled correctly
 	scanner := bufio.NewScanner(file)
 	if scanner.Scan() {
-		headers := splitFields(scanner.Text(), ",", "double")
-		if len(headers) != 8 {
+		headers, _, err := analyze.SplitFields(scanner.Text(), ",", "double")
+		if err != nil || len(headers) != 8 {
 			t.Errorf("Expected 8 headers, got %d", len(headers))
 		}
 	}
 
 	// Read first data line
 	if scanner.Scan() {
-		fields := splitFields(scanner.Text(), ",", "double")
-		if len(fields) != 8 {
+		fields, _, err := analyze.SplitFields(scanner.Text(), ",", "double")
+		if err != nil || len(fields) != 8 {
 			t.Errorf("Expected 8 fields, got %d", len(fields))
 		}
 		// Verify that fields with commas are preserved
@@ -409,3 +703,246 @@ func TestQuotedFileAnalysis(t *testing.T) {
 		}
 	}
 }
+
+func TestEmptyAndHeaderOnlyFiles(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+
+	t.Run("empty file", func(t *testing.T) {
+		_, err := analyzeFileTypes(strings.NewReader(""), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+		if err == nil || !strings.Contains(err.Error(), "file contains no data") {
+			t.Errorf("analyzeFileTypes() error = %v, want error containing %q", err, "file contains no data")
+		}
+	})
+
+	t.Run("header only", func(t *testing.T) {
+		opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, EmptyColumnType: "text"}
+		result, err := analyzeFileTypes(strings.NewReader("id,name\n"), opts, analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		if result.RowCount != 0 {
+			t.Errorf("RowCount = %d, want 0", result.RowCount)
+		}
+		if len(result.Columns) != 2 {
+			t.Fatalf("got %d columns, want 2", len(result.Columns))
+		}
+		for _, col := range result.Columns {
+			if got := analyzer.GetTypes()[col.TypeIndex].Name; got != "text" {
+				t.Errorf("Column %s: got type %s, want text", col.Name, got)
+			}
+		}
+	})
+
+	t.Run("header only with configured type", func(t *testing.T) {
+		opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, EmptyColumnType: "integer"}
+		result, err := analyzeFileTypes(strings.NewReader("id,name\n"), opts, analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		for _, col := range result.Columns {
+			if got := analyzer.GetTypes()[col.TypeIndex].Name; got != "integer" {
+				t.Errorf("Column %s: got type %s, want integer", col.Name, got)
+			}
+		}
+	})
+}
+
+func TestBlankLines(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	input := "\nid,name\n1,Alice\n\n2,Bob\n\n3,x,y\n"
+
+	t.Run("skipped by default", func(t *testing.T) {
+		_, err := analyzeFileTypes(strings.NewReader("id,name\n1,Alice\n\n2,Bob\n"), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("counted and still advance the line number", func(t *testing.T) {
+		_, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+		if err == nil || !strings.Contains(err.Error(), "line 7 has 3 fields") {
+			t.Errorf("analyzeFileTypes() error = %v, want error containing %q", err, "line 7 has 3 fields")
+		}
+
+		result, err := analyzeFileTypes(strings.NewReader("\nid,name\n1,Alice\n\n2,Bob\n"), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		if result.BlankLines != 2 {
+			t.Errorf("BlankLines = %d, want 2", result.BlankLines)
+		}
+		if result.RowCount != 2 {
+			t.Errorf("RowCount = %d, want 2", result.RowCount)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none", StrictBlankLines: true}}
+		_, err := analyzeFileTypes(strings.NewReader("id,name\n1,Alice\n\n2,Bob\n"), opts, analyzer)
+		if err == nil || !strings.Contains(err.Error(), "line 3 is blank") {
+			t.Errorf("analyzeFileTypes() error = %v, want error containing %q", err, "line 3 is blank")
+		}
+	})
+
+	t.Run("only blank lines", func(t *testing.T) {
+		_, err := analyzeFileTypes(strings.NewReader("\n\n"), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+		if err == nil || !strings.Contains(err.Error(), "file contains no data") {
+			t.Errorf("analyzeFileTypes() error = %v, want error containing %q", err, "file contains no data")
+		}
+	})
+}
+
+func TestTimestampPrecision(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	input := strings.Join([]string{
+		"plain,millis,micros,nanos",
+		"2024-03-20 10:30:00,2024-03-20 10:30:00.1,2024-03-20 10:30:00.123456,2024-03-20T10:30:00.123456789Z",
+		"2024-03-20 10:31:00,2024-03-20 10:31:00.123,2024-03-20 10:31:00,2024-03-20T10:31:00Z",
+	}, "\n")
+
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	expected := map[string]string{
+		"plain":  "timestamp",
+		"millis": "timestamp(3)",
+		"micros": "timestamp(6)",
+		"nanos":  "timestamp(6)",
+	}
+	for _, col := range result.Columns {
+		if got := columnTypeName(col, analyzer); got != expected[col.Name] {
+			t.Errorf("Column %s: got type %s, want %s", col.Name, got, expected[col.Name])
+		}
+	}
+
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "column nanos has values with 9 fractional-second digits") {
+		t.Errorf("Warnings = %v, want a single clamp warning for nanos", result.Warnings)
+	}
+}
+
+func TestTwelveHourTimestampColumn(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	input := "ts\n3/20/2024 2:45:00 PM\n2024-03-20 14:45:00\n3/20/2024 12:00 am\n"
+
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if got := analyzer.GetTypes()[result.Columns[0].TypeIndex].Name; got != "timestamp" {
+		t.Errorf("got type %s, want timestamp", got)
+	}
+}
+
+func TestTwelveHourEdgeHours(t *testing.T) {
+	tests := []struct {
+		value string
+		hour  int
+	}{
+		{"3/20/2024 12:15:00 AM", 0},
+		{"3/20/2024 12:15:00 PM", 12},
+		{"3/20/2024 1:15:00 AM", 1},
+		{"3/20/2024 11:15:00 PM", 23},
+	}
+
+	for _, tt := range tests {
+		parsed, err := time.Parse("1/2/2006 3:04:05 PM", tt.value)
+		if err != nil {
+			t.Errorf("time.Parse(%q) error = %v", tt.value, err)
+			continue
+		}
+		if parsed.Hour() != tt.hour {
+			t.Errorf("time.Parse(%q) hour = %d, want %d", tt.value, parsed.Hour(), tt.hour)
+		}
+	}
+}
+
+func TestExpectedColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		header  string
+		columns []string
+		errText string
+	}{
+		{"header", "a,b,c\n1,2,3\n", "yes", []string{"a", "b", "c"}, ""},
+		{"header too narrow", "a,b\n1,2\n", "yes", nil, "header line has 2 fields, expected 3"},
+		{"ragged row", "a,b,c\n1,2,3\n4,5\n", "yes", nil, "line 3 has 2 fields, expected 3 from -ncols"},
+		{"no header", "1,2,3\n4,5,6\n", "no", []string{"column_1", "column_2", "column_3"}, ""},
+		{"no header, first row ragged", "1,2\n4,5,6\n", "no", nil, "line 1 has 2 fields, expected 3 from -ncols"},
+		{"no header, later row ragged", "1,2,3\n\n4,5,6,7\n", "no", nil, "line 3 has 4 fields, expected 3 from -ncols"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, Header: tt.header, ExpectedCols: 3}
+			result, err := analyzeFileTypes(strings.NewReader(tt.input), opts, &dbtypes.PostgreSQLAnalyzer{})
+			if tt.errText != "" {
+				if err == nil || err.Error() != tt.errText {
+					t.Errorf("analyzeFileTypes() error = %v, want %q", err, tt.errText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			var columns []string
+			for _, col := range result.Columns {
+				columns = append(columns, col.Name)
+			}
+			if !reflect.DeepEqual(columns, tt.columns) {
+				t.Errorf("columns = %q, want %q", columns, tt.columns)
+			}
+		})
+	}
+}
+
+func TestEmptyColumns(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	input := "id,a,b\n1,,\n2,,\n"
+
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, EmptyColumnType: "integer"}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	for _, col := range result.Columns[1:] {
+		if got := columnTypeName(col, analyzer); got != "integer" || !col.NoValues {
+			t.Errorf("column %s: got %s, no values %v, want integer without values", col.Name, got, col.NoValues)
+		}
+		if notes := columnNotes(col); !reflect.DeepEqual(notes, []string{"no non-null values observed"}) {
+			t.Errorf("column %s: notes = %q", col.Name, notes)
+		}
+	}
+	if result.Columns[0].NoValues {
+		t.Error("column id has values, but NoValues is set")
+	}
+	want := "columns a, b have no non-null values in 2 rows and are reported as integer; a wrong delimiter or quote setting can shift data out of columns"
+	if !reflect.DeepEqual(result.Warnings, []string{want}) {
+		t.Errorf("warnings = %q, want %q", result.Warnings, want)
+	}
+
+	opts.StrictEmpty = true
+	if _, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer); err == nil || err.Error() != want {
+		t.Errorf("analyzeFileTypes() with StrictEmpty error = %v, want %q", err, want)
+	}
+}
+
+// TestREADMEFormats checks that the formats the README lists are those
+// -format accepts
+func TestREADMEFormats(t *testing.T) {
+	readme, err := os.ReadFile("README.md")
+	if err != nil {
+		t.Fatalf("Failed to read README.md: %v", err)
+	}
+	usage := "[-format " + strings.Join(outputFormats, "|") + "]"
+	if !strings.Contains(string(readme), usage) {
+		t.Errorf("README.md usage does not list -format as %s", usage)
+	}
+	last := len(outputFormats) - 1
+	bullet := "- `-format`: Output format: " + strings.Join(outputFormats[:last], ", ") + " or " + outputFormats[last] + " "
+	if !strings.Contains(string(readme), bullet) {
+		t.Errorf("README.md does not describe -format as %q", bullet)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+
+	"file2ddl/dbtypes"
+)
+
+// runManifest records what a run read and produced, so that later jobs can
+// tell from the input's hash whether the schema still applies
+type runManifest struct {
+	Tool         string            `json:"tool"`
+	Version      string            `json:"version"`
+	GeneratedAt  string            `json:"generated_at"`
+	Input        manifestInput     `json:"input"`
+	RowsAnalyzed int               `json:"rows_analyzed"`
+	Flags        map[string]string `json:"flags"`
+	Table        string            `json:"table"`
+	Schema       jsonReport        `json:"schema"`
+}
+
+// manifestInput identifies the input of a run. The hash and size are of the
+// data analyzed: the decompressed contents of a .gz input, the entry of a
+// zip archive, and with -head-bytes only the prefix read.
+type manifestInput struct {
+	Path      string `json:"path"`
+	Name      string `json:"name"`
+	Format    string `json:"format"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+	HashScope string `json:"hash_scope"`
+}
+
+// contentHash is a SHA-256 hash that also counts the bytes written to it
+type contentHash struct {
+	hash.Hash
+	n int64
+}
+
+func newContentHash() *contentHash {
+	return &contentHash{Hash: sha256.New()}
+}
+
+func (h *contentHash) Write(p []byte) (int, error) {
+	h.n += int64(len(p))
+	return h.Hash.Write(p)
+}
+
+// finish reads what the analysis left of the input into the hash, so that
+// it covers the whole input although some formats stop reading early
+func (h *contentHash) finish(r io.Reader) error {
+	if _, err := io.Copy(h, r); err != nil {
+		return fmt.Errorf("error hashing input: %v", err)
+	}
+	return nil
+}
+
+// newManifest describes a run whose input hashed to h
+func newManifest(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, input manifestInput, h *contentHash,
+	flags map[string]string, table string, generated time.Time) runManifest {
+	input.Size = h.n
+	input.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return runManifest{
+		Tool:         "file2ddl",
+		Version:      toolVersion,
+		GeneratedAt:  generated.UTC().Format(time.RFC3339),
+		Input:        input,
+		RowsAnalyzed: result.RowCount,
+		Flags:        flags,
+		Table:        table,
+		Schema:       newJSONReport(result, analyzer),
+	}
+}
+
+// writeManifest writes a run manifest as JSON
+func writeManifest(path string, manifest runManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error writing manifest: %v", err)
+	}
+	return nil
+}
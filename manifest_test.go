@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestManifest(t *testing.T) {
+	input := "id,name\n1,ada\n2,grace\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}
+
+	h := newContentHash()
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	// Part of the input is hashed as it is read, the rest by finish
+	h.Write([]byte(input[:10]))
+	if err := h.finish(strings.NewReader(input[10:])); err != nil {
+		t.Fatalf("finish() error = %v, want nil", err)
+	}
+
+	generated := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	manifest := newManifest(result, analyzer, manifestInput{Path: "in.csv", Name: "in.csv", Format: "delimited", HashScope: "whole input"},
+		h, map[string]string{"delim": ","}, "people", generated)
+	path := filepath.Join(t.TempDir(), "run.manifest.json")
+	if err := writeManifest(path, manifest); err != nil {
+		t.Fatalf("writeManifest() error = %v, want nil", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got runManifest
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("manifest is not JSON: %v", err)
+	}
+	if got.Input.Size != int64(len(input)) {
+		t.Errorf("size = %d, want %d", got.Input.Size, len(input))
+	}
+	// sha256 of the input, from sha256sum
+	const want = "1f8a01539ff6c98c54a4c142aef136b68c8809f170de14f8a7b8a1abaf54d6b2"
+	if got.Input.SHA256 != want {
+		t.Errorf("sha256 = %s, want %s", got.Input.SHA256, want)
+	}
+	if got.GeneratedAt != "2026-10-16T12:00:00Z" || got.Table != "people" || got.RowsAnalyzed != 2 || got.Flags["delim"] != "," {
+		t.Errorf("manifest = %+v", got)
+	}
+	if len(got.Schema.Columns) != 2 {
+		t.Errorf("schema has %d columns, want 2", len(got.Schema.Columns))
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// byteUnits are the suffixes -memory-budget takes, in binary multiples
+var byteUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GIB", 1 << 30}, {"GB", 1 << 30}, {"G", 1 << 30},
+	{"MIB", 1 << 20}, {"MB", 1 << 20}, {"M", 1 << 20},
+	{"KIB", 1 << 10}, {"KB", 1 << 10}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize reads a size such as 512MB, 1.5GiB or 65536, where KB, MB
+// and GB count 1,024 bytes to the step like KiB, MiB and GiB
+func parseByteSize(s string) (int64, error) {
+	number, unit := strings.TrimSpace(s), int64(1)
+	upper := strings.ToUpper(number)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(upper, u.suffix) {
+			number, unit = strings.TrimSpace(number[:len(number)-len(u.suffix)]), u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q, want e.g. 512MB", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// formatBytes writes a size in the largest unit it reaches, e.g. 37.5 MB
+// or 512 MB
+func formatBytes(n int64) string {
+	for _, u := range []struct {
+		suffix string
+		bytes  int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}} {
+		if n >= u.bytes {
+			return strings.TrimSuffix(strconv.FormatFloat(float64(n)/float64(u.bytes), 'f', 1, 64), ".0") + " " + u.suffix
+		}
+	}
+	return countOf(int(n), "byte")
+}
+
+// memoryBudget accounts for the memory held by the trackers whose state
+// grows with the rows, the duplicate, key and value trackers, so that
+// together they stay within -memory-budget. Each reserves its estimated
+// usage before growing and degrades when refused, from exact to
+// approximate to disabled, rather than running the process out of memory.
+type memoryBudget struct {
+	limit int64 // most bytes the trackers may hold, 0 without a limit
+	used  int64
+	peak  int64
+}
+
+// reserve accounts for n more bytes, reporting false and accounting for
+// nothing when they would take the trackers over the limit
+func (b *memoryBudget) reserve(n int64) bool {
+	if b.limit > 0 && b.used+n > b.limit {
+		return false
+	}
+	b.used += n
+	b.peak = max(b.peak, b.used)
+	return true
+}
+
+// release gives back n bytes a tracker no longer holds
+func (b *memoryBudget) release(n int64) {
+	b.used -= n
+}
+
+// exactHashBytes estimates the memory a hash held in a map takes, with the
+// line of its first occurrence and the map's overhead
+const exactHashBytes = 48
+
+// bloomProbes and bloomBitsPerHash give Bloom filters about 1% false
+// positives once they hold as many hashes as they are sized for
+const (
+	bloomProbes      = 7
+	bloomBitsPerHash = 10
+)
+
+// minBloomBytes is the smallest Bloom filter worth switching to; a set
+// holding less than this when refused is disabled instead
+const minBloomBytes = 1 << 10
+
+// hashSetMode is how a hashSet remembers hashes
+type hashSetMode int
+
+const (
+	exactHashes       hashSetMode = iota // every hash, in a map
+	approximateHashes                    // in a Bloom filter, about 1% of new hashes taken for repeats
+	noHashes                             // none, every hash taken as new
+)
+
+// hashSet remembers the 64-bit hashes of values or rows, exactly in a map
+// while the budget allows, then approximately in a Bloom filter the size
+// of the memory the map held, then, once the filter is full, not at all
+type hashSet struct {
+	budget   *memoryBudget
+	mode     hashSetMode
+	exact    map[uint64]int // hash to the line it was first seen at, while exact
+	bits     []uint64       // the Bloom filter, once approximate
+	capacity int            // hashes the filter is sized for
+	added    int            // hashes added to the filter
+	held     int64          // bytes reserved from the budget
+	modeLine [3]int         // line at which each mode was entered
+}
+
+func newHashSet(budget *memoryBudget) *hashSet {
+	return &hashSet{budget: budget, exact: make(map[uint64]int)}
+}
+
+// add records a hash read from line, reporting whether it was seen before
+// and the line it was first seen at, 0 when no longer known
+func (s *hashSet) add(sum uint64, line int) (first int, seen bool) {
+	switch s.mode {
+	case exactHashes:
+		if first, ok := s.exact[sum]; ok {
+			return first, true
+		}
+		if s.budget.reserve(exactHashBytes) {
+			s.held += exactHashBytes
+			s.exact[sum] = line
+			return 0, false
+		}
+		s.degrade(line)
+		return s.add(sum, line)
+	case approximateHashes:
+		if s.added >= s.capacity {
+			s.disable(line)
+			return 0, false
+		}
+		if s.probe(sum) {
+			return 0, true
+		}
+		s.added++
+	}
+	return 0, false
+}
+
+// probe sets the filter's bits for a hash, reporting whether all of them
+// were set already. The probes are derived from the two halves of the hash.
+func (s *hashSet) probe(sum uint64) bool {
+	size := uint64(len(s.bits)) * 64
+	h1, h2 := sum&0xffffffff, sum>>32|1
+	seen := true
+	for i := uint64(0); i < bloomProbes; i++ {
+		bit := (h1 + i*h2) % size
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if s.bits[word]&mask == 0 {
+			seen = false
+			s.bits[word] |= mask
+		}
+	}
+	return seen
+}
+
+// degrade moves the hashes of a map refused more memory into a Bloom
+// filter taking the memory the map held, or disables a set holding too
+// little for a useful filter
+func (s *hashSet) degrade(line int) {
+	if s.held < minBloomBytes {
+		s.disable(line)
+		return
+	}
+	s.mode, s.modeLine[approximateHashes] = approximateHashes, line
+	s.bits = make([]uint64, s.held/8)
+	s.capacity = int(s.held * 8 / bloomBitsPerHash)
+	for sum := range s.exact {
+		if !s.probe(sum) {
+			s.added++
+		}
+	}
+	s.exact = nil
+}
+
+// disable drops the set's hashes and gives back their memory
+func (s *hashSet) disable(line int) {
+	s.mode, s.modeLine[noHashes] = noHashes, line
+	s.exact, s.bits = nil, nil
+	s.free()
+}
+
+// free gives back the memory the set holds
+func (s *hashSet) free() {
+	s.budget.release(s.held)
+	s.held = 0
+}
+
+// size returns the number of hashes the set holds
+func (s *hashSet) size() int {
+	if s.mode == approximateHashes {
+		return s.added
+	}
+	return len(s.exact)
+}
+
+// budgetWarning describes what a feature gave up to stay within the budget
+func budgetWarning(budget *memoryBudget, format string, args ...interface{}) string {
+	return fmt.Sprintf(format, args...) + ", to stay within the -memory-budget of " + formatBytes(budget.limit)
+}
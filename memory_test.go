@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int64
+	}{
+		{"512MB", 512 << 20},
+		{"512 mb", 512 << 20},
+		{"1.5GiB", 3 << 29},
+		{"64k", 64 << 10},
+		{"65536", 65536},
+		{"100B", 100},
+	}
+	for _, tt := range tests {
+		got, err := parseByteSize(tt.s)
+		if err != nil || got != tt.want {
+			t.Errorf("parseByteSize(%q) = %d, %v, want %d", tt.s, got, err, tt.want)
+		}
+	}
+	for _, s := range []string{"", "MB", "-1GB", "lots"} {
+		if _, err := parseByteSize(s); err == nil {
+			t.Errorf("parseByteSize(%q) error = nil, want an error", s)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{1: "1 byte", 900: "900 bytes", 1536: "1.5 KB", 512 << 20: "512 MB", 3 << 29: "1.5 GB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestHashSetDegrades(t *testing.T) {
+	sum := func(i int) uint64 { return hashRecord([]string{fmt.Sprint(i)}) }
+	budget := &memoryBudget{limit: 30 * exactHashBytes}
+	s := newHashSet(budget)
+	for i := 1; i <= 30; i++ {
+		if _, seen := s.add(sum(i), i); seen {
+			t.Fatalf("hash %d seen before it was added", i)
+		}
+	}
+	if first, seen := s.add(sum(3), 31); !seen || first != 3 {
+		t.Errorf("exact repeat = line %d, %v, want line 3, true", first, seen)
+	}
+
+	// Refused memory for a 31st hash, the set moves to a filter of the
+	// memory it held, which still knows the earlier hashes
+	s.add(sum(31), 32)
+	if s.mode != approximateHashes || s.modeLine[approximateHashes] != 32 {
+		t.Fatalf("mode = %d from line %d, want approximate from line 32", s.mode, s.modeLine[approximateHashes])
+	}
+	if first, seen := s.add(sum(3), 33); !seen || first != 0 {
+		t.Errorf("approximate repeat = line %d, %v, want line 0, true", first, seen)
+	}
+
+	// Full, the filter is dropped and its memory given back
+	for i := 100; s.mode == approximateHashes; i++ {
+		s.add(sum(i), i)
+	}
+	if budget.used != 0 || budget.peak != 30*exactHashBytes {
+		t.Errorf("budget used %d, peak %d, want 0 and %d", budget.used, budget.peak, 30*exactHashBytes)
+	}
+	if _, seen := s.add(sum(3), 10000); seen {
+		t.Errorf("disabled set saw a repeat")
+	}
+
+	// A set holding too little for a filter is disabled at once
+	small := newHashSet(&memoryBudget{limit: 100})
+	for i := 1; i <= 3; i++ {
+		small.add(sum(i), i)
+	}
+	if small.mode != noHashes || small.modeLine[noHashes] != 3 {
+		t.Errorf("small set mode = %d from line %d, want disabled from line 3", small.mode, small.modeLine[noHashes])
+	}
+}
+
+func TestMemoryBudgetAnalysis(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("id,kind,note\n")
+	for i := 1; i <= 200; i++ {
+		fmt.Fprintf(&b, "%d,%c,%s\n", i, 'a'+i%3, strings.Repeat("x", i%12))
+	}
+	input := b.String()
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ","}, DetectDuplicates: true, DetectKeys: true, TrackValues: true}
+	exact, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if len(exact.Warnings) > 0 || exact.PeakMemory == 0 {
+		t.Fatalf("without a budget: warnings %q, peak %d, want none and some memory tracked", exact.Warnings, exact.PeakMemory)
+	}
+
+	// The duplicate rows give up their memory to the key of id, which goes
+	// on approximately
+	opts.MemoryBudget = 2048
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if result.PeakMemory > opts.MemoryBudget {
+		t.Errorf("PeakMemory = %d, over the budget of %d", result.PeakMemory, opts.MemoryBudget)
+	}
+	if !result.Columns[0].Distinct {
+		t.Errorf("id Distinct = false, want true")
+	}
+	if result.Columns[1].Values == nil {
+		t.Errorf("kind Values = nil, want its three values")
+	}
+	want := []string{
+		"duplicate rows were not counted from line 11 on, to stay within the -memory-budget of 2 KB",
+		"-suggest-indexes checked the values of 1 column approximately",
+	}
+	got := strings.Join(result.Warnings, "\n")
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("warnings = %q, want one containing %q", result.Warnings, w)
+		}
+	}
+}
+
+func TestValueTrackerBudget(t *testing.T) {
+	v := newValueTracker(2, &memoryBudget{limit: 2*valueEntryBytes + 2})
+	v.observe(0, "a")
+	v.observe(1, "b")
+	v.observe(1, "c")
+	if v.values[0] == nil || v.values[1] != nil {
+		t.Fatalf("values = %v, want column 0 kept and column 1 dropped", v.values)
+	}
+	if v.budget.used != valueEntryBytes+1 {
+		t.Errorf("budget used %d, want %d once column 1 gave its memory back", v.budget.used, valueEntryBytes+1)
+	}
+	want := "-with-checks dropped the values of 1 column, which get no constraint listing them, to stay within the -memory-budget of 66 bytes"
+	if got := v.budgetWarnings(); len(got) != 1 || got[0] != want {
+		t.Errorf("budgetWarnings() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// quoteQualified quotes each part of a schema-qualified name such as
+// prod.customers
+func quoteQualified(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// parseMergeKey splits a -merge-key list and checks that each column is one
+// of the file's
+func parseMergeKey(spec string, result *fileAnalysis) ([]string, error) {
+	known := make(map[string]bool)
+	for _, col := range result.Columns {
+		known[col.Name] = true
+	}
+	var keys []string
+	for _, key := range strings.Split(spec, ",") {
+		key = strings.TrimSpace(key)
+		if !known[key] {
+			return nil, fmt.Errorf("merge key column %s not found", key)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// mergeSQL returns a statement loading the rows of the staging table into
+// the target table: rows whose keys match update every other column and
+// the rest are inserted. PostgreSQL gets INSERT ... ON CONFLICT, which needs
+// a unique constraint on the keys in the target, and Snowflake gets MERGE.
+// MariaDB, which has neither, gets INSERT ... ON DUPLICATE KEY UPDATE, which
+// needs a unique key on them, with VALUES() rather than MySQL's row alias;
+// as a single INSERT it takes a RETURNING clause on MariaDB 10.5 and later.
+func mergeSQL(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, staging, target string, keys []string) string {
+	isKey := make(map[string]bool)
+	for _, key := range keys {
+		isKey[key] = true
+	}
+	var columns, updates []string
+	for _, col := range result.Columns {
+		columns = append(columns, quoteIdentifier(col.Name))
+	}
+	quotedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		quotedKeys[i] = quoteIdentifier(key)
+	}
+
+	if _, ok := analyzer.(*dbtypes.MariaDBAnalyzer); ok {
+		return mariadbMergeSQL(result, analyzer, staging, target, isKey)
+	}
+
+	if _, ok := analyzer.(*dbtypes.SnowflakeAnalyzer); ok {
+		var matches, values []string
+		for _, key := range quotedKeys {
+			matches = append(matches, fmt.Sprintf("t.%s = s.%s", key, key))
+		}
+		for i, col := range result.Columns {
+			values = append(values, "s."+columns[i])
+			if !isKey[col.Name] {
+				updates = append(updates, fmt.Sprintf("    %s = s.%s", columns[i], columns[i]))
+			}
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "MERGE INTO %s AS t\nUSING %s AS s\nON %s\n", quoteQualified(target), quoteIdentifier(staging), strings.Join(matches, " AND "))
+		if len(updates) > 0 {
+			fmt.Fprintf(&b, "WHEN MATCHED THEN UPDATE SET\n%s\n", strings.Join(updates, ",\n"))
+		}
+		fmt.Fprintf(&b, "WHEN NOT MATCHED THEN INSERT (%s)\n    VALUES (%s);\n", strings.Join(columns, ", "), strings.Join(values, ", "))
+		return b.String()
+	}
+
+	for i, col := range result.Columns {
+		if !isKey[col.Name] {
+			updates = append(updates, fmt.Sprintf("    %s = EXCLUDED.%s", columns[i], columns[i]))
+		}
+	}
+	action := "DO NOTHING"
+	if len(updates) > 0 {
+		action = "DO UPDATE SET\n" + strings.Join(updates, ",\n")
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s)\nSELECT %s FROM %s\nON CONFLICT (%s) %s;\n",
+		quoteQualified(target), strings.Join(columns, ", "), strings.Join(columns, ", "), quoteIdentifier(staging),
+		strings.Join(quotedKeys, ", "), action)
+}
+
+// mariadbMergeSQL returns the merge of mergeSQL in MariaDB's terms, quoting
+// with backticks. ON DUPLICATE KEY UPDATE matches on whichever unique key of
+// the target a row collides with rather than on the merge keys, so the
+// statement is headed by a comment saying the target needs a primary or
+// unique key on exactly those columns and no other. A table of only keys
+// updates its first key to itself, leaving matching rows as they are.
+func mariadbMergeSQL(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, staging, target string, isKey map[string]bool) string {
+	var columns, updates, keys []string
+	for _, col := range result.Columns {
+		column := flavorIdentifier(analyzer, col.Name)
+		columns = append(columns, column)
+		if isKey[col.Name] {
+			keys = append(keys, column)
+		} else {
+			updates = append(updates, fmt.Sprintf("    %s = VALUES(%s)", column, column))
+		}
+	}
+	if len(updates) == 0 {
+		updates = []string{fmt.Sprintf("    %s = %s", keys[0], keys[0])}
+	}
+	parts := strings.Split(target, ".")
+	for i, part := range parts {
+		parts[i] = flavorIdentifier(analyzer, part)
+	}
+	table := strings.Join(parts, ".")
+	return fmt.Sprintf("-- ON DUPLICATE KEY UPDATE matches on any unique key of %s, so it needs\n"+
+		"-- a primary or unique key on (%s) and no other unique key\n"+
+		"INSERT INTO %s (%s)\nSELECT %s FROM %s\nON DUPLICATE KEY UPDATE\n%s;\n",
+		table, strings.Join(keys, ", "),
+		table, strings.Join(columns, ", "), strings.Join(columns, ", "), flavorIdentifier(analyzer, staging),
+		strings.Join(updates, ",\n"))
+}
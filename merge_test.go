@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestMergeSQL(t *testing.T) {
+	input := "id,region,First Name,order\n1,eu,Ann,3\n2,us,Bob,4\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	keys, err := parseMergeKey("id, region", result)
+	if err != nil {
+		t.Fatalf("parseMergeKey() error = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name     string
+		analyzer dbtypes.TypeAnalyzer
+		keys     []string
+		expected string
+	}{
+		{
+			name:     "postgresql",
+			analyzer: analyzer,
+			keys:     keys,
+			expected: `INSERT INTO prod."Customers" (id, region, "First Name", "order")
+SELECT id, region, "First Name", "order" FROM staging
+ON CONFLICT (id, region) DO UPDATE SET
+    "First Name" = EXCLUDED."First Name",
+    "order" = EXCLUDED."order";
+`,
+		},
+		{
+			name:     "snowflake",
+			analyzer: &dbtypes.SnowflakeAnalyzer{},
+			keys:     keys,
+			expected: `MERGE INTO prod."Customers" AS t
+USING staging AS s
+ON t.id = s.id AND t.region = s.region
+WHEN MATCHED THEN UPDATE SET
+    "First Name" = s."First Name",
+    "order" = s."order"
+WHEN NOT MATCHED THEN INSERT (id, region, "First Name", "order")
+    VALUES (s.id, s.region, s."First Name", s."order");
+`,
+		},
+		{
+			name:     "mariadb",
+			analyzer: &dbtypes.MariaDBAnalyzer{},
+			keys:     keys,
+			expected: "-- ON DUPLICATE KEY UPDATE matches on any unique key of prod.`Customers`, so it needs\n" +
+				"-- a primary or unique key on (id, region) and no other unique key\n" +
+				"INSERT INTO prod.`Customers` (id, region, `First Name`, `order`)\n" +
+				"SELECT id, region, `First Name`, `order` FROM staging\n" +
+				"ON DUPLICATE KEY UPDATE\n" +
+				"    `First Name` = VALUES(`First Name`),\n" +
+				"    `order` = VALUES(`order`);\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeSQL(result, tt.analyzer, "staging", "prod.Customers", tt.keys); got != tt.expected {
+				t.Errorf("mergeSQL() =\n%s\nwant\n%s", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := parseMergeKey("id,customer_id", result); err == nil || err.Error() != "merge key column customer_id not found" {
+		t.Errorf("parseMergeKey() error = %v, want merge key column customer_id not found", err)
+	}
+}
+
+func TestMergeSQLOnlyKeys(t *testing.T) {
+	result := &fileAnalysis{Columns: []columnAnalysis{{Name: "id"}}}
+	want := "INSERT INTO target (id)\nSELECT id FROM staging\nON CONFLICT (id) DO NOTHING;\n"
+	if got := mergeSQL(result, &dbtypes.PostgreSQLAnalyzer{}, "staging", "target", []string{"id"}); got != want {
+		t.Errorf("mergeSQL() = %q, want %q", got, want)
+	}
+	want = "-- ON DUPLICATE KEY UPDATE matches on any unique key of target, so it needs\n" +
+		"-- a primary or unique key on (id) and no other unique key\n" +
+		"INSERT INTO target (id)\nSELECT id FROM staging\nON DUPLICATE KEY UPDATE\n    id = id;\n"
+	if got := mergeSQL(result, &dbtypes.MariaDBAnalyzer{}, "staging", "target", []string{"id"}); got != want {
+		t.Errorf("mergeSQL() into mariadb = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"file2ddl/dbtypes"
+)
+
+// sqlPlainIdentifier matches identifiers that need no quoting in SQL
+var sqlPlainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// sqlReservedWords are common keywords that must be quoted as identifiers
+var sqlReservedWords = map[string]bool{
+	"all": true, "and": true, "as": true, "check": true, "column": true, "constraint": true,
+	"create": true, "default": true, "desc": true, "distinct": true, "from": true,
+	"group": true, "having": true, "in": true, "limit": true, "not": true, "null": true,
+	"or": true, "order": true, "primary": true, "select": true, "table": true,
+	"to": true, "union": true, "unique": true, "user": true, "where": true, "with": true,
+}
+
+// quoteIdentifier double-quotes a SQL identifier unless it is a plain,
+// lower-case name that is not a reserved word
+func quoteIdentifier(name string) string {
+	if sqlPlainIdentifier.MatchString(name) && !sqlReservedWords[name] {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// flavorIdentifier quotes a SQL identifier as quoteIdentifier does, and also
+// when the flavor's database reserves it, with the flavor's quote character.
+// Plain names are written in the case the flavor folds them to, quoted or
+// not, so that they read as the database stores them.
+func flavorIdentifier(analyzer dbtypes.TypeAnalyzer, name string) string {
+	reserver, ok := analyzer.(dbtypes.KeywordReserver)
+	plain := sqlPlainIdentifier.MatchString(name)
+	reserved := sqlReservedWords[name] || ok && reserver.ReservedWord(name)
+	if caser, ok := analyzer.(dbtypes.IdentifierCaser); ok && plain && caser.IdentifierCase() == "upper" {
+		name = strings.ToUpper(name)
+	}
+	if plain && !reserved {
+		return name
+	}
+	quote := `"`
+	if quoter, ok := analyzer.(dbtypes.IdentifierQuoter); ok {
+		quote = quoter.IdentifierQuote()
+	}
+	return quote + strings.ReplaceAll(name, quote, quote+quote) + quote
+}
+
+// createTableSQL returns a CREATE TABLE statement for the analyzed file.
+// Columns without empty values are NOT NULL, the added columns go ahead of
+// or after the file's, and the primaryKey column, if any, must be one of
+// either.
+func createTableSQL(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table, primaryKey string) (string, error) {
+	var columns, comments []string
+	foundKey := false
+	addColumn := func(col addedColumn) {
+		column := "    " + col.columnSQL(analyzer)
+		if col.Name == primaryKey {
+			column += " PRIMARY KEY"
+			foundKey = true
+		}
+		columns = append(columns, column)
+		comments = append(comments, "")
+	}
+	for _, col := range result.AddedColumns {
+		if col.First {
+			addColumn(col)
+		}
+	}
+	for _, col := range result.Columns {
+		column := fmt.Sprintf("    %s %s", flavorIdentifier(analyzer, col.Name), columnTypeName(col, analyzer))
+		switch {
+		case col.Name == primaryKey:
+			column += " PRIMARY KEY"
+			foundKey = true
+		case col.notNull(result.RowCount):
+			column += " NOT NULL"
+		}
+		columns = append(columns, column)
+		if col.Stats != nil {
+			comments = append(comments, col.Stats.String())
+		} else {
+			comments = append(comments, "")
+		}
+	}
+	for _, col := range result.AddedColumns {
+		if !col.First {
+			addColumn(col)
+		}
+	}
+	if primaryKey != "" && !foundKey {
+		return "", fmt.Errorf("primary key column %s not found", primaryKey)
+	}
+
+	// Stats go in line comments after the separating commas
+	for i := range columns {
+		if i < len(columns)-1 {
+			columns[i] += ","
+		}
+		if comments[i] != "" {
+			columns[i] += " -- " + comments[i]
+		}
+	}
+	clause, comment := partitionClause(result, analyzer, table, primaryKey)
+	distribution, reason, err := distributionClause(result, analyzer, primaryKey)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %s (\n%s\n)%s%s;\n%s%s", createTableKeywords(analyzer), flavorIdentifier(analyzer, table), strings.Join(columns, "\n"), clause, distribution, reason, comment), nil
+}
+
+// createTableKeywords returns the flavor's keywords creating a table: SAP
+// HANA names the store it goes in, as CREATE COLUMN TABLE or CREATE ROW TABLE
+func createTableKeywords(analyzer dbtypes.TypeAnalyzer) string {
+	if hana, ok := analyzer.(*dbtypes.HanaAnalyzer); ok {
+		return "CREATE " + cmp.Or(hana.TableType, "COLUMN") + " TABLE"
+	}
+	return "CREATE TABLE"
+}
+
+// writeMigration writes migration files creating the table to dir and
+// returns their paths. The flyway style writes a versioned V file and a
+// matching U undo file; the goose style writes a single file with Up and
+// Down sections.
+func writeMigration(dir, style, version, table, createSQL string, analyzer dbtypes.TypeAnalyzer) ([]string, error) {
+	dropSQL := fmt.Sprintf("DROP TABLE %s;\n", flavorIdentifier(analyzer, table))
+	name := "create_" + strings.ToLower(avroName(table))
+
+	var names, contents []string
+	switch style {
+	case "flyway":
+		names = []string{fmt.Sprintf("V%s__%s.sql", version, name), fmt.Sprintf("U%s__%s.sql", version, name)}
+		contents = []string{createSQL, dropSQL}
+	case "goose":
+		names = []string{fmt.Sprintf("%s_%s.sql", version, name)}
+		contents = []string{"-- +goose Up\n" + createSQL + "\n-- +goose Down\n" + dropSQL}
+	default:
+		return nil, fmt.Errorf("unsupported migration style: %s", style)
+	}
+
+	var paths []string
+	for i, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents[i]), 0o644); err != nil {
+			return nil, fmt.Errorf("error writing migration: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// quoteLiteral single-quotes a SQL string literal
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// commentSQL returns COMMENT ON statements documenting the table and each
+// column: where the data came from, when the DDL was generated, and what was
+// observed about each column's values. A zero generated time leaves the tool
+// version and time out, so the statements are the same on every run.
+func commentSQL(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table, source string, generated time.Time) string {
+	var b strings.Builder
+	banner := "Generated from " + source
+	if !generated.IsZero() {
+		banner = fmt.Sprintf("Generated by file2ddl %s from %s at %s", toolVersion, source, generated.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "COMMENT ON TABLE %s IS %s;\n", flavorIdentifier(analyzer, table), quoteLiteral(banner))
+	for _, col := range result.Columns {
+		notes := []string{"source: " + source}
+		if col.MaxLength > 0 {
+			notes = append(notes, fmt.Sprintf("max length: %d", col.MaxLength))
+		}
+		if result.RowCount > 0 {
+			notes = append(notes, fmt.Sprintf("null: %.1f%%", 100*float64(col.EmptyCount)/float64(result.RowCount)))
+		}
+		if low, high, ok := columnRange(col, analyzer, result.Location); ok {
+			notes = append(notes, fmt.Sprintf("range: %v to %v", low, high))
+		}
+		fmt.Fprintf(&b, "COMMENT ON COLUMN %s.%s IS %s;\n", flavorIdentifier(analyzer, table), flavorIdentifier(analyzer, col.Name),
+			quoteLiteral(strings.Join(notes, "; ")))
+	}
+	return b.String()
+}
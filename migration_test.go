@@ -0,0 +1,153 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"id", "id"},
+		{"order_id", "order_id"},
+		{"order", `"order"`},
+		{"Order", `"Order"`},
+		{"order note", `"order note"`},
+		{`say "hi"`, `"say ""hi"""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoteIdentifier(tt.name); got != tt.expected {
+				t.Errorf("quoteIdentifier(%q) = %s, want %s", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlavorIdentifier(t *testing.T) {
+	tests := []struct {
+		analyzer dbtypes.TypeAnalyzer
+		name     string
+		expected string
+	}{
+		{&dbtypes.PostgreSQLAnalyzer{}, "date", "date"},
+		{&dbtypes.FirebirdAnalyzer{}, "date", `"date"`},
+		{&dbtypes.FirebirdAnalyzer{}, "order", `"order"`},
+		{&dbtypes.FirebirdAnalyzer{}, "placed_on", "placed_on"},
+		{&dbtypes.ExasolAnalyzer{}, "order_id", "ORDER_ID"},
+		{&dbtypes.ExasolAnalyzer{}, "date", `"DATE"`},
+		{&dbtypes.ExasolAnalyzer{}, "Order", `"Order"`},
+		{&dbtypes.MariaDBAnalyzer{}, "key", "`key`"},
+		{&dbtypes.MariaDBAnalyzer{}, "order", "`order`"},
+		{&dbtypes.MariaDBAnalyzer{}, "odd`name", "`odd``name`"},
+		{&dbtypes.MariaDBAnalyzer{}, "date", "date"},
+	}
+	for _, tt := range tests {
+		if got := flavorIdentifier(tt.analyzer, tt.name); got != tt.expected {
+			t.Errorf("flavorIdentifier(%T, %q) = %s, want %s", tt.analyzer, tt.name, got, tt.expected)
+		}
+	}
+}
+
+// TestCreateTableSQLHanaDecimal checks that a SAP HANA decimal column is
+// declared with a precision and scale even when none was observed, since a
+// bare DECIMAL is a floating decimal
+func TestCreateTableSQLHanaDecimal(t *testing.T) {
+	analyzer := &dbtypes.HanaAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader("id|price\n1|19.99\n2|5.5\n"), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	createSQL, err := createTableSQL(result, analyzer, "orders", "")
+	if err != nil {
+		t.Fatalf("createTableSQL() error = %v, want nil", err)
+	}
+	if want := "    price decimal(38,2) NOT NULL\n"; !strings.Contains(createSQL, want) {
+		t.Errorf("createTableSQL() = %q, want it to declare %q", createSQL, want)
+	}
+}
+
+func TestWriteMigration(t *testing.T) {
+	input := "id|order note\n1|gift\n2|\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	createSQL, err := createTableSQL(result, analyzer, "orders", "id")
+	if err != nil {
+		t.Fatalf("createTableSQL() error = %v, want nil", err)
+	}
+	wantCreate := "CREATE TABLE orders (\n    id smallint PRIMARY KEY,\n    \"order note\" varchar(4)\n);\n"
+	if createSQL != wantCreate {
+		t.Errorf("createTableSQL() = %q, want %q", createSQL, wantCreate)
+	}
+
+	tests := []struct {
+		style string
+		files map[string]string
+	}{
+		{"flyway", map[string]string{
+			"V20240320103000__create_orders.sql": wantCreate,
+			"U20240320103000__create_orders.sql": "DROP TABLE orders;\n",
+		}},
+		{"goose", map[string]string{
+			"20240320103000_create_orders.sql": "-- +goose Up\n" + wantCreate + "\n-- +goose Down\nDROP TABLE orders;\n",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			dir := t.TempDir()
+			paths, err := writeMigration(dir, tt.style, "20240320103000", "orders", createSQL, analyzer)
+			if err != nil {
+				t.Fatalf("writeMigration() error = %v, want nil", err)
+			}
+			if len(paths) != len(tt.files) {
+				t.Errorf("wrote %d files, want %d", len(paths), len(tt.files))
+			}
+			for name, want := range tt.files {
+				got, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Errorf("missing migration %s: %v", name, err)
+					continue
+				}
+				if string(got) != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCommentSQL(t *testing.T) {
+	input := "id|order note\n1|gift\n2|\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	generated := time.Date(2024, 3, 20, 10, 30, 0, 0, time.UTC)
+	got := commentSQL(result, analyzer, "orders", "bob's orders.csv", generated)
+	want := "COMMENT ON TABLE orders IS 'Generated by file2ddl dev from bob''s orders.csv at 2024-03-20T10:30:00Z';\n" +
+		"COMMENT ON COLUMN orders.id IS 'source: bob''s orders.csv; null: 0.0%; range: 1 to 2';\n" +
+		"COMMENT ON COLUMN orders.\"order note\" IS 'source: bob''s orders.csv; max length: 4; null: 50.0%';\n"
+	if got != want {
+		t.Errorf("commentSQL() =\n%s\nwant\n%s", got, want)
+	}
+
+	got = commentSQL(result, analyzer, "orders", "orders.csv", time.Time{})
+	if first, _, _ := strings.Cut(got, "\n"); first != "COMMENT ON TABLE orders IS 'Generated from orders.csv';" {
+		t.Errorf("commentSQL() without a time starts %q, want no version or time", first)
+	}
+}
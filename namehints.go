@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// maxHintValues is how many values that do not fit the type a column's name
+// suggests are kept to show in its warning
+const maxHintValues = 3
+
+// nameHint is a type suggested by a pattern of column names: the whole
+// name, a prefix or a suffix, matched against the name in lower case
+type nameHint struct {
+	Name   string
+	Prefix string
+	Suffix string
+	Type   string   // the type the name suggests
+	Types  []string // type names that satisfy the hint
+}
+
+var (
+	integerTypes  = []string{"tinyint", "smallint", "integer", "bigint"}
+	temporalTypes = []string{"date", "timestamp"}
+)
+
+// nameHints are the patterns checked by -name-hints, in order; the first
+// that matches a name decides its hint
+var nameHints = []nameHint{
+	{Name: "id", Type: "integer", Types: integerTypes},
+	{Prefix: "is_", Type: "boolean", Types: []string{"boolean"}},
+	{Prefix: "has_", Type: "boolean", Types: []string{"boolean"}},
+	{Suffix: "_id", Type: "integer", Types: integerTypes},
+	{Suffix: "_count", Type: "integer", Types: integerTypes},
+	{Suffix: "_at", Type: "timestamp", Types: temporalTypes},
+	{Suffix: "_date", Type: "date", Types: temporalTypes},
+}
+
+// nameHintFlag is -name-hints: "warn" reports columns whose type disagrees
+// with their name, and is what the flag means without a value, "prefer"
+// also lets names decide ambiguous columns, and "" checks nothing
+type nameHintFlag string
+
+func (f *nameHintFlag) String() string {
+	return string(*f)
+}
+
+func (f *nameHintFlag) Set(value string) error {
+	switch value {
+	case "true", "warn":
+		*f = "warn"
+	case "false":
+		*f = ""
+	case "prefer":
+		*f = "prefer"
+	default:
+		return fmt.Errorf("must be warn or prefer, got %q", value)
+	}
+	return nil
+}
+
+func (f *nameHintFlag) IsBoolFlag() bool {
+	return true
+}
+
+// hintFor returns the hint for a column name, or nil if no pattern matches
+func hintFor(name string) *nameHint {
+	name = strings.ToLower(name)
+	for i, hint := range nameHints {
+		switch {
+		case hint.Name != "" && name == hint.Name,
+			hint.Prefix != "" && strings.HasPrefix(name, hint.Prefix) && len(name) > len(hint.Prefix),
+			hint.Suffix != "" && strings.HasSuffix(name, hint.Suffix) && len(name) > len(hint.Suffix):
+			return &nameHints[i]
+		}
+	}
+	return nil
+}
+
+// nameHintChecker keeps, for each column with a hint, the first distinct
+// values whose type does not satisfy it
+type nameHintChecker struct {
+	hints  []*nameHint
+	values [][]string
+}
+
+func newNameHintChecker(headers []string) *nameHintChecker {
+	h := &nameHintChecker{hints: make([]*nameHint, len(headers)), values: make([][]string, len(headers))}
+	for i, header := range headers {
+		h.hints[i] = hintFor(header)
+	}
+	return h
+}
+
+// observe records a non-null value of column i inferred as typeName
+func (h *nameHintChecker) observe(i int, field, typeName string) {
+	hint := h.hints[i]
+	if hint == nil || slices.Contains(hint.Types, typeName) || len(h.values[i]) >= maxHintValues || slices.Contains(h.values[i], field) {
+		return
+	}
+	h.values[i] = append(h.values[i], strings.Clone(field))
+}
+
+// finish compares the type of each column with a hint to it, returning
+// warnings for those that disagree. With prefer, integer columns of only 0
+// and 1 named like booleans are declared boolean instead.
+func (h *nameHintChecker) finish(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, prefer bool) []string {
+	booleanType := typeIndex(analyzer, "boolean")
+	var warnings []string
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		hint := h.hints[i]
+		if hint == nil || col.EmptyCount == result.RowCount {
+			continue
+		}
+		col.NameHint = hint.Type
+		typeName := analyzer.GetTypes()[col.TypeIndex].Name
+		if slices.Contains(hint.Types, typeName) {
+			continue
+		}
+		if prefer && booleanType >= 0 && slices.Contains(hint.Types, "boolean") && slices.Contains(integerTypes, typeName) &&
+			col.IntCount == result.RowCount-col.EmptyCount && col.IntMin >= 0 && col.IntMax <= 1 {
+			col.TypeIndex = booleanType
+			col.BooleanByName = true
+			if verbose {
+				fmt.Fprintf(os.Stderr, "DEBUG: field %s reclassified as boolean by its name\n", col.Name)
+			}
+			continue
+		}
+		quoted := make([]string, len(h.values[i]))
+		for j, value := range h.values[i] {
+			quoted[j] = "'" + exampleValue(value) + "'"
+		}
+		article := "a"
+		if strings.ContainsRune("aeiou", rune(hint.Type[0])) {
+			article = "an"
+		}
+		warning := fmt.Sprintf("column %s named like %s %s but inferred %s", col.Name, article, hint.Type, columnTypeName(*col, analyzer))
+		if len(quoted) > 0 {
+			warning += "; values include " + strings.Join(quoted, ", ")
+		}
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestHintFor(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"id", "integer"},
+		{"customer_id", "integer"},
+		{"Order_ID", "integer"},
+		{"is_deleted", "boolean"},
+		{"has_children", "boolean"},
+		{"login_count", "integer"},
+		{"created_at", "timestamp"},
+		{"ship_date", "date"},
+		{"is_", ""},
+		{"_id", ""},
+		{"idea", ""},
+		{"format", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if hint := hintFor(tt.name); hint != nil {
+			got = hint.Type
+		}
+		if got != tt.want {
+			t.Errorf("hintFor(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNameHintFlag(t *testing.T) {
+	var f nameHintFlag
+	for _, tt := range []struct{ value, want string }{{"true", "warn"}, {"prefer", "prefer"}, {"false", ""}} {
+		if err := f.Set(tt.value); err != nil || string(f) != tt.want {
+			t.Errorf("Set(%q) = %q, %v, want %q", tt.value, f, err, tt.want)
+		}
+	}
+	if err := f.Set("always"); err == nil {
+		t.Errorf("Set(always) error = nil, want an error")
+	}
+}
+
+func TestNameHintAnalysis(t *testing.T) {
+	input := "id,is_deleted,is_active,created_at,note\n" +
+		"1,yes,1,2024-01-15 10:00:00,a\n" +
+		"2,no,0,2024-01-16 11:00:00,b\n" +
+		"3,unknown,1,2024-01-17 12:00:00,c\n" +
+		"4,no,,2024-01-18 13:00:00,d\n"
+	tests := []struct {
+		mode       nameHintFlag
+		wantActive string
+		wantWarn   []string
+	}{
+		{
+			mode:       "warn",
+			wantActive: "smallint",
+			wantWarn: []string{
+				"column is_deleted named like a boolean but inferred varchar(7); values include 'yes', 'no', 'unknown'",
+				"column is_active named like a boolean but inferred smallint; values include '1', '0'",
+			},
+		},
+		{
+			mode:       "prefer",
+			wantActive: "boolean",
+			wantWarn: []string{
+				"column is_deleted named like a boolean but inferred varchar(7); values include 'yes', 'no', 'unknown'",
+			},
+		},
+	}
+	for _, tt := range tests {
+		analyzer := &dbtypes.PostgreSQLAnalyzer{}
+		opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}, NameHints: tt.mode}
+		result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		if got := columnTypeName(result.Columns[2], analyzer); got != tt.wantActive {
+			t.Errorf("%s: is_active type = %s, want %s", tt.mode, got, tt.wantActive)
+		}
+		if got, want := strings.Join(result.Warnings, "\n"), strings.Join(tt.wantWarn, "\n"); got != want {
+			t.Errorf("%s: warnings = %q, want %q", tt.mode, got, want)
+		}
+		if got := result.Columns[3].NameHint; got != "timestamp" {
+			t.Errorf("%s: created_at NameHint = %q, want timestamp", tt.mode, got)
+		}
+		if got := result.Columns[4].NameHint; got != "" {
+			t.Errorf("%s: note NameHint = %q, want none", tt.mode, got)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"unicode/utf8"
+
+	"file2ddl/dbtypes"
+)
+
+// identifierLimit returns the bytes of an identifier the flavor keeps, or 0
+// if it keeps them whole
+func identifierLimit(analyzer dbtypes.TypeAnalyzer) int {
+	if limiter, ok := analyzer.(dbtypes.IdentifierLimiter); ok {
+		return limiter.IdentifierLimit()
+	}
+	return 0
+}
+
+// fitColumnNames puts a prefix and a suffix around every column name and
+// shortens the names longer than limit bytes, keeping the name in the file
+// as the source name. A shortened name keeps the start of the name and ends
+// in a hash of the whole of it, so the same header always gets the same
+// identifier and headers that differ only past the limit stay apart. The
+// returned warnings list the shortened names. A limit of 0 shortens none.
+func fitColumnNames(result *fileAnalysis, prefix, suffix string, limit int) []string {
+	taken := make(map[string]bool)
+	for _, col := range result.Columns {
+		taken[prefix+col.Name+suffix] = true
+	}
+	var warnings []string
+	renamed := make(map[string]string)
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		name := prefix + col.Name + suffix
+		if limit > 0 && len(name) > limit {
+			delete(taken, name)
+			short := shortName(name, limit, taken)
+			taken[short] = true
+			long := col.Name
+			if name != col.Name {
+				long += " as " + name
+			}
+			warnings = append(warnings, fmt.Sprintf("column %s is %d bytes long, over the limit of %d, so it is named %s",
+				long, len(name), limit, short))
+			name = short
+		}
+		if name == col.Name {
+			continue
+		}
+		if col.SourceName == "" {
+			col.SourceName = col.Name
+		}
+		renamed[col.Name] = name
+		col.Name = name
+	}
+
+	for i, point := range result.GeoPoints {
+		result.GeoPoints[i] = geoPoint{Latitude: keptRename(point.Latitude, renamed), Longitude: keptRename(point.Longitude, renamed)}
+	}
+	result.PartitionKey = keptRename(result.PartitionKey, renamed)
+	return warnings
+}
+
+// shortName cuts a name to limit bytes ending in _ and a hash of the whole
+// name, lengthening the hash in the unlikely case the result is taken
+func shortName(name string, limit int, taken map[string]bool) string {
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])
+	for digits := 8; digits <= len(hash) && digits < limit; digits += 4 {
+		short := truncateBytes(name, limit-digits-1) + "_" + hash[:digits]
+		if !taken[short] {
+			return short
+		}
+	}
+	// A limit too short for a hash falls back to numbering
+	short := truncateBytes(name, limit)
+	for n := 2; taken[short]; n++ {
+		tag := fmt.Sprintf("_%d", n)
+		short = truncateBytes(name, limit-len(tag)) + tag
+	}
+	return short
+}
+
+// keptRename returns a column's new name, or the name if it was not renamed
+func keptRename(name string, renamed map[string]string) string {
+	if newName, ok := renamed[name]; ok {
+		return newName
+	}
+	return name
+}
+
+// truncateBytes cuts s to at most n bytes without splitting a character
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
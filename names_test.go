@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestFitColumnNames(t *testing.T) {
+	result := &fileAnalysis{
+		Columns:      []columnAnalysis{{Name: "id"}, {Name: "lat"}, {Name: "lon"}},
+		GeoPoints:    []geoPoint{{Latitude: "lat", Longitude: "lon"}},
+		PartitionKey: "id",
+	}
+	if warnings := fitColumnNames(result, "src_", "_raw", 63); warnings != nil {
+		t.Errorf("warnings = %q, want none", warnings)
+	}
+	var names, sources []string
+	for _, col := range result.Columns {
+		names = append(names, col.Name)
+		sources = append(sources, col.SourceName)
+	}
+	if want := []string{"src_id_raw", "src_lat_raw", "src_lon_raw"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %q, want %q", names, want)
+	}
+	if want := []string{"id", "lat", "lon"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("source names = %q, want %q", sources, want)
+	}
+	if result.GeoPoints[0] != (geoPoint{Latitude: "src_lat_raw", Longitude: "src_lon_raw"}) || result.PartitionKey != "src_id_raw" {
+		t.Errorf("GeoPoints = %v, PartitionKey = %s, want the affixed names", result.GeoPoints, result.PartitionKey)
+	}
+
+	// Without affixes or long names nothing is renamed
+	result = &fileAnalysis{Columns: []columnAnalysis{{Name: "id"}}}
+	fitColumnNames(result, "", "", 63)
+	if result.Columns[0].SourceName != "" {
+		t.Errorf("SourceName = %q, want none", result.Columns[0].SourceName)
+	}
+}
+
+func TestLongHeadersStayDistinct(t *testing.T) {
+	long := strings.Repeat("customer_lifetime_value_", 4)[:79]
+	input := long + "a," + long + "b\n1,2\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	warnings := fitColumnNames(result, "", "", identifierLimit(analyzer))
+	if len(warnings) != 2 {
+		t.Errorf("warnings = %q, want one per column", warnings)
+	}
+
+	first, second := result.Columns[0].Name, result.Columns[1].Name
+	if first == second {
+		t.Fatalf("both columns are named %s", first)
+	}
+	for i, col := range result.Columns {
+		sum := sha256.Sum256([]byte(col.SourceName))
+		if want := long[:54] + "_" + hex.EncodeToString(sum[:])[:8]; col.Name != want || len(col.Name) != 63 {
+			t.Errorf("column %d = %s, want %s", i, col.Name, want)
+		}
+	}
+}
+
+func TestShortName(t *testing.T) {
+	name := strings.Repeat("x", 20)
+	taken := map[string]bool{}
+	short := shortName(name, 12, taken)
+	if len(short) != 12 || !strings.HasPrefix(short, "xxx_") {
+		t.Errorf("shortName() = %s, want 3 bytes of the name and an 8-digit hash", short)
+	}
+	taken[short] = true
+	if got := shortName(name, 5, taken); got != "xxxxx" {
+		t.Errorf("shortName() with a limit too short for a hash = %s, want xxxxx", got)
+	}
+	taken["xxxxx"] = true
+	if got := shortName(name, 5, taken); got != "xxx_2" {
+		t.Errorf("shortName() of a taken name = %s, want xxx_2", got)
+	}
+}
+
+func TestTruncateBytes(t *testing.T) {
+	if got := truncateBytes("naïve", 3); got != "na" {
+		t.Errorf("truncateBytes() = %q, want %q", got, "na")
+	}
+}
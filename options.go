@@ -0,0 +1,239 @@
+package main
+
+import (
+	"flag"
+	"time"
+)
+
+// runOptions holds the settings of a run, one field per flag: register
+// defines each flag under the name in its field's yaml tag, and a -profile
+// sets the field by the same name through applyProfile. The settings the
+// analysis takes as given are the fields of the embedded analysisOptions,
+// and through it of the library's analyze.Options; main reads the others
+// into them.
+type runOptions struct {
+	analysisOptions `yaml:",inline"`
+
+	Delim               string           `yaml:"delim"`
+	DelimRegex          string           `yaml:"delim-regex"`
+	FormatPreset        string           `yaml:"format-preset"`
+	RecordSep           string           `yaml:"record-sep"`
+	Flavor              string           `yaml:"flavor"`
+	HANATableType       string           `yaml:"hana-table-type"`
+	MariaDBVersion      string           `yaml:"mariadb-version"`
+	MariaDBUnsigned     bool             `yaml:"mariadb-unsigned-tinyint"`
+	DistributedBy       string           `yaml:"distributed-by"`
+	DistributedRandomly bool             `yaml:"distributed-randomly"`
+	Null                string           `yaml:"null"`
+	Format              string           `yaml:"format"`
+	ColumnPrefix        string           `yaml:"column-prefix"`
+	ColumnSuffix        string           `yaml:"column-suffix"`
+	ColumnOrder         string           `yaml:"column-order"`
+	Table               string           `yaml:"table"`
+	SparkDDL            bool             `yaml:"spark-ddl"`
+	PrimaryKey          string           `yaml:"primary-key"`
+	TSBigNumbers        string           `yaml:"ts-big-numbers"`
+	ChangeSetID         string           `yaml:"changeset-id"`
+	ChangeSetAuthor     string           `yaml:"changeset-author"`
+	Output              string           `yaml:"o"`
+	MigrationStyle      string           `yaml:"migration-style"`
+	MigrationVersion    string           `yaml:"migration-version"`
+	LengthSemanticsFlag string           `yaml:"length-semantics"`
+	VarcharPercentile   float64          `yaml:"varchar-percentile"`
+	Stats               bool             `yaml:"stats"`
+	WithLoad            bool             `yaml:"with-load"`
+	Stage               string           `yaml:"stage"`
+	StagePattern        string           `yaml:"stage-pattern"`
+	LoadOnError         string           `yaml:"load-on-error"`
+	LoadPurge           bool             `yaml:"load-purge"`
+	WithMerge           bool             `yaml:"with-merge"`
+	MergeTarget         string           `yaml:"target"`
+	MergeKey            string           `yaml:"merge-key"`
+	RawTable            string           `yaml:"raw-table"`
+	ExternalLocation    string           `yaml:"location"`
+	ExternalDialect     string           `yaml:"external-dialect"`
+	ViewName            string           `yaml:"view"`
+	AddColumns          addedColumnFlags `yaml:"add-columns"`
+	AddSurrogateKey     bool             `yaml:"add-surrogate-key"`
+	AddAuditColumns     bool             `yaml:"add-audit-columns"`
+	WithComments        bool             `yaml:"with-comments"`
+	NoBanner            bool             `yaml:"no-banner"`
+	DBTSource           string           `yaml:"dbt-source"`
+	OnBadRow            string           `yaml:"on-bad-row"`
+	SuggestPartitioning bool             `yaml:"suggest-partitioning"`
+	PartitionTolerance  float64          `yaml:"partition-tolerance"`
+	WithChecks          bool             `yaml:"with-checks"`
+	CheckHeadroom       float64          `yaml:"check-headroom"`
+	SuggestIndexes      bool             `yaml:"suggest-indexes"`
+	CheckAppendFile     string           `yaml:"check-append"`
+	AppendPad           int              `yaml:"append-pad"`
+	ManifestFile        string           `yaml:"manifest"`
+	Interactive         bool             `yaml:"interactive"`
+	OverrideFile        string           `yaml:"override-file"`
+	Overrides           overrideFlags    `yaml:"override"`
+	SaveOverridesFile   string           `yaml:"save-overrides"`
+	MemoryBudgetFlag    string           `yaml:"memory-budget"`
+	AssumeTZ            string           `yaml:"assume-tz"`
+	HeadBytes           int64            `yaml:"head-bytes"`
+	StartByte           int64            `yaml:"start-byte"`
+	EndByte             int64            `yaml:"end-byte"`
+	HTTPTimeout         time.Duration    `yaml:"http-timeout"`
+	InputFormat         string           `yaml:"input"`
+	Scan                bool             `yaml:"scan"`
+	Sheet               string           `yaml:"sheet"`
+	ZipEntry            string           `yaml:"zip-entry"`
+	AWSRegion           string           `yaml:"aws-region"`
+	StateFile           string           `yaml:"state"`
+	ResetState          bool             `yaml:"reset-state"`
+	CheckpointFile      string           `yaml:"checkpoint"`
+	CheckpointRows      int              `yaml:"checkpoint-rows"`
+	Verbose             bool             `yaml:"v"`
+	Plain               bool             `yaml:"plain"`
+	Profile             string           `yaml:"profile"`
+
+	set map[string]bool // flags given on the command line or by the profile
+}
+
+// register defines the flags of the tool on fs, filling the options
+func (o *runOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.Delim, "delim", "", "Field delimiter, one or more characters; escapes such as \\t and \\x1f are interpreted (required)")
+	fs.StringVar(&o.DelimRegex, "delim-regex", "", "Go regular expression matching the field delimiter, instead of -delim, for unquoted input")
+	fs.StringVar(&o.FormatPreset, "format-preset", "", "Read the file as a known format: tsv splits on tabs without quotes and decodes \\t, \\n, \\r and \\\\ in fields (default: tsv for .tsv and .tab files without -delim)")
+	fs.StringVar(&o.RecordSep, "record-sep", "", "Character ending each record instead of a newline, e.g. \\x1e, or \\0 for NUL")
+	fs.StringVar(&o.Flavor, "flavor", "postgresql", "Database flavor, or a comma-separated list to report the types under each (default: postgresql)")
+	fs.StringVar(&o.HANATableType, "hana-table-type", "column", "Store of the SAP HANA table: column or row (default: column)")
+	fs.StringVar(&o.MariaDBVersion, "mariadb-version", "11.4", "MariaDB version the DDL is for, which gates the native UUID type of 10.7 and later (default: 11.4)")
+	fs.BoolVar(&o.MariaDBUnsigned, "mariadb-unsigned-tinyint", false, "Infer MariaDB integers from 0 to 255 as tinyint unsigned, rather than -128 to 127 as tinyint")
+	fs.StringVar(&o.DistributedBy, "distributed-by", "", "Column a Greenplum table's rows are distributed by (default: the primary key, else the first column of distinct values)")
+	fs.BoolVar(&o.DistributedRandomly, "distributed-randomly", false, "Distribute a Greenplum table's rows randomly")
+	fs.StringVar(&o.Quotes, "quotes", "none", "Quote character type: none, single, or double (default: none)")
+	fs.BoolVar(&o.QuotedEmpty, "quoted-empty-is-empty", false, "Read a quoted empty field as an empty string rather than a null; an unquoted empty field is still a null")
+	fs.StringVar(&o.Null, "null", "", "Comma-separated values read as nulls like empty fields, e.g. NULL,N/A (optional)")
+	fs.IntVar(&o.ExpectedCols, "ncols", 0, "Number of columns every row must have, and of generated names with -header no (optional)")
+	fs.StringVar(&o.Header, "header", "yes", "Whether the first row names the columns: yes, no or auto to decide by its contents (default: yes)")
+	fs.StringVar(&o.Format, "format", "text", "Output format: text, json, dbt, gostruct, avro, jsonschema, spark, sqlalchemy, typescript, proto, liquibase, migration, ddl, typed-view, external or bqschema (default: text)")
+	fs.StringVar(&o.ColumnPrefix, "column-prefix", "", "Prefix added to every column name in the output, e.g. src_")
+	fs.StringVar(&o.ColumnSuffix, "column-suffix", "", "Suffix added to every column name in the output")
+	fs.StringVar(&o.ColumnOrder, "column-order", "file", "Order of the columns in the output: file, or name for alphabetical (default: file)")
+	fs.StringVar(&o.Table, "table", "", "Table name for the schema and code output formats (default: the file name without extension)")
+	fs.BoolVar(&o.SparkDDL, "spark-ddl", false, "Write -format spark as a Spark SQL DDL string instead of a PySpark StructType")
+	fs.StringVar(&o.PrimaryKey, "primary-key", "", "Column marked as the primary key by -format sqlalchemy, liquibase, migration and ddl")
+	fs.StringVar(&o.TSBigNumbers, "ts-big-numbers", "string", "TypeScript type for bigint and numeric columns in -format typescript: string or number (default: string)")
+	fs.StringVar(&o.ChangeSetID, "changeset-id", "", "ChangeSet id for -format liquibase (default: derived from the table name and file contents)")
+	fs.StringVar(&o.ChangeSetAuthor, "changeset-author", "file2ddl", "ChangeSet author for -format liquibase (default: file2ddl)")
+	fs.StringVar(&o.Output, "o", "", "Write the output to this file, or for -format migration to this directory (default: stdout, or the current directory)")
+	fs.StringVar(&o.MigrationStyle, "migration-style", "flyway", "Migration file convention for -format migration: flyway or goose (default: flyway)")
+	fs.StringVar(&o.MigrationVersion, "migration-version", "", "Version for -format migration file names (default: the current UTC time as YYYYMMDDHHMMSS)")
+	fs.StringVar(&o.LengthSemanticsFlag, "length-semantics", "", "Measure varchar lengths in bytes or chars (default: chars for postgresql, snowflake, hana, greenplum, mariadb and exasol, bytes for firebird and vertica)")
+	fs.Float64Var(&o.VarcharPercentile, "varchar-percentile", 0, "Size varchar columns to this percentile of their value lengths, e.g. 99, instead of the longest value")
+	fs.BoolVar(&o.Stats, "stats", false, "Report each column's row count, non-null rows, fill rate and values that do not fit the type most of its values fit")
+	fs.BoolVar(&o.WithLoad, "with-load", false, "Follow the CREATE TABLE of -format ddl with a COPY loading the file, or write a bq load command for -format bqschema, reading the -null tokens as nulls")
+	fs.StringVar(&o.Stage, "stage", "", "Snowflake stage -with-load loads the file from, e.g. @landing/orders/ (default: the table's stage)")
+	fs.StringVar(&o.StagePattern, "stage-pattern", "", "Regular expression of the staged files -with-load loads into Snowflake (default: the input's file name, compressed or not)")
+	fs.StringVar(&o.LoadOnError, "load-on-error", "", "ON_ERROR of the Snowflake COPY INTO of -with-load: continue, abort_statement, skip_file, skip_file_n or skip_file_n% (default: abort_statement)")
+	fs.BoolVar(&o.LoadPurge, "load-purge", false, "Remove the staged files once the Snowflake COPY INTO of -with-load loaded them")
+	fs.BoolVar(&o.WithMerge, "with-merge", false, "Follow the CREATE TABLE of -format ddl with a statement merging the table into -target by -merge-key")
+	fs.StringVar(&o.MergeTarget, "target", "", "Table -with-merge loads into, optionally schema-qualified, e.g. prod.customers")
+	fs.StringVar(&o.MergeKey, "merge-key", "", "Comma-separated columns -with-merge matches rows on")
+	fs.StringVar(&o.RawTable, "raw-table", "", "All-text table -format typed-view selects from, optionally schema-qualified, e.g. raw.events")
+	fs.StringVar(&o.ExternalLocation, "location", "", "S3 prefix holding the files -format external registers, e.g. s3://bucket/orders/")
+	fs.StringVar(&o.ExternalDialect, "external-dialect", "athena", "SQL dialect of -format external: athena or spectrum, for Redshift Spectrum (default: athena)")
+	fs.StringVar(&o.ViewName, "view", "", "View -format typed-view creates, optionally schema-qualified (default: the table name)")
+	fs.Var(&o.AddColumns, "add-columns", "Add a column to the DDL after the file's, as name:type or name:type:default, e.g. batch_id:integer:0; may be repeated")
+	fs.BoolVar(&o.AddSurrogateKey, "add-surrogate-key", false, "Add an _id bigint identity column ahead of the file's columns in the DDL")
+	fs.BoolVar(&o.AddAuditColumns, "add-audit-columns", false, "Add _loaded_at, defaulting to the load time, and _source_file columns after the file's columns in the DDL")
+	fs.BoolVar(&o.WithComments, "with-comments", false, "Add COMMENT ON statements with provenance and observed stats to -format ddl and migration")
+	fs.BoolVar(&o.NoBanner, "no-banner", false, "Leave the tool version and generation time out of -with-comments, so the output is the same on every run")
+	fs.StringVar(&o.DBTSource, "dbt-source", "raw", "Source name for -format dbt (default: raw)")
+	fs.StringVar(&o.EmptyColumnType, "empty-column-type", "text", "Type reported for columns without non-null values, and for every column when no data rows are present (default: text)")
+	fs.BoolVar(&o.StrictEmpty, "strict-empty-columns", false, "Fail instead of warning about columns without non-null values")
+	fs.StringVar(&o.OnBadRow, "on-bad-row", "error", "What to do with a row whose number of fields differs from the header, is over the size limits or has an unterminated quote: error, or skip and summarize them (default: error)")
+	fs.IntVar(&o.MaxRecordBytes, "max-record-bytes", 16<<20, "Longest line of a delimited file read, in bytes; longer ones are bad rows (default: 16 MiB)")
+	fs.IntVar(&o.MaxFieldBytes, "max-field-bytes", 4<<20, "Longest field of a delimited file read, in bytes; rows with longer ones are bad rows (default: 4 MiB)")
+	fs.BoolVar(&o.KeepHeaderRepeats, "no-skip-repeated-headers", false, "Read rows identical to the header as data instead of skipping them as repeated headers")
+	fs.BoolVar(&o.StrictBlankLines, "strict-blank-lines", false, "Treat blank lines as errors instead of skipping them")
+	fs.BoolVar(&o.DetectEpoch, "detect-epoch", false, "Reclassify integer columns holding Unix timestamps (seconds or milliseconds) as timestamp")
+	fs.IntVar(&o.EpochMinYear, "epoch-min-year", 1990, "Earliest year accepted by -detect-epoch (default: 1990)")
+	fs.IntVar(&o.EpochMaxYear, "epoch-max-year", 2035, "Latest year accepted by -detect-epoch (default: 2035)")
+	fs.BoolVar(&o.DetectCompact, "detect-compact-dates", false, "Reclassify integer columns of YYYYMMDD or YYYYMM values as date")
+	fs.BoolVar(&o.StripPercent, "strip-percent", false, "Remove a percent sign ending a number, e.g. 45% or 3.5 %, before inference, inferring numeric columns of percentages")
+	fs.BoolVar(&o.PercentFraction, "percent-as-fraction", false, "With -strip-percent, declare percentages as fractions, divided by 100 in -format typed-view")
+	fs.BoolVar(&o.AccountingNumbers, "accounting-numbers", false, "Read negatives written as (1,234.56) or 1234.56- and thousands separators as numbers before inference")
+	fs.BoolVar(&o.DetectHex, "detect-hex", false, "Reclassify columns of 0x hex and 0b binary integer literals, possibly mixed with decimal integers, as integers")
+	fs.BoolVar(&o.DetectGeo, "detect-geo", false, "Detect WKT geometry columns and latitude/longitude column pairs")
+	fs.BoolVar(&o.DetectBinary, "detect-binary", false, "Reclassify columns of base64 or hex encoded data as bytea")
+	fs.IntVar(&o.BinaryMinLength, "binary-min-length", 32, "Average value length a column needs for -detect-binary (default: 32)")
+	fs.BoolVar(&o.DetectXML, "detect-xml", false, "Reclassify columns of well-formed XML as xml")
+	fs.IntVar(&o.XMLMaxBytes, "xml-max-bytes", 1<<20, "Bytes of each value checked by -detect-xml (default: 1048576)")
+	fs.BoolVar(&o.SuggestPartitioning, "suggest-partitioning", false, "Partition the DDL by the date or timestamp column whose values run in file order, if there is exactly one")
+	fs.Float64Var(&o.PartitionTolerance, "partition-tolerance", 0.01, "Fraction of a column's dates that -suggest-partitioning and -suggest-indexes let be out of order (default: 0.01)")
+	fs.BoolVar(&o.WithChecks, "with-checks", false, "Follow the CREATE TABLE of -format ddl with CHECK constraints holding the observed integer and date ranges and few-valued strings")
+	fs.Float64Var(&o.CheckHeadroom, "check-headroom", 0.5, "Fraction of a column's observed range -with-checks adds at each end (default: 0.5)")
+	fs.BoolVar(&o.SuggestIndexes, "suggest-indexes", false, "Follow the CREATE TABLE of -format ddl with CREATE INDEX suggestions for likely keys and date columns in file order")
+	fs.StringVar(&o.CheckAppendFile, "check-append", "", "Fail unless the file can be appended to the table of the state or manifest file of a previous run")
+	fs.IntVar(&o.AppendPad, "append-pad", 0, "Characters by which -check-append lets varchar values exceed the previous length (default: 0)")
+	fs.StringVar(&o.ManifestFile, "manifest", "", "Write a JSON manifest of the run to this file: the input's size and SHA-256, the flags, the tool version and the schema")
+	fs.BoolVar(&o.Interactive, "interactive", false, "Review each column in the terminal, accepting, retyping, renaming or dropping it, before the output is written")
+	fs.StringVar(&o.OverrideFile, "override-file", "", "Apply the column decisions saved by -save-overrides without asking")
+	fs.Var(&o.Overrides, "override", "Set a column's type, with the layout of its dates or timestamps, e.g. order_date=date:01/02/2006; may be repeated")
+	fs.StringVar(&o.SaveOverridesFile, "save-overrides", "", "Write the decisions of -interactive to this file for -override-file")
+	fs.BoolVar(&o.Examples, "examples", false, "Show example values of each column: the first, shortest and longest, and the value behind each type promotion")
+	fs.Float64Var(&o.OutlierFraction, "outlier-fraction", 0.01, "Warn about columns forced to their type by fewer than this fraction of their values, 0 to disable (default: 0.01)")
+	fs.BoolVar(&o.StrictOutliers, "strict-outliers", false, "Fail instead of warning about columns forced to their type by a few outlying values")
+	fs.BoolVar(&o.StrictConfidence, "strict-low-confidence", false, "Fail on columns whose inferred type has low confidence, from too few values or values of other types")
+	fs.BoolVar(&o.DetectDuplicates, "detect-duplicates", false, "Count rows that repeat an earlier row, with example line numbers")
+	fs.StringVar(&o.MemoryBudgetFlag, "memory-budget", "", "Most memory the trackers of -detect-duplicates, -suggest-indexes and -with-checks may hold, e.g. 512MB; past it they degrade to approximate, then stop (default: no limit)")
+	fs.BoolVar(&o.DetectCodes, "detect-codes", false, "Reclassify columns of ISO country or currency codes as char(2) or char(3)")
+	fs.BoolVar(&o.StripControl, "strip-control-chars", false, "Strip control characters other than the delimiter from values before inferring types and measuring lengths")
+	fs.StringVar(&o.ControlReplacement, "control-char-replacement", "", "String that -strip-control-chars replaces each control character by, e.g. a space (default: none)")
+	fs.BoolVar(&o.NormalizePunct, "normalize-punctuation", false, "Replace curly quotes, en and em dashes, ellipses and no-break spaces by ASCII before inferring types and measuring lengths")
+	fs.Var(&o.NameHints, "name-hints", "Compare column types with those their names suggest, such as boolean for is_*, warning where they disagree; =prefer also declares 0/1 columns named like booleans boolean")
+	fs.BoolVar(&o.UnwrapExcel, "unwrap-excel-formulas", false, "Read values wrapped as Excel formulas, e.g. =\"0123\", as the values inside before inferring types and measuring lengths")
+	fs.StringVar(&o.AssumeTZ, "assume-tz", "", "Time zone of timestamps written without an offset, e.g. America/New_York (default: UTC)")
+	fs.BoolVar(&o.TwoDigitYears, "two-digit-years", false, "Accept dates with two-digit years such as 03/20/24")
+	fs.IntVar(&o.YearPivot, "year-pivot", 69, "Two-digit years below the pivot are read as 20xx, the rest as 19xx (default: 69)")
+	fs.Int64Var(&o.HeadBytes, "head-bytes", 0, "Read only the first N bytes of the input, dropping a line cut off at the end (default: the whole file)")
+	fs.IntVar(&o.StartLine, "start-line", 0, "Analyze delimited text from this line on, reading the header from the top (default: the first line)")
+	fs.IntVar(&o.EndLine, "end-line", 0, "Analyze delimited text up to and including this line (default: the last line)")
+	fs.Int64Var(&o.StartByte, "start-byte", 0, "Analyze the records of delimited text starting at or after this byte offset, reading the header from the top (default: the start)")
+	fs.Int64Var(&o.EndByte, "end-byte", 0, "Analyze the records of delimited text starting before this byte offset (default: the end)")
+	fs.DurationVar(&o.HTTPTimeout, "http-timeout", 5*time.Minute, "Time limit for fetching an http(s) input (default: 5m)")
+	fs.StringVar(&o.InputFormat, "input", "", "Input format: delimited, xlsx, parquet, avro or arrow (default: by file extension)")
+	fs.BoolVar(&o.Scan, "scan", false, "Decode the records of an avro or arrow input to count nulls and measure string lengths")
+	fs.StringVar(&o.Sheet, "sheet", "", "Worksheet of an xlsx input, by name or 1-based position (default: the first)")
+	fs.StringVar(&o.ZipEntry, "zip-entry", "", "File to analyze inside a .zip archive with several entries")
+	fs.StringVar(&o.AWSRegion, "aws-region", "", "AWS region for s3:// inputs (default: from the AWS configuration)")
+	fs.StringVar(&o.StateFile, "state", "", "Merge the analysis with the state saved in this file by earlier runs, then save it back")
+	fs.BoolVar(&o.ResetState, "reset-state", false, "Ignore the existing -state file and start fresh")
+	fs.StringVar(&o.CheckpointFile, "checkpoint", "", "Save the analysis of a delimited file to this file every -checkpoint-rows rows, and resume from it when rerun with the same flags")
+	fs.IntVar(&o.CheckpointRows, "checkpoint-rows", 1000000, "Data rows between the checkpoints saved by -checkpoint (default: 1,000,000)")
+	fs.BoolVar(&o.Verbose, "v", false, "Enable verbose mode with DEBUG output on stderr")
+	fs.BoolVar(&o.Plain, "plain", false, "Write diagnostics without color or emphasis escape codes, even on a terminal")
+	fs.StringVar(&o.Profile, "profile", "", "YAML file of flag values, e.g. delim: \"|\", taken for the flags not given on the command line")
+}
+
+// parse reads the flags of args into the options, then the -profile they
+// name, if any, for the flags they leave out
+func (o *runOptions) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	o.set = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { o.set[f.Name] = true })
+	if o.Profile == "" {
+		return nil
+	}
+	return o.applyProfile(o.Profile)
+}
+
+// settings returns the flags set for the run, on the command line or by its
+// profile, with their values as the command line writes them
+func (o *runOptions) settings(fs *flag.FlagSet) map[string]string {
+	settings := make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		if o.set[f.Name] {
+			settings[f.Name] = f.Value.String()
+		}
+	})
+	return settings
+}
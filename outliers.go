@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// maxOutlierLines caps the line numbers kept per column and type to point
+// at values that do not fit a column's dominant type
+const maxOutlierLines = 5
+
+// findOutliers describes the columns forced to a string type by text in a
+// fraction of their values smaller than the given one, such as a single word
+// in a column of integers, which is usually a data error rather than a
+// reason for the type. Widening within numbers or dates, e.g. by one large
+// integer, is left alone. Each description names the dominant type, the
+// number of text values and the lines of up to maxOutlierLines of them.
+func findOutliers(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, fraction float64) []string {
+	var found []string
+	for _, col := range result.Columns {
+		if !isStringType(analyzer.GetTypes()[col.TypeIndex].Name) {
+			continue
+		}
+		stats := newColumnStats(col, result.RowCount, analyzer, result.Location)
+		if stats.MajorityType == "" || isStringType(stats.MajorityType) {
+			continue
+		}
+
+		// The outliers are the values that only a string type holds
+		outliers := 0
+		var lines []int
+		for i, n := range col.TypeCounts {
+			if isStringType(analyzer.GetTypes()[i].Name) {
+				outliers += n
+				lines = append(lines, col.TypeLines[i]...)
+			}
+		}
+		if outliers == 0 || float64(outliers) >= fraction*float64(stats.NonNullRows) {
+			continue
+		}
+		slices.Sort(lines)
+		lineList := make([]string, 0, maxOutlierLines)
+		for _, line := range lines[:min(len(lines), maxOutlierLines)] {
+			lineList = append(lineList, fmt.Sprint(line))
+		}
+
+		verb, lineWord := "are", "lines"
+		if outliers == 1 {
+			verb, lineWord = "is", "line"
+		}
+		found = append(found, fmt.Sprintf("column %s is mostly %s, but %d of %d values %s text and made it %s (%s %s)",
+			col.Name, stats.MajorityType, outliers, stats.NonNullRows, verb,
+			analyzer.GetTypes()[col.TypeIndex].Name, lineWord, strings.Join(lineList, ", ")))
+	}
+	return found
+}
+
+// isStringType reports whether a type holds any text
+func isStringType(name string) bool {
+	return name == "varchar" || name == "text"
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestOutliers(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("id,amount,big,code\n")
+	for i := 1; i <= 700; i++ {
+		amount, big, code := fmt.Sprint(i), "1", fmt.Sprint(i)
+		switch i {
+		case 100, 200, 300, 400, 500, 600:
+			amount = "N/A"
+		case 650:
+			big = "100000"
+		}
+		if i%10 == 0 {
+			code = "x"
+		}
+		fmt.Fprintf(&b, "%d,%s,%s,%s\n", i, amount, big, code)
+	}
+	input := b.String()
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, OutlierFraction: 0.01}
+
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	// One large integer widens big without a warning, and a tenth of the
+	// codes being text is too many to be outliers
+	want := []string{"column amount is mostly smallint, but 6 of 700 values are text and made it varchar (lines 101, 201, 301, 401, 501)"}
+	if !reflect.DeepEqual(result.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", result.Warnings, want)
+	}
+
+	opts.StrictOutliers = true
+	if _, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer); err == nil || err.Error() != want[0] {
+		t.Errorf("analyzeFileTypes() error = %v, want %q", err, want[0])
+	}
+
+	opts.OutlierFraction = 0
+	if _, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer); err != nil {
+		t.Errorf("analyzeFileTypes() without outlier detection error = %v, want nil", err)
+	}
+}
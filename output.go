@@ -0,0 +1,354 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// columnTypeName renders a column's inferred type with the parameters it
+// takes, such as the length of varchar and char columns and the
+// fractional-second precision of timestamps, in the flavor's spelling
+func columnTypeName(col columnAnalysis, analyzer dbtypes.TypeAnalyzer) string {
+	dataType := analyzer.GetTypes()[col.TypeIndex]
+	spelling := dataType.Name
+	if namer, ok := analyzer.(dbtypes.TypeNamer); ok {
+		spelling = namer.TypeName(dataType.Name)
+	}
+	return dataType.Spec(spelling, dbtypes.TypeParams{
+		Length:     col.MaxLength,
+		Precision:  col.Precision,
+		Scale:      col.NumScale,
+		FracDigits: col.FracDigits,
+	})
+}
+
+// sortColumnsByName orders the columns alphabetically by their final names,
+// ignoring case, for outputs that are compared across files
+func sortColumnsByName(result *fileAnalysis) {
+	slices.SortStableFunc(result.Columns, func(a, b columnAnalysis) int {
+		return cmp.Or(strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)), strings.Compare(a.Name, b.Name))
+	})
+}
+
+// columnNotes returns remarks about how a column's type was decided that
+// someone loading the data needs to know about
+func columnNotes(col columnAnalysis) []string {
+	var notes []string
+	if col.NoValues {
+		notes = append(notes, "no non-null values observed")
+	}
+	if col.EpochUnit != "" {
+		notes = append(notes, "epoch "+col.EpochUnit)
+	}
+	if col.CompactFormat != "" {
+		notes = append(notes, "compact "+col.CompactFormat)
+	}
+	if col.BinaryEncoding != "" {
+		notes = append(notes, col.BinaryEncoding+" encoded")
+	}
+	if col.LiteralBase != "" {
+		notes = append(notes, literalNote(col))
+	}
+	if col.Percentages {
+		notes = append(notes, percentNote(col))
+	}
+	if col.Accounting {
+		notes = append(notes, "accounting notation, rewritten as plain numbers when loading")
+	}
+	if col.CodeList != "" {
+		notes = append(notes, col.CodeList+" code", codeCheck(col))
+	}
+	if len(col.EnumSymbols) > 0 {
+		notes = append(notes, "enum "+strings.Join(col.EnumSymbols, ", "))
+	}
+	if col.Normalized > 0 {
+		notes = append(notes, fmt.Sprintf("%s punctuation characters normalized", groupDigits(col.Normalized)))
+	}
+	if col.BooleanByName {
+		notes = append(notes, "0 and 1 read as boolean by its name")
+	}
+	if col.ExcelFormulas > 0 {
+		notes = append(notes, fmt.Sprintf(`%s written as Excel formulas ="..."`, countOf(col.ExcelFormulas, "value")))
+	}
+	return notes
+}
+
+// printText writes the human-readable column report
+func printText(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) {
+	fmt.Fprintln(w, "Column Analysis:")
+	for _, col := range result.Columns {
+		notes := columnNotes(col)
+		if col.Stats != nil {
+			notes = append(notes, col.Stats.String())
+			if col.Confidence != nil {
+				notes = append(notes, col.Confidence.String())
+			}
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(w, "%s: %s (%s)\n", col.Name, columnTypeName(col, analyzer), strings.Join(notes, "; "))
+		} else {
+			fmt.Fprintf(w, "%s: %s\n", col.Name, columnTypeName(col, analyzer))
+		}
+		if col.Examples != nil {
+			for _, line := range col.Examples.lines() {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+	for _, point := range result.GeoPoints {
+		fmt.Fprintf(w, "Candidate point: latitude=%s, longitude=%s\n", point.Latitude, point.Longitude)
+	}
+	if d := result.Duplicates; d != nil {
+		var examples []string
+		for _, pair := range d.Examples {
+			examples = append(examples, fmt.Sprintf("line %d repeats line %d", pair.Line, pair.FirstLine))
+		}
+		if len(examples) > 0 {
+			fmt.Fprintf(w, "Duplicate rows: %d (%s)\n", d.Rows, strings.Join(examples, ", "))
+		} else {
+			fmt.Fprintf(w, "Duplicate rows: %d\n", d.Rows)
+		}
+	}
+	if result.MemoryBudget > 0 {
+		fmt.Fprintf(w, "Tracked memory: %s at peak, of a %s budget\n", formatBytes(result.PeakMemory), formatBytes(result.MemoryBudget))
+	}
+}
+
+// jsonColumn is the JSON representation of a single column
+type jsonColumn struct {
+	Name           string            `json:"name"`
+	Ordinal        int               `json:"ordinal"`
+	SourceName     string            `json:"source_name,omitempty"`
+	Type           string            `json:"type"`
+	MaxLength      int               `json:"max_length"`
+	MaxBytes       int               `json:"max_bytes,omitempty"`
+	MaxChars       int               `json:"max_chars,omitempty"`
+	EpochUnit      string            `json:"epoch_unit,omitempty"`
+	CompactFormat  string            `json:"compact_format,omitempty"`
+	BinaryEncoding string            `json:"binary_encoding,omitempty"`
+	LiteralBase    string            `json:"literal_base,omitempty"`
+	Percentages    bool              `json:"percentages,omitempty"`
+	Fraction       bool              `json:"percent_as_fraction,omitempty"`
+	Accounting     bool              `json:"accounting_notation,omitempty"`
+	CodeList       string            `json:"code_list,omitempty"`
+	NoValues       bool              `json:"no_values,omitempty"`
+	Normalized     int               `json:"normalized_punctuation,omitempty"`
+	ExcelFormulas  int               `json:"excel_formulas,omitempty"`
+	NameHint       string            `json:"name_hint,omitempty"`
+	OverLimits     []string          `json:"over_length_limits,omitempty"`
+	Confidence     *float64          `json:"confidence,omitempty"`
+	ConfidenceOf   string            `json:"confidence_label,omitempty"`
+	ControlChars   int               `json:"control_chars,omitempty"`
+	NULBytes       int               `json:"nul_bytes,omitempty"`
+	Check          string            `json:"check,omitempty"`
+	Types          map[string]string `json:"types,omitempty"`
+	Stats          *jsonStats        `json:"stats,omitempty"`
+	Examples       *jsonExamples     `json:"examples,omitempty"`
+}
+
+// jsonExamples is the JSON representation of a column's example values
+type jsonExamples struct {
+	First      string      `json:"first"`
+	Shortest   string      `json:"shortest"`
+	Longest    string      `json:"longest"`
+	Promotions []promotion `json:"promotions"`
+}
+
+// jsonStats is the JSON representation of a column's completeness
+type jsonStats struct {
+	TotalRows     int          `json:"total_rows"`
+	NonNullRows   int          `json:"non_null_rows"`
+	FillRate      float64      `json:"fill_rate"`
+	MajorityType  string       `json:"majority_type,omitempty"`
+	Nonconforming int          `json:"nonconforming_values"`
+	Min           interface{}  `json:"min,omitempty"`
+	Max           interface{}  `json:"max,omitempty"`
+	Lengths       *jsonLengths `json:"lengths,omitempty"`
+}
+
+// jsonLengths is the JSON representation of a column's value length
+// distribution
+type jsonLengths struct {
+	P50 int `json:"p50"`
+	P95 int `json:"p95"`
+	P99 int `json:"p99"`
+	Max int `json:"max"`
+}
+
+// jsonReport is the JSON representation of a file analysis
+type jsonReport struct {
+	RowCount        int          `json:"row_count"`
+	LengthSemantics string       `json:"length_semantics,omitempty"`
+	AssumedTimeZone string       `json:"assumed_time_zone,omitempty"`
+	Columns         []jsonColumn `json:"columns"`
+	Warnings        []string     `json:"warnings,omitempty"`
+	Points          []jsonPoint  `json:"point_candidates,omitempty"`
+
+	DuplicateRows     *int            `json:"duplicate_rows,omitempty"`
+	DuplicateExamples []duplicatePair `json:"duplicate_examples,omitempty"`
+
+	MemoryBudget *int64 `json:"memory_budget_bytes,omitempty"`
+	PeakMemory   *int64 `json:"peak_tracked_bytes,omitempty"`
+}
+
+// jsonPoint is the JSON representation of a candidate latitude/longitude pair
+type jsonPoint struct {
+	Latitude  string `json:"latitude"`
+	Longitude string `json:"longitude"`
+}
+
+// printJSON writes the column report as a JSON document
+func printJSON(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(newJSONReport(result, analyzer))
+}
+
+// newJSONReport builds the JSON representation of a file analysis
+func newJSONReport(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) jsonReport {
+	report := jsonReport{RowCount: result.RowCount, LengthSemantics: result.LengthSemantics, Columns: []jsonColumn{}, Warnings: result.Warnings}
+	if result.Location != nil {
+		report.AssumedTimeZone = result.Location.String()
+	}
+	for _, col := range result.Columns {
+		var check string
+		if col.CodeList != "" {
+			check = codeCheck(col)
+		}
+		var stats *jsonStats
+		if s := col.Stats; s != nil {
+			stats = &jsonStats{TotalRows: s.TotalRows, NonNullRows: s.NonNullRows, FillRate: s.FillRate,
+				MajorityType: s.MajorityType, Nonconforming: s.Nonconforming, Min: s.Min, Max: s.Max}
+			if l := s.Lengths; l != nil {
+				stats.Lengths = &jsonLengths{P50: l.P50, P95: l.P95, P99: l.P99, Max: l.Max}
+			}
+		}
+		var confidence *float64
+		var confidenceLabel string
+		if c := col.Confidence; c != nil {
+			confidence, confidenceLabel = &c.Score, c.Label
+		}
+		var examples *jsonExamples
+		if e := col.Examples; e != nil {
+			examples = &jsonExamples{First: e.First, Shortest: e.Shortest, Longest: e.Longest, Promotions: e.Promotions}
+			if examples.Promotions == nil {
+				examples.Promotions = []promotion{}
+			}
+		}
+		report.Columns = append(report.Columns, jsonColumn{
+			Name:           col.Name,
+			Ordinal:        col.Ordinal,
+			SourceName:     col.SourceName,
+			Type:           columnTypeName(col, analyzer),
+			MaxLength:      col.MaxLength,
+			EpochUnit:      col.EpochUnit,
+			CompactFormat:  col.CompactFormat,
+			BinaryEncoding: col.BinaryEncoding,
+			LiteralBase:    col.LiteralBase,
+			Percentages:    col.Percentages,
+			Fraction:       col.PercentFraction,
+			Accounting:     col.Accounting,
+			CodeList:       col.CodeList,
+			NoValues:       col.NoValues,
+			Normalized:     col.Normalized,
+			ExcelFormulas:  col.ExcelFormulas,
+			NameHint:       col.NameHint,
+			OverLimits:     col.OverLimits,
+			Confidence:     confidence,
+			ConfidenceOf:   confidenceLabel,
+			ControlChars:   col.ControlCount,
+			NULBytes:       col.NULCount,
+			MaxBytes:       col.MaxBytes,
+			MaxChars:       col.MaxChars,
+			Check:          check,
+			Stats:          stats,
+			Examples:       examples,
+		})
+	}
+	for _, point := range result.GeoPoints {
+		report.Points = append(report.Points, jsonPoint{Latitude: point.Latitude, Longitude: point.Longitude})
+	}
+	if d := result.Duplicates; d != nil {
+		report.DuplicateRows, report.DuplicateExamples = &d.Rows, d.Examples
+	}
+	if result.MemoryBudget > 0 {
+		report.MemoryBudget, report.PeakMemory = &result.MemoryBudget, &result.PeakMemory
+	}
+	return report
+}
+
+// yamlPlain matches strings that can be written as plain YAML scalars
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\([0-9]+\))?$`)
+
+// yamlReserved lists plain scalars that YAML parsers read as something other
+// than a string
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true,
+}
+
+// yamlString renders a string as a YAML scalar, quoting it when written
+// plainly it would be misread or fail to parse
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !yamlReserved[strings.ToLower(s)] {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// yamlValue renders an integer or string as a YAML scalar
+func yamlValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return yamlString(s)
+	}
+	return fmt.Sprint(v)
+}
+
+// printDBT writes the column report as a dbt schema.yml declaring the file
+// as a table of the given source, with a not_null test on every column that
+// had no empty values
+func printDBT(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, source, table string) {
+	fmt.Fprintln(w, "version: 2")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "sources:")
+	fmt.Fprintf(w, "  - name: %s\n", yamlString(source))
+	fmt.Fprintln(w, "    tables:")
+	fmt.Fprintf(w, "      - name: %s\n", yamlString(table))
+	fmt.Fprintln(w, "        columns:")
+	for _, col := range result.Columns {
+		fmt.Fprintf(w, "          - name: %s\n", yamlString(col.Name))
+		fmt.Fprintf(w, "            data_type: %s\n", yamlString(columnTypeName(col, analyzer)))
+		if s := col.Stats; s != nil {
+			fmt.Fprintln(w, "            meta:")
+			fmt.Fprintf(w, "              total_rows: %d\n", s.TotalRows)
+			fmt.Fprintf(w, "              non_null_rows: %d\n", s.NonNullRows)
+			fmt.Fprintf(w, "              fill_rate: %g\n", s.FillRate)
+			if s.MajorityType != "" {
+				fmt.Fprintf(w, "              majority_type: %s\n", yamlString(s.MajorityType))
+			}
+			fmt.Fprintf(w, "              nonconforming_values: %d\n", s.Nonconforming)
+			if s.Min != nil {
+				fmt.Fprintf(w, "              min: %s\n", yamlValue(s.Min))
+				fmt.Fprintf(w, "              max: %s\n", yamlValue(s.Max))
+			}
+			if l := s.Lengths; l != nil {
+				fmt.Fprintf(w, "              length_p50: %d\n", l.P50)
+				fmt.Fprintf(w, "              length_p95: %d\n", l.P95)
+				fmt.Fprintf(w, "              length_p99: %d\n", l.P99)
+				fmt.Fprintf(w, "              length_max: %d\n", l.Max)
+			}
+		}
+		if col.notNull(result.RowCount) {
+			fmt.Fprintln(w, "            tests:")
+			fmt.Fprintln(w, "              - not_null")
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestPrintJSON(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader("id,name\n1,Alice\n2,Bob\n"), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	if err := printJSON(&buf, result, analyzer); err != nil {
+		t.Fatalf("printJSON() error = %v, want nil", err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if report.RowCount != 2 {
+		t.Errorf("row_count = %d, want 2", report.RowCount)
+	}
+	if len(report.Columns) != 2 {
+		t.Fatalf("got %d columns, want 2", len(report.Columns))
+	}
+	if report.Columns[0].Name != "id" || report.Columns[0].Type != "smallint" {
+		t.Errorf("column 0 = %+v, want id smallint", report.Columns[0])
+	}
+	if report.Columns[1].Name != "name" || report.Columns[1].Type != "varchar(5)" {
+		t.Errorf("column 1 = %+v, want name varchar(5)", report.Columns[1])
+	}
+}
+
+func TestPrintText(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result := &fileAnalysis{
+		Columns: []columnAnalysis{
+			{Name: "id", TypeIndex: 1},
+			{Name: "name", TypeIndex: 7, MaxLength: 14},
+			{Name: "created", TypeIndex: 5, EpochUnit: "seconds"},
+		},
+		RowCount: 3,
+	}
+
+	var buf bytes.Buffer
+	printText(&buf, result, analyzer)
+
+	want := "Column Analysis:\nid: smallint\nname: varchar(14)\ncreated: timestamp (epoch seconds)\n"
+	if buf.String() != want {
+		t.Errorf("printText() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestPrintDBT(t *testing.T) {
+	input := strings.Join([]string{
+		"id,customer name,notes,yes",
+		"1,Alice,,true",
+		"2,Bob,late,false",
+	}, "\n")
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	printDBT(&buf, result, analyzer, "raw", "orders")
+
+	var schema struct {
+		Version int `yaml:"version"`
+		Sources []struct {
+			Name   string `yaml:"name"`
+			Tables []struct {
+				Name    string `yaml:"name"`
+				Columns []struct {
+					Name     string   `yaml:"name"`
+					DataType string   `yaml:"data_type"`
+					Tests    []string `yaml:"tests"`
+				} `yaml:"columns"`
+			} `yaml:"tables"`
+		} `yaml:"sources"`
+	}
+	if err := yaml.Unmarshal(buf.Bytes(), &schema); err != nil {
+		t.Fatalf("output is not valid YAML: %v\n%s", err, buf.String())
+	}
+	if schema.Version != 2 || len(schema.Sources) != 1 || schema.Sources[0].Name != "raw" {
+		t.Fatalf("unexpected schema header: %+v", schema)
+	}
+	tables := schema.Sources[0].Tables
+	if len(tables) != 1 || tables[0].Name != "orders" {
+		t.Fatalf("tables = %+v, want a single orders table", tables)
+	}
+
+	expected := []struct {
+		name     string
+		dataType string
+		notNull  bool
+	}{
+		{"id", "smallint", true},
+		{"customer name", "varchar(5)", true},
+		{"notes", "varchar(4)", false},
+		{"yes", "boolean", true},
+	}
+	columns := tables[0].Columns
+	if len(columns) != len(expected) {
+		t.Fatalf("got %d columns, want %d", len(columns), len(expected))
+	}
+	for i, want := range expected {
+		col := columns[i]
+		if col.Name != want.name || col.DataType != want.dataType {
+			t.Errorf("column %d = %s %s, want %s %s", i, col.Name, col.DataType, want.name, want.dataType)
+		}
+		if notNull := len(col.Tests) == 1 && col.Tests[0] == "not_null"; notNull != want.notNull {
+			t.Errorf("column %s tests = %v, want not_null %v", col.Name, col.Tests, want.notNull)
+		}
+	}
+}
+
+func TestSortColumnsByName(t *testing.T) {
+	result := &fileAnalysis{Columns: []columnAnalysis{{Name: "zeta", Ordinal: 1}, {Name: "alpha", Ordinal: 2}, {Name: "Beta", Ordinal: 3}}}
+	sortColumnsByName(result)
+	var got []string
+	for _, col := range result.Columns {
+		got = append(got, fmt.Sprintf("%s:%d", col.Name, col.Ordinal))
+	}
+	if want := []string{"alpha:2", "Beta:3", "zeta:1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sortColumnsByName() = %v, want %v", got, want)
+	}
+
+	// A manifest of the sorted columns is read back in file order
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	path := filepath.Join(t.TempDir(), "run.manifest.json")
+	if err := writeManifest(path, newManifest(result, analyzer, manifestInput{}, newContentHash(), nil, "t", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	columns, err := loadAppendSchema(path, analyzer)
+	if err != nil {
+		t.Fatalf("loadAppendSchema() error = %v, want nil", err)
+	}
+	got = nil
+	for _, col := range columns {
+		got = append(got, col.Name)
+	}
+	if want := []string{"zeta", "alpha", "Beta"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("loadAppendSchema() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// parquetSeparator joins the names of nested fields into a column name
+const parquetSeparator = "_"
+
+// parquetFile is what file2ddl needs from a Parquet file's footer
+type parquetFile struct {
+	Columns  []parquetColumn
+	RowCount int64
+	Footer   []byte // the encoded footer, which identifies the file's contents
+}
+
+// parquetColumn is a leaf field of a Parquet schema, flattened into a column
+type parquetColumn struct {
+	Name         string
+	Type         dbtypes.ParquetType
+	Optional     bool  // the field or one of its parents may be null
+	NullCount    int64 // nulls counted in the row group statistics
+	HasNullCount bool  // every row group's statistics gave a null count
+}
+
+// parquetPhysicalTypes names the Parquet physical types by their Thrift value
+var parquetPhysicalTypes = []string{"BOOLEAN", "INT32", "INT64", "INT96", "FLOAT", "DOUBLE", "BYTE_ARRAY", "FIXED_LEN_BYTE_ARRAY"}
+
+// parquetTimeUnits names the TimeUnit union members by their field id
+var parquetTimeUnits = map[int16]string{1: "MILLIS", 2: "MICROS", 3: "NANOS"}
+
+// Parquet repetition types
+const (
+	parquetOptional = 1
+	parquetRepeated = 2
+)
+
+// readParquet reads the schema and row group statistics from the footer of
+// a Parquet file, without reading any data pages. Nested groups are
+// flattened into columns named by their path joined with parquetSeparator;
+// repeated fields, lists and maps have no column equivalent and are
+// rejected.
+func readParquet(r io.Reader) (*parquetFile, error) {
+	ra, size, err := readerAt(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+	tail := make([]byte, 8)
+	if size < 12 {
+		return nil, fmt.Errorf("not a Parquet file")
+	}
+	if _, err := ra.ReadAt(tail, size-8); err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+	switch string(tail[4:]) {
+	case "PAR1":
+	case "PARE":
+		return nil, fmt.Errorf("encrypted Parquet files are not supported")
+	default:
+		return nil, fmt.Errorf("not a Parquet file")
+	}
+	footerLen := int64(binary.LittleEndian.Uint32(tail))
+	if footerLen > size-12 {
+		return nil, fmt.Errorf("corrupt Parquet footer: length %d exceeds the file", footerLen)
+	}
+	footer := make([]byte, footerLen)
+	if _, err := ra.ReadAt(footer, size-8-footerLen); err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+
+	meta, err := (&thriftReader{data: footer}).readStruct(0)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt Parquet footer: %v", err)
+	}
+	pf := &parquetFile{Footer: footer}
+	pf.RowCount, _ = thriftInt(meta, 3)
+
+	var schema []map[int16]interface{}
+	for _, element := range thriftList(meta, 2) {
+		if s, ok := element.(map[int16]interface{}); ok {
+			schema = append(schema, s)
+		}
+	}
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("Parquet file has no schema")
+	}
+	rootChildren, _ := thriftInt(schema[0], 5)
+	next := 1
+	for i := int64(0); i < rootChildren; i++ {
+		if next, err = pf.addField(schema, next, "", false); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sum the null counts of each column chunk, which are in leaf order
+	for i := range pf.Columns {
+		pf.Columns[i].HasNullCount = true
+	}
+	for _, rowGroup := range thriftList(meta, 4) {
+		group, _ := rowGroup.(map[int16]interface{})
+		chunks := thriftList(group, 1)
+		for i := range pf.Columns {
+			var nulls int64
+			ok := false
+			if i < len(chunks) {
+				chunk, _ := chunks[i].(map[int16]interface{})
+				nulls, ok = thriftInt(thriftStruct(thriftStruct(chunk, 3), 12), 3)
+			}
+			pf.Columns[i].NullCount += nulls
+			pf.Columns[i].HasNullCount = pf.Columns[i].HasNullCount && ok
+		}
+	}
+	return pf, nil
+}
+
+// addField adds the columns for the schema element at index i and its
+// children, returning the index of the next element
+func (pf *parquetFile) addField(schema []map[int16]interface{}, i int, prefix string, optional bool) (int, error) {
+	if i >= len(schema) {
+		return i, fmt.Errorf("corrupt Parquet schema: missing elements")
+	}
+	element := schema[i]
+	name := prefix + thriftString(element, 4)
+	repetition, _ := thriftInt(element, 3)
+	converted, hasConverted := thriftInt(element, 6)
+	logical := thriftStruct(element, 10)
+	_, isList := logical[3]
+	_, isMap := logical[2]
+	if repetition == parquetRepeated || isList || isMap || (hasConverted && converted >= 1 && converted <= 3) {
+		return i, fmt.Errorf("column %s is a repeated field, list or map, which cannot be flattened into columns", name)
+	}
+	optional = optional || repetition == parquetOptional
+
+	// Groups have children and no type
+	if children, ok := thriftInt(element, 5); ok && children > 0 {
+		next := i + 1
+		var err error
+		for c := int64(0); c < children; c++ {
+			if next, err = pf.addField(schema, next, name+parquetSeparator, optional); err != nil {
+				return next, err
+			}
+		}
+		return next, nil
+	}
+
+	physical, _ := thriftInt(element, 1)
+	if physical < 0 || physical >= int64(len(parquetPhysicalTypes)) {
+		return i, fmt.Errorf("column %s has unknown Parquet type %d", name, physical)
+	}
+	t := dbtypes.ParquetType{Physical: parquetPhysicalTypes[physical]}
+	if len(logical) > 0 {
+		parquetLogicalType(&t, logical)
+	} else if hasConverted {
+		parquetConvertedType(&t, converted, element)
+	}
+	pf.Columns = append(pf.Columns, parquetColumn{Name: name, Type: t, Optional: optional})
+	return i + 1, nil
+}
+
+// parquetLogicalType fills in the type from a LogicalType union
+func parquetLogicalType(t *dbtypes.ParquetType, logical map[int16]interface{}) {
+	for id := range logical {
+		member := thriftStruct(logical, id)
+		switch id {
+		case 1:
+			t.Logical = "STRING"
+		case 4:
+			t.Logical = "ENUM"
+		case 5:
+			t.Logical = "DECIMAL"
+			scale, _ := thriftInt(member, 1)
+			precision, _ := thriftInt(member, 2)
+			t.Scale, t.Precision = int(scale), int(precision)
+		case 6:
+			t.Logical = "DATE"
+		case 7, 8:
+			t.Logical = map[int16]string{7: "TIME", 8: "TIMESTAMP"}[id]
+			for unit := range thriftStruct(member, 2) {
+				t.Unit = parquetTimeUnits[unit]
+			}
+		case 10:
+			t.Logical = "INTEGER"
+			width, _ := thriftInt(member, 1)
+			signed, _ := member[2].(bool)
+			t.BitWidth, t.Signed = int(width), signed
+		case 12:
+			t.Logical = "JSON"
+		case 13:
+			t.Logical = "BSON"
+		case 14:
+			t.Logical = "UUID"
+		}
+	}
+}
+
+// parquetConvertedType fills in the type from a legacy ConvertedType
+func parquetConvertedType(t *dbtypes.ParquetType, converted int64, element map[int16]interface{}) {
+	switch {
+	case converted == 0:
+		t.Logical = "STRING"
+	case converted == 4:
+		t.Logical = "ENUM"
+	case converted == 5:
+		t.Logical = "DECIMAL"
+		scale, _ := thriftInt(element, 7)
+		precision, _ := thriftInt(element, 8)
+		t.Scale, t.Precision = int(scale), int(precision)
+	case converted == 6:
+		t.Logical = "DATE"
+	case converted == 7 || converted == 8:
+		t.Logical, t.Unit = "TIME", map[int64]string{7: "MILLIS", 8: "MICROS"}[converted]
+	case converted == 9 || converted == 10:
+		t.Logical, t.Unit = "TIMESTAMP", map[int64]string{9: "MILLIS", 10: "MICROS"}[converted]
+	case converted >= 11 && converted <= 18:
+		// UINT_8 through UINT_64, then INT_8 through INT_64
+		t.Logical = "INTEGER"
+		t.BitWidth = 8 << ((converted - 11) % 4)
+		t.Signed = converted >= 15
+	case converted == 19:
+		t.Logical = "JSON"
+	case converted == 20:
+		t.Logical = "BSON"
+	}
+}
+
+// analyzeParquet translates a Parquet schema into the flavor's types. The
+// row count and null counts come from the footer's statistics; columns the
+// writer kept no null counts for are reported as nullable.
+func analyzeParquet(pf *parquetFile, analyzer dbtypes.TypeAnalyzer) *fileAnalysis {
+	result := &fileAnalysis{RowCount: int(pf.RowCount)}
+	for _, pc := range pf.Columns {
+		col := columnAnalysis{Name: pc.Name, TypeIndex: dbtypes.MapParquetType(analyzer, pc.Type)}
+		typeName := analyzer.GetTypes()[col.TypeIndex].Name
+		if typeName == "timestamp" {
+			col.FracDigits = pc.Type.FracDigits()
+		}
+		if pc.Type.Logical == "DECIMAL" {
+			col.Precision, col.NumScale = pc.Type.Precision, pc.Type.Scale
+			col.NumDigits = max(pc.Type.Precision-pc.Type.Scale, 1)
+		}
+		switch {
+		case pc.HasNullCount:
+			col.EmptyCount = int(pc.NullCount)
+		case pc.Optional:
+			result.warnf("column %s has no null counts in the file; reported as nullable", pc.Name)
+			col.Nullable = true
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: field %s is Parquet %s, mapped to %s\n",
+				pc.Name, strings.TrimSpace(pc.Type.Physical+" "+pc.Type.Logical), typeName)
+		}
+		result.Columns = append(result.Columns, col)
+	}
+	clampTimestampPrecision(result, analyzer)
+	return result
+}
+
+// thriftReader decodes the Thrift compact protocol that Parquet footers are
+// written in. Structs decode to maps from field id to value, lists and sets
+// to slices, integers to int64 and binary fields to strings.
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+// thriftMaxDepth bounds the nesting of structs and collections, so that a
+// corrupt footer cannot recurse without end
+const thriftMaxDepth = 64
+
+// Thrift compact protocol type codes
+const (
+	compactTrue   = 1
+	compactFalse  = 2
+	compactByte   = 3
+	compactI16    = 4
+	compactI32    = 5
+	compactI64    = 6
+	compactDouble = 7
+	compactBinary = 8
+	compactList   = 9
+	compactSet    = 10
+	compactMap    = 11
+	compactStruct = 12
+)
+
+func (t *thriftReader) readByte() (byte, error) {
+	if t.pos >= len(t.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b := t.data[t.pos]
+	t.pos++
+	return b, nil
+}
+
+func (t *thriftReader) readVarint() (uint64, error) {
+	n, size := binary.Uvarint(t.data[t.pos:])
+	if size <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	t.pos += size
+	return n, nil
+}
+
+func (t *thriftReader) readZigzag() (int64, error) {
+	n, err := t.readVarint()
+	return int64(n>>1) ^ -int64(n&1), err
+}
+
+func (t *thriftReader) readStruct(depth int) (map[int16]interface{}, error) {
+	if depth > thriftMaxDepth {
+		return nil, fmt.Errorf("nesting too deep")
+	}
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		header, err := t.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return fields, nil
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			n, err := t.readZigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(n)
+		}
+		last = id
+
+		switch typ := header & 0x0f; typ {
+		case compactTrue, compactFalse:
+			fields[id] = typ == compactTrue
+		default:
+			if fields[id], err = t.readValue(typ, depth); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (t *thriftReader) readValue(typ byte, depth int) (interface{}, error) {
+	switch typ {
+	case compactTrue, compactFalse:
+		// Booleans in collections take a byte of their own
+		b, err := t.readByte()
+		return b == compactTrue, err
+	case compactByte:
+		b, err := t.readByte()
+		return int64(int8(b)), err
+	case compactI16, compactI32, compactI64:
+		return t.readZigzag()
+	case compactDouble:
+		if t.pos+8 > len(t.data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		bits := binary.LittleEndian.Uint64(t.data[t.pos:])
+		t.pos += 8
+		return math.Float64frombits(bits), nil
+	case compactBinary:
+		n, err := t.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(t.data)-t.pos) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		s := string(t.data[t.pos : t.pos+int(n)])
+		t.pos += int(n)
+		return s, nil
+	case compactList, compactSet:
+		header, err := t.readByte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = t.readVarint(); err != nil {
+				return nil, err
+			}
+		}
+		// Every element takes at least a byte
+		if size > uint64(len(t.data)-t.pos) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		items := make([]interface{}, 0, size)
+		for i := uint64(0); i < size; i++ {
+			item, err := t.readValue(header&0x0f, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case compactMap:
+		size, err := t.readVarint()
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		if size > uint64(len(t.data)-t.pos) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		kinds, err := t.readByte()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < 2*size; i++ {
+			kind := kinds >> 4
+			if i%2 == 1 {
+				kind = kinds & 0x0f
+			}
+			if _, err := t.readValue(kind, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case compactStruct:
+		return t.readStruct(depth + 1)
+	}
+	return nil, fmt.Errorf("unknown type %d", typ)
+}
+
+// thriftInt returns an integer field of a decoded struct
+func thriftInt(s map[int16]interface{}, id int16) (int64, bool) {
+	n, ok := s[id].(int64)
+	return n, ok
+}
+
+// thriftString returns a binary field of a decoded struct as a string
+func thriftString(s map[int16]interface{}, id int16) string {
+	str, _ := s[id].(string)
+	return str
+}
+
+// thriftStruct returns a struct field of a decoded struct, empty if missing
+func thriftStruct(s map[int16]interface{}, id int16) map[int16]interface{} {
+	field, _ := s[id].(map[int16]interface{})
+	return field
+}
+
+// thriftList returns a list field of a decoded struct
+func thriftList(s map[int16]interface{}, id int16) []interface{} {
+	list, _ := s[id].([]interface{})
+	return list
+}
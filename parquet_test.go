@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/dbtypes"
+)
+
+// field is a Thrift struct field for the test encoder: an int64, string,
+// bool, nested struct ([]field) or list of structs ([][]field)
+type field struct {
+	id    int16
+	value interface{}
+}
+
+// encodeStruct encodes fields, in increasing id order, in the Thrift compact
+// protocol
+func encodeStruct(buf *bytes.Buffer, fields []field) {
+	var last int16
+	for _, f := range fields {
+		var typ byte
+		switch v := f.value.(type) {
+		case int64:
+			typ = compactI64
+		case string:
+			typ = compactBinary
+		case bool:
+			typ = compactFalse
+			if v {
+				typ = compactTrue
+			}
+		case []field:
+			typ = compactStruct
+		case [][]field:
+			typ = compactList
+		}
+		buf.WriteByte(byte(f.id-last)<<4 | typ)
+		last = f.id
+
+		switch v := f.value.(type) {
+		case int64:
+			buf.Write(binary.AppendUvarint(nil, uint64(v<<1^v>>63)))
+		case string:
+			buf.Write(binary.AppendUvarint(nil, uint64(len(v))))
+			buf.WriteString(v)
+		case []field:
+			encodeStruct(buf, v)
+		case [][]field:
+			buf.WriteByte(15<<4 | compactStruct)
+			buf.Write(binary.AppendUvarint(nil, uint64(len(v))))
+			for _, item := range v {
+				encodeStruct(buf, item)
+			}
+		}
+	}
+	buf.WriteByte(0)
+}
+
+// testParquet builds a Parquet file holding only a footer with the schema
+// elements and, per row group, the null count of each column (-1 for none)
+func testParquet(rows int64, schema [][]field, nullCounts ...[]int64) []byte {
+	var groups [][]field
+	for _, counts := range nullCounts {
+		var chunks [][]field
+		for _, n := range counts {
+			var meta []field
+			if n >= 0 {
+				meta = []field{{12, []field{{3, n}}}}
+			}
+			chunks = append(chunks, []field{{2, int64(4)}, {3, meta}})
+		}
+		groups = append(groups, []field{{1, chunks}, {3, rows}})
+	}
+
+	var footer bytes.Buffer
+	encodeStruct(&footer, []field{{1, int64(2)}, {2, schema}, {3, rows}, {4, groups}})
+	var file bytes.Buffer
+	file.WriteString("PAR1")
+	file.Write(footer.Bytes())
+	file.Write(binary.LittleEndian.AppendUint32(nil, uint32(footer.Len())))
+	file.WriteString("PAR1")
+	return file.Bytes()
+}
+
+// Schema elements: type is field 1, repetition 3, name 4, children 5,
+// converted type 6, scale 7, precision 8 and logical type 10
+var ordersParquetSchema = [][]field{
+	{{4, "schema"}, {5, int64(7)}},
+	{{1, int64(2)}, {3, int64(0)}, {4, "id"}},
+	{{1, int64(6)}, {3, int64(1)}, {4, "name"}, {6, int64(0)}},
+	{{1, int64(7)}, {3, int64(1)}, {4, "amount"}, {10, []field{{5, []field{{1, int64(2)}, {2, int64(10)}}}}}},
+	{{1, int64(2)}, {3, int64(1)}, {4, "created"}, {10, []field{{8, []field{{1, true}, {2, []field{{3, []field{}}}}}}}}},
+	{{1, int64(1)}, {3, int64(0)}, {4, "placed"}, {6, int64(6)}},
+	{{1, int64(1)}, {3, int64(0)}, {4, "quantity"}, {6, int64(16)}},
+	{{3, int64(1)}, {4, "address"}, {5, int64(2)}},
+	{{1, int64(6)}, {3, int64(0)}, {4, "city"}, {10, []field{{1, []field{}}}}},
+	{{1, int64(0)}, {3, int64(1)}, {4, "verified"}},
+}
+
+func TestAnalyzeParquet(t *testing.T) {
+	data := testParquet(10, ordersParquetSchema,
+		[]int64{0, 2, 0, 1, 0, 0, 0, -1},
+		[]int64{0, 1, 0, 0, 0, 0, 1, -1})
+	pf, err := readParquet(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("readParquet() error = %v, want nil", err)
+	}
+
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result := analyzeParquet(pf, analyzer)
+	want := []struct {
+		name       string
+		typeName   string
+		fracDigits int
+		emptyCount int
+	}{
+		{"id", "bigint", 0, 0},
+		{"name", "text", 0, 3},
+		{"amount", "numeric", 0, 0},
+		{"created", "timestamp", 6, 1},
+		{"placed", "date", 0, 0},
+		{"quantity", "smallint", 0, 0},
+		{"address_city", "text", 0, 1},
+		{"address_verified", "boolean", 0, 0},
+	}
+	if result.RowCount != 10 {
+		t.Errorf("RowCount = %d, want 10", result.RowCount)
+	}
+	if len(result.Columns) != len(want) {
+		t.Fatalf("got %d columns, want %d", len(result.Columns), len(want))
+	}
+	for i, w := range want {
+		col := result.Columns[i]
+		typeName := analyzer.GetTypes()[col.TypeIndex].Name
+		if col.Name != w.name || typeName != w.typeName || col.FracDigits != w.fracDigits || col.EmptyCount != w.emptyCount {
+			t.Errorf("column %d = %s %s(%d) with %d empty, want %s %s(%d) with %d empty", i,
+				col.Name, typeName, col.FracDigits, col.EmptyCount, w.name, w.typeName, w.fracDigits, w.emptyCount)
+		}
+	}
+	if verified := result.Columns[7]; !verified.Nullable || verified.notNull(result.RowCount) {
+		t.Errorf("address_verified is not nullable, want nullable for lack of null counts")
+	}
+	if got := columnTypeName(result.Columns[2], analyzer); got != "numeric(10,2)" {
+		t.Errorf("amount type = %s, want numeric(10,2)", got)
+	}
+
+	wantWarnings := []string{
+		"column address_verified has no null counts in the file; reported as nullable",
+		"column created has values with 9 fractional-second digits; precision clamped to timestamp(6)",
+	}
+	if !reflect.DeepEqual(result.Warnings, wantWarnings) {
+		t.Errorf("Warnings = %q, want %q", result.Warnings, wantWarnings)
+	}
+}
+
+func TestReadParquetErrors(t *testing.T) {
+	listSchema := [][]field{
+		{{4, "schema"}, {5, int64(2)}},
+		{{1, int64(2)}, {3, int64(0)}, {4, "id"}},
+		{{3, int64(1)}, {4, "tags"}, {5, int64(1)}, {6, int64(3)}},
+		{{3, int64(2)}, {4, "list"}, {5, int64(1)}},
+		{{1, int64(6)}, {3, int64(1)}, {4, "element"}, {6, int64(0)}},
+	}
+	repeatedSchema := [][]field{
+		{{4, "schema"}, {5, int64(1)}},
+		{{1, int64(1)}, {3, int64(2)}, {4, "scores"}},
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		errText string
+	}{
+		{"list", testParquet(1, listSchema), "column tags is a repeated field, list or map"},
+		{"repeated", testParquet(1, repeatedSchema), "column scores is a repeated field, list or map"},
+		{"csv", []byte("id,name\n1,Alice\n2,Bob\n"), "not a Parquet file"},
+		{"encrypted", []byte("PAREPARE\x00\x00\x00\x00PARE"), "encrypted Parquet files are not supported"},
+		{"truncated", []byte("PAR1\x19\x1c\x02\x00\x00\x00PAR1"), "corrupt Parquet footer"},
+		{"oversized footer", []byte("PAR1\x19\x1c\xff\x00\x00\x00PAR1"), "length 255 exceeds the file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readParquet(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("readParquet() error = %v, want containing %q", err, tt.errText)
+			}
+		})
+	}
+}
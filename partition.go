@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// suggestPartitionKey sets the partition key of the analysis to its date or
+// timestamp column whose values run in file order, allowing a tolerance
+// fraction of them to be earlier than a value before them. Nothing is
+// suggested when no column or several columns qualify; several are warned
+// about, since the choice is the user's.
+func suggestPartitionKey(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, tolerance float64) {
+	var ordered []string
+	for _, col := range result.Columns {
+		switch analyzer.GetTypes()[col.TypeIndex].Name {
+		case "date", "timestamp":
+		default:
+			continue
+		}
+		// A column of a single value orders nothing
+		if col.TimeCount < 2 || col.Earliest.At.Equal(col.Latest.At) {
+			continue
+		}
+		if float64(col.OutOfOrder) <= tolerance*float64(col.TimeCount) {
+			ordered = append(ordered, col.Name)
+		}
+	}
+
+	switch len(ordered) {
+	case 0:
+	case 1:
+		result.PartitionKey = ordered[0]
+	default:
+		result.warnf("no partition key suggested: columns %s all run in file order", strings.Join(ordered, ", "))
+	}
+}
+
+// partitionClause returns the flavor's clause partitioning or clustering the
+// table by its partition key, to follow the column list of CREATE TABLE, and
+// the text to follow the statement. Vertica partitions by month.
+// PostgreSQL rejects rows a partitioned table has no partition for, so its
+// clause is followed by a default partition taking every row until ranges
+// are added. PostgreSQL needs the key in the primary key of a partitioned
+// table, so with
+// another primary key the clause is instead returned as a comment to follow
+// the statement, as it always is for SAP HANA and MariaDB, which need the
+// ranges, for Exasol, which partitions by ALTER TABLE, and for Firebird,
+// which has no partitions.
+func partitionClause(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table, primaryKey string) (clause, comment string) {
+	if result.PartitionKey == "" {
+		return "", ""
+	}
+	key := flavorIdentifier(analyzer, result.PartitionKey)
+	switch analyzer.(type) {
+	case *dbtypes.SnowflakeAnalyzer:
+		return " CLUSTER BY (" + key + ")", ""
+	case *dbtypes.HanaAnalyzer:
+		// HANA takes range partitions only with their bounds listed
+		return "", fmt.Sprintf("-- suggested: PARTITION BY RANGE (%s), with the ranges to split it by\n", key)
+	case *dbtypes.VerticaAnalyzer:
+		// Vertica keeps at most 1,024 partitions, so one a month rather than
+		// one a value
+		return fmt.Sprintf(" PARTITION BY EXTRACT(YEAR FROM %s) * 100 + EXTRACT(MONTH FROM %s)", key, key), ""
+	case *dbtypes.FirebirdAnalyzer:
+		return "", fmt.Sprintf("-- suggested partition key %s, but Firebird does not partition tables\n", key)
+	case *dbtypes.ExasolAnalyzer:
+		// Exasol partitions a table by ALTER TABLE, or by a PARTITION BY
+		// among its columns
+		return "", fmt.Sprintf("-- suggested: ALTER TABLE ... PARTITION BY %s\n", key)
+	case *dbtypes.MariaDBAnalyzer:
+		// MariaDB too takes range partitions only with their bounds listed
+		return "", fmt.Sprintf("-- suggested: PARTITION BY RANGE COLUMNS (%s), with the ranges to split it by\n", key)
+	}
+	if primaryKey != "" && primaryKey != result.PartitionKey {
+		return "", fmt.Sprintf("-- suggested: PARTITION BY RANGE (%s), once %s is part of the primary key\n", key, result.PartitionKey)
+	}
+	name := table + "_default"
+	if limit := identifierLimit(analyzer); limit > 0 && len(name) > limit {
+		name = shortName(name, limit, map[string]bool{})
+	}
+	return " PARTITION BY RANGE (" + key + ")", fmt.Sprintf("-- rows go to the default partition until ranges are added\nCREATE TABLE %s PARTITION OF %s DEFAULT;\n",
+		flavorIdentifier(analyzer, name), flavorIdentifier(analyzer, table))
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestSuggestPartitionKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		tolerance float64
+		want      string
+		warning   string
+	}{
+		{
+			name:  "one column in order",
+			input: "id|created|day\n1|2024-01-01 10:00:00|2024-03-01\n2|2024-01-01 11:00:00|2024-02-01\n3|2024-01-02 09:00:00|2024-01-01\n",
+			want:  "created",
+		},
+		{
+			name:  "repeated values are in order",
+			input: "id|day\n1|2024-01-01\n2|2024-01-01\n3|2024-01-02\n",
+			want:  "day",
+		},
+		{
+			name:  "out of order",
+			input: "id|day\n1|2024-01-02\n2|2024-01-01\n3|2024-01-03\n",
+		},
+		{
+			name:      "out of order within the tolerance",
+			input:     "id|day\n1|2024-01-02\n2|2024-01-01\n3|2024-01-03\n",
+			tolerance: 0.4,
+			want:      "day",
+		},
+		{
+			name:  "a single value",
+			input: "id|day\n1|2024-01-01\n2|2024-01-01\n",
+		},
+		{
+			name:    "several columns in order",
+			input:   "created|updated\n2024-01-01|2024-02-01\n2024-01-02|2024-02-02\n",
+			warning: "no partition key suggested: columns created, updated all run in file order",
+		},
+	}
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeFileTypes(strings.NewReader(tt.input), opts, analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			suggestPartitionKey(result, analyzer, tt.tolerance)
+			if result.PartitionKey != tt.want {
+				t.Errorf("PartitionKey = %q, want %q", result.PartitionKey, tt.want)
+			}
+			if warning := strings.Join(result.Warnings, "\n"); warning != tt.warning {
+				t.Errorf("warnings = %q, want %q", warning, tt.warning)
+			}
+		})
+	}
+}
+
+func TestPartitionClause(t *testing.T) {
+	result := &fileAnalysis{
+		Columns:      []columnAnalysis{{Name: "id"}, {Name: "created at", TypeIndex: 5}},
+		RowCount:     1,
+		PartitionKey: "created at",
+	}
+	tests := []struct {
+		name       string
+		analyzer   dbtypes.TypeAnalyzer
+		primaryKey string
+		want       string
+	}{
+		{"postgresql", &dbtypes.PostgreSQLAnalyzer{}, "", `) PARTITION BY RANGE ("created at");` + "\n" +
+			"-- rows go to the default partition until ranges are added\nCREATE TABLE events_default PARTITION OF events DEFAULT;\n"},
+		{"postgresql with another primary key", &dbtypes.PostgreSQLAnalyzer{}, "id",
+			");\n" + `-- suggested: PARTITION BY RANGE ("created at"), once created at is part of the primary key` + "\n"},
+		{"snowflake", &dbtypes.SnowflakeAnalyzer{}, "id", `) CLUSTER BY ("created at");` + "\n"},
+		{"hana", &dbtypes.HanaAnalyzer{TableType: "ROW"}, "",
+			");\n" + `-- suggested: PARTITION BY RANGE ("created at"), with the ranges to split it by` + "\n"},
+		{"vertica", &dbtypes.VerticaAnalyzer{}, "id",
+			`) PARTITION BY EXTRACT(YEAR FROM "created at") * 100 + EXTRACT(MONTH FROM "created at");` + "\n"},
+		{"firebird", &dbtypes.FirebirdAnalyzer{}, "",
+			");\n" + `-- suggested partition key "created at", but Firebird does not partition tables` + "\n"},
+		{"exasol", &dbtypes.ExasolAnalyzer{}, "id",
+			");\n" + `-- suggested: ALTER TABLE ... PARTITION BY "created at"` + "\n"},
+		{"mariadb", &dbtypes.MariaDBAnalyzer{}, "",
+			");\n" + "-- suggested: PARTITION BY RANGE COLUMNS (`created at`), with the ranges to split it by\n"},
+		{"greenplum", &dbtypes.GreenplumAnalyzer{}, "",
+			`) PARTITION BY RANGE ("created at")` + "\nDISTRIBUTED RANDOMLY;\n" +
+				"-- distributed randomly: no column's values are all present and distinct to spread its rows evenly by\n" +
+				"-- rows go to the default partition until ranges are added\nCREATE TABLE events_default PARTITION OF events DEFAULT;\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := createTableSQL(result, tt.analyzer, "events", tt.primaryKey)
+			if err != nil {
+				t.Fatalf("createTableSQL() error = %v, want nil", err)
+			}
+			if !strings.HasSuffix(got, tt.want) {
+				t.Errorf("createTableSQL() = %q, want it to end with %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+// stripPercent removes the percent sign ending a number, with the space
+// that may precede it, e.g. "45%" or "3.5 %", reporting false for values
+// that are not numbers once it is removed
+func stripPercent(value string) (string, bool) {
+	number, ok := strings.CutSuffix(value, "%")
+	if !ok {
+		return value, false
+	}
+	number = strings.TrimSuffix(number, " ")
+	if !analyze.IsNumeric(number) {
+		return value, false
+	}
+	return number, true
+}
+
+// detectPercentColumns marks the numeric columns holding percentages, read
+// with -strip-percent, and declares them with the precision and scale of
+// their values, or of the fractions they stand for once divided by 100
+func detectPercentColumns(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, fraction bool) {
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		if col.PercentCount == 0 || analyzer.GetTypes()[col.TypeIndex].Name != "numeric" {
+			continue
+		}
+		col.Percentages = true
+		if fraction {
+			col.NumDigits, col.NumScale = max(col.NumDigits-2, 1), col.NumScale+2
+			col.PercentFraction = true
+		}
+		col.Precision = col.NumDigits + col.NumScale
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: field %s holds percentages in %s of %d values\n", col.Name, groupDigits(col.PercentCount), result.RowCount-col.EmptyCount)
+		}
+	}
+}
+
+// percentNote tells that a column's values carry percent signs, which are
+// stripped when loading, along with the division by 100 if asked for
+func percentNote(col columnAnalysis) string {
+	if col.PercentFraction {
+		return "percentages, % stripped and divided by 100 when loading"
+	}
+	return "percentages, % stripped when loading"
+}
+
+// percentExpression converts the text of a column of percentages to its
+// numeric type
+func percentExpression(col columnAnalysis, value, typeName string) string {
+	number := fmt.Sprintf("rtrim(%s, '%% ')", value)
+	if col.PercentFraction {
+		return fmt.Sprintf("CAST(CAST(%s AS numeric) / 100 AS %s)", number, typeName)
+	}
+	return fmt.Sprintf("CAST(%s AS %s)", number, typeName)
+}
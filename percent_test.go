@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestStripPercent(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"45%", "45", true},
+		{"3.5 %", "3.5", true},
+		{"-120%", "-120", true},
+		{"45", "45", false},
+		{"50% off", "50% off", false},
+		{"%", "%", false},
+		{"abc%", "abc%", false},
+		{"45  %", "45  %", false},
+	}
+	for _, tt := range tests {
+		got, ok := stripPercent(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("stripPercent(%q) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestStripPercentAnalysis(t *testing.T) {
+	input := "rate,share,label\n45%,3.5 %,10%\n120%,12,off\n7%,0.25%,5%\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	tests := []struct {
+		name     string
+		opts     analysisOptions
+		want     []string
+		wantNote string // of rate
+	}{
+		{
+			name:     "percent",
+			opts:     analysisOptions{Options: analyze.Options{Delimiter: ","}, StripPercent: true},
+			want:     []string{"numeric(3,0)", "numeric(4,2)", "varchar(3)"},
+			wantNote: "percentages, % stripped when loading",
+		},
+		{
+			name:     "fraction",
+			opts:     analysisOptions{Options: analyze.Options{Delimiter: ","}, StripPercent: true, PercentFraction: true},
+			want:     []string{"numeric(3,2)", "numeric(5,4)", "varchar(3)"},
+			wantNote: "percentages, % stripped and divided by 100 when loading",
+		},
+		{
+			name: "off",
+			opts: analysisOptions{Options: analyze.Options{Delimiter: ","}},
+			want: []string{"varchar(4)", "varchar(5)", "varchar(3)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeFileTypes(strings.NewReader(input), tt.opts, analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			for i, col := range result.Columns {
+				if got := columnTypeName(col, analyzer); got != tt.want[i] {
+					t.Errorf("column %s = %s, want %s", col.Name, got, tt.want[i])
+				}
+			}
+			if notes := columnNotes(result.Columns[0]); tt.wantNote != "" && (len(notes) == 0 || notes[0] != tt.wantNote) {
+				t.Errorf("notes = %q, want %q", notes, tt.wantNote)
+			}
+			if result.Columns[2].Percentages {
+				t.Errorf("label marked as percentages, want it left a string")
+			}
+		})
+	}
+}
+
+func TestPercentExpression(t *testing.T) {
+	col := columnAnalysis{Percentages: true}
+	if got, want := percentExpression(col, "x", "numeric(3,0)"), "CAST(rtrim(x, '% ') AS numeric(3,0))"; got != want {
+		t.Errorf("percentExpression() = %s, want %s", got, want)
+	}
+	col.PercentFraction = true
+	if got, want := percentExpression(col, "x", "numeric(3,2)"), "CAST(CAST(rtrim(x, '% ') AS numeric) / 100 AS numeric(3,2))"; got != want {
+		t.Errorf("percentExpression() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// applyProfile fills the options from the keys of a YAML profile, e.g.
+// delim: "|", each naming the flag of the field it sets, leaving alone the
+// flags given on the command line, which win. Values are read as the command
+// line reads them. A list is one value per item for a flag that may be
+// repeated, such as override, and its items joined by commas for the
+// others, such as null. Keys that name no flag are errors giving their line.
+func (o *runOptions) applyProfile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading profile: %v", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("profile %s: %v", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("profile %s line %d: want a mapping of flag names to values", path, root.Line)
+	}
+
+	fields := o.fieldsByFlag()
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		field, ok := fields[key.Value]
+		if !ok || key.Value == "profile" {
+			return fmt.Errorf("profile %s line %d: unknown key %q", path, key.Line, key.Value)
+		}
+		if o.set[key.Value] {
+			continue
+		}
+		values, err := profileValues(field.Kind() == reflect.Slice, value)
+		if err != nil {
+			return fmt.Errorf("profile %s line %d: %s: %v", path, value.Line, key.Value, err)
+		}
+		for _, v := range values {
+			if err := setOption(field, v); err != nil {
+				return fmt.Errorf("profile %s line %d: invalid value %q for %s: %v", path, value.Line, v, key.Value, err)
+			}
+		}
+		o.set[key.Value] = true
+	}
+	return nil
+}
+
+// fieldsByFlag returns the fields of the options by the flag names of their
+// yaml tags, including those of the embedded analysisOptions
+func (o *runOptions) fieldsByFlag() map[string]reflect.Value {
+	v := reflect.ValueOf(o).Elem()
+	fields := make(map[string]reflect.Value)
+	for _, f := range reflect.VisibleFields(v.Type()) {
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if f.Anonymous || name == "" || name == "-" {
+			continue
+		}
+		fields[name] = v.FieldByIndex(f.Index)
+	}
+	return fields
+}
+
+// profileValues returns the values a profile gives a flag, as they would be
+// written on the command line
+func profileValues(repeated bool, value *yaml.Node) ([]string, error) {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		return []string{value.Value}, nil
+	case yaml.SequenceNode:
+		var items []string
+		for _, item := range value.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("list items must be values")
+			}
+			items = append(items, item.Value)
+		}
+		if repeated {
+			return items, nil
+		}
+		return []string{strings.Join(items, ",")}, nil
+	}
+	return nil, fmt.Errorf("want a value or a list of values")
+}
+
+// setOption sets a field of the options to a value written as its flag
+// takes it on the command line
+func setOption(field reflect.Value, value string) error {
+	if v, ok := field.Addr().Interface().(flag.Value); ok {
+		return v.Set(value)
+	}
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return parseError(err)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 0, field.Type().Bits())
+		if err != nil {
+			return parseError(err)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return parseError(err)
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("options of type %s cannot be set", field.Type())
+	}
+	return nil
+}
+
+// parseError drops the function and value strconv repeats in its errors
+func parseError(err error) error {
+	if ne, ok := err.(*strconv.NumError); ok {
+		return ne.Err
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyProfile(t *testing.T) {
+	parse := func(args ...string) (*runOptions, *flag.FlagSet, error) {
+		var o runOptions
+		fs := flag.NewFlagSet("file2ddl", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		o.register(fs)
+		return &o, fs, o.parse(fs, args)
+	}
+	writeProfile := func(t *testing.T, text string) string {
+		path := filepath.Join(t.TempDir(), "profile.yaml")
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("values", func(t *testing.T) {
+		path := writeProfile(t, "delim: \"|\"\nquotes: double\nnull: [NULL, N/A]\nstats: true\nncols: 4\nname-hints: prefer\noverride:\n  - id=bigint\n  - note=text\n")
+		o, fs, err := parse("-quotes", "single", "-profile", path)
+		if err != nil {
+			t.Fatalf("parse() error = %v, want nil", err)
+		}
+		if o.Delim != "|" || o.Null != "NULL,N/A" || !o.Stats || o.ExpectedCols != 4 || o.NameHints != "prefer" {
+			t.Errorf("delim, null, stats, ncols, name-hints = %q, %q, %v, %d, %q, want \"|\", \"NULL,N/A\", true, 4, prefer", o.Delim, o.Null, o.Stats, o.ExpectedCols, o.NameHints)
+		}
+		if o.Quotes != "single" {
+			t.Errorf("quotes = %q, want the command line's single", o.Quotes)
+		}
+		if want := (overrideFlags{"id=bigint", "note=text"}); !reflect.DeepEqual(o.Overrides, want) {
+			t.Errorf("overrides = %q, want %q", o.Overrides, want)
+		}
+		settings := o.settings(fs)
+		if settings["delim"] != "|" || settings["quotes"] != "single" || settings["profile"] != path {
+			t.Errorf("settings() = %v, want the flags of the command line and the profile", settings)
+		}
+		if _, ok := settings["format"]; ok {
+			t.Errorf("settings() = %v, want no -format, which is set by neither", settings)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		text    string
+		errText string
+	}{
+		{"unknown key", "delim: \",\"\n\ndelimiter: \";\"\n", `line 3: unknown key "delimiter"`},
+		{"profile in a profile", "profile: other.yaml\n", `line 1: unknown key "profile"`},
+		{"invalid value", "stats: maybe\n", `line 1: invalid value "maybe" for stats`},
+		{"invalid number", "ncols: four\n", `line 1: invalid value "four" for ncols`},
+		{"nested mapping", "delim:\n  char: \",\"\n", "line 2: delim: want a value or a list of values"},
+		{"not a mapping", "- delim\n", "line 1: want a mapping of flag names to values"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parse("-profile", writeProfile(t, tt.text))
+			if err == nil || !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("parse() error = %v, want containing %q", err, tt.errText)
+			}
+		})
+	}
+}
+
+func TestFieldsByFlag(t *testing.T) {
+	var o runOptions
+	fs := flag.NewFlagSet("file2ddl", flag.ContinueOnError)
+	o.register(fs)
+	fields := o.fieldsByFlag()
+	fs.VisitAll(func(f *flag.Flag) {
+		field, ok := fields[f.Name]
+		if !ok {
+			t.Errorf("no field of runOptions has the yaml tag of flag %s", f.Name)
+		} else if reflect.ValueOf(f.Value).Pointer() != field.Addr().Pointer() {
+			t.Errorf("flag %s fills another field than the one of its yaml tag", f.Name)
+		}
+	})
+	if len(fields) != countFlags(fs) {
+		t.Errorf("runOptions has %d tagged fields for %d flags", len(fields), countFlags(fs))
+	}
+}
+
+func countFlags(fs *flag.FlagSet) int {
+	n := 0
+	fs.VisitAll(func(*flag.Flag) { n++ })
+	return n
+}
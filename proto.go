@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"file2ddl/dbtypes"
+)
+
+// protoMaxDoubleDigits is the most digits a numeric column can need and
+// still round-trip through a double; wider columns are sent as strings
+const protoMaxDoubleDigits = 15
+
+// protoFieldName converts a column header to a snake_case proto field name,
+// e.g. "Order Total" and "orderTotal" both become "order_total"
+func protoFieldName(header string) string {
+	var b strings.Builder
+	runes := []rune(header)
+	for i, r := range runes {
+		switch {
+		case r > unicode.MaxASCII:
+			b.WriteByte('_')
+		case unicode.IsUpper(r):
+			// Start a new word at a lower-to-upper boundary
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	name := strings.Trim(b.String(), "_")
+	for strings.Contains(name, "__") {
+		name = strings.ReplaceAll(name, "__", "_")
+	}
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "field_" + name
+	}
+	return name
+}
+
+// protoType maps a column's inferred type to a proto3 type
+func protoType(col columnAnalysis, analyzer dbtypes.TypeAnalyzer) string {
+	switch analyzer.GetTypes()[col.TypeIndex].Name {
+	case "boolean":
+		return "bool"
+	case "tinyint", "smallint", "integer":
+		return "int32"
+	case "bigint":
+		return "int64"
+	case "numeric":
+		if col.NumDigits+col.NumScale <= protoMaxDoubleDigits {
+			return "double"
+		}
+	case "timestamp":
+		return "google.protobuf.Timestamp"
+	}
+	return "string"
+}
+
+// printProto writes the column report as a proto3 message named after the
+// table, numbering the fields in column order. Columns with empty values are
+// marked optional; Timestamp fields are messages and already have presence.
+func printProto(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table string) {
+	var fields []string
+	usesTimestamp := false
+	seen := make(map[string]int)
+	for i, col := range result.Columns {
+		name := protoFieldName(col.Name)
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name += "_" + strconv.Itoa(n)
+		}
+		typeName := protoType(col, analyzer)
+		label := ""
+		if typeName == "google.protobuf.Timestamp" {
+			usesTimestamp = true
+		} else if !col.notNull(result.RowCount) {
+			label = "optional "
+		}
+		if name != col.Name {
+			fields = append(fields, fmt.Sprintf("  // Column %s", strconv.Quote(col.Name)))
+		}
+		if col.Stats != nil {
+			fields = append(fields, "  // "+col.Stats.String())
+		}
+		fields = append(fields, fmt.Sprintf("  %s%s %s = %d;", label, typeName, name, i+1))
+	}
+
+	fmt.Fprintln(w, `syntax = "proto3";`)
+	fmt.Fprintln(w)
+	if usesTimestamp {
+		fmt.Fprintln(w, `import "google/protobuf/timestamp.proto";`)
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "message %s {\n", goFieldName(table))
+	for _, field := range fields {
+		fmt.Fprintln(w, field)
+	}
+	fmt.Fprintln(w, "}")
+}
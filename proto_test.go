@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestProtoFieldName(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"id", "id"},
+		{"Order Total", "order_total"},
+		{"orderTotal", "order_total"},
+		{"customer--id", "customer_id"},
+		{"2024 sales", "field_2024_sales"},
+		{"café", "caf"},
+		{"%", "field_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := protoFieldName(tt.header); got != tt.expected {
+				t.Errorf("protoFieldName(%q) = %q, want %q", tt.header, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPrintProto(t *testing.T) {
+	input := strings.Join([]string{
+		"id|account_id|total|placed|shipped|active|Order Note",
+		"1|3000000000|9.50|2024-03-20|2024-03-20 10:30:00|true|gift",
+		"2|3000000001|120.125|2024-03-21|2024-03-21 11:00:00|false|",
+	}, "\n")
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	printProto(&buf, result, analyzer, "daily_orders")
+	want := `syntax = "proto3";
+
+import "google/protobuf/timestamp.proto";
+
+message DailyOrders {
+  int32 id = 1;
+  int64 account_id = 2;
+  double total = 3;
+  string placed = 4;
+  google.protobuf.Timestamp shipped = 5;
+  bool active = 6;
+  // Column "Order Note"
+  optional string order_note = 7;
+}
+`
+	if buf.String() != want {
+		t.Errorf("printProto() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
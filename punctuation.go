@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// smartPunctuation maps the typographic punctuation that word processors and
+// Excel put in place of ASCII to the ASCII it stands for
+var smartPunctuation = map[rune]string{
+	'‘': "'",   // left single quotation mark
+	'’': "'",   // right single quotation mark, the curly apostrophe
+	'‚': "'",   // single low-9 quotation mark
+	'“': `"`,   // left double quotation mark
+	'”': `"`,   // right double quotation mark
+	'„': `"`,   // double low-9 quotation mark
+	'–': "-",   // en dash
+	'—': "-",   // em dash
+	'…': "...", // horizontal ellipsis
+	' ': " ",   // no-break space
+}
+
+// cp1252Punctuation maps the Windows-1252 bytes of that punctuation, which
+// files saved as ANSI hold where UTF-8 has no character, to its runes
+var cp1252Punctuation = map[byte]rune{
+	0x82: '‚',
+	0x84: '„',
+	0x85: '…',
+	0x91: '‘',
+	0x92: '’',
+	0x93: '“',
+	0x94: '”',
+	0x96: '–',
+	0x97: '—',
+	0xA0: ' ',
+}
+
+// normalizePunctuation replaces the smart punctuation in a value, written as
+// UTF-8 or as stray Windows-1252 bytes, by ASCII, returning the value and
+// the number of characters replaced
+func normalizePunctuation(s string) (string, int) {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s, 0
+	}
+
+	var b strings.Builder
+	replaced := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			if cp, ok := cp1252Punctuation[s[i]]; ok {
+				r = cp
+			}
+		}
+		if ascii, ok := smartPunctuation[r]; ok {
+			b.WriteString(ascii)
+			replaced++
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String(), replaced
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestNormalizePunctuation(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     string
+		replaced int
+	}{
+		{"plain", "plain", 0},
+		{"O’Brien", "O'Brien", 1},
+		{"“quoted” – ok…", `"quoted" - ok...`, 4},
+		{"10 kg", "10 kg", 1},
+		{"\x93ansi\x94 \x96 \x92", `"ansi" - '`, 4},
+		{"ёжик", "ёжик", 0},       // 0x91 as a UTF-8 continuation byte is left alone
+		{"caf\xe9", "caf\xe9", 0}, // other Windows-1252 bytes are not punctuation
+	}
+	for _, tt := range tests {
+		got, replaced := normalizePunctuation(tt.input)
+		if got != tt.want || replaced != tt.replaced {
+			t.Errorf("normalizePunctuation(%q) = %q, %d, want %q, %d", tt.input, got, replaced, tt.want, tt.replaced)
+		}
+	}
+}
+
+func TestNormalizePunctuationAnalysis(t *testing.T) {
+	input := "name,range\nO’Brien,1–2\n“Ann”,3—4\n"
+	tests := []struct {
+		normalize  bool
+		wantLength int
+		wantCounts [2]int
+	}{
+		{normalize: false, wantLength: 9, wantCounts: [2]int{0, 0}},
+		{normalize: true, wantLength: 7, wantCounts: [2]int{3, 2}},
+	}
+	for _, tt := range tests {
+		opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "double"}, NormalizePunct: tt.normalize}
+		result, err := analyzeFileTypes(strings.NewReader(input), opts, &dbtypes.PostgreSQLAnalyzer{})
+		if err != nil {
+			t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+		}
+		if got := result.Columns[0].MaxBytes; got != tt.wantLength {
+			t.Errorf("normalize %v: MaxBytes = %d, want %d", tt.normalize, got, tt.wantLength)
+		}
+		for i, want := range tt.wantCounts {
+			if got := result.Columns[i].Normalized; got != want {
+				t.Errorf("normalize %v: column %d Normalized = %d, want %d", tt.normalize, i, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// byteRange returns the part of a delimited file made of the records that
+// start at or after byte start and before byte end, 0 for the end of the
+// file, preceded by the first record as the header when header is set. A
+// record cut by start is skipped and one cut by end is read to its end,
+// so that a range only holds whole records. Files that can seek are moved
+// to start; others, such as decompressed gzip streams, are read up to it
+// and the bytes discarded, offsets then counting the decompressed text.
+func byteRange(r io.Reader, start, end int64, header bool, sep string) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	var head string
+	var pos int64
+	if header {
+		var err error
+		head, err = readThrough(br, sep)
+		if err == io.EOF {
+			return strings.NewReader(head), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		pos = int64(len(head))
+	}
+
+	// Reading through the next separator from the byte before start lands
+	// on the first record starting at or after it
+	if target := start - 1; target >= pos {
+		if seeker, ok := r.(io.Seeker); ok {
+			if _, err := seeker.Seek(target, io.SeekStart); err != nil {
+				return nil, err
+			}
+			br.Reset(r)
+		} else if _, err := br.Discard(int(target - pos)); err == io.EOF {
+			return strings.NewReader(head), nil
+		} else if err != nil {
+			return nil, err
+		}
+		skipped, err := readThrough(br, sep)
+		if err == io.EOF {
+			return strings.NewReader(head), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		pos = target + int64(len(skipped))
+	}
+
+	var body io.Reader = br
+	if end > 0 {
+		body = &rangeReader{r: br, remaining: max(end-pos, 0), sep: sep}
+	}
+	return io.MultiReader(strings.NewReader(head), body), nil
+}
+
+// readThrough reads up to and including the next separator, or to the end
+// of the input
+func readThrough(r *bufio.Reader, sep string) (string, error) {
+	var b strings.Builder
+	for {
+		s, err := r.ReadString(sep[len(sep)-1])
+		b.WriteString(s)
+		if err != nil || strings.HasSuffix(b.String(), sep) {
+			return b.String(), err
+		}
+	}
+}
+
+// rangeReader reads a number of bytes and then the rest of the record they
+// end in, if they end inside one
+type rangeReader struct {
+	r         *bufio.Reader
+	remaining int64
+	sep       string
+	tail      string          // last bytes read, to tell whether they ended a record
+	rest      *strings.Reader // rest of the record the bytes ended in
+}
+
+func (rr *rangeReader) Read(p []byte) (int, error) {
+	if rr.remaining > 0 {
+		if int64(len(p)) > rr.remaining {
+			p = p[:rr.remaining]
+		}
+		n, err := rr.r.Read(p)
+		rr.remaining -= int64(n)
+		rr.tail += string(p[:n])
+		rr.tail = rr.tail[max(len(rr.tail)-len(rr.sep), 0):]
+		if err == io.EOF {
+			rr.tail = rr.sep
+		}
+		return n, err
+	}
+	if rr.rest == nil {
+		if rr.tail == "" || strings.HasSuffix(rr.tail, rr.sep) {
+			return 0, io.EOF
+		}
+		rest, err := readThrough(rr.r, rr.sep)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		rr.rest = strings.NewReader(rest)
+	}
+	return rr.rest.Read(p)
+}
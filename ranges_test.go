@@ -0,0 +1,105 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+// streamReader hides the Seek method of a reader, like a gzip stream
+type streamReader struct {
+	io.Reader
+}
+
+func TestByteRange(t *testing.T) {
+	// Lines start at bytes 0, 8, 13, 18, 23 and 28
+	input := "id,name\n1,aa\n2,bb\n3,cc\n4,dd\n5,ee\n"
+	tests := []struct {
+		name       string
+		start, end int64
+		header     bool
+		want       string
+	}{
+		{name: "whole", header: true, want: input},
+		{name: "at line start", start: 13, end: 23, header: true, want: "id,name\n2,bb\n3,cc\n"},
+		{name: "inside lines", start: 14, end: 19, header: true, want: "id,name\n3,cc\n"},
+		{name: "end at line start", start: 18, end: 23, header: true, want: "id,name\n3,cc\n"},
+		{name: "to the end", start: 24, header: true, want: "id,name\n5,ee\n"},
+		{name: "start within header", start: 3, end: 10, header: true, want: "id,name\n1,aa\n"},
+		{name: "past the end", start: 40, header: true, want: "id,name\n"},
+		{name: "no header", start: 1, end: 9, want: "1,aa\n"},
+		{name: "empty range", start: 14, end: 15, header: true, want: "id,name\n"},
+	}
+	for _, tt := range tests {
+		for _, seekable := range []bool{true, false} {
+			var r io.Reader = strings.NewReader(input)
+			if !seekable {
+				r = streamReader{r}
+			}
+			ranged, err := byteRange(r, tt.start, tt.end, tt.header, "\n")
+			if err != nil {
+				t.Fatalf("%s: byteRange() error = %v, want nil", tt.name, err)
+			}
+			got, err := io.ReadAll(ranged)
+			if err != nil {
+				t.Fatalf("%s: reading error = %v, want nil", tt.name, err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("%s (seekable %v): byteRange() = %q, want %q", tt.name, seekable, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestByteRangeRecordSeparator(t *testing.T) {
+	input := "id,note\x1e1,a\nb\x1e2,c\x1e"
+	ranged, err := byteRange(strings.NewReader(input), 9, 0, true, "\x1e")
+	if err != nil {
+		t.Fatalf("byteRange() error = %v, want nil", err)
+	}
+	got, _ := io.ReadAll(ranged)
+	if want := "id,note\x1e2,c\x1e"; string(got) != want {
+		t.Errorf("byteRange() = %q, want %q", got, want)
+	}
+}
+
+func TestLineRange(t *testing.T) {
+	input := "id,name\n1,a\n2,bb\n\n3,ccc\n4,dddd\nbad\n"
+	tests := []struct {
+		name           string
+		opts           analysisOptions
+		wantRows       int
+		wantNameLength int
+	}{
+		{name: "middle", opts: analysisOptions{StartLine: 3, EndLine: 5}, wantRows: 2, wantNameLength: 3},
+		{name: "from", opts: analysisOptions{StartLine: 6, EndLine: 6}, wantRows: 1, wantNameLength: 4},
+		{name: "to", opts: analysisOptions{EndLine: 2}, wantRows: 1, wantNameLength: 1},
+		{name: "no header", opts: analysisOptions{Header: "no", StartLine: 2, EndLine: 3}, wantRows: 2, wantNameLength: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Delimiter = ","
+			result, err := analyzeFileTypes(strings.NewReader(input), tt.opts, &dbtypes.PostgreSQLAnalyzer{})
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			if result.RowCount != tt.wantRows {
+				t.Errorf("RowCount = %d, want %d", result.RowCount, tt.wantRows)
+			}
+			if got := result.Columns[1].MaxLength; got != tt.wantNameLength {
+				t.Errorf("MaxLength = %d, want %d", got, tt.wantNameLength)
+			}
+		})
+	}
+}
+
+func TestLineRangeErrorLine(t *testing.T) {
+	input := "id,name\n1,a\n2\n3,c\n"
+	_, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ","}, StartLine: 3}, &dbtypes.PostgreSQLAnalyzer{})
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("analyzeFileTypes() error = %v, want one at line 3 of the file", err)
+	}
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"file2ddl/dbtypes"
+)
+
+// columnOverride is a decision about a column made in review: a type to use
+// instead of the inferred one, a new name, or dropping it. A date or
+// timestamp type can come with the layout of the column's values, which
+// inference then uses instead of trying its own.
+type columnOverride struct {
+	Type   string `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
+	Rename string `json:"rename,omitempty"`
+	Drop   bool   `json:"drop,omitempty"`
+}
+
+// overrideFile holds review decisions by the column names of the file, so
+// that later runs can apply them without asking
+type overrideFile struct {
+	Columns map[string]columnOverride `json:"columns"`
+}
+
+// newOverrideFile returns overrides without any decisions
+func newOverrideFile() *overrideFile {
+	return &overrideFile{Columns: make(map[string]columnOverride)}
+}
+
+// loadOverrides reads the decisions saved by an interactive review
+func loadOverrides(path string) (*overrideFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading overrides: %v", err)
+	}
+	var overrides overrideFile
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("error parsing overrides %s: %v", path, err)
+	}
+	if overrides.Columns == nil {
+		overrides.Columns = make(map[string]columnOverride)
+	}
+	// Columns are checked by name, so the same file gives the same error
+	for _, name := range slices.Sorted(maps.Keys(overrides.Columns)) {
+		if err := checkFormat(overrides.Columns[name]); err != nil {
+			return nil, fmt.Errorf("error in overrides %s, column %s: %v", path, name, err)
+		}
+	}
+	return &overrides, nil
+}
+
+// overrideFlags collects the -override flags, which may be repeated
+type overrideFlags []string
+
+func (o *overrideFlags) String() string {
+	return strings.Join(*o, " ")
+}
+
+func (o *overrideFlags) Set(value string) error {
+	*o = append(*o, value)
+	return nil
+}
+
+// parseOverrideFlag reads an -override flag, a column's type with an
+// optional layout for dates and timestamps, e.g. order_date=date:01/02/2006
+func parseOverrideFlag(spec string) (string, columnOverride, error) {
+	name, typeSpec, found := strings.Cut(spec, "=")
+	if !found || name == "" || typeSpec == "" {
+		return "", columnOverride{}, fmt.Errorf("-override %q is not column=type or column=type:layout", spec)
+	}
+	var override columnOverride
+	override.Type, override.Format, _ = strings.Cut(typeSpec, ":")
+	if err := checkFormat(override); err != nil {
+		return "", columnOverride{}, fmt.Errorf("-override %q: %v", spec, err)
+	}
+	return name, override, nil
+}
+
+// checkFormat validates the layout of an override, which only dates and
+// timestamps take and which must have date or time elements
+func checkFormat(override columnOverride) error {
+	if override.Format == "" {
+		return nil
+	}
+	if override.Type != "date" && override.Type != "timestamp" {
+		return fmt.Errorf("only date and timestamp take a layout, not %s", override.Type)
+	}
+	if time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC).Format(override.Format) == override.Format {
+		return fmt.Errorf("layout %q has no date or time elements; write it for Mon Jan 2 15:04:05 2006, e.g. 01/02/2006", override.Format)
+	}
+	return nil
+}
+
+// columnFormats returns the layouts the overrides declare by column name
+func columnFormats(overrides *overrideFile) map[string]columnFormat {
+	formats := make(map[string]columnFormat)
+	for name, override := range overrides.Columns {
+		if override.Format != "" {
+			formats[name] = columnFormat{Type: override.Type, Layout: override.Format}
+		}
+	}
+	return formats
+}
+
+// columnFormat is the layout declared for a date or timestamp column
+type columnFormat struct {
+	Type   string // "date" or "timestamp"
+	Layout string // Go reference layout, e.g. 02.01.2006
+}
+
+// saveOverrides writes the decisions of an interactive review
+func saveOverrides(path string, overrides *overrideFile) error {
+	data, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error writing overrides: %v", err)
+	}
+	return nil
+}
+
+// typeSpec matches a type given in review, with an optional length
+var typeSpec = regexp.MustCompile(`^([a-z]+)(?:\((\d+)\))?$`)
+
+// parseTypeOverride validates a type against the flavor's types, returning
+// its index and its length, 0 when none was given. Types with a length, such
+// as varchar and char, need one unless the column already has one.
+func parseTypeOverride(spec string, col columnAnalysis, analyzer dbtypes.TypeAnalyzer) (int, int, error) {
+	match := typeSpec.FindStringSubmatch(strings.ToLower(strings.TrimSpace(spec)))
+	if match == nil {
+		return 0, 0, fmt.Errorf("%q is not a type, e.g. integer or varchar(20)", spec)
+	}
+	index := typeIndex(analyzer, match[1])
+	if index < 0 {
+		return 0, 0, fmt.Errorf("%s is not a type of this flavor, which has %s", match[1], strings.Join(typeNames(analyzer), ", "))
+	}
+	dataType := analyzer.GetTypes()[index]
+	length := 0
+	if match[2] != "" {
+		if !dataType.HasLength {
+			return 0, 0, fmt.Errorf("%s does not take a length", match[1])
+		}
+		length, _ = strconv.Atoi(match[2])
+		if length == 0 {
+			return 0, 0, fmt.Errorf("the length must be positive")
+		}
+		if dataType.MaxLength > 0 && length > dataType.MaxLength {
+			return 0, 0, fmt.Errorf("%s takes a length of at most %d", match[1], dataType.MaxLength)
+		}
+	} else if dataType.HasLength && col.MaxLength == 0 {
+		return 0, 0, fmt.Errorf("%s needs a length for column %s, e.g. %s(20)", match[1], col.Name, match[1])
+	}
+	return index, length, nil
+}
+
+// applyOverrides changes, renames and drops columns as decided in review.
+// Every column named must be in the file and every type valid.
+func applyOverrides(result *fileAnalysis, overrides *overrideFile, analyzer dbtypes.TypeAnalyzer) error {
+	known := make(map[string]bool)
+	for _, col := range result.Columns {
+		known[col.Name] = true
+	}
+	for _, name := range slices.Sorted(maps.Keys(overrides.Columns)) {
+		if !known[name] {
+			return fmt.Errorf("overrides name column %s, which the file does not have", name)
+		}
+	}
+
+	var columns []columnAnalysis
+	renamed := make(map[string]string)
+	for _, col := range result.Columns {
+		override := overrides.Columns[col.Name]
+		if override.Drop {
+			continue
+		}
+		if override.Type != "" {
+			index, length, err := parseTypeOverride(override.Type, col, analyzer)
+			if err != nil {
+				return fmt.Errorf("override of column %s: %v", col.Name, err)
+			}
+			col.TypeIndex = index
+			if length > 0 {
+				col.MaxLength = length
+			}
+			// How the inferred type was decided no longer applies
+			col.EpochUnit, col.CompactFormat, col.BinaryEncoding, col.CodeList = "", "", "", ""
+			col.LiteralBase = ""
+			col.EnumSymbols = nil
+		}
+		if override.Rename != "" {
+			renamed[col.Name] = override.Rename
+			if col.SourceName == "" {
+				col.SourceName = col.Name
+			}
+			col.Name = override.Rename
+		}
+		columns = append(columns, col)
+	}
+	result.Columns = columns
+
+	// Keep the point candidates whose columns are both still there
+	var points []geoPoint
+	for _, point := range result.GeoPoints {
+		lat, latKept := keptName(point.Latitude, overrides, renamed)
+		lon, lonKept := keptName(point.Longitude, overrides, renamed)
+		if latKept && lonKept {
+			points = append(points, geoPoint{Latitude: lat, Longitude: lon})
+		}
+	}
+	result.GeoPoints = points
+
+	if result.PartitionKey != "" {
+		key, kept := keptName(result.PartitionKey, overrides, renamed)
+		if !kept {
+			key = ""
+		}
+		result.PartitionKey = key
+	}
+	return nil
+}
+
+// keptName returns the name of a column after the overrides, or false if
+// it was dropped
+func keptName(name string, overrides *overrideFile, renamed map[string]string) (string, bool) {
+	if overrides.Columns[name].Drop {
+		return "", false
+	}
+	if newName, ok := renamed[name]; ok {
+		return newName, true
+	}
+	return name, true
+}
+
+// isTerminal reports whether the file is a terminal rather than a pipe or
+// a regular file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// reviewColumns presents each column on out and reads a decision for it
+// from in: Enter accepts it, "type <type>" changes its type, "rename <name>"
+// renames it and "drop" drops it. Invalid answers are explained and asked
+// again. Only the columns that were changed appear in the returned overrides.
+func reviewColumns(in io.Reader, out io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) (*overrideFile, error) {
+	overrides := newOverrideFile()
+	names := make(map[string]bool)
+	for _, col := range result.Columns {
+		names[col.Name] = true
+	}
+
+	scanner := bufio.NewScanner(in)
+	for i, col := range result.Columns {
+		nullability := "nullable"
+		if col.notNull(result.RowCount) {
+			nullability = "not null"
+		}
+		fmt.Fprintf(out, "[%d/%d] %s: %s, max length %d, %s\n", i+1, len(result.Columns),
+			col.Name, columnTypeName(col, analyzer), col.MaxLength, nullability)
+		if col.Examples != nil {
+			for _, line := range col.Examples.lines() {
+				fmt.Fprintf(out, "  %s\n", line)
+			}
+		}
+
+		var override columnOverride
+		for {
+			fmt.Fprint(out, "Enter to accept, or type <type>, rename <name>, drop: ")
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return nil, err
+				}
+				return nil, fmt.Errorf("review ended before column %s was decided", col.Name)
+			}
+			command, arg, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+			arg = strings.TrimSpace(arg)
+
+			var problem error
+			switch command {
+			case "":
+			case "type", "t":
+				if _, _, problem = parseTypeOverride(arg, col, analyzer); problem == nil {
+					override.Type = arg
+				}
+			case "rename", "r":
+				current := col.Name
+				if override.Rename != "" {
+					current = override.Rename
+				}
+				switch {
+				case arg == "":
+					problem = fmt.Errorf("give the new name, e.g. rename %s_id", col.Name)
+				case arg != current && names[arg]:
+					problem = fmt.Errorf("there is already a column named %s", arg)
+				default:
+					delete(names, current)
+					names[arg] = true
+					override.Rename = arg
+				}
+			case "drop", "d":
+				override.Drop = true
+			default:
+				problem = fmt.Errorf("unknown answer %q", command)
+			}
+			if problem != nil {
+				fmt.Fprintf(out, "  %v\n", problem)
+				continue
+			}
+			if command == "" || override.Drop {
+				break
+			}
+			// A type or a new name asks again, so both can be changed
+			fmt.Fprintf(out, "  now %s\n", describeOverride(col, override))
+		}
+		if override != (columnOverride{}) {
+			overrides.Columns[col.Name] = override
+		}
+	}
+	return overrides, nil
+}
+
+// describeOverride shows a column as a review decision leaves it
+func describeOverride(col columnAnalysis, override columnOverride) string {
+	name := col.Name
+	if override.Rename != "" {
+		name = override.Rename
+	}
+	if override.Type != "" {
+		return name + ": " + override.Type
+	}
+	return name
+}
+
+// formatCheck parses the values of a column with its declared layout,
+// keeping the lines of up to maxOutlierLines values that do not match
+type formatCheck struct {
+	columnFormat
+	TypeIndex int
+	Misses    int
+	Lines     []int
+}
+
+// parse reads a value read from line with the column's layout, in loc when
+// the layout has no zone
+func (c *formatCheck) parse(value string, line int, loc *time.Location) (time.Time, bool) {
+	t, err := time.ParseInLocation(c.Layout, strings.TrimSpace(value), loc)
+	if err != nil {
+		c.Misses++
+		if len(c.Lines) < maxOutlierLines {
+			c.Lines = append(c.Lines, line)
+		}
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// warning describes the values of the column that did not match its layout
+func (c *formatCheck) warning(column string) string {
+	lineList := make([]string, len(c.Lines))
+	for i, line := range c.Lines {
+		lineList[i] = fmt.Sprint(line)
+	}
+	values, lineWord := "values do", "lines"
+	if c.Misses == 1 {
+		values, lineWord = "value does", "line"
+	}
+	return fmt.Sprintf("column %s: %d %s not match its %s layout %s (%s %s)",
+		column, c.Misses, values, c.Type, c.Layout, lineWord, strings.Join(lineList, ", "))
+}
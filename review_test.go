@@ -0,0 +1,179 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestReviewColumns(t *testing.T) {
+	input := "id,amount,junk,lat,lon\n1,10,x,52.5,13.4\n2,N/A,y,48.9,2.35\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}, DetectGeo: true}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	answers := strings.Join([]string{
+		"type bigint",
+		"",
+		"rename junk",     // taken
+		"type money",      // not a type of the flavor
+		"type integer(4)", // integer does not take a length
+		"rename amount_usd",
+		"type numeric",
+		"",
+		"drop",
+		"r latitude",
+		"",
+		"",
+	}, "\n") + "\n"
+	var out strings.Builder
+	overrides, err := reviewColumns(strings.NewReader(answers), &out, result, analyzer)
+	if err != nil {
+		t.Fatalf("reviewColumns() error = %v, want nil", err)
+	}
+	want := map[string]columnOverride{
+		"id":     {Type: "bigint"},
+		"amount": {Type: "numeric", Rename: "amount_usd"},
+		"junk":   {Drop: true},
+		"lat":    {Rename: "latitude"},
+	}
+	if !reflect.DeepEqual(overrides.Columns, want) {
+		t.Errorf("overrides = %+v, want %+v", overrides.Columns, want)
+	}
+	for _, text := range []string{
+		"[2/5] amount: varchar(3), max length 3, not null\n",
+		"there is already a column named junk\n",
+		"money is not a type of this flavor",
+		"integer does not take a length\n",
+		"now amount_usd: numeric\n",
+	} {
+		if !strings.Contains(out.String(), text) {
+			t.Errorf("review output does not contain %q:\n%s", text, out.String())
+		}
+	}
+
+	// Saved and loaded again, the decisions give the same schema
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	if err := saveOverrides(path, overrides); err != nil {
+		t.Fatalf("saveOverrides() error = %v, want nil", err)
+	}
+	loaded, err := loadOverrides(path)
+	if err != nil {
+		t.Fatalf("loadOverrides() error = %v, want nil", err)
+	}
+	if err := applyOverrides(result, loaded, analyzer); err != nil {
+		t.Fatalf("applyOverrides() error = %v, want nil", err)
+	}
+	var columns []string
+	for _, col := range result.Columns {
+		columns = append(columns, col.Name+" "+columnTypeName(col, analyzer))
+	}
+	wantColumns := []string{"id bigint", "amount_usd numeric", "latitude numeric", "lon numeric"}
+	if !reflect.DeepEqual(columns, wantColumns) {
+		t.Errorf("columns = %q, want %q", columns, wantColumns)
+	}
+	if wantPoints := []geoPoint{{Latitude: "latitude", Longitude: "lon"}}; !reflect.DeepEqual(result.GeoPoints, wantPoints) {
+		t.Errorf("GeoPoints = %+v, want %+v", result.GeoPoints, wantPoints)
+	}
+}
+
+func TestReviewColumnsErrors(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader("id,name\n1,a\n"), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	var out strings.Builder
+	if _, err := reviewColumns(strings.NewReader("\n"), &out, result, analyzer); err == nil || err.Error() != "review ended before column name was decided" {
+		t.Errorf("reviewColumns() error = %v, want the review to end early", err)
+	}
+
+	tests := []struct {
+		name      string
+		overrides map[string]columnOverride
+		errText   string
+	}{
+		{"unknown column", map[string]columnOverride{"region": {Drop: true}}, "overrides name column region, which the file does not have"},
+		{"varchar without length", map[string]columnOverride{"id": {Type: "varchar"}}, "override of column id: varchar needs a length for column id, e.g. varchar(20)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyOverrides(result, &overrideFile{Columns: tt.overrides}, analyzer)
+			if err == nil || err.Error() != tt.errText {
+				t.Errorf("applyOverrides() error = %v, want %q", err, tt.errText)
+			}
+		})
+	}
+}
+
+func TestParseOverrideFlag(t *testing.T) {
+	tests := []struct {
+		spec     string
+		name     string
+		override columnOverride
+		errText  string
+	}{
+		{"order_date=date:01/02/2006", "order_date", columnOverride{Type: "date", Format: "01/02/2006"}, ""},
+		{"seen=timestamp:2006-01-02T15:04:05Z07:00", "seen", columnOverride{Type: "timestamp", Format: "2006-01-02T15:04:05Z07:00"}, ""},
+		{"id=bigint", "id", columnOverride{Type: "bigint"}, ""},
+		{"id", "", columnOverride{}, `-override "id" is not column=type or column=type:layout`},
+		{"id=integer:01", "", columnOverride{}, `-override "id=integer:01": only date and timestamp take a layout, not integer`},
+		{"d=date:dd.mm.yyyy", "", columnOverride{}, `-override "d=date:dd.mm.yyyy": layout "dd.mm.yyyy" has no date or time elements; write it for Mon Jan 2 15:04:05 2006, e.g. 01/02/2006`},
+	}
+	for _, tt := range tests {
+		name, override, err := parseOverrideFlag(tt.spec)
+		if tt.errText != "" {
+			if err == nil || err.Error() != tt.errText {
+				t.Errorf("parseOverrideFlag(%q) error = %v, want %q", tt.spec, err, tt.errText)
+			}
+			continue
+		}
+		if err != nil || name != tt.name || override != tt.override {
+			t.Errorf("parseOverrideFlag(%q) = %q, %+v, %v, want %q, %+v", tt.spec, name, override, err, tt.name, tt.override)
+		}
+	}
+}
+
+func TestColumnFormats(t *testing.T) {
+	// Each column is read in its own order of day and month, and a value
+	// that does not match is warned about instead of making the column text
+	input := "id|ordered|shipped\n1|03/20/2024|20.03.2024\n2|12/01/2024|01.12.2024\n3|13/01/2024|soon\n4||02.12.2024\n"
+	overrides := newOverrideFile()
+	for _, spec := range []string{"ordered=date:01/02/2006", "shipped=date:02.01.2006"} {
+		name, override, err := parseOverrideFlag(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		overrides.Columns[name] = override
+	}
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}, ColumnFormats: columnFormats(overrides)}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	for _, col := range result.Columns[1:] {
+		if got := columnTypeName(col, analyzer); got != "date" {
+			t.Errorf("column %s: got %s, want date", col.Name, got)
+		}
+	}
+	if shipped := result.Columns[2]; shipped.Earliest.Value != "20.03.2024" || shipped.Latest.Value != "02.12.2024" {
+		t.Errorf("shipped range = %s to %s, want 20.03.2024 to 02.12.2024", shipped.Earliest.Value, shipped.Latest.Value)
+	}
+	want := []string{
+		"column ordered: 1 value does not match its date layout 01/02/2006 (line 4)",
+		"column shipped: 1 value does not match its date layout 02.01.2006 (line 4)",
+	}
+	if !reflect.DeepEqual(result.Warnings, want) {
+		t.Errorf("warnings = %q, want %q", result.Warnings, want)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// sparkMaxPrecision is the largest precision of a Spark DecimalType; wider
+// numeric columns fall back to doubles
+const sparkMaxPrecision = 38
+
+// sparkType maps a column's inferred type to a PySpark type constructor and
+// the matching Spark SQL DDL type
+func sparkType(col columnAnalysis, analyzer dbtypes.TypeAnalyzer) (pyType, ddlType string) {
+	dataType := analyzer.GetTypes()[col.TypeIndex]
+	switch dataType.Name {
+	case "boolean":
+		return "BooleanType()", "BOOLEAN"
+	case "tinyint":
+		// Spark's bytes are signed, so values up to 255 need a short
+		if !dataType.Unsigned {
+			return "ByteType()", "TINYINT"
+		}
+		return "ShortType()", "SMALLINT"
+	case "smallint":
+		return "ShortType()", "SMALLINT"
+	case "integer":
+		return "IntegerType()", "INT"
+	case "bigint":
+		return "LongType()", "BIGINT"
+	case "numeric":
+		precision := max(col.NumDigits+col.NumScale, 1)
+		if precision > sparkMaxPrecision {
+			return "DoubleType()", "DOUBLE"
+		}
+		return fmt.Sprintf("DecimalType(%d, %d)", precision, col.NumScale),
+			fmt.Sprintf("DECIMAL(%d,%d)", precision, col.NumScale)
+	case "timestamp":
+		return "TimestampType()", "TIMESTAMP"
+	case "date":
+		return "DateType()", "DATE"
+	}
+	return "StringType()", "STRING"
+}
+
+// sparkPlainName matches column names that need no quoting in Spark DDL
+var sparkPlainName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sparkDDLName quotes a column name with backticks when Spark SQL needs it
+func sparkDDLName(name string) string {
+	if sparkPlainName.MatchString(name) {
+		return name
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// sparkString quotes a string as a Spark SQL literal
+func sparkString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// printSpark writes the column report as a PySpark StructType, or with ddl
+// set as a Spark SQL DDL string. Columns with empty values are nullable.
+func printSpark(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, ddl bool) {
+	if ddl {
+		var fields []string
+		for _, col := range result.Columns {
+			_, ddlType := sparkType(col, analyzer)
+			field := sparkDDLName(col.Name) + " " + ddlType
+			if col.notNull(result.RowCount) {
+				field += " NOT NULL"
+			}
+			if col.Stats != nil {
+				field += " COMMENT " + sparkString(col.Stats.String())
+			}
+			fields = append(fields, field)
+		}
+		fmt.Fprintln(w, strings.Join(fields, ", "))
+		return
+	}
+
+	imports := map[string]bool{"StructType": true, "StructField": true}
+	var fields []string
+	for _, col := range result.Columns {
+		pyType, _ := sparkType(col, analyzer)
+		imports[pyType[:strings.IndexByte(pyType, '(')]] = true
+		nullable := "True"
+		if col.notNull(result.RowCount) {
+			nullable = "False"
+		}
+		if col.Stats != nil {
+			// Spark carries a column's comment in its metadata
+			fields = append(fields, fmt.Sprintf("    StructField(%s, %s, %s, {\"comment\": %s}),",
+				strconv.Quote(col.Name), pyType, nullable, strconv.Quote(col.Stats.String())))
+		} else {
+			fields = append(fields, fmt.Sprintf("    StructField(%s, %s, %s),", strconv.Quote(col.Name), pyType, nullable))
+		}
+	}
+
+	var names []string
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "from pyspark.sql.types import %s\n\n", strings.Join(names, ", "))
+	fmt.Fprintln(w, "schema = StructType([")
+	for _, field := range fields {
+		fmt.Fprintln(w, field)
+	}
+	fmt.Fprintln(w, "])")
+}
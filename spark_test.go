@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestPrintSpark(t *testing.T) {
+	input := strings.Join([]string{
+		"id|total|placed|order note",
+		"1|9.50|2024-03-20|gift",
+		"2|120.125|2024-03-21|",
+	}, "\n")
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	printSpark(&buf, result, analyzer, false)
+	want := `from pyspark.sql.types import DateType, DecimalType, ShortType, StringType, StructField, StructType
+
+schema = StructType([
+    StructField("id", ShortType(), False),
+    StructField("total", DecimalType(6, 3), False),
+    StructField("placed", DateType(), False),
+    StructField("order note", StringType(), True),
+])
+`
+	if buf.String() != want {
+		t.Errorf("printSpark() =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	printSpark(&buf, result, analyzer, true)
+	want = "id SMALLINT NOT NULL, total DECIMAL(6,3) NOT NULL, placed DATE NOT NULL, `order note` STRING\n"
+	if buf.String() != want {
+		t.Errorf("printSpark(ddl) = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSparkTypeTinyInt(t *testing.T) {
+	// Spark's bytes are signed, so SAP HANA's unsigned tinyint needs a short
+	for _, tt := range []struct {
+		analyzer dbtypes.TypeAnalyzer
+		want     string
+	}{
+		{&dbtypes.MariaDBAnalyzer{}, "TINYINT"},
+		{&dbtypes.HanaAnalyzer{}, "SMALLINT"},
+	} {
+		col := columnAnalysis{TypeIndex: typeIndex(tt.analyzer, "tinyint")}
+		if _, got := sparkType(col, tt.analyzer); got != tt.want {
+			t.Errorf("sparkType() of tinyint under %T = %s, want %s", tt.analyzer, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// pythonIdentifier matches names usable as Python attribute names
+var pythonIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// pythonKeywords are reserved words that cannot be used as attribute names
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true,
+	"async": true, "await": true, "break": true, "class": true, "continue": true,
+	"def": true, "del": true, "elif": true, "else": true, "except": true, "finally": true,
+	"for": true, "from": true, "global": true, "if": true, "import": true, "in": true,
+	"is": true, "lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true,
+	"raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// pythonAttribute converts a column header to a Python attribute name,
+// reporting whether it had to be changed
+func pythonAttribute(name string) (string, bool) {
+	if pythonIdentifier.MatchString(name) && !pythonKeywords[name] {
+		return name, false
+	}
+	attr := strings.ToLower(avroInvalidChars.ReplaceAllString(name, "_"))
+	if attr == "" || (attr[0] >= '0' && attr[0] <= '9') || pythonKeywords[attr] {
+		attr = "_" + attr
+	}
+	return attr, true
+}
+
+// sqlalchemyType maps a column's inferred type to a SQLAlchemy type
+func sqlalchemyType(col columnAnalysis, analyzer dbtypes.TypeAnalyzer) string {
+	switch analyzer.GetTypes()[col.TypeIndex].Name {
+	case "boolean":
+		return "Boolean"
+	case "tinyint", "smallint":
+		return "SmallInteger"
+	case "integer":
+		return "Integer"
+	case "bigint":
+		return "BigInteger"
+	case "numeric":
+		return fmt.Sprintf("Numeric(%d, %d)", max(col.NumDigits+col.NumScale, 1), col.NumScale)
+	case "timestamp":
+		return "DateTime"
+	case "date":
+		return "Date"
+	case "varchar", "char":
+		return fmt.Sprintf("String(%d)", max(col.MaxLength, 1))
+	}
+	return "Text"
+}
+
+// printSQLAlchemy writes the column report as a SQLAlchemy declarative model
+// for the table. The primaryKey column, if any, must be one of the file's
+// columns.
+func printSQLAlchemy(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table, primaryKey string) error {
+	imports := map[string]bool{"Column": true}
+	var lines []string
+	foundKey := false
+	for _, col := range result.Columns {
+		sqlType := sqlalchemyType(col, analyzer)
+		imports[strings.SplitN(sqlType, "(", 2)[0]] = true
+
+		attr, renamed := pythonAttribute(col.Name)
+		var args []string
+		if renamed {
+			args = append(args, strconv.Quote(col.Name))
+		}
+		args = append(args, sqlType)
+		switch {
+		case col.Name == primaryKey:
+			args = append(args, "primary_key=True")
+			foundKey = true
+		case col.notNull(result.RowCount):
+			args = append(args, "nullable=False")
+		default:
+			args = append(args, "nullable=True")
+		}
+		if col.Stats != nil {
+			args = append(args, "comment="+strconv.Quote(col.Stats.String()))
+		}
+		lines = append(lines, fmt.Sprintf("    %s = Column(%s)", attr, strings.Join(args, ", ")))
+	}
+	if primaryKey != "" && !foundKey {
+		return fmt.Errorf("primary key column %s not found", primaryKey)
+	}
+
+	var names []string
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "from sqlalchemy import %s\n", strings.Join(names, ", "))
+	fmt.Fprintln(w, "from sqlalchemy.orm import declarative_base")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Base = declarative_base()")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "class %s(Base):\n", goFieldName(table))
+	fmt.Fprintf(w, "    __tablename__ = %s\n", strconv.Quote(table))
+	if primaryKey == "" {
+		fmt.Fprintln(w, "    # SQLAlchemy needs a primary key to map the class; name one with -primary-key")
+	}
+	fmt.Fprintln(w)
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestPrintSQLAlchemy(t *testing.T) {
+	file, err := os.Open("testdata/orders.csv")
+	if err != nil {
+		t.Fatalf("Failed to open test file: %v", err)
+	}
+	defer file.Close()
+
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(file, analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	if err := printSQLAlchemy(&buf, result, analyzer, "orders", "id"); err != nil {
+		t.Fatalf("printSQLAlchemy() error = %v, want nil", err)
+	}
+	golden, err := os.ReadFile("testdata/orders_sqlalchemy.golden")
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if buf.String() != string(golden) {
+		t.Errorf("printSQLAlchemy() =\n%s\nwant\n%s", buf.String(), golden)
+	}
+
+	if err := printSQLAlchemy(&buf, result, analyzer, "orders", "missing"); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("printSQLAlchemy() with unknown primary key error = %v, want column not found", err)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"file2ddl/dbtypes"
+)
+
+// analysisState is what is kept between runs so that the schema covers every
+// file seen so far without rescanning them
+type analysisState struct {
+	RowCount int           `json:"row_count"`
+	Columns  []stateColumn `json:"columns"`
+}
+
+// stateColumn is the saved inference result for a single column. Types are
+// saved by name so that a state stays readable if a flavor's type list grows.
+type stateColumn struct {
+	Name           string         `json:"name"`
+	Type           string         `json:"type"`
+	MaxLength      int            `json:"max_length"`
+	MaxBytes       int            `json:"max_bytes,omitempty"`
+	MaxChars       int            `json:"max_chars,omitempty"`
+	FracDigits     int            `json:"frac_digits,omitempty"`
+	NumDigits      int            `json:"num_digits,omitempty"`
+	NumScale       int            `json:"num_scale,omitempty"`
+	Precision      int            `json:"precision,omitempty"`
+	EmptyCount     int            `json:"empty_count"`
+	Nullable       bool           `json:"nullable,omitempty"`
+	TypeCounts     map[string]int `json:"type_counts,omitempty"`
+	LengthCounts   map[int]int    `json:"length_counts,omitempty"`
+	IntCount       int            `json:"int_count,omitempty"`
+	IntMin         int64          `json:"int_min,omitempty"`
+	IntMax         int64          `json:"int_max,omitempty"`
+	Earliest       *observedTime  `json:"earliest,omitempty"`
+	Latest         *observedTime  `json:"latest,omitempty"`
+	TimeLayout     string         `json:"time_layout,omitempty"`
+	MixedTimes     bool           `json:"mixed_times,omitempty"`
+	EpochUnit      string         `json:"epoch_unit,omitempty"`
+	CompactFormat  string         `json:"compact_format,omitempty"`
+	BinaryEncoding string         `json:"binary_encoding,omitempty"`
+	LiteralBase    string         `json:"literal_base,omitempty"`
+	CodeList       string         `json:"code_list,omitempty"`
+}
+
+// loadState reads a saved analysis state, returning nil if the file does not
+// exist yet
+func loadState(path string) (*analysisState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state: %v", err)
+	}
+	var state analysisState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error parsing state %s: %v", path, err)
+	}
+	return &state, nil
+}
+
+// saveState writes the analysis as the state for the next run
+func saveState(path string, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) error {
+	state := analysisState{RowCount: result.RowCount, Columns: []stateColumn{}}
+	for _, col := range result.Columns {
+		typeCounts := make(map[string]int)
+		for i, n := range col.TypeCounts {
+			if n > 0 {
+				typeCounts[analyzer.GetTypes()[i].Name] = n
+			}
+		}
+		state.Columns = append(state.Columns, stateColumn{
+			Name:           col.Name,
+			Type:           analyzer.GetTypes()[col.TypeIndex].Name,
+			MaxLength:      col.MaxLength,
+			MaxBytes:       col.MaxBytes,
+			MaxChars:       col.MaxChars,
+			FracDigits:     col.FracDigits,
+			NumDigits:      col.NumDigits,
+			NumScale:       col.NumScale,
+			Precision:      col.Precision,
+			EmptyCount:     col.EmptyCount,
+			Nullable:       col.Nullable,
+			TypeCounts:     typeCounts,
+			LengthCounts:   col.LengthCounts,
+			IntCount:       col.IntCount,
+			IntMin:         col.IntMin,
+			IntMax:         col.IntMax,
+			Earliest:       col.Earliest,
+			Latest:         col.Latest,
+			TimeLayout:     col.TimeLayout,
+			MixedTimes:     col.MixedTimes,
+			EpochUnit:      col.EpochUnit,
+			CompactFormat:  col.CompactFormat,
+			BinaryEncoding: col.BinaryEncoding,
+			LiteralBase:    col.LiteralBase,
+			CodeList:       col.CodeList,
+		})
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error writing state: %v", err)
+	}
+	return nil
+}
+
+// mergeState folds a saved state into the analysis of the current file. The
+// headers must match; each column takes the most specific type both sides
+// are compatible with, and lengths, precisions, counts and ranges are
+// combined.
+func mergeState(result *fileAnalysis, state *analysisState, analyzer dbtypes.TypeAnalyzer) error {
+	if err := compareHeaders(state.Columns, result.Columns); err != nil {
+		return err
+	}
+
+	for i := range result.Columns {
+		col := &result.Columns[i]
+		saved := state.Columns[i]
+		savedType := typeIndex(analyzer, saved.Type)
+		if savedType < 0 {
+			return fmt.Errorf("state column %s has type %s, which the flavor does not support", saved.Name, saved.Type)
+		}
+
+		switch {
+		case state.RowCount == 0 || saved.EmptyCount == state.RowCount:
+			// Nothing was inferred before, so the current file decides
+		case result.RowCount == 0 || col.NoValues:
+			// A header-only file or empty column says nothing about the type
+			col.TypeIndex = savedType
+			col.EpochUnit, col.CompactFormat = saved.EpochUnit, saved.CompactFormat
+			col.BinaryEncoding, col.CodeList = saved.BinaryEncoding, saved.CodeList
+			col.LiteralBase = saved.LiteralBase
+		default:
+			types := analyzer.GetTypes()
+			common, err := dbtypes.CommonType(analyzer, types[col.TypeIndex].Name, saved.Type)
+			if err != nil {
+				return fmt.Errorf("column %s: %v", col.Name, err)
+			}
+			// Lengths are kept only for types that take one, so a side
+			// without them leaves the length unknown
+			if types[typeIndex(analyzer, common)].HasLength && (!types[col.TypeIndex].HasLength || !types[savedType].HasLength) {
+				common = unsizedType(analyzer, common)
+			}
+			col.TypeIndex = typeIndex(analyzer, common)
+			// Keep how a detected type was decided only if both runs agree
+			if col.EpochUnit != saved.EpochUnit || col.CompactFormat != saved.CompactFormat ||
+				col.BinaryEncoding != saved.BinaryEncoding || col.CodeList != saved.CodeList ||
+				col.LiteralBase != saved.LiteralBase {
+				col.EpochUnit, col.CompactFormat, col.BinaryEncoding, col.CodeList = "", "", "", ""
+				col.LiteralBase = ""
+			}
+		}
+
+		col.MaxLength = max(col.MaxLength, saved.MaxLength)
+		col.MaxBytes = max(col.MaxBytes, saved.MaxBytes)
+		col.MaxChars = max(col.MaxChars, saved.MaxChars)
+		col.FracDigits = max(col.FracDigits, saved.FracDigits)
+		col.NumDigits = max(col.NumDigits, saved.NumDigits)
+		col.NumScale = max(col.NumScale, saved.NumScale)
+		col.Precision = max(col.Precision, saved.Precision)
+		col.EmptyCount += saved.EmptyCount
+		// The values of earlier files are not kept, so they may repeat or
+		// add to the few seen
+		if state.RowCount > 0 {
+			col.Distinct, col.Values = false, nil
+		}
+		col.Nullable = col.Nullable || saved.Nullable
+		for name, n := range saved.TypeCounts {
+			if index := typeIndex(analyzer, name); index >= 0 && col.TypeCounts != nil {
+				col.TypeCounts[index] += n
+			}
+		}
+		for length, n := range saved.LengthCounts {
+			if col.LengthCounts == nil {
+				col.LengthCounts = make(map[int]int)
+			}
+			col.LengthCounts[length] += n
+		}
+		if saved.IntCount > 0 {
+			if col.IntCount == 0 || saved.IntMin < col.IntMin {
+				col.IntMin = saved.IntMin
+			}
+			if col.IntCount == 0 || saved.IntMax > col.IntMax {
+				col.IntMax = saved.IntMax
+			}
+			col.IntCount += saved.IntCount
+		}
+		col.Earliest, col.Latest = earlier(col.Earliest, saved.Earliest), later(col.Latest, saved.Latest)
+		if saved.MixedTimes {
+			col.observeLayout("")
+		} else if saved.TimeLayout != "" {
+			col.observeLayout(saved.TimeLayout)
+		}
+	}
+	result.RowCount += state.RowCount
+	for i := range result.Columns {
+		result.Columns[i].NoValues = result.RowCount > 0 && result.Columns[i].EmptyCount == result.RowCount
+	}
+	return nil
+}
+
+// unsizedType returns the first type without a length that values of the
+// named type can be loaded into, for values of unknown length
+func unsizedType(analyzer dbtypes.TypeAnalyzer, name string) string {
+	for _, candidate := range dbtypes.CompatibleTypes(analyzer, name) {
+		if index := typeIndex(analyzer, candidate); !analyzer.GetTypes()[index].HasLength {
+			return candidate
+		}
+	}
+	return analyzer.GetTypes()[len(analyzer.GetTypes())-1].Name
+}
+
+// compareHeaders reports an error naming the added and removed columns when
+// the current file's headers differ from the saved ones
+func compareHeaders(saved []stateColumn, current []columnAnalysis) error {
+	savedNames := make(map[string]bool)
+	for _, col := range saved {
+		savedNames[col.Name] = true
+	}
+	currentNames := make(map[string]bool)
+	var added, removed []string
+	for _, col := range current {
+		currentNames[col.Name] = true
+		if !savedNames[col.Name] {
+			added = append(added, col.Name)
+		}
+	}
+	for _, col := range saved {
+		if !currentNames[col.Name] {
+			removed = append(removed, col.Name)
+		}
+	}
+
+	var problems []string
+	if len(added) > 0 {
+		problems = append(problems, "added "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		problems = append(problems, "removed "+strings.Join(removed, ", "))
+	}
+	if len(problems) == 0 && len(saved) != len(current) {
+		problems = append(problems, "duplicated columns")
+	}
+	if len(problems) == 0 {
+		for i := range saved {
+			if saved[i].Name != current[i].Name {
+				problems = append(problems, "columns reordered")
+				break
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("headers do not match the saved state: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
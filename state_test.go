@@ -0,0 +1,107 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestStateMerge(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	runs := []string{
+		"id|name|seen|note\n1|ab|2024-03-20 10:30:00|x\n",
+		"id|name|seen|note\n70000|abcdef|17|\n",
+		"id|name|seen|note\n",
+	}
+	var result *fileAnalysis
+	for i, input := range runs {
+		var err error
+		result, err = analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+		if err != nil {
+			t.Fatalf("run %d: analyzeFileTypes() error = %v, want nil", i, err)
+		}
+		state, err := loadState(path)
+		if err != nil {
+			t.Fatalf("run %d: loadState() error = %v, want nil", i, err)
+		}
+		if (state == nil) != (i == 0) {
+			t.Fatalf("run %d: loadState() = %v, want a state after the first run", i, state)
+		}
+		if state != nil {
+			if err := mergeState(result, state, analyzer); err != nil {
+				t.Fatalf("run %d: mergeState() error = %v, want nil", i, err)
+			}
+		}
+		if err := saveState(path, result, analyzer); err != nil {
+			t.Fatalf("run %d: saveState() error = %v, want nil", i, err)
+		}
+	}
+
+	if result.RowCount != 2 {
+		t.Errorf("row count = %d, want 2", result.RowCount)
+	}
+	// A timestamp column that also saw an integer has no common type but text
+	expected := map[string]string{"id": "integer", "name": "varchar(6)", "seen": "text", "note": "varchar(1)"}
+	for _, col := range result.Columns {
+		if got := columnTypeName(col, analyzer); got != expected[col.Name] {
+			t.Errorf("column %s: got %s, want %s", col.Name, got, expected[col.Name])
+		}
+	}
+	if note := result.Columns[3]; note.EmptyCount != 1 {
+		t.Errorf("note empty count = %d, want 1", note.EmptyCount)
+	}
+}
+
+func TestStateHeaderMismatch(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader("id|nm|extra\n1|x|y\n"), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	state := &analysisState{RowCount: 1, Columns: []stateColumn{{Name: "id", Type: "smallint"}, {Name: "name", Type: "varchar"}}}
+
+	err = mergeState(result, state, analyzer)
+	if err == nil || !strings.Contains(err.Error(), "added nm, extra; removed name") {
+		t.Errorf("mergeState() error = %v, want added and removed columns named", err)
+	}
+}
+
+func TestStateMergeEmptyColumn(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}, EmptyColumnType: "text"}
+
+	// An empty column takes the type seen by the other run, in either order
+	for _, runs := range [][2]string{
+		{"id|note\n1|abc\n", "id|note\n2|\n"},
+		{"id|note\n2|\n", "id|note\n1|abc\n"},
+	} {
+		path := filepath.Join(t.TempDir(), "state.json")
+		first, err := analyzeFileTypes(strings.NewReader(runs[0]), opts, analyzer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := saveState(path, first, analyzer); err != nil {
+			t.Fatal(err)
+		}
+		state, err := loadState(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := analyzeFileTypes(strings.NewReader(runs[1]), opts, analyzer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := mergeState(result, state, analyzer); err != nil {
+			t.Fatalf("mergeState() error = %v, want nil", err)
+		}
+		if note := result.Columns[1]; columnTypeName(note, analyzer) != "varchar(3)" || note.NoValues {
+			t.Errorf("after %q: note is %s, no values %v, want varchar(3) with values", runs, columnTypeName(note, analyzer), note.NoValues)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"file2ddl/dbtypes"
+)
+
+// columnStats is the completeness of a column, reported with -stats
+type columnStats struct {
+	TotalRows     int
+	NonNullRows   int
+	FillRate      float64      // percentage of rows with a value, to two decimals
+	MajorityType  string       // most specific type that more than half of the values fit, "" when unknown
+	Nonconforming int          // values that do not fit MajorityType, having forced the column past it
+	Min, Max      interface{}  // range of an integer, date or timestamp column, nil when unknown
+	Lengths       *lengthStats // value length distribution of a varchar column
+}
+
+// observedTime is a date or timestamp value as written in the file, with the
+// instant it stands for
+type observedTime struct {
+	Value string    `json:"value"`
+	At    time.Time `json:"at"`
+}
+
+// observeTime records a date or timestamp value in the column's range,
+// comparing instants so that differently formatted values order correctly,
+// and counts the values that are out of file order. Only a value that
+// extends the range is copied and kept.
+func (c *columnAnalysis) observeTime(value string, at time.Time) {
+	c.TimeCount++
+	if c.Latest != nil && at.Before(c.Latest.At) {
+		c.OutOfOrder++
+	}
+	if c.Earliest == nil || at.Before(c.Earliest.At) {
+		c.Earliest = &observedTime{Value: strings.Clone(value), At: at}
+	}
+	if c.Latest == nil || at.After(c.Latest.At) {
+		c.Latest = &observedTime{Value: strings.Clone(value), At: at}
+	}
+}
+
+// observeLayout records the layout a date or timestamp value matched, an
+// empty layout being one no single layout describes
+func (c *columnAnalysis) observeLayout(layout string) {
+	switch {
+	case c.MixedTimes:
+	case layout == "" || c.TimeLayout != "" && c.TimeLayout != layout:
+		c.TimeLayout, c.MixedTimes = "", true
+	default:
+		c.TimeLayout = layout
+	}
+}
+
+// earlier returns the earlier of two observed times, either of which may be nil
+func earlier(a, b *observedTime) *observedTime {
+	if a == nil || b != nil && b.At.Before(a.At) {
+		return b
+	}
+	return a
+}
+
+// later returns the later of two observed times, either of which may be nil
+func later(a, b *observedTime) *observedTime {
+	if a == nil || b != nil && b.At.After(a.At) {
+		return b
+	}
+	return a
+}
+
+// columnRange returns the smallest and largest values of an integer, date or
+// timestamp column: int64s for integers, and for dates and timestamps the
+// values as written, or for epoch columns as timestamps in loc, UTC when nil.
+// It returns false when the column has another type or no such values were
+// seen.
+func columnRange(col columnAnalysis, analyzer dbtypes.TypeAnalyzer, loc *time.Location) (low, high interface{}, ok bool) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	switch {
+	case col.EpochUnit == "seconds" && col.IntCount > 0:
+		const layout = "2006-01-02 15:04:05"
+		return time.Unix(col.IntMin, 0).In(loc).Format(layout), time.Unix(col.IntMax, 0).In(loc).Format(layout), true
+	case col.EpochUnit == "milliseconds" && col.IntCount > 0:
+		const layout = "2006-01-02 15:04:05.000"
+		return time.UnixMilli(col.IntMin).In(loc).Format(layout), time.UnixMilli(col.IntMax).In(loc).Format(layout), true
+	case col.CompactFormat != "" && col.IntCount > 0:
+		// Compact dates order like the integers they are written as
+		return fmt.Sprint(col.IntMin), fmt.Sprint(col.IntMax), true
+	}
+
+	switch analyzer.GetTypes()[col.TypeIndex].Name {
+	case "tinyint", "smallint", "integer", "bigint":
+		if col.IntCount > 0 {
+			return col.IntMin, col.IntMax, true
+		}
+	case "date", "timestamp":
+		if col.Earliest != nil {
+			return col.Earliest.Value, col.Latest.Value, true
+		}
+	}
+	return nil, nil, false
+}
+
+// newColumnStats returns the completeness of a column of a file with rows
+// rows, with epoch ranges in loc. The nonconforming values are counted from
+// the types the values parsed as, which typed inputs do not record.
+func newColumnStats(col columnAnalysis, rows int, analyzer dbtypes.TypeAnalyzer, loc *time.Location) *columnStats {
+	stats := &columnStats{TotalRows: rows, NonNullRows: rows - col.EmptyCount}
+	if rows > 0 {
+		stats.FillRate = math.Round(10000*float64(stats.NonNullRows)/float64(rows)) / 100
+	}
+	stats.Min, stats.Max, _ = columnRange(col, analyzer, loc)
+	stats.Lengths = newLengthStats(col, analyzer)
+
+	total := 0
+	for _, n := range col.TypeCounts {
+		total += n
+	}
+	// Types are ordered from most to least specific, and a value fits
+	// every type after its own
+	fitting := 0
+	for i, n := range col.TypeCounts {
+		fitting += n
+		if total > 0 && fitting*2 > total {
+			stats.MajorityType = analyzer.GetTypes()[i].Name
+			stats.Nonconforming = total - fitting
+			break
+		}
+	}
+	return stats
+}
+
+// addColumnStats sets the completeness of every column
+func addColumnStats(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer) {
+	for i := range result.Columns {
+		result.Columns[i].Stats = newColumnStats(result.Columns[i], result.RowCount, analyzer, result.Location)
+	}
+}
+
+// String describes the completeness for comments and notes, e.g. "99.8%
+// filled, 998 of 1000 rows, range 1 to 999, 1 value does not fit smallint"
+func (s *columnStats) String() string {
+	text := fmt.Sprintf("%g%% filled, %d of %d rows", s.FillRate, s.NonNullRows, s.TotalRows)
+	if s.Min != nil {
+		text += fmt.Sprintf(", range %v to %v", s.Min, s.Max)
+	}
+	if l := s.Lengths; l != nil {
+		text += fmt.Sprintf(", length p50 %d, p95 %d, p99 %d, max %d", l.P50, l.P95, l.P99, l.Max)
+	}
+	switch {
+	case s.Nonconforming == 1:
+		text += fmt.Sprintf(", 1 value does not fit %s", s.MajorityType)
+	case s.Nonconforming > 1:
+		text += fmt.Sprintf(", %d values do not fit %s", s.Nonconforming, s.MajorityType)
+	}
+	return text
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+const statsSample = "id,name,amount\n1,Alice,10\n2,,N/A\n3,Carol,7.5\n4,Dan,3\n"
+
+func TestColumnStats(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(statsSample), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	addColumnStats(result, analyzer)
+
+	want := []columnStats{
+		{TotalRows: 4, NonNullRows: 4, FillRate: 100, MajorityType: "smallint", Min: int64(1), Max: int64(4)},
+		{TotalRows: 4, NonNullRows: 3, FillRate: 75, MajorityType: "varchar", Lengths: &lengthStats{P50: 5, P95: 5, P99: 5, Max: 5}},
+		{TotalRows: 4, NonNullRows: 4, FillRate: 100, MajorityType: "numeric", Nonconforming: 1, Lengths: &lengthStats{P50: 2, P95: 3, P99: 3, Max: 3}},
+	}
+	for i, col := range result.Columns {
+		if !reflect.DeepEqual(*col.Stats, want[i]) {
+			t.Errorf("column %s stats = %+v, want %+v", col.Name, *col.Stats, want[i])
+		}
+	}
+	if got := result.Columns[2].Stats.String(); got != "100% filled, 4 of 4 rows, length p50 2, p95 3, p99 3, max 3, 1 value does not fit numeric" {
+		t.Errorf("amount stats = %q", got)
+	}
+
+	// Typed inputs record no value types, so nothing is nonconforming
+	typed := newColumnStats(columnAnalysis{EmptyCount: 1}, 3, analyzer, nil)
+	if typed.MajorityType != "" || typed.Nonconforming != 0 || typed.FillRate != 66.67 {
+		t.Errorf("typed column stats = %+v, want a 66.67%% fill rate only", *typed)
+	}
+}
+
+func TestColumnStatsAcrossRuns(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	var result *fileAnalysis
+	for i, input := range []string{"code,day\n1,2024-02-01\n2,2024-01-15\n", "code,day\nx,2023-12-31\n3,2024-01-20\n"} {
+		var err error
+		if result, err = analyzeFileTypes(strings.NewReader(input), opts, analyzer); err != nil {
+			t.Fatalf("run %d: analyzeFileTypes() error = %v, want nil", i, err)
+		}
+		state, err := loadState(path)
+		if err != nil {
+			t.Fatalf("run %d: loadState() error = %v, want nil", i, err)
+		}
+		if state != nil {
+			if err := mergeState(result, state, analyzer); err != nil {
+				t.Fatalf("run %d: mergeState() error = %v, want nil", i, err)
+			}
+		}
+		if err := saveState(path, result, analyzer); err != nil {
+			t.Fatalf("run %d: saveState() error = %v, want nil", i, err)
+		}
+	}
+
+	stats := newColumnStats(result.Columns[0], result.RowCount, analyzer, nil)
+	if stats.MajorityType != "smallint" || stats.Nonconforming != 1 || stats.TotalRows != 4 {
+		t.Errorf("stats = %+v, want 1 of 4 values not fitting smallint", *stats)
+	}
+	// The text value leaves the code column without a range, the dates span both runs
+	if low, high, ok := columnRange(result.Columns[1], analyzer, nil); low != "2023-12-31" || high != "2024-02-01" || !ok {
+		t.Errorf("day range = %v to %v, want 2023-12-31 to 2024-02-01", low, high)
+	}
+	if result.Columns[0].IntMin != 1 || result.Columns[0].IntMax != 3 {
+		t.Errorf("code integers = %d to %d, want 1 to 3", result.Columns[0].IntMin, result.Columns[0].IntMax)
+	}
+}
+
+func TestStatsOutput(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(statsSample), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	addColumnStats(result, analyzer)
+
+	// JSON carries the stats as numbers
+	var buf bytes.Buffer
+	if err := printJSON(&buf, result, analyzer); err != nil {
+		t.Fatalf("printJSON() error = %v, want nil", err)
+	}
+	var report struct {
+		Columns []struct {
+			Stats map[string]interface{} `json:"stats"`
+		} `json:"columns"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	wantStats := map[string]interface{}{
+		"total_rows": 4.0, "non_null_rows": 3.0, "fill_rate": 75.0, "majority_type": "varchar", "nonconforming_values": 0.0,
+		"lengths": map[string]interface{}{"p50": 5.0, "p95": 5.0, "p99": 5.0, "max": 5.0},
+	}
+	if got := report.Columns[1].Stats; !reflect.DeepEqual(got, wantStats) {
+		t.Errorf("name stats = %v, want %v", got, wantStats)
+	}
+
+	// DDL puts them in line comments after the commas
+	createSQL, err := createTableSQL(result, analyzer, "payments", "")
+	if err != nil {
+		t.Fatalf("createTableSQL() error = %v, want nil", err)
+	}
+	wantSQL := `CREATE TABLE payments (
+    id smallint NOT NULL, -- 100% filled, 4 of 4 rows, range 1 to 4
+    name varchar(5), -- 75% filled, 3 of 4 rows, length p50 5, p95 5, p99 5, max 5
+    amount varchar(3) NOT NULL -- 100% filled, 4 of 4 rows, length p50 2, p95 3, p99 3, max 3, 1 value does not fit numeric
+);
+`
+	if createSQL != wantSQL {
+		t.Errorf("createTableSQL() =\n%s\nwant\n%s", createSQL, wantSQL)
+	}
+}
+
+func TestColumnRange(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	tests := []struct {
+		name      string
+		input     string
+		opts      analysisOptions
+		low, high interface{}
+		ok        bool
+	}{
+		{"integers", "n\n9\n-40000\n100000\n", analysisOptions{}, int64(-40000), int64(100000), true},
+		{"dates compared as days", "d\n2024-01-05\nDec 31, 2023\n02/29/2024\n", analysisOptions{}, "Dec 31, 2023", "02/29/2024", true},
+		{"timestamps compared as instants", "ts\n2024-03-01T10:00:00+02:00\n2024-03-01 09:00:00\n2024-03-01T07:30:00Z\n", analysisOptions{},
+			"2024-03-01T07:30:00Z", "2024-03-01 09:00:00", true},
+		{"two-digit years", "d\n01/02/99\n01/02/03\n", analysisOptions{Options: analyze.Options{TwoDigitYears: true, YearPivot: 50}}, "01/02/99", "01/02/03", true},
+		{"epoch seconds", "ts\n1700000000\n1600000000\n", analysisOptions{DetectEpoch: true, EpochMinYear: 2000, EpochMaxYear: 2100},
+			"2020-09-13 12:26:40", "2023-11-14 22:13:20", true},
+		{"compact dates", "d\n20240105\n20231231\n", analysisOptions{DetectCompact: true}, "20231231", "20240105", true},
+		{"numeric", "n\n1.5\n2\n", analysisOptions{}, nil, nil, false},
+		{"text", "s\nb\na\n", analysisOptions{}, nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Delimiter, tt.opts.Quotes = "|", "none"
+			result, err := analyzeFileTypes(strings.NewReader(tt.input), tt.opts, analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			low, high, ok := columnRange(result.Columns[0], analyzer, nil)
+			if low != tt.low || high != tt.high || ok != tt.ok {
+				t.Errorf("columnRange() = %v, %v, %v, want %v, %v, %v", low, high, ok, tt.low, tt.high, tt.ok)
+			}
+		})
+	}
+}
+
+func TestAssumedTimeZone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no time zone database: %v", err)
+	}
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	input := "at|ep\n2024-03-10 01:30:00|1710000000\n2024-03-10T04:00:00+02:00|1710003600\n"
+
+	tests := []struct {
+		name     string
+		loc      *time.Location
+		at, ep   [2]interface{}
+		zoneName string
+	}{
+		// 01:30 is earlier than 02:00 UTC, but later in New York
+		{"utc", nil, [2]interface{}{"2024-03-10 01:30:00", "2024-03-10T04:00:00+02:00"}, [2]interface{}{"2024-03-09 16:00:00", "2024-03-09 17:00:00"}, ""},
+		{"new york", newYork, [2]interface{}{"2024-03-10T04:00:00+02:00", "2024-03-10 01:30:00"}, [2]interface{}{"2024-03-09 11:00:00", "2024-03-09 12:00:00"}, "America/New_York"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}, DetectEpoch: true, EpochMinYear: 1990, EpochMaxYear: 2100, Location: tt.loc}
+			result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			for i, want := range [][2]interface{}{tt.at, tt.ep} {
+				low, high, _ := columnRange(result.Columns[i], analyzer, result.Location)
+				if low != want[0] || high != want[1] {
+					t.Errorf("column %s range = %v to %v, want %v to %v", result.Columns[i].Name, low, high, want[0], want[1])
+				}
+			}
+			if zone := newJSONReport(result, analyzer).AssumedTimeZone; zone != tt.zoneName {
+				t.Errorf("assumed_time_zone = %q, want %q", zone, tt.zoneName)
+			}
+		})
+	}
+}
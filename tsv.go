@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// tsvExtensions are the file extensions read as TSV when no delimiter is
+// given, compressed or not
+var tsvExtensions = []string{".tsv", ".tab"}
+
+// isTSVName reports whether a file name has a TSV extension
+func isTSVName(name string) bool {
+	ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(strings.ToLower(name), ".gz")))
+	for _, tsv := range tsvExtensions {
+		if ext == tsv {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTSVPreset checks that the delimiter and quoting given alongside
+// -format-preset tsv are those of TSV: tabs and no quotes
+func checkTSVPreset(delimiter, delimRegex, quotes string) error {
+	if delimRegex != "" || (delimiter != "" && unescapeSeparator(delimiter) != "\t") {
+		return fmt.Errorf("-format-preset tsv splits fields on tabs, so it cannot be combined with another -delim or -delim-regex")
+	}
+	if quotes != "none" {
+		return fmt.Errorf("-format-preset tsv reads fields unquoted, so it cannot be combined with -quotes %s", quotes)
+	}
+	return nil
+}
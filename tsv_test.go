@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestIsTSVName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"export.tsv":     true,
+		"EXPORT.TAB":     true,
+		"export.tsv.gz":  true,
+		"export.csv":     false,
+		"export.tsv.zip": false,
+		"tsv":            false,
+	} {
+		if got := isTSVName(name); got != want {
+			t.Errorf("isTSVName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCheckTSVPreset(t *testing.T) {
+	tests := []struct {
+		delim, regex, quotes string
+		ok                   bool
+	}{
+		{"", "", "none", true},
+		{`\t`, "", "none", true},
+		{"\t", "", "none", true},
+		{",", "", "none", false},
+		{"", `\s+`, "none", false},
+		{"", "", "double", false},
+	}
+	for _, tt := range tests {
+		if err := checkTSVPreset(tt.delim, tt.regex, tt.quotes); (err == nil) != tt.ok {
+			t.Errorf("checkTSVPreset(%q, %q, %q) error = %v, want ok %v", tt.delim, tt.regex, tt.quotes, err, tt.ok)
+		}
+	}
+}
+
+func TestTSVEscapeAnalysis(t *testing.T) {
+	// The escaped tab is one character of the value, not a delimiter, and
+	// the escaped newline no control character to warn about
+	input := "id\tnote\tpath\n" +
+		"1\tcol a\\tcol b\tC:\\\\data\n" +
+		"2\tfirst\\nsecond\tD:\\\\x\n"
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	opts := analysisOptions{Options: analyze.Options{Delimiter: "\t", Quotes: "none", TSVEscapes: true}, LengthSemantics: "chars"}
+	result, err := analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if len(result.Columns) != 3 {
+		t.Fatalf("got %d columns, want 3", len(result.Columns))
+	}
+	if got := columnTypeName(result.Columns[1], analyzer); got != "varchar(12)" {
+		t.Errorf("note type = %s, want varchar(12) for \"first\\nsecond\"", got)
+	}
+	if got := result.Columns[2].MaxLength; got != 7 {
+		t.Errorf("path MaxLength = %d, want 7 for C:\\data", got)
+	}
+	if len(result.Warnings) > 0 {
+		t.Errorf("warnings = %q, want none", result.Warnings)
+	}
+
+	opts.TSVEscapes = false
+	result, err = analyzeFileTypes(strings.NewReader(input), opts, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+	if got := columnTypeName(result.Columns[1], analyzer); got != "varchar(13)" {
+		t.Errorf("note type without TSV escapes = %s, want varchar(13)", got)
+	}
+}
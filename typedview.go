@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"file2ddl/dbtypes"
+)
+
+// layoutTokens maps the elements of a Go reference layout to the template
+// patterns of PostgreSQL's to_timestamp and Snowflake's TO_TIMESTAMP, longest
+// first so that e.g. January is not read as Jan. An empty pattern is an
+// element the flavor cannot express.
+var layoutTokens = []struct{ layout, postgres, snowflake string }{
+	{"January", "FMMonth", "MMMM"},
+	{"Monday", "FMDay", ""},
+	{".000000", ".US", ".FF6"},
+	{".000", ".MS", ".FF3"},
+	{"2006", "YYYY", "YYYY"},
+	{"Jan", "Mon", "MON"},
+	{"Mon", "Dy", "DY"},
+	{"MST", "", ""},
+	{"Z07", "", ""},
+	{"-07", "", ""},
+	{"PM", "AM", "AM"},
+	{"01", "MM", "MM"},
+	{"02", "DD", "DD"},
+	{"_2", "DD", "DD"},
+	{"03", "HH12", "HH12"},
+	{"04", "MI", "MI"},
+	{"05", "SS", "SS"},
+	{"06", "", ""}, // two-digit years follow -year-pivot, which neither flavor knows
+	{"15", "HH24", "HH24"},
+	{"1", "MM", "MM"},
+	{"2", "DD", "DD"},
+	{"3", "HH12", "HH12"},
+}
+
+// sqlTimeFormat translates a Go reference layout to a flavor's template
+// pattern, reporting false for layouts it cannot express such as those with
+// zones. A fraction is appended to the seconds of a layout without one when
+// the values carry fractional seconds, which Go accepts without saying so.
+func sqlTimeFormat(layout string, snowflake, fraction bool) (string, bool) {
+	var b strings.Builder
+	fractional := strings.Contains(layout, ".000")
+	for layout != "" {
+		matched := false
+		for _, token := range layoutTokens {
+			if !strings.HasPrefix(layout, token.layout) {
+				continue
+			}
+			pattern := token.postgres
+			if snowflake {
+				pattern = token.snowflake
+			}
+			if pattern == "" {
+				return "", false
+			}
+			b.WriteString(pattern)
+			if token.layout == "05" && fraction && !fractional {
+				if snowflake {
+					b.WriteString(".FF")
+				} else {
+					b.WriteString(".US")
+				}
+			}
+			layout = layout[len(token.layout):]
+			matched = true
+			break
+		}
+		if matched {
+			continue
+		}
+		// Letters and digits that are not layout elements are literals
+		r := rune(layout[0])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			fmt.Fprintf(&b, `"%c"`, r)
+		} else {
+			b.WriteRune(r)
+		}
+		layout = layout[1:]
+	}
+	return b.String(), true
+}
+
+// typedViewSQL returns a CREATE VIEW over a raw table whose columns all hold
+// the file's values as text, casting each to the type inferred for it. Dates
+// and timestamps are parsed with the layout their values were seen in, so
+// they do not depend on the session's DateStyle, and detected epochs,
+// compact dates, encoded binaries, integer literals, percentages and
+// accounting numbers are converted the way they were recognized. Empty values of nullable columns become nulls first.
+func typedViewSQL(result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, raw, view string) string {
+	_, snowflake := analyzer.(*dbtypes.SnowflakeAnalyzer)
+	var columns []string
+	for _, col := range result.Columns {
+		source := col.Name
+		if col.SourceName != "" {
+			source = col.SourceName
+		}
+		value := quoteIdentifier(source)
+		if !col.notNull(result.RowCount) {
+			value = fmt.Sprintf("NULLIF(%s, '')", value)
+		}
+		columns = append(columns, fmt.Sprintf("    %s AS %s", typedExpression(col, analyzer, value, snowflake), quoteIdentifier(col.Name)))
+	}
+	return fmt.Sprintf("CREATE VIEW %s AS\nSELECT\n%s\nFROM %s;\n",
+		quoteQualified(view), strings.Join(columns, ",\n"), quoteQualified(raw))
+}
+
+// typedExpression converts the text value of a column to its type
+func typedExpression(col columnAnalysis, analyzer dbtypes.TypeAnalyzer, value string, snowflake bool) string {
+	typeName := columnTypeName(col, analyzer)
+	switch analyzer.GetTypes()[col.TypeIndex].Name {
+	case "text":
+		return value
+	case "smallint", "integer", "bigint":
+		if col.Accounting {
+			return accountingExpression(value, typeName, snowflake)
+		}
+		if col.LiteralBase != "" {
+			expression := literalExpression(col, value, snowflake)
+			if typeName == "bigint" {
+				return expression
+			}
+			return fmt.Sprintf("CAST(%s AS %s)", expression, typeName)
+		}
+	case "numeric":
+		if col.Percentages {
+			return percentExpression(col, value, typeName)
+		}
+		if col.Accounting {
+			return accountingExpression(value, typeName, snowflake)
+		}
+	case "timestamp":
+		if col.EpochUnit != "" {
+			seconds := fmt.Sprintf("CAST(%s AS bigint)", value)
+			if snowflake {
+				scale := 0
+				if col.EpochUnit == "milliseconds" {
+					scale = 3
+				}
+				return fmt.Sprintf("TO_TIMESTAMP_NTZ(%s, %d)", seconds, scale)
+			}
+			if col.EpochUnit == "milliseconds" {
+				seconds += " / 1000.0"
+			}
+			return fmt.Sprintf("CAST(to_timestamp(%s) AT TIME ZONE 'UTC' AS %s)", seconds, typeName)
+		}
+		if pattern, ok := sqlTimeFormat(col.TimeLayout, snowflake, col.FracDigits > 0); ok && col.TimeLayout != "" {
+			if snowflake {
+				return fmt.Sprintf("TO_TIMESTAMP_NTZ(%s, '%s')", value, pattern)
+			}
+			return fmt.Sprintf("CAST(to_timestamp(%s, '%s') AS %s)", value, pattern, typeName)
+		}
+	case "date":
+		if col.CompactFormat != "" {
+			return fmt.Sprintf("%s(%s, '%s')", dateFunction(snowflake), value, col.CompactFormat)
+		}
+		if pattern, ok := sqlTimeFormat(col.TimeLayout, snowflake, false); ok && col.TimeLayout != "" {
+			return fmt.Sprintf("%s(%s, '%s')", dateFunction(snowflake), value, pattern)
+		}
+	case "bytea":
+		if col.BinaryEncoding != "" {
+			if snowflake {
+				return fmt.Sprintf("TO_BINARY(%s, '%s')", value, strings.ToUpper(col.BinaryEncoding))
+			}
+			return fmt.Sprintf("decode(%s, '%s')", value, col.BinaryEncoding)
+		}
+	}
+	return fmt.Sprintf("CAST(%s AS %s)", value, typeName)
+}
+
+func dateFunction(snowflake bool) string {
+	if snowflake {
+		return "TO_DATE"
+	}
+	return "to_date"
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestTypedViewSQL(t *testing.T) {
+	input := "id,created,day,note,order\n" +
+		"1,2024-03-20 10:30:00,03/20/2024,,3\n" +
+		"2,2024-03-21 11:00:00,03/21/2024,x,4\n"
+	tests := []struct {
+		name     string
+		analyzer dbtypes.TypeAnalyzer
+		expected string
+	}{
+		{
+			name:     "postgresql",
+			analyzer: &dbtypes.PostgreSQLAnalyzer{},
+			expected: `CREATE VIEW analytics.events AS
+SELECT
+    CAST(id AS smallint) AS id,
+    CAST(to_timestamp(created, 'YYYY-MM-DD HH24:MI:SS') AS timestamp) AS created,
+    to_date(day, 'MM/DD/YYYY') AS day,
+    CAST(NULLIF(note, '') AS varchar(1)) AS note,
+    CAST("order" AS smallint) AS "order"
+FROM raw.events;
+`,
+		},
+		{
+			name:     "snowflake",
+			analyzer: &dbtypes.SnowflakeAnalyzer{},
+			expected: `CREATE VIEW analytics.events AS
+SELECT
+    CAST(id AS smallint) AS id,
+    TO_TIMESTAMP_NTZ(created, 'YYYY-MM-DD HH24:MI:SS') AS created,
+    TO_DATE(day, 'MM/DD/YYYY') AS day,
+    CAST(NULLIF(note, '') AS varchar(1)) AS note,
+    CAST("order" AS smallint) AS "order"
+FROM raw.events;
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: ",", Quotes: "none"}}, tt.analyzer)
+			if err != nil {
+				t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+			}
+			if got := typedViewSQL(result, tt.analyzer, "raw.events", "analytics.events"); got != tt.expected {
+				t.Errorf("typedViewSQL() =\n%s\nwant\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTypedExpression(t *testing.T) {
+	analyzer := &dbtypes.PostgreSQLAnalyzer{Bytea: true}
+	tests := []struct {
+		name     string
+		col      columnAnalysis
+		expected string
+	}{
+		{"mixed layouts", columnAnalysis{TypeIndex: typeIndex(analyzer, "date"), MixedTimes: true}, "CAST(x AS date)"},
+		{"zoned layout", columnAnalysis{TypeIndex: typeIndex(analyzer, "timestamp"), TimeLayout: "2006-01-02T15:04:05Z07:00"}, "CAST(x AS timestamp)"},
+		{"literal T", columnAnalysis{TypeIndex: typeIndex(analyzer, "timestamp"), TimeLayout: "2006-01-02T15:04:05.000", FracDigits: 3}, `CAST(to_timestamp(x, 'YYYY-MM-DD"T"HH24:MI:SS.MS') AS timestamp(3))`},
+		{"twelve hour", columnAnalysis{TypeIndex: typeIndex(analyzer, "timestamp"), TimeLayout: "1/2/2006 3:04 PM"}, "CAST(to_timestamp(x, 'MM/DD/YYYY HH12:MI AM') AS timestamp)"},
+		{"month name", columnAnalysis{TypeIndex: typeIndex(analyzer, "date"), TimeLayout: "January 2, 2006"}, "to_date(x, 'FMMonth DD, YYYY')"},
+		{"epoch milliseconds", columnAnalysis{TypeIndex: typeIndex(analyzer, "timestamp"), EpochUnit: "milliseconds", FracDigits: 3}, "CAST(to_timestamp(CAST(x AS bigint) / 1000.0) AT TIME ZONE 'UTC' AS timestamp(3))"},
+		{"compact date", columnAnalysis{TypeIndex: typeIndex(analyzer, "date"), CompactFormat: "YYYYMMDD"}, "to_date(x, 'YYYYMMDD')"},
+		{"base64", columnAnalysis{TypeIndex: typeIndex(analyzer, "bytea"), BinaryEncoding: "base64"}, "decode(x, 'base64')"},
+		{"text", columnAnalysis{TypeIndex: typeIndex(analyzer, "text")}, "x"},
+		{"hex literals", columnAnalysis{TypeIndex: typeIndex(analyzer, "integer"), LiteralBase: "hex", HexLiterals: 2, IntCount: 2},
+			"CAST(CAST(CAST('x' || lpad(substring(x, 3), 16, '0') AS bit(64)) AS bigint) AS integer)"},
+		{"binary literals", columnAnalysis{TypeIndex: typeIndex(analyzer, "bigint"), LiteralBase: "binary", BinaryLiterals: 2, IntCount: 2},
+			"CAST(CAST(lpad(substring(x, 3), 64, '0') AS bit(64)) AS bigint)"},
+		{"hex mixed with decimal", columnAnalysis{TypeIndex: typeIndex(analyzer, "bigint"), LiteralBase: "hex", HexLiterals: 1, IntCount: 2},
+			"CASE WHEN x ILIKE '0x%' THEN CAST(CAST('x' || lpad(substring(x, 3), 16, '0') AS bit(64)) AS bigint) ELSE CAST(x AS bigint) END"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := typedExpression(tt.col, analyzer, "x", false); got != tt.expected {
+				t.Errorf("typedExpression() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTypedExpressionSnowflakeHex(t *testing.T) {
+	analyzer := &dbtypes.SnowflakeAnalyzer{}
+	col := columnAnalysis{TypeIndex: typeIndex(analyzer, "bigint"), LiteralBase: "hex", HexLiterals: 1, IntCount: 1}
+	if got, want := typedExpression(col, analyzer, "x", true), "TO_NUMBER(SUBSTR(x, 3), 'XXXXXXXXXXXXXXXX')"; got != want {
+		t.Errorf("typedExpression() = %s, want %s", got, want)
+	}
+}
+
+func TestTypedViewReadsRenamedColumns(t *testing.T) {
+	result := &fileAnalysis{RowCount: 1, Columns: []columnAnalysis{{Name: "customer_id", SourceName: "Customer ID"}}}
+	want := "CREATE VIEW v AS\nSELECT\n    CAST(\"Customer ID\" AS boolean) AS customer_id\nFROM r;\n"
+	if got := typedViewSQL(result, &dbtypes.PostgreSQLAnalyzer{}, "r", "v"); got != want {
+		t.Errorf("typedViewSQL() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"file2ddl/dbtypes"
+)
+
+// jsIdentifier matches property names that need no quoting in TypeScript
+var jsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsPropertyName converts a column header to a camelCase property name,
+// e.g. "customer_id" becomes "customerId"
+func tsPropertyName(header string) string {
+	parts := strings.FieldsFunc(header, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for i, part := range parts {
+		runes := []rune(part)
+		// Whole-word capitals like "ID" or "URL" are lowered as one word
+		if strings.ToUpper(part) == part {
+			runes = []rune(strings.ToLower(part))
+		}
+		if i == 0 {
+			runes[0] = unicode.ToLower(runes[0])
+		} else {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// tsType maps a column's inferred type to a TypeScript type. Dates and
+// timestamps stay strings since JSON has no date type; bigint and numeric
+// values use bigNumberType because JavaScript numbers lose precision past
+// 2^53.
+func tsType(col columnAnalysis, analyzer dbtypes.TypeAnalyzer, bigNumberType string) string {
+	switch analyzer.GetTypes()[col.TypeIndex].Name {
+	case "boolean":
+		return "boolean"
+	case "tinyint", "smallint", "integer":
+		return "number"
+	case "bigint", "numeric":
+		return bigNumberType
+	}
+	return "string"
+}
+
+// printTypeScript writes the column report as an exported TypeScript
+// interface named after the table. Columns with empty values are optional.
+func printTypeScript(w io.Writer, result *fileAnalysis, analyzer dbtypes.TypeAnalyzer, table, bigNumberType string) {
+	fmt.Fprintf(w, "export interface %s {\n", goFieldName(table))
+	for _, col := range result.Columns {
+		name := tsPropertyName(col.Name)
+		if name == "" {
+			name = col.Name
+		}
+		var docs []string
+		if name != col.Name {
+			docs = append(docs, "Column "+strconv.Quote(col.Name))
+		}
+		if col.Stats != nil {
+			docs = append(docs, col.Stats.String())
+		}
+		if len(docs) > 0 {
+			fmt.Fprintf(w, "  /** %s */\n", strings.Join(docs, "; "))
+		}
+		if !jsIdentifier.MatchString(name) {
+			name = strconv.Quote(name)
+		}
+		optional := ""
+		if !col.notNull(result.RowCount) {
+			optional = "?"
+		}
+		fmt.Fprintf(w, "  %s%s: %s;\n", name, optional, tsType(col, analyzer, bigNumberType))
+	}
+	fmt.Fprintln(w, "}")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"file2ddl/analyze"
+	"file2ddl/dbtypes"
+)
+
+func TestTSPropertyName(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"id", "id"},
+		{"customer_id", "customerId"},
+		{"Order Total", "orderTotal"},
+		{"createdAt", "createdAt"},
+		{"CUSTOMER_ID", "customerId"},
+		{"2024 sales", "2024Sales"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := tsPropertyName(tt.header); got != tt.expected {
+				t.Errorf("tsPropertyName(%q) = %q, want %q", tt.header, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPrintTypeScript(t *testing.T) {
+	input := strings.Join([]string{
+		"id|account_id|total|placed|active|2024 note",
+		"1|3000000000|9.50|2024-03-20|true|gift",
+		"2|3000000001|120.125|2024-03-21|false|",
+	}, "\n")
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeFileTypes(strings.NewReader(input), analysisOptions{Options: analyze.Options{Delimiter: "|", Quotes: "none"}}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeFileTypes() error = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	printTypeScript(&buf, result, analyzer, "daily_orders", "string")
+	want := `export interface DailyOrders {
+  id: number;
+  /** Column "account_id" */
+  accountId: string;
+  total: string;
+  placed: string;
+  active: boolean;
+  /** Column "2024 note" */
+  "2024Note"?: string;
+}
+`
+	if buf.String() != want {
+		t.Errorf("printTypeScript() =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	printTypeScript(&buf, result, analyzer, "daily_orders", "number")
+	if !strings.Contains(buf.String(), "accountId: number;") || !strings.Contains(buf.String(), "total: number;") {
+		t.Errorf("printTypeScript() with number big numbers =\n%s", buf.String())
+	}
+}
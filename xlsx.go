@@ -0,0 +1,366 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xlsxRecords reads the rows of one worksheet of an xlsx workbook as records,
+// decoding a row at a time. Cells are converted to the strings a delimited
+// export would hold, with date-formatted numbers spelled out as ISO dates.
+type xlsxRecords struct {
+	sheet      io.ReadCloser
+	decoder    *xml.Decoder
+	shared     []string       // the workbook's shared strings
+	dateStyles map[int]string // cell style index to "date", "timestamp" or "time"
+	date1904   bool           // serial numbers count days from 1904 rather than 1900
+	row        int            // row number of the record last read
+	width      int            // number of header fields, once the header is read
+}
+
+// xlsxWorkbook is the part of xl/workbook.xml naming the sheets
+type xlsxWorkbook struct {
+	Properties struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxRelationships is a relationships part, locating the sheets
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is rich or plain text, as in shared strings and inline strings
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+// xlsxStyles is the part of xl/styles.xml giving each cell style's number format
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+// xlsxRow is a row of a worksheet; cells may be missing and give their
+// position in their reference
+type xlsxRow struct {
+	Number int `xml:"r,attr"`
+	Cells  []struct {
+		Ref    string   `xml:"r,attr"`
+		Type   string   `xml:"t,attr"`
+		Style  int      `xml:"s,attr"`
+		Value  string   `xml:"v"`
+		Inline xlsxText `xml:"is"`
+	} `xml:"c"`
+}
+
+// builtinDateFormats gives the kind of value shown by the built-in number
+// formats that display dates and times
+var builtinDateFormats = map[int]string{
+	14: "date", 15: "date", 16: "date", 17: "date", 22: "timestamp",
+	18: "time", 19: "time", 20: "time", 21: "time", 45: "time", 46: "time", 47: "time",
+	27: "date", 28: "date", 29: "date", 30: "date", 31: "date", 32: "time", 33: "time",
+	34: "date", 35: "date", 36: "date", 50: "date", 51: "date", 52: "date", 53: "date",
+	54: "date", 55: "date", 56: "date", 57: "date", 58: "date",
+}
+
+// numFmtLiterals matches the parts of a number format code that are shown as
+// is rather than formatting the value: quoted text, escaped characters,
+// padding and fill characters, and bracketed colors and locales
+var numFmtLiterals = regexp.MustCompile(`"[^"]*"|\\.|_.|\*.|\[[^\]]*\]`)
+
+// numFmtKind returns the kind of value a custom number format code displays
+// a number as: "date", "timestamp", "time", or "" for plain numbers
+func numFmtKind(code string) string {
+	section, _, _ := strings.Cut(code, ";")
+	if strings.Contains(strings.ToLower(section), "[h]") || strings.Contains(strings.ToLower(section), "[mm]") {
+		return "time"
+	}
+	section = strings.ToLower(numFmtLiterals.ReplaceAllString(section, ""))
+	hasDate := strings.ContainsAny(section, "dy")
+	hasTime := strings.ContainsAny(section, "hs")
+	switch {
+	case hasDate && hasTime:
+		return "timestamp"
+	case hasDate:
+		return "date"
+	case hasTime:
+		return "time"
+	}
+	return ""
+}
+
+// excelTime converts an Excel serial date, counting days since the epoch of
+// the workbook's date system, to a time
+func excelTime(serial float64, date1904 bool) time.Time {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	} else if serial < 60 {
+		// Excel counts a February 29, 1900 that never was
+		epoch = epoch.AddDate(0, 0, 1)
+	}
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 86400)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+}
+
+// openWorkbook opens the named worksheet of an xlsx workbook, or the first
+// worksheet when no name is given. A sheet may also be picked by its
+// 1-based position.
+func openWorkbook(r io.Reader, sheetName string) (*xlsxRecords, error) {
+	zr, err := openZip(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading workbook: %v", err)
+	}
+	parts := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodePart(parts, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := decodePart(parts, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("workbook has no sheets")
+	}
+
+	// Pick the sheet by name, then by position
+	var names []string
+	chosen := -1
+	for i, sheet := range workbook.Sheets {
+		names = append(names, sheet.Name)
+		if sheet.Name == sheetName {
+			chosen = i
+		}
+	}
+	if sheetName == "" {
+		chosen = 0
+	} else if n, err := strconv.Atoi(sheetName); chosen < 0 && err == nil && n >= 1 && n <= len(names) {
+		chosen = n - 1
+	}
+	if chosen < 0 {
+		return nil, fmt.Errorf("workbook has no sheet %s; sheets: %s", sheetName, strings.Join(names, ", "))
+	}
+	target := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[chosen].RID {
+			target = rel.Target
+		}
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+	sheetPart, ok := parts[target]
+	if !ok {
+		return nil, fmt.Errorf("workbook is missing sheet %s", names[chosen])
+	}
+
+	records := &xlsxRecords{date1904: workbook.Properties.Date1904, dateStyles: make(map[int]string)}
+
+	// Shared strings and styles are optional parts
+	var shared struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if _, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(parts, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range shared.Items {
+		records.shared = append(records.shared, item.String())
+	}
+	var styles xlsxStyles
+	if _, ok := parts["xl/styles.xml"]; ok {
+		if err := decodePart(parts, "xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+	}
+	customFormats := make(map[int]string)
+	for _, numFmt := range styles.NumFmts {
+		customFormats[numFmt.ID] = numFmtKind(numFmt.Code)
+	}
+	for i, xf := range styles.CellXfs {
+		kind, ok := customFormats[xf.NumFmtID]
+		if !ok {
+			kind = builtinDateFormats[xf.NumFmtID]
+		}
+		if kind != "" {
+			records.dateStyles[i] = kind
+		}
+	}
+
+	records.sheet, err = sheetPart.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error reading sheet %s: %v", names[chosen], err)
+	}
+	records.decoder = xml.NewDecoder(records.sheet)
+	return records, nil
+}
+
+// decodePart decodes an XML part of the workbook package
+func decodePart(parts map[string]*zip.File, name string, v interface{}) error {
+	part, ok := parts[name]
+	if !ok {
+		return fmt.Errorf("workbook is missing %s", name)
+	}
+	r, err := part.Open()
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", name, err)
+	}
+	defer r.Close()
+	if err := xml.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("error reading %s: %v", name, err)
+	}
+	return nil
+}
+
+// Read returns the next non-empty row. Empty rows, which Excel keeps for
+// cells that were formatted but never filled, are skipped, as are empty
+// cells past the last header. Rows shorter than the header are padded.
+func (x *xlsxRecords) Read() ([]string, error) {
+	for {
+		token, err := x.decoder.Token()
+		if err == io.EOF {
+			x.sheet.Close()
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading sheet: %v", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row xlsxRow
+		if err := x.decoder.DecodeElement(&row, &start); err != nil {
+			return nil, fmt.Errorf("error reading sheet: %v", err)
+		}
+		if row.Number > 0 {
+			x.row = row.Number
+		} else {
+			x.row++
+		}
+
+		var fields []string
+		for _, cell := range row.Cells {
+			col := len(fields)
+			if c := cellColumn(cell.Ref); c >= 0 {
+				col = c
+			}
+			for len(fields) <= col {
+				fields = append(fields, "")
+			}
+			fields[col] = x.cellValue(cell.Type, cell.Style, cell.Value, cell.Inline)
+		}
+		for len(fields) > 0 && fields[len(fields)-1] == "" {
+			fields = fields[:len(fields)-1]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		if x.width == 0 {
+			x.width = len(fields)
+		}
+		for len(fields) < x.width {
+			fields = append(fields, "")
+		}
+		return fields, nil
+	}
+}
+
+// Line returns the worksheet row number of the record last read
+func (x *xlsxRecords) Line() int {
+	return x.row
+}
+
+// cellValue converts a cell to text, looking up shared strings and spelling
+// out date-formatted serial numbers as ISO dates and times
+func (x *xlsxRecords) cellValue(cellType string, style int, value string, inline xlsxText) string {
+	switch cellType {
+	case "s":
+		if i, err := strconv.Atoi(value); err == nil && i >= 0 && i < len(x.shared) {
+			return x.shared[i]
+		}
+		return ""
+	case "inlineStr":
+		return inline.String()
+	case "b":
+		if value == "1" {
+			return "true"
+		}
+		return "false"
+	case "str", "e", "d":
+		return value
+	}
+
+	kind := x.dateStyles[style]
+	serial, err := strconv.ParseFloat(value, 64)
+	if kind == "" || err != nil || serial < 0 {
+		return value
+	}
+	t := excelTime(serial, x.date1904)
+	switch kind {
+	case "date":
+		return t.Format("2006-01-02")
+	case "time":
+		return t.Format("15:04:05")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// cellColumn returns the 0-based column index of a cell reference such as
+// "AB12", or -1 when the reference is missing
+func cellColumn(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"file2ddl/dbtypes"
+)
+
+// testWorkbook builds an xlsx workbook with the given sheet XML, in order,
+// sharing the strings "id", "name" and "hired" and a style table where style
+// 1 is a built-in date, 2 a custom timestamp and 3 a plain number
+func testWorkbook(t *testing.T, date1904 bool, sheets map[string]string, order ...string) []byte {
+	t.Helper()
+	var workbook, rels strings.Builder
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	if date1904 {
+		workbook.WriteString(`<workbookPr date1904="1"/>`)
+	}
+	workbook.WriteString(`<sheets>`)
+	rels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	parts := map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>id</t></si><si><t>name</t></si><si><r><t>hi</t></r><r><t>red</t></r></si></sst>`,
+		"xl/styles.xml": `<styleSheet><numFmts count="1"><numFmt numFmtId="164" formatCode="dd/mm/yyyy\ hh:mm;@"/></numFmts>` +
+			`<cellXfs count="4"><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/><xf numFmtId="2"/></cellXfs></styleSheet>`,
+	}
+	for i, name := range order {
+		id := "rId" + string(rune('1'+i))
+		target := "worksheets/sheet" + string(rune('1'+i)) + ".xml"
+		workbook.WriteString(`<sheet name="` + name + `" sheetId="1" r:id="` + id + `"/>`)
+		rels.WriteString(`<Relationship Id="` + id + `" Target="` + target + `"/>`)
+		parts["xl/"+target] = `<worksheet><sheetData>` + sheets[name] + `</sheetData></worksheet>`
+	}
+	workbook.WriteString(`</sheets></workbook>`)
+	rels.WriteString(`</Relationships>`)
+	parts["xl/workbook.xml"] = workbook.String()
+	parts["xl/_rels/workbook.xml.rels"] = rels.String()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range parts {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// readRecords returns all records of the sheet
+func readRecords(t *testing.T, data []byte, sheet string) ([][]string, error) {
+	t.Helper()
+	records, err := openWorkbook(bytes.NewReader(data), sheet)
+	if err != nil {
+		return nil, err
+	}
+	var rows [][]string
+	for {
+		record, err := records.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, record)
+	}
+}
+
+const testSheet = `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" s="3"/></row>
+<row r="2"><c r="A2"><v>1</v></c><c r="B2" t="inlineStr"><is><t>Alice</t></is></c><c r="C2" s="1"><v>45292</v></c></row>
+<row r="3"><c r="A3"><v>2</v></c><c r="C3" s="1"><v>45310</v></c></row>
+<row r="4" spans="1:4"><c r="B4" s="3"/></row>
+<row r="7"><c r="A7"><v>3</v></c><c r="B7" t="str"><v>Carol</v></c><c r="C7" s="1"><v>45351</v></c></row>
+<row r="8"/><row r="9"><c r="D9" s="3"/></row>`
+
+func TestXLSXRecords(t *testing.T) {
+	data := testWorkbook(t, false, map[string]string{
+		"Summary": `<row r="1"><c r="A1" t="b"><v>1</v></c></row>`,
+		"Data":    testSheet,
+		"Times":   `<row r="1"><c r="A1" t="s"><v>0</v></c></row><row r="2"><c r="A2" s="2"><v>45292.5</v></c></row>`,
+	}, "Summary", "Data", "Times")
+
+	tests := []struct {
+		name    string
+		sheet   string
+		want    [][]string
+		errText string
+	}{
+		{"first sheet", "", [][]string{{"true"}}, ""},
+		{"by name", "Data", [][]string{
+			{"id", "name", "hired"},
+			{"1", "Alice", "2024-01-01"},
+			{"2", "", "2024-01-19"},
+			{"3", "Carol", "2024-02-29"},
+		}, ""},
+		{"by position", "3", [][]string{{"id"}, {"2024-01-01 12:00:00"}}, ""},
+		{"missing", "Totals", nil, "workbook has no sheet Totals; sheets: Summary, Data, Times"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readRecords(t, data, tt.sheet)
+			if tt.errText != "" {
+				if err == nil || err.Error() != tt.errText {
+					t.Fatalf("openWorkbook() error = %v, want %q", err, tt.errText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("openWorkbook() error = %v, want nil", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("records = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXLSXDate1904(t *testing.T) {
+	data := testWorkbook(t, true, map[string]string{
+		"Sheet1": `<row r="1"><c r="A1" t="s"><v>2</v></c></row><row r="2"><c r="A2" s="1"><v>0</v></c></row>`,
+	}, "Sheet1")
+	got, err := readRecords(t, data, "")
+	if err != nil {
+		t.Fatalf("openWorkbook() error = %v, want nil", err)
+	}
+	if want := [][]string{{"hired"}, {"1904-01-01"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+}
+
+func TestAnalyzeWorkbook(t *testing.T) {
+	data := testWorkbook(t, false, map[string]string{"Data": testSheet}, "Data")
+	records, err := openWorkbook(bytes.NewReader(data), "")
+	if err != nil {
+		t.Fatalf("openWorkbook() error = %v, want nil", err)
+	}
+	analyzer := &dbtypes.PostgreSQLAnalyzer{}
+	result, err := analyzeRecords(records, analysisOptions{EmptyColumnType: "text"}, analyzer)
+	if err != nil {
+		t.Fatalf("analyzeRecords() error = %v, want nil", err)
+	}
+
+	want := map[string]string{"id": "smallint", "name": "varchar", "hired": "date"}
+	if result.RowCount != 3 || len(result.Columns) != len(want) {
+		t.Fatalf("analyzed %d rows of %d columns, want 3 rows of %d", result.RowCount, len(result.Columns), len(want))
+	}
+	for _, col := range result.Columns {
+		if got := analyzer.GetTypes()[col.TypeIndex].Name; got != want[col.Name] {
+			t.Errorf("column %s type = %s, want %s", col.Name, got, want[col.Name])
+		}
+	}
+}
+
+func TestNumFmtKind(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"yyyy-mm-dd", "date"},
+		{"dd/mm/yyyy\\ hh:mm;@", "timestamp"},
+		{"h:mm AM/PM", "time"},
+		{"[h]:mm:ss", "time"},
+		{"[$-409]mmmm d, yyyy", "date"},
+		{"0.00", ""},
+		{`#,##0 "days"`, ""},
+		{"[Red]#,##0.00;[Blue]-#,##0.00", ""},
+		{"_(* #,##0_);_(* (#,##0)", ""},
+		{"General", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := numFmtKind(tt.code); got != tt.want {
+				t.Errorf("numFmtKind(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcelTime(t *testing.T) {
+	tests := []struct {
+		serial   float64
+		date1904 bool
+		want     string
+	}{
+		{1, false, "1900-01-01 00:00:00"},
+		{59, false, "1900-02-28 00:00:00"},
+		{61, false, "1900-03-01 00:00:00"},
+		{45292, false, "2024-01-01 00:00:00"},
+		{45292.75, false, "2024-01-01 18:00:00"},
+		{0, true, "1904-01-01 00:00:00"},
+	}
+
+	for _, tt := range tests {
+		if got := excelTime(tt.serial, tt.date1904).Format(time.DateTime); got != tt.want {
+			t.Errorf("excelTime(%v, %v) = %s, want %s", tt.serial, tt.date1904, got, tt.want)
+		}
+	}
+}